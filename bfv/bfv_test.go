@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"runtime"
 	"testing"
 
@@ -71,6 +72,10 @@ func TestBFV(t *testing.T) {
 			testEvaluator,
 			testEvaluatorKeySwitch,
 			testEvaluatorRotate,
+			testLinearTransform,
+			testPolynomialEvaluation,
+			testParallelEvaluator,
+			testNoiseBudget,
 			testMarshaller,
 		} {
 			testSet(testctx, t)
@@ -79,6 +84,124 @@ func TestBFV(t *testing.T) {
 	}
 }
 
+func TestMatching(t *testing.T) {
+
+	// Fermat's little theorem requires a prime plaintext modulus, which is kept small to limit the depth.
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:  7,
+		T:     257,
+		LogQ:  []int{55, 55, 55, 55, 55, 55},
+		LogP:  []int{61},
+		Sigma: rlwe.DefaultSigma,
+	})
+	require.NoError(t, err)
+
+	testctx, err := genTestParams(params)
+	require.NoError(t, err)
+
+	T := params.T()
+	N := params.N()
+
+	// Only the values of the first slots are random, so that the others are matched
+	newTestVectors := func(matching []uint64) (values []uint64, ciphertext *Ciphertext) {
+		values = testctx.uSampler.ReadNew().Coeffs[0]
+		for i := range values[:N>>1] {
+			values[i] = matching[i%len(matching)]
+		}
+		pt := NewPlaintext(params)
+		testctx.encoder.EncodeUint(values, pt)
+		return values, testctx.encryptorPk.EncryptNew(pt)
+	}
+
+	indicator := func(b bool) uint64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	t.Run(testString("Matching/IsZero", params), func(t *testing.T) {
+
+		values, ciphertext := newTestVectors([]uint64{0})
+
+		res, err := testctx.evaluator.IsZero(ciphertext)
+		require.NoError(t, err)
+
+		for i := range values {
+			values[i] = indicator(values[i] == 0)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{values}}, res, t)
+	})
+
+	t.Run(testString("Matching/Equal", params), func(t *testing.T) {
+
+		values0, ciphertext := newTestVectors([]uint64{1, 2, 3})
+		values1, pt := newTestVectorsRingT(testctx, t)
+		for i := range values1.Coeffs[0][:N>>1] {
+			values1.Coeffs[0][i] = uint64(i%3 + 1)
+		}
+		testctx.encoder.EncodeUintRingT(values1.Coeffs[0], pt)
+
+		res, err := testctx.evaluator.Equal(ciphertext, pt)
+		require.NoError(t, err)
+
+		want := make([]uint64, N)
+		for i := range want {
+			want[i] = indicator(values0[i] == values1.Coeffs[0][i])
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, res, t)
+	})
+
+	t.Run(testString("Matching/IsInSet", params), func(t *testing.T) {
+
+		require.Equal(t, []uint64{6, T - 5, 1}, NewPolyFromRoots(T, []uint64{2, 3}).Coeffs)
+
+		set := []uint64{7, 42, T - 1}
+
+		values, ciphertext := newTestVectors(set)
+
+		res, err := testctx.evaluator.IsInSet(ciphertext, set)
+		require.NoError(t, err)
+
+		for i := range values {
+			values[i] = indicator(values[i] == 7 || values[i] == 42 || values[i] == T-1)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{values}}, res, t)
+
+		_, err = testctx.evaluator.IsInSet(ciphertext, nil)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Matching/ExpandQuery", params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		qe := GenQueryExpansion(testctx.encoder, params, 5)
+		rotkey := testctx.kgen.GenRotationKeys(qe.GaloisElements(params), testctx.sk)
+		evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+		for i, ct := range evaluator.ExpandQuery(ciphertext, qe) {
+			want := make([]uint64, N)
+			for j := range want {
+				want[j] = values.Coeffs[0][i]
+			}
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ct, t)
+		}
+	})
+
+	t.Run(testString("Matching/InvalidModulus", params), func(t *testing.T) {
+
+		params, err := NewParametersFromLiteral(ParametersLiteral{LogN: 4, T: 97 * 97, LogQ: []int{55}, LogP: []int{61}, Sigma: rlwe.DefaultSigma})
+		require.NoError(t, err)
+
+		_, err = NewEvaluator(params, rlwe.EvaluationKey{}).IsZero(NewCiphertext(params, 1))
+		require.Error(t, err)
+	})
+}
+
 func genTestParams(params Parameters) (testctx *testContext, err error) {
 
 	testctx = new(testContext)
@@ -651,6 +774,357 @@ func testEvaluatorRotate(testctx *testContext, t *testing.T) {
 	})
 }
 
+func testLinearTransform(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	t.Run(testString("Evaluator/LinearTransform", testctx.params), func(t *testing.T) {
+
+		params := testctx.params
+		N := params.N()
+		n := N >> 1
+
+		diagMatrix := make(map[int][]uint64)
+		for _, k := range []int{0, 1, n - 1, n, n + 5} {
+			diagMatrix[k] = testctx.uSampler.ReadNew().Coeffs[0]
+		}
+
+		linTransf := GenLinearTransform(testctx.encoder, params, diagMatrix)
+
+		rotkey := testctx.kgen.GenRotationKeys(linTransf.GaloisElements(params), testctx.sk)
+		evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		evaluator.LinearTransform(ciphertext, linTransf, ciphertext)
+
+		T := params.T()
+		want := make([]uint64, N)
+		for k, diag := range diagMatrix {
+			for i := 0; i < N; i++ {
+				row, col := i/n, i%n
+				j := ((row^(k/n))*n + (col+k)%n)
+				want[i] = (want[i] + ring.BRed(diag[i], values.Coeffs[0][j], T, testctx.ringT.BredParams[0])) % T
+			}
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ciphertext, t)
+	})
+}
+
+func testPolynomialEvaluation(testctx *testContext, t *testing.T) {
+
+	skipIfNotEnoughBudget := func(t *testing.T) {
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+		if testctx.params.LogN() < 13 {
+			t.Skip("not enough noise budget")
+		}
+	}
+
+	T := testctx.params.T()
+
+	evalPoly := func(coeffs []uint64, x uint64) (y uint64) {
+		for i := len(coeffs) - 1; i >= 0; i-- {
+			y = (ring.BRed(y, x, T, testctx.ringT.BredParams[0]) + coeffs[i]) % T
+		}
+		return
+	}
+
+	t.Run(testString("PolyEval/Scalar", testctx.params), func(t *testing.T) {
+
+		skipIfNotEnoughBudget(t)
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		coeffs := testctx.uSampler.ReadNew().Coeffs[0][:8]
+		coeffs[3] = 0
+
+		poly := NewPoly(coeffs)
+		require.Equal(t, 3, poly.Depth())
+
+		res, err := testctx.evaluator.EvaluatePoly(ciphertext, poly)
+		require.NoError(t, err)
+
+		for i, x := range values.Coeffs[0] {
+			values.Coeffs[0][i] = evalPoly(coeffs, x)
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, values, res, t)
+	})
+
+	t.Run(testString("PolyEval/Vector", testctx.params), func(t *testing.T) {
+
+		skipIfNotEnoughBudget(t)
+
+		values, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		N := testctx.params.N()
+
+		pols := []*Polynomial{
+			NewPoly(testctx.uSampler.ReadNew().Coeffs[0][:4]),
+			NewPoly(testctx.uSampler.ReadNew().Coeffs[0][:6]),
+		}
+
+		slotsIndex := map[int][]int{0: make([]int, 0, N>>1), 1: make([]int, 0, N>>2)}
+		for i := 0; i < N>>1; i++ {
+			slotsIndex[0] = append(slotsIndex[0], 2*i)
+		}
+		for i := 0; i < N>>2; i++ {
+			slotsIndex[1] = append(slotsIndex[1], 4*i+1)
+		}
+
+		res, err := testctx.evaluator.EvaluatePolyVector(ciphertext, pols, testctx.encoder, slotsIndex)
+		require.NoError(t, err)
+
+		want := make([]uint64, N)
+		for i, slots := range slotsIndex {
+			for _, j := range slots {
+				want[j] = evalPoly(pols[i].Coeffs, values.Coeffs[0][j])
+			}
+		}
+
+		verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, res, t)
+
+		_, err = testctx.evaluator.EvaluatePolyVector(ciphertext, pols, testctx.encoder, map[int][]int{2: {0}})
+		require.Error(t, err)
+	})
+
+	t.Run(testString("PolyEval/InterpolateLookupTable", testctx.params), func(t *testing.T) {
+
+		const p = 257
+
+		table := testctx.uSampler.ReadNew().Coeffs[0][:p]
+		f := func(x uint64) uint64 { return table[x] % p }
+
+		poly, err := InterpolateLookupTable(p, f)
+		require.NoError(t, err)
+		require.Equal(t, p-1, poly.Degree())
+
+		for x := uint64(0); x < p; x++ {
+			var y uint64
+			for i := len(poly.Coeffs) - 1; i >= 0; i-- {
+				y = (y*x + poly.Coeffs[i]) % p
+			}
+			require.Equal(t, f(x), y)
+		}
+
+		_, err = InterpolateLookupTable(256, f)
+		require.Error(t, err)
+	})
+}
+
+func testParallelEvaluator(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	params := testctx.params
+	T := params.T()
+
+	rotkey := testctx.kgen.GenRotationKeysForRotations([]int{5}, false, testctx.sk)
+	eval := NewParallelEvaluator(params, testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey}), 3)
+	require.Equal(t, 3, eval.Workers())
+
+	n := 5
+	values := make([]*ring.Poly, n)
+	ciphertexts := make([]*Ciphertext, n)
+	for i := range ciphertexts {
+		values[i], _, ciphertexts[i] = newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+	}
+
+	t.Run(testString("ParallelEvaluator/MulRelin", params), func(t *testing.T) {
+
+		op1 := make([]*Ciphertext, n)
+		for i := range op1 {
+			op1[i] = ciphertexts[(i+1)%n]
+		}
+
+		ctOut := eval.MulRelinNew(ciphertexts, op1)
+		require.Len(t, ctOut, n)
+
+		for i := range ctOut {
+			require.Equal(t, 1, ctOut[i].Degree())
+			want := testctx.ringT.NewPoly()
+			testctx.ringT.MulCoeffs(values[i], values[(i+1)%n], want)
+			verifyTestVectors(testctx, testctx.decryptor, want, ctOut[i], t)
+		}
+	})
+
+	t.Run(testString("ParallelEvaluator/RotateColumns", params), func(t *testing.T) {
+
+		ctOut := eval.RotateColumnsNew(ciphertexts, 5)
+		require.Len(t, ctOut, n)
+
+		for i := range ctOut {
+			want := utils.RotateUint64Slots(values[i].Coeffs[0], 5)
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ctOut[i], t)
+		}
+	})
+
+	t.Run(testString("ParallelEvaluator/Panic", params), func(t *testing.T) {
+
+		// The panic of a worker is raised again with its stack trace
+		ctOut := []*Ciphertext{NewCiphertext(params, 2)}
+		r := func() (r interface{}) {
+			defer func() { r = recover() }()
+			eval.RotateColumns(ciphertexts[:1], 5, ctOut)
+			return
+		}()
+
+		taskPanic, ok := r.(*utils.TaskPanic)
+		require.True(t, ok)
+		require.Contains(t, string(taskPanic.Stack), "(*evaluator).RotateColumns")
+	})
+
+	t.Run(testString("ParallelEvaluator/LinearTransform", params), func(t *testing.T) {
+
+		N := params.N()
+
+		diagMatrix := map[int][]uint64{0: testctx.uSampler.ReadNew().Coeffs[0]}
+		linTransf := GenLinearTransform(testctx.encoder, params, diagMatrix)
+
+		ctOut := make([]*Ciphertext, n)
+		for i := range ctOut {
+			ctOut[i] = NewCiphertext(params, 1)
+		}
+
+		eval.LinearTransform(ciphertexts, linTransf, ctOut)
+
+		for i := range ctOut {
+			want := make([]uint64, N)
+			for j := range want {
+				want[j] = ring.BRed(diagMatrix[0][j], values[i].Coeffs[0][j], T, testctx.ringT.BredParams[0])
+			}
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{want}}, ctOut[i], t)
+		}
+	})
+}
+
+func testNoiseBudget(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	rotations := []int{1, 2, 3}
+	galEls := testctx.params.GaloisElementsForRowInnerSum()
+	for _, k := range rotations {
+		galEls = append(galEls, testctx.params.GaloisElementForColumnRotationBy(k))
+	}
+	rtks := testctx.kgen.GenRotationKeys(galEls, testctx.sk)
+	eval := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rtks})
+
+	// verifyBudget checks that the estimated noise budget of ct is, up to one bit of slack for the
+	// heuristics, a lower bound that is not too far from the budget measured with the secret key.
+	// The estimate of InnerSum assumes that the noise of the N rotations adds up, whereas it can partially
+	// cancel, and the gap grows further with the following multiplication, up to about 30 bits.
+	verifyBudget := func(t *testing.T, ct *Ciphertext) {
+		estimated, exact := eval.NoiseBudget(ct), testctx.decryptor.NoiseBudget(ct)
+		require.LessOrEqual(t, estimated, exact+1)
+		require.Greater(t, estimated+32, exact)
+	}
+
+	for _, encryptor := range []struct {
+		name string
+		Encryptor
+	}{{"Pk", testctx.encryptorPk}, {"Sk", testctx.encryptorSk}} {
+
+		t.Run(testString("NoiseBudget/Fresh/"+encryptor.name, testctx.params), func(t *testing.T) {
+			_, _, ciphertext := newTestVectorsRingQ(testctx, encryptor.Encryptor, t)
+			verifyBudget(t, ciphertext)
+		})
+	}
+
+	t.Run(testString("NoiseBudget/Evaluator", testctx.params), func(t *testing.T) {
+
+		_, plaintext, ciphertext0 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		_, _, ciphertext1 := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		ciphertext := eval.AddNew(ciphertext0, ciphertext1)
+		verifyBudget(t, ciphertext)
+
+		eval.Add(ciphertext, plaintext, ciphertext)
+		verifyBudget(t, ciphertext)
+
+		eval.MulScalar(ciphertext, 1<<10, ciphertext)
+		verifyBudget(t, ciphertext)
+
+		ciphertext = eval.MulNew(ciphertext, ciphertext1)
+		verifyBudget(t, ciphertext)
+
+		eval.Relinearize(ciphertext, ciphertext)
+		verifyBudget(t, ciphertext)
+
+		eval.RotateColumns(ciphertext, 1, ciphertext)
+		verifyBudget(t, ciphertext)
+
+		eval.InnerSum(ciphertext, ciphertext)
+		verifyBudget(t, ciphertext)
+
+		ciphertext = eval.RelinearizeNew(eval.MulNew(ciphertext, ciphertext))
+		verifyBudget(t, ciphertext)
+
+		ptMul := NewPlaintextMul(testctx.params)
+		testctx.encoder.EncodeUintMul(testctx.uSampler.ReadNew().Coeffs[0], ptMul)
+		eval.Mul(ciphertext, ptMul, ciphertext)
+		verifyBudget(t, ciphertext)
+	})
+
+	t.Run(testString("NoiseBudget/EvaluatePoly", testctx.params), func(t *testing.T) {
+
+		if testctx.params.LogN() < 13 {
+			t.Skip("not enough noise budget")
+		}
+
+		_, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		res, err := eval.EvaluatePoly(ciphertext, NewPoly(testctx.uSampler.ReadNew().Coeffs[0][:8]))
+		require.NoError(t, err)
+		require.Greater(t, eval.NoiseBudget(res), 0.0)
+		verifyBudget(t, res)
+
+		slotsIndex := map[int][]int{0: {0, 2}, 1: {1, 3}}
+		pols := []*Polynomial{NewPoly(testctx.uSampler.ReadNew().Coeffs[0][:4]), NewPoly(testctx.uSampler.ReadNew().Coeffs[0][:6])}
+		res, err = eval.EvaluatePolyVector(ciphertext, pols, testctx.encoder, slotsIndex)
+		require.NoError(t, err)
+		require.Greater(t, eval.NoiseBudget(res), 0.0)
+		verifyBudget(t, res)
+	})
+
+	t.Run(testString("NoiseBudget/Exhausted", testctx.params), func(t *testing.T) {
+
+		_, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+
+		for eval.NoiseBudget(ciphertext) > 0 {
+			ciphertext = eval.RelinearizeNew(eval.MulNew(ciphertext, ciphertext))
+		}
+
+		require.Zero(t, eval.NoiseBudget(ciphertext))
+	})
+
+	t.Run(testString("NoiseBudget/Unknown", testctx.params), func(t *testing.T) {
+
+		_, _, ciphertext := newTestVectorsRingQ(testctx, testctx.encryptorPk, t)
+		require.Greater(t, eval.NoiseBudget(ciphertext), 0.0)
+
+		unknown := NewCiphertextRandom(testctx.prng, testctx.params, 1)
+		require.True(t, math.IsInf(unknown.Noise, 1))
+		require.Zero(t, eval.NoiseBudget(unknown))
+
+		// The noise of the result of an operation involving an unknown noise is unknown
+		eval.Add(ciphertext, unknown, ciphertext)
+		require.True(t, math.IsInf(ciphertext.Noise, 1))
+		eval.Add(ciphertext, unknown, ciphertext)
+		require.True(t, math.IsInf(ciphertext.Noise, 1))
+		require.Zero(t, eval.NoiseBudget(ciphertext))
+	})
+}
+
 func testMarshaller(testctx *testContext, t *testing.T) {
 
 	t.Run(testString("Marshaller/Parameters/Binary", testctx.params), func(t *testing.T) {
@@ -694,6 +1168,7 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 	t.Run(testString("Marshaller/Ciphertext", testctx.params), func(t *testing.T) {
 
 		ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 2)
+		ciphertextWant.Noise = 42.5
 
 		marshalledCiphertext, err := ciphertextWant.MarshalBinary()
 		require.NoError(t, err)
@@ -701,6 +1176,7 @@ func testMarshaller(testctx *testContext, t *testing.T) {
 		ciphertextTest := new(Ciphertext)
 		err = ciphertextTest.UnmarshalBinary(marshalledCiphertext)
 		require.NoError(t, err)
+		require.True(t, math.IsInf(ciphertextTest.Noise, 1))
 
 		for i := range ciphertextWant.Value {
 			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
@@ -0,0 +1,133 @@
+// Package bootstrapping implements the bootstrapping of the BFV scheme for plaintext moduli of the form T = p^r,
+// based on the homomorphic digit extraction.
+package bootstrapping
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Bootstrapper is a struct storing the evaluators and the plaintext matrices of the BFV bootstrapping.
+//
+// The bootstrapping of a ciphertext encrypting m modulo T = p^r proceeds as follows:
+//  1. SlotsToCoeffs: the homomorphic evaluation of the encoding map moves the slots of the plaintext to its coefficients.
+//  2. ModUp: each polynomial of the ciphertext is switched from Q to p^E and lifted back to Q, so that the ciphertext
+//     decrypts to p^(E-r) * m + e modulo p^E, with a small error e.
+//  3. CoeffsToSlots: the inverse of the encoding map moves the coefficients p^(E-r) * m + e back to the slots.
+//  4. Digit extraction: the E-r lowest digits in base p of each slot are removed with the lifting polynomial, and the
+//     ciphertext is re-interpreted under the plaintext modulus p^r.
+type Bootstrapper struct {
+	Parameters
+
+	params bfv.Parameters
+
+	prime uint64
+	r     int
+
+	// paramsK[k] and evaluators[k] are the BFV parameters and evaluators for the plaintext modulus p^k, for r <= k <= E.
+	paramsK    []bfv.Parameters
+	evaluators []bfv.Evaluator
+	encoderE   bfv.Encoder
+
+	slotsToCoeffs []bfv.LinearTransform
+	coeffsToSlots []bfv.LinearTransform
+
+	liftingPolynomial *bfv.Polynomial
+	offset            *bfv.PlaintextRingT
+
+	ptRt *bfv.PlaintextRingT
+}
+
+// NewBootstrapper creates a new Bootstrapper for the given BFV parameters. The evaluation key must contain the
+// relinearization key and the rotation keys for the Galois elements returned by Parameters.GaloisElements.
+func NewBootstrapper(params bfv.Parameters, btpParams Parameters, evk rlwe.EvaluationKey) (btp *Bootstrapper, err error) {
+
+	btp = new(Bootstrapper)
+	btp.Parameters = btpParams
+	btp.params = params
+
+	if btp.prime, btp.r, err = btpParams.PlaintextModulus(params); err != nil {
+		return nil, err
+	}
+
+	btp.paramsK = make([]bfv.Parameters, btpParams.E+1)
+	btp.evaluators = make([]bfv.Evaluator, btpParams.E+1)
+	btp.paramsK[btp.r] = params
+	btp.evaluators[btp.r] = bfv.NewEvaluator(params, evk)
+	for k := btp.r + 1; k <= btpParams.E; k++ {
+		if btp.paramsK[k], err = bfv.NewParameters(params.Parameters, powUint64(btp.prime, k)); err != nil {
+			return nil, err
+		}
+		btp.evaluators[k] = bfv.NewEvaluator(btp.paramsK[k], evk)
+	}
+
+	paramsE := btp.paramsK[btpParams.E]
+	btp.encoderE = bfv.NewEncoder(paramsE)
+
+	encoder := bfv.NewEncoder(params)
+	for _, m := range genEncodingMatrices(params.RingT(), btpParams.SlotsToCoeffsDepth, false) {
+		btp.slotsToCoeffs = append(btp.slotsToCoeffs, bfv.GenLinearTransform(encoder, params, m))
+	}
+
+	for _, m := range genEncodingMatrices(paramsE.RingT(), btpParams.CoeffsToSlotsDepth, true) {
+		btp.coeffsToSlots = append(btp.coeffsToSlots, bfv.GenLinearTransform(btp.encoderE, paramsE, m))
+	}
+
+	btp.liftingPolynomial = bfv.NewPoly(genLiftingPolynomial(btp.prime, btpParams.E))
+
+	// (p^(E-r) - 1)/2, which maps the error e in [-(p^(E-r)-1)/2, (p^(E-r)-1)/2] to the lowest E-r digits
+	btp.offset = bfv.NewPlaintextRingT(paramsE)
+	btp.offset.Value.Coeffs[0][0] = (powUint64(btp.prime, btpParams.E-btp.r) - 1) >> 1
+
+	btp.ptRt = bfv.NewPlaintextRingT(paramsE)
+
+	return btp, nil
+}
+
+// Bootstrap re-encrypts the input ciphertext and returns the result on a new ciphertext.
+// The input ciphertext must be of degree 1 and its noise must not exceed Q/(2 * p^E).
+func (btp *Bootstrapper) Bootstrap(ctIn *bfv.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	if ctIn.Degree() != 1 {
+		panic("cannot Bootstrap: input ciphertext must be of degree 1")
+	}
+
+	// SlotsToCoeffs, modulo p^r
+	ctOut = ctIn.CopyNew()
+	for _, lt := range btp.slotsToCoeffs {
+		btp.evaluators[btp.r].LinearTransform(ctOut, lt, ctOut)
+	}
+
+	// ModUp: round(Q/p^E * round(p^E/Q * c)) for each polynomial c of the ciphertext
+	for _, pol := range ctOut.Value {
+		pt := &bfv.Plaintext{Plaintext: &rlwe.Plaintext{Value: pol}}
+		btp.encoderE.ScaleDown(pt, btp.ptRt)
+		btp.encoderE.ScaleUp(btp.ptRt, pt)
+	}
+
+	// CoeffsToSlots, modulo p^E
+	evalE := btp.evaluators[btp.Parameters.E]
+	for _, lt := range btp.coeffsToSlots {
+		evalE.LinearTransform(ctOut, lt, ctOut)
+	}
+
+	evalE.Add(ctOut, btp.offset, ctOut)
+
+	// Digit extraction: at each iteration the lowest digit of the slots is computed with the lifting
+	// polynomial and subtracted, and the result is divided by p by switching the plaintext modulus
+	// from p^k to p^(k-1).
+	for k := btp.Parameters.E; k > btp.r; k-- {
+
+		var err error
+		ctDigit := ctOut
+		for i := 0; i < k-1; i++ {
+			if ctDigit, err = btp.evaluators[k].EvaluatePoly(ctDigit, btp.liftingPolynomial); err != nil {
+				panic(err)
+			}
+		}
+
+		btp.evaluators[k].Sub(ctOut, ctDigit, ctOut)
+	}
+
+	return
+}
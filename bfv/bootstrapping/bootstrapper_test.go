@@ -0,0 +1,187 @@
+package bootstrapping
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// testParams are insecure parameters with a small ring degree, for fast testing only.
+var testParams = []struct {
+	bfv bfv.ParametersLiteral
+	btp Parameters
+}{
+	{
+		bfv: bfv.ParametersLiteral{LogN: 4, LogQ: []int{60, 60, 60, 60, 60, 60, 60, 60}, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: 97},
+		btp: Parameters{E: 2, SlotsToCoeffsDepth: 2, CoeffsToSlotsDepth: 2},
+	},
+	{
+		bfv: bfv.ParametersLiteral{LogN: 4, LogQ: []int{60, 60, 60, 60, 60, 60, 60, 60, 60, 60, 60, 60, 60, 60}, LogP: []int{61}, Sigma: rlwe.DefaultSigma, T: 97 * 97},
+		btp: Parameters{E: 3, SlotsToCoeffsDepth: 1, CoeffsToSlotsDepth: 4},
+	},
+}
+
+func testString(opname string, params bfv.Parameters, btpParams Parameters) string {
+	return fmt.Sprintf("%s/LogN=%d/logQP=%d/T=%d/E=%d", opname, params.LogN(), params.LogQP(), params.T(), btpParams.E)
+}
+
+func TestBootstrapping(t *testing.T) {
+
+	for i, p := range testParams {
+
+		if testing.Short() && i > 0 {
+			break
+		}
+
+		params, err := bfv.NewParametersFromLiteral(p.bfv)
+		require.NoError(t, err)
+
+		testEncodingMatrices(params, p.btp, t)
+		testLiftingPolynomial(params, p.btp, t)
+		testBootstrap(params, p.btp, t)
+	}
+}
+
+func testEncodingMatrices(params bfv.Parameters, btpParams Parameters, t *testing.T) {
+
+	t.Run(testString("EncodingMatrices", params, btpParams), func(t *testing.T) {
+
+		ringT := params.RingT()
+		encoder := bfv.NewEncoder(params)
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+
+		values := ring.NewUniformSampler(prng, ringT).ReadNew().Coeffs[0]
+
+		// Plaintext whose coefficients are the values, in bit-reversed order within each half
+		N, n := params.N(), params.N()>>1
+		logn := uint64(bits.Len64(uint64(n)) - 1)
+		ptRt := bfv.NewPlaintextRingT(params)
+		for k := 0; k < n; k++ {
+			rev := int(utils.BitReverse64(uint64(k), logn))
+			ptRt.Value.Coeffs[0][k] = values[rev]
+			ptRt.Value.Coeffs[0][k+n] = values[rev+n]
+		}
+		want := encoder.DecodeUintNew(ptRt)
+
+		for depth := 1; depth <= params.LogN(); depth++ {
+
+			matrices := genEncodingMatrices(ringT, depth, false)
+			assert.Len(t, matrices, depth)
+
+			have := values
+			for _, m := range matrices {
+				have = m.apply(have, ringT)
+			}
+			require.Equal(t, want, have[:N])
+
+			for _, m := range genEncodingMatrices(ringT, depth, true) {
+				have = m.apply(have, ringT)
+			}
+			require.Equal(t, values, have)
+		}
+	})
+}
+
+func testLiftingPolynomial(params bfv.Parameters, btpParams Parameters, t *testing.T) {
+
+	t.Run(testString("LiftingPolynomial", params, btpParams), func(t *testing.T) {
+
+		p, _, err := btpParams.PlaintextModulus(params)
+		require.NoError(t, err)
+
+		pE := powUint64(p, btpParams.E)
+		coeffs := genLiftingPolynomial(p, btpParams.E)
+
+		eval := func(x uint64) (y uint64) {
+			for i := len(coeffs) - 1; i >= 0; i-- {
+				y = (mulMod(y, x, pE) + coeffs[i]) % pE
+			}
+			return
+		}
+
+		for z0 := uint64(0); z0 < p; z0++ {
+			require.Equal(t, z0, eval(z0))
+			for _, z := range []uint64{z0 + p, z0 + p*(p-1), (z0 + p*p) % pE} {
+				// F^(E-1)(z) = z0 mod p^E
+				y := z
+				for i := 0; i < btpParams.E-1; i++ {
+					y = eval(y)
+				}
+				require.Equal(t, z0, y)
+			}
+		}
+	})
+}
+
+func testBootstrap(params bfv.Parameters, btpParams Parameters, t *testing.T) {
+
+	t.Run(testString("Bootstrap", params, btpParams), func(t *testing.T) {
+
+		kgen := bfv.NewKeyGenerator(params)
+		sk := kgen.GenSecretKey()
+		rlk := kgen.GenRelinearizationKey(sk, 1)
+		rtks := kgen.GenRotationKeys(btpParams.GaloisElements(params), sk)
+
+		btp, err := NewBootstrapper(params, btpParams, rlwe.EvaluationKey{Rlk: rlk, Rtks: rtks})
+		require.NoError(t, err)
+
+		encoder := bfv.NewEncoder(params)
+		encryptor := bfv.NewEncryptor(params, sk)
+		decryptor := bfv.NewDecryptor(params, sk)
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+
+		values := ring.NewUniformSampler(prng, params.RingT()).ReadNew().Coeffs[0]
+
+		pt := bfv.NewPlaintext(params)
+		encoder.EncodeUint(values, pt)
+		ct := encryptor.EncryptNew(pt)
+
+		ct = btp.Bootstrap(ct)
+
+		require.Equal(t, values, encoder.DecodeUintNew(decryptor.DecryptNew(ct)))
+
+		// The bootstrapped ciphertext can be further evaluated
+		eval := bfv.NewEvaluator(params, rlwe.EvaluationKey{Rlk: rlk})
+		ct = eval.MulNew(ct, ct)
+		eval.Relinearize(ct, ct)
+
+		want := make([]uint64, len(values))
+		for i := range values {
+			want[i] = mulMod(values[i], values[i], params.T())
+		}
+
+		require.Equal(t, want, encoder.DecodeUintNew(decryptor.DecryptNew(ct)))
+	})
+
+	t.Run(testString("InvalidParameters", params, btpParams), func(t *testing.T) {
+		_, _, err := Parameters{E: 1, SlotsToCoeffsDepth: 1, CoeffsToSlotsDepth: 1}.PlaintextModulus(params)
+		assert.Error(t, err)
+		_, _, err = Parameters{E: btpParams.E, SlotsToCoeffsDepth: 0, CoeffsToSlotsDepth: 1}.PlaintextModulus(params)
+		assert.Error(t, err)
+	})
+}
+
+// apply evaluates the matrix on the vector of slots in the plain.
+func (m diagMatrix) apply(values []uint64, ringT *ring.Ring) (res []uint64) {
+	N := ringT.N
+	t := ringT.Modulus[0]
+	res = make([]uint64, N)
+	for k, diag := range m {
+		for x := 0; x < N; x++ {
+			res[x] = ring.CRed(res[x]+ring.BRed(diag[x], values[rotateIndex(x, k, N>>1)], t, ringT.BredParams[0]), t)
+		}
+	}
+	return
+}
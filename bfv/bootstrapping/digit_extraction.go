@@ -0,0 +1,83 @@
+package bootstrapping
+
+import (
+	"math/bits"
+)
+
+// genLiftingPolynomial returns the coefficients modulo p^e of the lifting polynomial F(X) = X^p + p * G(X),
+// where G is the polynomial of degree at most p-1 that interpolates (z - z^p)/p modulo p^(e-1) at z = 0, ..., p-1.
+// F satisfies F(z) = z mod p^e for all z in [0, p), and F(z + p^k * y) = F(z) mod p^(k+1) for all k >= 1,
+// so that F^(k)(z) = z mod p^(k+1) for any z whose lowest digit in base p is z mod p.
+// The interpolation is computed in time quadratic in p.
+func genLiftingPolynomial(p uint64, e int) (coeffs []uint64) {
+
+	pE := powUint64(p, e)
+	pE1 := pE / p
+
+	// Values to interpolate
+	values := make([]uint64, p)
+	for z := uint64(0); z < p; z++ {
+		values[z] = ((z + pE - powMod(z, p, pE)) % pE) / p
+	}
+
+	// Newton divided differences on the nodes 0, ..., p-1: the k-th coefficient is the k-th
+	// forward difference at 0 divided by k!, which is invertible modulo p^(e-1) since k < p.
+	newton := make([]uint64, p)
+	factorial := uint64(1)
+	for k := uint64(0); k < p; k++ {
+		if k > 0 {
+			factorial = mulMod(factorial, k, pE1)
+		}
+		newton[k] = mulMod(values[0], modInverse(factorial, pE1), pE1)
+		for z := uint64(0); z < p-1-k; z++ {
+			values[z] = (values[z+1] + pE1 - values[z]) % pE1
+		}
+	}
+
+	// Conversion to the monomial basis: G = d_0 + X (d_1 + (X - 1) (d_2 + (X - 2) (...)))
+	g := make([]uint64, p)
+	g[0] = newton[p-1]
+	for k := int(p) - 2; k >= 0; k-- {
+		// g = g * (X - k) + d_k
+		for i := int(p) - 1; i > 0; i-- {
+			g[i] = (g[i-1] + pE1 - mulMod(g[i], uint64(k), pE1)) % pE1
+		}
+		g[0] = (pE1 - mulMod(g[0], uint64(k), pE1) + newton[k]) % pE1
+	}
+
+	coeffs = make([]uint64, p+1)
+	for i := range g {
+		coeffs[i] = mulMod(g[i], p, pE)
+	}
+	coeffs[p] = 1
+
+	return
+}
+
+func powUint64(x uint64, e int) (y uint64) {
+	y = 1
+	for i := 0; i < e; i++ {
+		y *= x
+	}
+	return
+}
+
+// powMod returns x^e mod m for any modulus m.
+func powMod(x, e, m uint64) (y uint64) {
+	y = 1 % m
+	x %= m
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			y = mulMod(y, x, m)
+		}
+		x = mulMod(x, x, m)
+	}
+	return
+}
+
+// mulMod returns x * y mod m for any modulus m.
+func mulMod(x, y, m uint64) uint64 {
+	hi, lo := bits.Mul64(x, y)
+	_, rem := bits.Div64(hi%m, lo, m)
+	return rem
+}
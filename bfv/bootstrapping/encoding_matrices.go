@@ -0,0 +1,189 @@
+package bootstrapping
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// diagMatrix is a matrix over Z_T in diagonal form, indexed as the diagonals of a bfv.LinearTransform:
+// the slots are viewed as a 2 x N/2 matrix, the diagonal k < N/2 is associated with the left rotation of
+// the columns by k positions, and the diagonal k >= N/2 with the swap of the rows followed by the left
+// rotation of the columns by k - N/2 positions.
+type diagMatrix map[int][]uint64
+
+// genEncodingMatrices returns the factorization, merged into depth matrices and listed in their order of application,
+// of the encoding map E, which maps a vector of slots x to the vector of slots of the plaintext whose coefficients
+// are x (in bit-reversed order within each half), or of its inverse if inverse is true.
+func genEncodingMatrices(ringT *ring.Ring, depth int, inverse bool) (matrices []diagMatrix) {
+
+	stages := genEncodingStages(ringT, inverse)
+
+	if depth > len(stages) {
+		depth = len(stages)
+	}
+
+	matrices = make([]diagMatrix, depth)
+
+	start := 0
+	for i := range matrices {
+
+		size := len(stages) / depth
+		if i < len(stages)%depth {
+			size++
+		}
+
+		matrices[i] = stages[start]
+		for _, stage := range stages[start+1 : start+size] {
+			matrices[i] = stage.compose(matrices[i], ringT)
+		}
+
+		start += size
+	}
+
+	return
+}
+
+// genEncodingStages returns the sparse factors of the encoding map (or of its inverse) in their order of application.
+// The encoding map is a pre-processing step that recombines the two rows of the slots (using the element iota = psi^(N/2))
+// followed by log2(N/2) butterfly stages of a special FFT over each row, with respect to psi for the first row and to psi^-1
+// for the second row, where psi is the primitive 2N-th root of unity of the NTT modulo T.
+func genEncodingStages(ringT *ring.Ring, inverse bool) (stages []diagMatrix) {
+
+	N := ringT.N
+	n := N >> 1
+	t := ringT.Modulus[0]
+	bredParams := ringT.BredParams[0]
+
+	// psi^i for 0 <= i < 2N
+	psi := ring.MRed(ringT.PsiMont[0], 1, t, ringT.MredParams[0])
+	roots := make([]uint64, 2*N)
+	roots[0] = 1
+	for i := 1; i < 2*N; i++ {
+		roots[i] = ring.BRed(roots[i-1], psi, t, bredParams)
+	}
+
+	// 5^i mod 2N for 0 <= i < N/2
+	pow5 := make([]int, n)
+	pow5[0] = 1
+	for i := 1; i < n; i++ {
+		pow5[i] = (pow5[i-1] * 5) & (2*N - 1)
+	}
+
+	inv2 := modInverse(2, t)
+
+	iota := roots[n]
+	pre := make(diagMatrix)
+	for x := 0; x < n; x++ {
+		if inverse {
+			inv2Iota := ring.BRed(inv2, modInverse(iota, t), t, bredParams)
+			pre.set(0, x, N, inv2)
+			pre.set(n, x, N, inv2)
+			pre.set(0, x+n, N, t-inv2Iota)
+			pre.set(n, x+n, N, inv2Iota)
+		} else {
+			pre.set(0, x, N, 1)
+			pre.set(n, x, N, iota)
+			pre.set(0, x+n, N, t-iota)
+			pre.set(n, x+n, N, 1)
+		}
+	}
+
+	stages = append(stages, pre)
+
+	for length := 2; length <= n; length <<= 1 {
+
+		lenh := length >> 1
+		stage := make(diagMatrix)
+
+		for row := 0; row < 2; row++ {
+			for i := 0; i < n; i += length {
+				for j := 0; j < lenh; j++ {
+
+					exp := (pow5[j] & (4*length - 1)) * (n / length)
+					if row == 1 {
+						exp = (2*N - exp) & (2*N - 1)
+					}
+
+					w := roots[exp]
+					upper, lower := row*n+i+j, row*n+i+j+lenh
+
+					if inverse {
+						inv2w := ring.BRed(inv2, modInverse(w, t), t, bredParams)
+						stage.set(0, upper, N, inv2)
+						stage.set(lenh, upper, N, inv2)
+						stage.set(0, lower, N, t-inv2w)
+						stage.set(n-lenh, lower, N, inv2w)
+					} else {
+						stage.set(0, upper, N, 1)
+						stage.set(lenh, upper, N, w)
+						stage.set(0, lower, N, t-w)
+						stage.set(n-lenh, lower, N, 1)
+					}
+				}
+			}
+		}
+
+		stages = append(stages, stage)
+	}
+
+	if inverse {
+		for i, j := 0, len(stages)-1; i < j; i, j = i+1, j-1 {
+			stages[i], stages[j] = stages[j], stages[i]
+		}
+	}
+
+	return
+}
+
+// set sets the value of the diagonal k at the position x, allocating the diagonal of N values if needed.
+func (m diagMatrix) set(k, x, N int, value uint64) {
+	if _, ok := m[k]; !ok {
+		m[k] = make([]uint64, N)
+	}
+	m[k][x] = value
+}
+
+// compose returns the matrix m x other in diagonal form.
+func (m diagMatrix) compose(other diagMatrix, ringT *ring.Ring) (res diagMatrix) {
+
+	N := ringT.N
+	n := N >> 1
+	t := ringT.Modulus[0]
+	bredParams := ringT.BredParams[0]
+
+	res = make(diagMatrix)
+
+	for g, diagG := range m {
+		for h, diagH := range other {
+
+			k := (((g + h) & (n - 1)) | ((g ^ h) & n))
+
+			diag, ok := res[k]
+			if !ok {
+				diag = make([]uint64, N)
+				res[k] = diag
+			}
+
+			// diag += diagG * rotate(diagH, g)
+			for x := 0; x < N; x++ {
+				y := rotateIndex(x, g, n)
+				diag[x] = ring.CRed(diag[x]+ring.BRed(diagG[x], diagH[y], t, bredParams), t)
+			}
+		}
+	}
+
+	return
+}
+
+// rotateIndex returns the position of the slot that is moved to the position x by the automorphism
+// associated with the diagonal k.
+func rotateIndex(x, k, n int) int {
+	row := (x & n) ^ (k & n)
+	return row | ((x + k) & (n - 1))
+}
+
+// modInverse returns x^-1 mod t for t not necessarily prime.
+func modInverse(x, t uint64) uint64 {
+	return new(big.Int).ModInverse(new(big.Int).SetUint64(x), new(big.Int).SetUint64(t)).Uint64()
+}
@@ -0,0 +1,81 @@
+package bootstrapping
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Parameters is a struct storing the parameters of the BFV bootstrapping circuit.
+// The plaintext modulus of the bootstrapped ciphertexts must be of the form T = p^R for
+// a prime p equal to 1 modulo 2N, and the bootstrapping circuit is evaluated with the
+// intermediate plaintext modulus p^E, where E > R.
+type Parameters struct {
+	E                  int // E is the exponent of the intermediate plaintext modulus p^E, the E-R lowest digits of which are removed by the digit extraction.
+	SlotsToCoeffsDepth int // SlotsToCoeffsDepth is the number of plaintext multiplications on which the SlotsToCoeffs transform is spread (in [1, log2(N)]).
+	CoeffsToSlotsDepth int // CoeffsToSlotsDepth is the number of plaintext multiplications on which the CoeffsToSlots transform is spread (in [1, log2(N)]).
+}
+
+// PlaintextModulus returns the decomposition p^R of the plaintext modulus of the BFV parameters.
+// It returns an error if the plaintext modulus is not a prime power or if it does not enable the
+// bootstrapping with the receiver parameters.
+func (p Parameters) PlaintextModulus(params bfv.Parameters) (prime uint64, r int, err error) {
+
+	var ok bool
+	if prime, r, ok = ring.IsPrimePower(params.T()); !ok {
+		return 0, 0, fmt.Errorf("plaintext modulus T=%d is not a prime power", params.T())
+	}
+
+	if p.E <= r {
+		return 0, 0, fmt.Errorf("invalid bootstrapping parameters: E=%d must be larger than R=%d", p.E, r)
+	}
+
+	hi, pE := bits.Mul64(params.T(), 1)
+	for i := r; i < p.E; i++ {
+		if hi, pE = bits.Mul64(pE, prime); hi != 0 || pE > params.Q()[0] {
+			return 0, 0, fmt.Errorf("invalid bootstrapping parameters: p^E is larger than Q[0]=%d", params.Q()[0])
+		}
+	}
+
+	maxDepth := params.LogN()
+	if p.SlotsToCoeffsDepth < 1 || p.SlotsToCoeffsDepth > maxDepth {
+		return 0, 0, fmt.Errorf("invalid bootstrapping parameters: SlotsToCoeffsDepth must be in [1, %d]", maxDepth)
+	}
+
+	if p.CoeffsToSlotsDepth < 1 || p.CoeffsToSlotsDepth > maxDepth {
+		return 0, 0, fmt.Errorf("invalid bootstrapping parameters: CoeffsToSlotsDepth must be in [1, %d]", maxDepth)
+	}
+
+	return prime, r, nil
+}
+
+// GaloisElements returns the Galois elements for which rotation keys must be generated to
+// evaluate the bootstrapping circuit with the given BFV parameters.
+func (p Parameters) GaloisElements(params bfv.Parameters) (galEls []uint64) {
+
+	// The indexes of the non-zero diagonals of the encoding matrices do not depend on
+	// the plaintext modulus, hence those of the matrices modulo T are used for both transforms.
+	ringT := params.RingT()
+
+	galElsMap := make(map[uint64]bool)
+	for _, matrices := range [][]diagMatrix{
+		genEncodingMatrices(ringT, p.SlotsToCoeffsDepth, false),
+		genEncodingMatrices(ringT, p.CoeffsToSlotsDepth, true),
+	} {
+		for _, m := range matrices {
+			for k := range m {
+				if k != 0 {
+					galElsMap[bfv.GaloisElementForDiagonal(params, k)] = true
+				}
+			}
+		}
+	}
+
+	for galEl := range galElsMap {
+		galEls = append(galEls, galEl)
+	}
+
+	return
+}
@@ -1,38 +1,52 @@
 package bfv
 
 import (
+	"math"
+
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // Ciphertext is a *ring.Poly array representing a polynomial of degree > 0 with coefficients in R_Q.
+// Noise is the base-2 logarithm of a heuristic bound on the noise of the ciphertext, which is tracked
+// by the Encryptor and the Evaluator (see Evaluator.NoiseBudget). It is equal to NoiseUnknown() for
+// ciphertexts whose noise is not tracked, for example new or unmarshaled ciphertexts.
 type Ciphertext struct {
 	*rlwe.Ciphertext
+	Noise float64
+}
+
+// NoiseUnknown returns the value of Ciphertext.Noise for ciphertexts whose noise is not tracked,
+// which is +Inf. The noise budget of such ciphertexts is zero, since their decryption cannot be verified.
+func NoiseUnknown() float64 {
+	return math.Inf(1)
 }
 
-// NewCiphertext creates a new ciphertext parameterized by degree, level and scale.
+// NewCiphertext creates a new ciphertext parameterized by degree, level and scale. Its noise is unknown.
 func NewCiphertext(params Parameters, degree int) (ciphertext *Ciphertext) {
-	return &Ciphertext{rlwe.NewCiphertext(params.Parameters, degree, params.MaxLevel())}
+	return &Ciphertext{Ciphertext: rlwe.NewCiphertext(params.Parameters, degree, params.MaxLevel()), Noise: NoiseUnknown()}
 }
 
-// NewCiphertextRandom generates a new uniformly distributed ciphertext of degree, level and scale.
+// NewCiphertextRandom generates a new uniformly distributed ciphertext of degree, level and scale. Its noise is unknown.
 func NewCiphertextRandom(prng utils.PRNG, params Parameters, degree int) (ciphertext *Ciphertext) {
-	return &Ciphertext{rlwe.NewCiphertextRandom(prng, params.Parameters, degree, params.MaxLevel())}
+	return &Ciphertext{Ciphertext: rlwe.NewCiphertextRandom(prng, params.Parameters, degree, params.MaxLevel()), Noise: NoiseUnknown()}
 }
 
 // CopyNew creates a deep copy of the receiver ciphertext and returns it.
 func (ct *Ciphertext) CopyNew() *Ciphertext {
-	return &Ciphertext{ct.Ciphertext.CopyNew()}
+	return &Ciphertext{Ciphertext: ct.Ciphertext.CopyNew(), Noise: ct.Noise}
 }
 
-// MarshalBinary encodes a Ciphertext in a byte slice.
+// MarshalBinary encodes a Ciphertext in a byte slice. The noise bound is not encoded.
 func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
 	return ct.Ciphertext.MarshalBinary()
 }
 
 // UnmarshalBinary decodes a previously marshaled Ciphertext in the target Ciphertext.
+// The noise bound of the decoded Ciphertext is unknown (see NoiseUnknown).
 func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
 	ct.Ciphertext = new(rlwe.Ciphertext)
+	ct.Noise = NoiseUnknown()
 	return ct.Ciphertext.UnmarshalBinary(data)
 }
 
@@ -1,6 +1,10 @@
 package bfv
 
 import (
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 )
 
@@ -8,16 +12,18 @@ import (
 type Decryptor interface {
 	DecryptNew(ciphertext *Ciphertext) (plaintext *Plaintext)
 	Decrypt(ciphertext *Ciphertext, plaintext *Plaintext)
+	NoiseBudget(ciphertext *Ciphertext) float64
 }
 
 type decryptor struct {
 	rlwe.Decryptor
 	params Parameters
+	buff   *ring.Poly
 }
 
 // NewDecryptor instantiates a Decryptor for the BFV scheme.
 func NewDecryptor(params Parameters, sk *rlwe.SecretKey) Decryptor {
-	return &decryptor{rlwe.NewDecryptor(params.Parameters, sk), params}
+	return &decryptor{rlwe.NewDecryptor(params.Parameters, sk), params, params.RingQ().NewPoly()}
 }
 
 // Decrypt decrypts the ciphertext and write the result in ptOut.
@@ -31,3 +37,31 @@ func (dec *decryptor) DecryptNew(ct *Ciphertext) (ptOut *Plaintext) {
 	dec.Decryptor.Decrypt(ct.Ciphertext, pt.Plaintext)
 	return pt
 }
+
+// NoiseBudget returns the remaining noise budget of the ciphertext in bits, that is, the number of bits by
+// which its noise can grow before the decryption fails. It is computed with the secret key as
+// log2(Q) - 1 - log2(||t * ct(s) mod Q||), and returns zero if the ciphertext cannot be decrypted correctly.
+func (dec *decryptor) NoiseBudget(ct *Ciphertext) float64 {
+
+	ringQ := dec.params.RingQ()
+
+	dec.Decryptor.Decrypt(ct.Ciphertext, &rlwe.Plaintext{Value: dec.buff})
+	ringQ.MulScalar(dec.buff, dec.params.T(), dec.buff)
+
+	coeffs := make([]*big.Int, ringQ.N)
+	ringQ.PolyToBigint(dec.buff, coeffs)
+	center(coeffs, ringQ.ModulusBigint)
+
+	max := new(big.Int)
+	for i := range coeffs {
+		if coeffs[i].CmpAbs(max) > 0 {
+			max.Abs(coeffs[i])
+		}
+	}
+
+	if max.Sign() == 0 {
+		return logBigInt(ringQ.ModulusBigint) - 1
+	}
+
+	return math.Max(0, logBigInt(ringQ.ModulusBigint)-1-logBigInt(max))
+}
@@ -16,19 +16,35 @@ type Encryptor interface {
 type encryptor struct {
 	rlwe.Encryptor
 	params Parameters
+
+	freshNoise    float64 // estimated noise of the ciphertexts output by Encrypt
+	freshNoiseCRP float64 // estimated noise of the ciphertexts output by EncryptFromCRP
 }
 
 // NewEncryptor instantiates a new Encryptor for the BFV scheme. The key argument can
 // be either a *rlwe.PublicKey or a *rlwe.SecretKey.
 func NewEncryptor(params Parameters, key interface{}) Encryptor {
-	return &encryptor{rlwe.NewEncryptor(params.Parameters, key), params}
+	noise := newNoiseEstimator(params)
+	enc := &encryptor{Encryptor: rlwe.NewEncryptor(params.Parameters, key), params: params, freshNoiseCRP: noise.logFreshSk}
+	switch key.(type) {
+	case *rlwe.PublicKey:
+		if params.PCount() != 0 {
+			enc.freshNoise = noise.logFreshPk
+		} else {
+			enc.freshNoise = noise.logFreshPkQ
+		}
+	case *rlwe.SecretKey:
+		enc.freshNoise = noise.logFreshSk
+	}
+	return enc
 }
 
 // NewFastEncryptor instantiates a new Encryptor for the BFV scheme.
 // This encryptor's Encrypt method first encrypts zero in Q and then adds the plaintext.
 // This method is faster than the normal encryptor but result in a noisier ciphertext.
 func NewFastEncryptor(params Parameters, key *rlwe.PublicKey) Encryptor {
-	return &encryptor{rlwe.NewFastEncryptor(params.Parameters, key), params}
+	noise := newNoiseEstimator(params)
+	return &encryptor{Encryptor: rlwe.NewFastEncryptor(params.Parameters, key), params: params, freshNoise: noise.logFreshPkQ, freshNoiseCRP: noise.logFreshSk}
 }
 
 // Encrypt encrypts the input plaintext and write the result on ctOut.
@@ -36,6 +52,7 @@ func NewFastEncryptor(params Parameters, key *rlwe.PublicKey) Encryptor {
 // NewEncryptor and NewFastEncryptor).
 func (enc *encryptor) Encrypt(plaintext *Plaintext, ctOut *Ciphertext) {
 	enc.Encryptor.Encrypt(&rlwe.Plaintext{Value: plaintext.Value}, &rlwe.Ciphertext{Value: ctOut.Value})
+	ctOut.Noise = enc.freshNoise
 }
 
 // EncryptNew encrypts the input plaintext returns the result as a newly allocated ciphertext.
@@ -44,6 +61,7 @@ func (enc *encryptor) Encrypt(plaintext *Plaintext, ctOut *Ciphertext) {
 func (enc *encryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
 	ct := NewCiphertext(enc.params, 1)
 	enc.Encryptor.Encrypt(plaintext.Plaintext, ct.Ciphertext)
+	ct.Noise = enc.freshNoise
 	return ct
 }
 
@@ -51,6 +69,7 @@ func (enc *encryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
 // The passed crp is always treated as being in the NTT domain.
 func (enc *encryptor) EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ctOut *Ciphertext) {
 	enc.Encryptor.EncryptFromCRP(&rlwe.Plaintext{Value: plaintext.Value}, crp, &rlwe.Ciphertext{Value: ctOut.Value})
+	ctOut.Noise = enc.freshNoiseCRP
 }
 
 // EncryptFromCRPNew encrypts the input plaintext and returns the result as a newly allocated ciphertext.
@@ -58,5 +77,6 @@ func (enc *encryptor) EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ctOut
 func (enc *encryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
 	ct := NewCiphertext(enc.params, 1)
 	enc.Encryptor.EncryptFromCRP(&rlwe.Plaintext{Value: plaintext.Value}, crp, ct.Ciphertext)
+	ct.Noise = enc.freshNoiseCRP
 	return ct
 }
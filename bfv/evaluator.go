@@ -44,6 +44,15 @@ type Evaluator interface {
 	RotateRows(ct0 *Ciphertext, ctOut *Ciphertext)
 	RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext)
 	InnerSum(ct0 *Ciphertext, ctOut *Ciphertext)
+	LinearTransform(ctIn *Ciphertext, linearTransform LinearTransform, ctOut *Ciphertext)
+	LinearTransformNew(ctIn *Ciphertext, linearTransform LinearTransform) (ctOut *Ciphertext)
+	EvaluatePoly(ctIn *Ciphertext, pol *Polynomial) (ctOut *Ciphertext, err error)
+	EvaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotsIndex map[int][]int) (ctOut *Ciphertext, err error)
+	IsZero(ctIn *Ciphertext) (ctOut *Ciphertext, err error)
+	Equal(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext, err error)
+	IsInSet(ctIn *Ciphertext, set []uint64) (ctOut *Ciphertext, err error)
+	ExpandQuery(ctIn *Ciphertext, qe QueryExpansion) (ctOut []*Ciphertext)
+	NoiseBudget(ct *Ciphertext) float64
 	ShallowCopy() Evaluator
 	WithKey(rlwe.EvaluationKey) Evaluator
 }
@@ -71,6 +80,8 @@ type evaluatorBase struct {
 
 	t     uint64
 	pHalf *big.Int
+
+	noise *noiseEstimator
 }
 
 func newEvaluatorPrecomp(params Parameters) *evaluatorBase {
@@ -86,6 +97,8 @@ func newEvaluatorPrecomp(params Parameters) *evaluatorBase {
 
 	ev.pHalf = new(big.Int).Rsh(ev.ringQMul.ModulusBigint, 1)
 
+	ev.noise = newNoiseEstimator(params)
+
 	return ev
 }
 
@@ -159,6 +172,7 @@ func (eval *evaluator) ShallowCopy() Evaluator {
 		evaluatorBase:     eval.evaluatorBase,
 		KeySwitcher:       eval.KeySwitcher.ShallowCopy(),
 		evaluatorBuffers:  newEvaluatorBuffer(eval.evaluatorBase),
+		lightEncoder:      eval.lightEncoder,
 		baseconverterQ1Q2: eval.baseconverterQ1Q2.ShallowCopy(),
 		rlk:               eval.rlk,
 		rtks:              eval.rtks,
@@ -172,6 +186,7 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 		evaluatorBase:     eval.evaluatorBase,
 		KeySwitcher:       eval.KeySwitcher,
 		evaluatorBuffers:  eval.evaluatorBuffers,
+		lightEncoder:      eval.lightEncoder,
 		baseconverterQ1Q2: eval.baseconverterQ1Q2,
 		rlk:               evaluationKey.Rlk,
 		rtks:              evaluationKey.Rtks,
@@ -182,6 +197,7 @@ func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
 func (eval *evaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.Add)
+	ctOut.Noise = eval.noise.add(eval.noiseOf(op0), eval.noiseOf(op1))
 }
 
 // AddNew adds op0 to op1 and creates a new element ctOut to store the result.
@@ -195,6 +211,7 @@ func (eval *evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
 func (eval *evaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 	eval.evaluateInPlaceBinary(el0, el1, elOut, eval.ringQ.AddNoMod)
+	ctOut.Noise = eval.noise.add(eval.noiseOf(op0), eval.noiseOf(op1))
 }
 
 // AddNoModNew adds op0 to op1 without modular reduction and creates a new element ctOut to store the result.
@@ -214,6 +231,8 @@ func (eval *evaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
 			eval.ringQ.Neg(ctOut.Value[i], ctOut.Value[i])
 		}
 	}
+
+	ctOut.Noise = eval.noise.add(eval.noiseOf(op0), eval.noiseOf(op1))
 }
 
 // SubNew subtracts op1 from op0 and creates a new element ctOut to store the result.
@@ -234,6 +253,8 @@ func (eval *evaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 			eval.ringQ.Neg(ctOut.Value[i], ctOut.Value[i])
 		}
 	}
+
+	ctOut.Noise = eval.noise.add(eval.noiseOf(op0), eval.noiseOf(op1))
 }
 
 // SubNoModNew subtracts op1 from op0 without modular reduction and creates a new element ctOut to store the result.
@@ -247,6 +268,7 @@ func (eval *evaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext) {
 func (eval *evaluator) Neg(op Operand, ctOut *Ciphertext) {
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	evaluateInPlaceUnary(el0, elOut, eval.ringQ.Neg)
+	ctOut.Noise = eval.noiseOf(op)
 }
 
 // NegNew negates op and creates a new element to store the result.
@@ -260,6 +282,7 @@ func (eval *evaluator) NegNew(op Operand) (ctOut *Ciphertext) {
 func (eval *evaluator) Reduce(op Operand, ctOut *Ciphertext) {
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	evaluateInPlaceUnary(el0, elOut, eval.ringQ.Reduce)
+	ctOut.Noise = eval.noiseOf(op)
 }
 
 // ReduceNew applies a modular reduction to op and creates a new element ctOut to store the result.
@@ -274,6 +297,7 @@ func (eval *evaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	fun := func(el, elOut *ring.Poly) { eval.ringQ.MulScalar(el, scalar, elOut) }
 	evaluateInPlaceUnary(el0, elOut, fun)
+	ctOut.Noise = eval.noise.mulScalar(eval.noiseOf(op), scalar)
 }
 
 // MulScalarNew multiplies op by a uint64 scalar and creates a new element ctOut to store the result.
@@ -469,17 +493,20 @@ func (eval *evaluator) quantize(ctOut *rlwe.Ciphertext) {
 // Mul multiplies op0 by op1 and returns the result in ctOut.
 func (eval *evaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, op0.Degree()+op1.Degree(), false)
+	noise0, noise1 := op0.Noise, eval.noiseOf(op1)
 	switch op1 := op1.(type) {
 	case *PlaintextMul:
 		eval.mulPlaintextMul(op0, op1, ctOut)
+		ctOut.Noise = eval.noise.mulPlaintext(noise0)
 	case *PlaintextRingT:
 		eval.mulPlaintextRingT(op0, op1, ctOut)
+		ctOut.Noise = eval.noise.mulPlaintext(noise0)
 	case *Plaintext, *Ciphertext:
 		eval.tensorAndRescale(el0, el1, elOut)
+		ctOut.Noise = eval.noise.tensor(noise0, el0.Degree(), noise1, el1.Degree())
 	default:
 		panic(fmt.Errorf("invalid operand type for Mul: %T", op1))
 	}
-
 }
 
 func (eval *evaluator) mulPlaintextMul(ct0 *Ciphertext, ptRt *PlaintextMul, ctOut *Ciphertext) {
@@ -548,6 +575,8 @@ func (eval *evaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
 // relinearize is a method common to Relinearize and RelinearizeNew. It switches ct0 to the NTT domain, applies the keyswitch, and returns the result out of the NTT domain.
 func (eval *evaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 
+	ctOut.Noise = eval.noise.keySwitch(ct0.Noise, ct0.Degree()-1)
+
 	if ctOut != ct0 {
 		ring.CopyValues(ct0.Value[0], ctOut.Value[0])
 		ring.CopyValues(ct0.Value[1], ctOut.Value[1])
@@ -583,6 +612,7 @@ func (eval *evaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 	if ct0.Degree() < 2 {
 		if ct0 != ctOut {
 			ctOut.Copy(ct0.El())
+			ctOut.Noise = ct0.Noise
 		}
 	} else {
 		eval.relinearize(ct0, ctOut)
@@ -615,6 +645,8 @@ func (eval *evaluator) SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey,
 
 	eval.ringQ.Add(ct0.Value[0], eval.Pool[1].Q, ctOut.Value[0])
 	ring.CopyValues(eval.Pool[2].Q, ctOut.Value[1])
+
+	ctOut.Noise = eval.noise.keySwitch(ct0.Noise, 1)
 }
 
 // SwitchKeysNew applies the key-switching procedure to the ciphertext ct0 and creates a new ciphertext to store the result. It requires as an additional input a valid switching-key:
@@ -640,6 +672,7 @@ func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
 	if k == 0 {
 
 		ctOut.Copy(ct0.El())
+		ctOut.Noise = ct0.Noise
 
 	} else {
 
@@ -696,6 +729,7 @@ func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 	cTmp := NewCiphertext(eval.params, 1)
 
 	ctOut.Copy(ct0.El())
+	ctOut.Noise = ct0.Noise
 
 	for i := 1; i < int(eval.ringQ.N>>1); i <<= 1 {
 		eval.RotateColumns(ctOut, i, cTmp)
@@ -714,6 +748,25 @@ func (eval *evaluator) permute(ct0 *Ciphertext, generator uint64, switchKey *rlw
 
 	eval.ringQ.Permute(eval.Pool[1].Q, generator, ctOut.Value[0])
 	eval.ringQ.Permute(eval.Pool[2].Q, generator, ctOut.Value[1])
+
+	ctOut.Noise = eval.noise.keySwitch(ct0.Noise, 1)
+}
+
+// NoiseBudget returns the estimated remaining noise budget of ct in bits, that is, the number of bits by
+// which its noise can grow before the decryption fails. The estimate is derived from the heuristic
+// bound ct.Noise tracked by the Encryptor and Evaluator and is thus expected to be smaller than the
+// budget measured with the secret key by Decryptor.NoiseBudget. A budget of zero indicates that the
+// decryption of ct is likely to be incorrect, or that the noise of ct is unknown (see NoiseUnknown).
+func (eval *evaluator) NoiseBudget(ct *Ciphertext) float64 {
+	return eval.noise.budget(ct.Noise)
+}
+
+// noiseOf returns the estimated noise of op.
+func (eval *evaluator) noiseOf(op Operand) float64 {
+	if ct, isCiphertext := op.(*Ciphertext); isCiphertext {
+		return ct.Noise
+	}
+	return eval.noise.plaintext()
 }
 
 func (eval *evaluator) getRingQElem(op Operand) *rlwe.Ciphertext {
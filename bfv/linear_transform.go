@@ -0,0 +1,127 @@
+package bfv
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// LinearTransform is a type for linear transformations on ciphertexts.
+// It stores a plaintext matrix in diagonal form and can be evaluated on
+// a ciphertext by using the Evaluator.LinearTransform method.
+//
+// The slots are viewed as a 2 x N/2 matrix. The diagonal indexed by k < N/2
+// multiplies the ciphertext whose columns are rotated to the left by k positions,
+// and the diagonal indexed by k >= N/2 multiplies the ciphertext whose rows are
+// swapped and whose columns are rotated to the left by k - N/2 positions.
+type LinearTransform struct {
+	Vec map[int]*PlaintextMul // Vec is the matrix, in diagonal form, where each entry of vec is an indexed non-zero diagonal.
+}
+
+// NewLinearTransform allocates a new LinearTransform with zero plaintexts for the given non-zero diagonals.
+func NewLinearTransform(params Parameters, nonZeroDiags []int) LinearTransform {
+	vec := make(map[int]*PlaintextMul)
+	for _, i := range nonZeroDiags {
+		vec[i&(params.N()-1)] = NewPlaintextMul(params)
+	}
+	return LinearTransform{Vec: vec}
+}
+
+// GenLinearTransform allocates and encodes a new LinearTransform from the matrix given in diagonal form.
+// Each diagonal must be a slice of at most N values (see LinearTransform for the indexing of the diagonals).
+func GenLinearTransform(encoder Encoder, params Parameters, value map[int][]uint64) LinearTransform {
+	nonZeroDiags := make([]int, 0, len(value))
+	for i := range value {
+		nonZeroDiags = append(nonZeroDiags, i)
+	}
+	LT := NewLinearTransform(params, nonZeroDiags)
+	LT.Encode(encoder, value)
+	return LT
+}
+
+// Encode encodes on a pre-allocated LinearTransform the linear transform's matrix in diagonal form value.
+// The indexes of value must match the ones of the receiver.
+func (LT *LinearTransform) Encode(encoder Encoder, value map[int][]uint64) {
+	for i, diag := range value {
+		pt, ok := LT.Vec[i]
+		if !ok {
+			panic(fmt.Sprintf("diagonal %d is not allocated in the LinearTransform", i))
+		}
+		encoder.EncodeUintMul(diag, pt)
+	}
+}
+
+// GaloisElements returns the list of Galois elements needed for the evaluation of the linear transform.
+func (LT *LinearTransform) GaloisElements(params Parameters) (galEls []uint64) {
+	galEls = make([]uint64, 0, len(LT.Vec))
+	for i := range LT.Vec {
+		if i != 0 {
+			galEls = append(galEls, GaloisElementForDiagonal(params, i))
+		}
+	}
+	return
+}
+
+// GaloisElementForDiagonal returns the Galois element of the automorphism applied on the ciphertext
+// before its multiplication with the diagonal indexed by k of a LinearTransform.
+func GaloisElementForDiagonal(params Parameters, k int) uint64 {
+	galEl := params.GaloisElementForColumnRotationBy(k & ((params.N() >> 1) - 1))
+	if k&(params.N()>>1) != 0 {
+		galEl = (galEl * params.GaloisElementForRowRotation()) & uint64(2*params.N()-1)
+	}
+	return galEl
+}
+
+// LinearTransformNew evaluates the linear transform on the input ciphertext and returns the result on a new ciphertext.
+func (eval *evaluator) LinearTransformNew(ctIn *Ciphertext, linearTransform LinearTransform) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1)
+	eval.LinearTransform(ctIn, linearTransform, ctOut)
+	return
+}
+
+// LinearTransform evaluates the linear transform on the input ciphertext and returns the result on ctOut.
+// It requires the rotation keys for the Galois elements returned by LinearTransform.GaloisElements.
+func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform LinearTransform, ctOut *Ciphertext) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot LinearTransform: input and output must be of degree 1")
+	}
+
+	ctRot := &Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{eval.poolQ[1][0], eval.poolQ[1][1]}}}
+	ctMul := &Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{eval.poolQ[1][2], eval.poolQ[1][3]}}}
+	ctAcc := &Ciphertext{Ciphertext: &rlwe.Ciphertext{Value: []*ring.Poly{eval.poolQ[1][4], eval.poolQ[1][5]}}}
+
+	ctAcc.Value[0].Zero()
+	ctAcc.Value[1].Zero()
+	ctAcc.Noise = math.Inf(-1)
+
+	for k, diag := range linearTransform.Vec {
+
+		if k == 0 {
+			eval.mulPlaintextMul(ctIn, diag, ctMul)
+			ctMul.Noise = eval.noise.mulPlaintext(ctIn.Noise)
+		} else {
+
+			galEl := GaloisElementForDiagonal(eval.params, k)
+
+			swk, inSet := eval.rtks.GetRotationKey(galEl)
+			if !inSet {
+				panic(fmt.Errorf("evaluator has no rotation key for the diagonal %d", k))
+			}
+
+			eval.permute(ctIn, galEl, swk, ctRot)
+			eval.mulPlaintextMul(ctRot, diag, ctMul)
+			ctMul.Noise = eval.noise.mulPlaintext(ctRot.Noise)
+		}
+
+		eval.ringQ.Add(ctAcc.Value[0], ctMul.Value[0], ctAcc.Value[0])
+		eval.ringQ.Add(ctAcc.Value[1], ctMul.Value[1], ctAcc.Value[1])
+		ctAcc.Noise = eval.noise.add(ctAcc.Noise, ctMul.Noise)
+	}
+
+	ring.CopyValues(ctAcc.Value[0], ctOut.Value[0])
+	ring.CopyValues(ctAcc.Value[1], ctOut.Value[1])
+	ctOut.Noise = ctAcc.Noise
+}
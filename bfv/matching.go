@@ -0,0 +1,162 @@
+package bfv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// NewPolyFromRoots returns the monic polynomial prod_i (X - roots[i]) modulo t, which evaluates to zero
+// exactly on the slots whose value is one of the roots if t is prime.
+func NewPolyFromRoots(t uint64, roots []uint64) (p *Polynomial) {
+
+	coeffs := make([]uint64, len(roots)+1)
+	coeffs[0] = 1
+
+	// Multiplies the current polynomial of degree i by (X - r)
+	for i, r := range roots {
+		r = t - r%t
+		for j := i + 1; j > 0; j-- {
+			coeffs[j] = (coeffs[j-1] + mulMod(coeffs[j], r, t)) % t
+		}
+		coeffs[0] = mulMod(coeffs[0], r, t)
+	}
+
+	return &Polynomial{Coeffs: coeffs}
+}
+
+// QueryExpansion is a type for the expansion of a PIR query, whose i-th slot selects the i-th entry of a database.
+// It stores the plaintext masks [0, ..., 0, 1_i, 0, ..., 0] and can be evaluated on a ciphertext by using the
+// Evaluator.ExpandQuery method.
+type QueryExpansion struct {
+	Masks []*PlaintextMul
+}
+
+// GenQueryExpansion allocates and encodes a new QueryExpansion for the first n slots.
+func GenQueryExpansion(encoder Encoder, params Parameters, n int) QueryExpansion {
+
+	if n < 1 || n > params.N() {
+		panic(fmt.Sprintf("cannot GenQueryExpansion: n must be in [1, %d]", params.N()))
+	}
+
+	masks := make([]*PlaintextMul, n)
+	values := make([]uint64, params.N())
+	for i := range masks {
+		values[i] = 1
+		masks[i] = NewPlaintextMul(params)
+		encoder.EncodeUintMul(values, masks[i])
+		values[i] = 0
+	}
+
+	return QueryExpansion{Masks: masks}
+}
+
+// GaloisElements returns the list of Galois elements needed for the evaluation of the query expansion.
+func (qe *QueryExpansion) GaloisElements(params Parameters) (galEls []uint64) {
+	return params.GaloisElementsForRowInnerSum()
+}
+
+// IsZero returns an encryption of 1 on the slots of ctIn that are equal to zero and of 0 on the other slots,
+// by evaluating 1 - ctIn^(T-1) with Fermat's little theorem. The power is computed with the repeated squarings
+// of ctIn in ceil(log2(T-1)) sequential multiplications.
+// Returns an error if the plaintext modulus T is not prime or if ctIn is not of degree 1.
+func (eval *evaluator) IsZero(ctIn *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	t := eval.params.T()
+
+	if !ring.IsPrime(t) {
+		return nil, fmt.Errorf("cannot IsZero: T=%d is not prime", t)
+	}
+
+	if ctIn.Degree() != 1 {
+		return nil, fmt.Errorf("cannot IsZero: input ciphertext must be of degree 1")
+	}
+
+	ctOut = eval.power(ctIn, t-1)
+
+	one := NewPlaintextRingT(eval.params)
+	one.Value.Coeffs[0][0] = 1
+	eval.Neg(ctOut, ctOut)
+	eval.Add(ctOut, one, ctOut)
+
+	return
+}
+
+// Equal returns an encryption of 1 on the slots where op0 and op1 are equal and of 0 on the other slots
+// (see IsZero).
+// Returns an error if the plaintext modulus T is not prime or if op0 or op1 is not of degree 1.
+func (eval *evaluator) Equal(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext, err error) {
+
+	if op1.Degree() > 1 {
+		return nil, fmt.Errorf("cannot Equal: op1 must be of degree 0 or 1")
+	}
+
+	return eval.IsZero(eval.SubNew(op0, op1))
+}
+
+// IsInSet returns an encryption of 1 on the slots of ctIn whose value belongs to the plaintext set and of 0
+// on the other slots, by evaluating the polynomial whose roots are the elements of the set followed by IsZero,
+// in ceil(log2(len(set)+1)) + ceil(log2(T-1)) sequential multiplications.
+// Returns an error if the set is empty, if the plaintext modulus T is not prime or if ctIn is not of degree 1.
+func (eval *evaluator) IsInSet(ctIn *Ciphertext, set []uint64) (ctOut *Ciphertext, err error) {
+
+	if len(set) == 0 {
+		return nil, fmt.Errorf("cannot IsInSet: set cannot be empty")
+	}
+
+	if ctOut, err = eval.EvaluatePoly(ctIn, NewPolyFromRoots(eval.params.T(), set)); err != nil {
+		return nil, err
+	}
+
+	return eval.IsZero(ctOut)
+}
+
+// ExpandQuery expands the query ctIn into len(qe.Masks) ciphertexts, the i-th of which encrypts the i-th slot
+// of ctIn replicated on all the slots. It requires the rotation keys for the Galois elements returned by
+// QueryExpansion.GaloisElements.
+func (eval *evaluator) ExpandQuery(ctIn *Ciphertext, qe QueryExpansion) (ctOut []*Ciphertext) {
+
+	if ctIn.Degree() != 1 {
+		panic("cannot ExpandQuery: input ciphertext must be of degree 1")
+	}
+
+	ctOut = make([]*Ciphertext, len(qe.Masks))
+	for i, mask := range qe.Masks {
+		ctOut[i] = NewCiphertext(eval.params, 1)
+		eval.Mul(ctIn, mask, ctOut[i])
+		eval.InnerSum(ctOut[i], ctOut[i])
+	}
+
+	return
+}
+
+// power returns ctIn^e for e > 0 by multiplying the repeated squarings of ctIn that match the bits of e,
+// always multiplying the two operands of smallest depth first so that the depth is ceil(log2(e)).
+func (eval *evaluator) power(ctIn *Ciphertext, e uint64) (ctOut *Ciphertext) {
+
+	type factor struct {
+		ct    *Ciphertext
+		depth int
+	}
+
+	factors := []factor{}
+
+	square := ctIn
+	for depth := 0; e > 0; depth, e = depth+1, e>>1 {
+		if e&1 == 1 {
+			factors = append(factors, factor{square, depth})
+		}
+		if e > 1 {
+			square = eval.RelinearizeNew(eval.MulNew(square, square))
+		}
+	}
+
+	for len(factors) > 1 {
+		sort.Slice(factors, func(i, j int) bool { return factors[i].depth < factors[j].depth })
+		prod := eval.RelinearizeNew(eval.MulNew(factors[0].ct, factors[1].ct))
+		factors = append(factors[2:], factor{prod, factors[1].depth + 1})
+	}
+
+	return factors[0].ct.CopyNew()
+}
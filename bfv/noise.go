@@ -0,0 +1,165 @@
+package bfv
+
+import (
+	"math"
+	"math/big"
+)
+
+// noiseEstimator tracks a heuristic bound on the noise of BFV ciphertexts without the secret key.
+//
+// The tracked quantity is the infinity norm of the invariant noise scaled by Q, that is ||t * ct(s) mod Q||,
+// where ct(s) = ct[0] + ct[1]*s + ... + ct[d]*s^d. Decryption is correct as long as it is smaller than Q/2,
+// so that the remaining noise budget in bits is log2(Q) - 1 - log2(||t * ct(s) mod Q||).
+//
+// All the bounds are stored and returned as base-2 logarithms. They rely on the usual heuristics that the
+// coefficients of the product of two polynomials are bounded by the expansion factor 2*sqrt(N) times the
+// product of the norms of the operands, and that the Gaussian errors are bounded by six standard deviations.
+type noiseEstimator struct {
+	logQ         float64 // log2(Q)
+	logT         float64 // log2(t)
+	logExpansion float64 // log2(2*sqrt(N)), the expansion factor of the ring
+	logRound     float64 // log2(1 + 2*sqrt(N)), the norm of (1 + s) for a ternary secret
+	logWrap      float64 // log2 of the norm of 1 + 2*ct(s)/Q for the ciphertexts entering the tensoring
+	logModDown   float64 // log2 of the norm of the error of the division by P, which floors instead of rounding
+	logFreshSk   float64 // noise of a fresh encryption with the secret key
+	logFreshPk   float64 // noise of a fresh encryption with the public key over QP
+	logFreshPkQ  float64 // noise of a fresh encryption with the public key over Q
+	logKeySwitch float64 // noise added by a key-switching
+}
+
+func newNoiseEstimator(params Parameters) (ne *noiseEstimator) {
+
+	ne = new(noiseEstimator)
+
+	N := float64(params.N())
+	sigma := params.Sigma()
+
+	ne.logQ = logBigInt(params.QBigInt())
+	ne.logT = math.Log2(float64(params.T()))
+	ne.logExpansion = 1 + 0.5*math.Log2(N)
+	ne.logRound = math.Log2(1 + 2*math.Sqrt(N))
+	// The tensoring extends the coefficients of the ciphertexts from Q to QMul with a fast basis extension, which
+	// returns x + u*Q where x/Q + u is the sum of #Qi uniform variables in [0, 1). The coefficients of ct(s)/Q thus
+	// have a variance of 2N/3 * (L/12 + L^2/4) with L = #Qi. Since their mean is L/2 * (1 + s), which depends on the
+	// secret key, they are bounded at twelve standard deviations.
+	L := float64(params.QCount())
+	ne.logWrap = math.Log2(1 + 24*math.Sqrt(N*L*(1+3*L)/18))
+
+	// r0 + r1*s with r0, r1 uniform in [0, 1)
+	ne.logModDown = math.Log2(6 * math.Sqrt((1+2*N/3)/3))
+
+	// Plaintexts are scaled by round(Q/t), which adds an error of norm at most t/2 to t * ct(s).
+	logPlaintext := ne.plaintext()
+
+	// sk : e
+	ne.logFreshSk = logAdd(ne.logT+math.Log2(6*sigma), logPlaintext)
+
+	// pk : u*e_pk + e0 + e1*s, with u and s ternary of variance 2/3
+	logErrPkQ := math.Log2(6 * sigma * math.Sqrt(1+4*N/3))
+	ne.logFreshPkQ = logAdd(ne.logT+logErrPkQ, logPlaintext)
+
+	ne.logFreshPk = ne.logFreshPkQ
+	ne.logKeySwitch = 0
+
+	if params.PCount() != 0 {
+
+		logP := logBigInt(params.PBigInt())
+
+		// (u*e_pk + e0 + e1*s)/P + error of the division by P
+		logErrPk := logAdd(logErrPkQ-logP, ne.logModDown)
+		ne.logFreshPk = logAdd(ne.logT+logErrPk, logPlaintext)
+
+		// sum_i digit_i * e_i / P + error of the division by P
+		levelQ, levelP := params.MaxLevel(), params.PCount()-1
+		logDigits := math.Log2(float64(params.DecompCount(levelQ, levelP))) + ne.logExpansion + logDigitBound(params) - 1 + math.Log2(6*sigma) - logP
+		ne.logKeySwitch = ne.logT + logAdd(logDigits, ne.logModDown)
+	}
+
+	return
+}
+
+// plaintext returns the noise of a plaintext operand.
+func (ne *noiseEstimator) plaintext() float64 {
+	return ne.logT - 1
+}
+
+// add returns the noise of the sum of two operands with noise a and b.
+func (ne *noiseEstimator) add(a, b float64) float64 {
+	return logAdd(a, b)
+}
+
+// mulScalar returns the noise of the product of an operand with noise a by scalar.
+func (ne *noiseEstimator) mulScalar(a float64, scalar uint64) float64 {
+	if scalar == 0 {
+		return 0
+	}
+	return a + math.Log2(float64(scalar))
+}
+
+// mulPlaintext returns the noise of the product of an operand with noise a by a plaintext of R_t,
+// whose coefficients are bounded by t/2.
+func (ne *noiseEstimator) mulPlaintext(a float64) float64 {
+	return a + ne.logExpansion + ne.logT - 1
+}
+
+// tensor returns the noise of the tensoring and rescaling by t/Q of two operands with noise a and b,
+// and degree degA and degB.
+//
+// Writing t*ct(s) = Q*(m + t*k) + v, the noise of the product is ||(m0 + t*k0)*v1 + (m1 + t*k1)*v0 + v0*v1/Q||
+// plus t times the rounding error of the rescaling, where ||m + t*k|| ~ t/2 * (1 + 2*||ct(s)/Q||)^deg.
+func (ne *noiseEstimator) tensor(a float64, degA int, b float64, degB int) float64 {
+	logMsgA := ne.logT - 1 + float64(degA)*ne.logWrap
+	logMsgB := ne.logT - 1 + float64(degB)*ne.logWrap
+	noise := logAdd(ne.logExpansion+logMsgA+b, ne.logExpansion+logMsgB+a)
+	noise = logAdd(noise, a+b-ne.logQ)
+	return logAdd(noise, ne.logT-1+float64(degA+degB)*ne.logRound)
+}
+
+// keySwitch returns the noise of an operand with noise a after count key-switchings.
+func (ne *noiseEstimator) keySwitch(a float64, count int) float64 {
+	return logAdd(a, math.Log2(float64(count))+ne.logKeySwitch)
+}
+
+// budget returns the remaining noise budget in bits of an operand with noise a, which is zero if a is unknown.
+func (ne *noiseEstimator) budget(a float64) float64 {
+	return math.Max(0, ne.logQ-1-a)
+}
+
+// logDigitBound returns the base-2 logarithm of the largest digit of the key-switching decomposition.
+func logDigitBound(params Parameters) (logDigit float64) {
+
+	if params.Pow2Base() != 0 {
+		return float64(params.Pow2Base())
+	}
+
+	alpha := params.Alpha()
+	qi := params.Q()
+	for i := 0; i < len(qi); i += alpha {
+		var logGroup float64
+		for j := i; j < i+alpha && j < len(qi); j++ {
+			logGroup += math.Log2(float64(qi[j]))
+		}
+		logDigit = math.Max(logDigit, logGroup)
+	}
+
+	return
+}
+
+// logAdd returns log2(2^a + 2^b).
+func logAdd(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(a, 1) || math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log2(1+math.Exp2(b-a))
+}
+
+// logBigInt returns log2(x) for x > 0.
+func logBigInt(x *big.Int) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(x).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
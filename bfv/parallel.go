@@ -0,0 +1,143 @@
+package bfv
+
+import (
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ParallelEvaluator is a pool of workers that evaluates batches of independent operations on ciphertexts across
+// goroutines. Each worker holds its own shallow copy of an Evaluator, and thus its own memory buffers, which are
+// reused from one operation to the next. The outputs are always in the order of the inputs.
+//
+// A panic raised by a worker is recovered and re-raised in the goroutine calling the ParallelEvaluator, once all the
+// other operations of the batch are completed, as a *utils.TaskPanic that holds the stack trace of the worker. Run
+// instead returns it as an error.
+//
+// A ParallelEvaluator must not be used concurrently by several goroutines.
+type ParallelEvaluator struct {
+	params     Parameters
+	evaluators []Evaluator
+	buffers    []*Ciphertext
+}
+
+// NewParallelEvaluator creates a new ParallelEvaluator with the given number of workers, each holding a shallow copy
+// of eval.
+func NewParallelEvaluator(params Parameters, eval Evaluator, workers int) *ParallelEvaluator {
+
+	if workers < 1 {
+		panic("cannot NewParallelEvaluator: workers must be at least 1")
+	}
+
+	p := &ParallelEvaluator{
+		params:     params,
+		evaluators: make([]Evaluator, workers),
+		buffers:    make([]*Ciphertext, workers),
+	}
+
+	for i := range p.evaluators {
+		p.evaluators[i] = eval.ShallowCopy()
+		p.buffers[i] = NewCiphertext(params, 2)
+	}
+
+	return p
+}
+
+// Workers returns the number of workers of the ParallelEvaluator.
+func (p *ParallelEvaluator) Workers() int {
+	return len(p.evaluators)
+}
+
+// Run calls task(eval, i) for each i in [0, n) across the workers, where eval is the Evaluator of the worker running
+// the task. The tasks must be independent and must only use the Evaluator they are given.
+// Run returns an error if a task panicked, as utils.RunParallel does.
+func (p *ParallelEvaluator) Run(n int, task func(eval Evaluator, i int)) (err error) {
+	return utils.RunParallel(len(p.evaluators), n, func(worker, i int) {
+		task(p.evaluators[worker], i)
+	})
+}
+
+// run calls Run and panics with its error, if any, which holds the stack trace of the panicking task.
+func (p *ParallelEvaluator) run(n int, task func(eval Evaluator, i int)) {
+	if err := p.Run(n, task); err != nil {
+		panic(err)
+	}
+}
+
+// MulRelin computes ctOut[i] = op0[i] * op1[i] with relinearization for each i. The degree 2 products are computed
+// in a buffer of the worker.
+func (p *ParallelEvaluator) MulRelin(op0, op1, ctOut []*Ciphertext) {
+
+	if len(op0) != len(op1) || len(op0) != len(ctOut) {
+		panic("cannot MulRelin: op0, op1 and ctOut must have the same length")
+	}
+
+	if err := utils.RunParallel(len(p.evaluators), len(op0), func(worker, i int) {
+		eval, tmp := p.evaluators[worker], p.buffers[worker]
+		eval.Mul(op0[i], op1[i], tmp)
+		eval.Relinearize(tmp, ctOut[i])
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// MulRelinNew returns op0[i] * op1[i] with relinearization for each i in new ciphertexts.
+func (p *ParallelEvaluator) MulRelinNew(op0, op1 []*Ciphertext) (ctOut []*Ciphertext) {
+
+	if len(op0) != len(op1) {
+		panic("cannot MulRelinNew: op0 and op1 must have the same length")
+	}
+
+	ctOut = make([]*Ciphertext, len(op0))
+	for i := range ctOut {
+		ctOut[i] = NewCiphertext(p.params, 1)
+	}
+
+	p.MulRelin(op0, op1, ctOut)
+
+	return
+}
+
+// RotateColumns rotates the columns of each ctIn[i] by k positions to the left and returns the result in ctOut[i].
+func (p *ParallelEvaluator) RotateColumns(ctIn []*Ciphertext, k int, ctOut []*Ciphertext) {
+
+	if len(ctIn) != len(ctOut) {
+		panic("cannot RotateColumns: ctIn and ctOut must have the same length")
+	}
+
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		eval.RotateColumns(ctIn[i], k, ctOut[i])
+	})
+}
+
+// RotateColumnsNew returns the rotations of the columns of each ctIn[i] by k positions to the left in new ciphertexts.
+func (p *ParallelEvaluator) RotateColumnsNew(ctIn []*Ciphertext, k int) (ctOut []*Ciphertext) {
+
+	ctOut = make([]*Ciphertext, len(ctIn))
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		ctOut[i] = eval.RotateColumnsNew(ctIn[i], k)
+	})
+
+	return
+}
+
+// LinearTransform evaluates linearTransform on each ctIn[i] and returns the result in ctOut[i].
+func (p *ParallelEvaluator) LinearTransform(ctIn []*Ciphertext, linearTransform LinearTransform, ctOut []*Ciphertext) {
+
+	if len(ctIn) != len(ctOut) {
+		panic("cannot LinearTransform: ctIn and ctOut must have the same length")
+	}
+
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		eval.LinearTransform(ctIn[i], linearTransform, ctOut[i])
+	})
+}
+
+// LinearTransformNew returns the evaluation of linearTransform on each ctIn[i] in new ciphertexts.
+func (p *ParallelEvaluator) LinearTransformNew(ctIn []*Ciphertext, linearTransform LinearTransform) (ctOut []*Ciphertext) {
+
+	ctOut = make([]*Ciphertext, len(ctIn))
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		ctOut[i] = eval.LinearTransformNew(ctIn[i], linearTransform)
+	})
+
+	return
+}
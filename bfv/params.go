@@ -100,13 +100,17 @@ var DefaultPostQuantumParams = []ParametersLiteral{PN12QP101pq, PN13QP202pq, PN1
 // Go programs. The NewParametersFromLiteral function is used to generate the actual
 // checked parameters from the literal representation.
 type ParametersLiteral struct {
-	LogN  int // Log Ring degree (power of 2)
-	Q     []uint64
-	P     []uint64
-	LogQ  []int   `json:",omitempty"`
-	LogP  []int   `json:",omitempty"`
-	Sigma float64 // Gaussian sampling standard deviation
-	T     uint64  // Plaintext modulus
+	LogN     int // Log Ring degree (power of 2)
+	Q        []uint64
+	P        []uint64
+	LogQ     []int   `json:",omitempty"`
+	LogP     []int   `json:",omitempty"`
+	Pow2Base int     `json:",omitempty"` // Base-2 logarithm of the key-switching digit decomposition (0 to disable)
+	DNum     int     `json:",omitempty"` // Number of elements of the key-switching RNS decomposition (0 for one element per #P moduli Q)
+	Sigma    float64 // Gaussian sampling standard deviation
+	T        uint64  // Plaintext modulus
+	Lambda   int     `json:",omitempty"` // Required classical security in bits (0 to disable the check, see rlwe.Parameters.CheckSecurity)
+	H        int     `json:",omitempty"` // Hamming weight of the secret for the security check (0 for a uniform ternary secret)
 }
 
 // Parameters represents a parameter set for the BFV cryptosystem. Its fields are private and
@@ -144,7 +148,7 @@ func NewParameters(rlweParams rlwe.Parameters, t uint64) (p Parameters, err erro
 // NewParametersFromLiteral instantiate a set of BFV parameters from a ParametersLiteral specification.
 // It returns the empty parameters Parameters{} and a non-nil error if the specified parameters are invalid.
 func NewParametersFromLiteral(pl ParametersLiteral) (Parameters, error) {
-	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, Sigma: pl.Sigma})
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, Pow2Base: pl.Pow2Base, DNum: pl.DNum, Sigma: pl.Sigma, Lambda: pl.Lambda, H: pl.H})
 	if err != nil {
 		return Parameters{}, err
 	}
@@ -200,7 +204,10 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary decodes a []byte into a parameter set struct.
 func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
-	if err := p.Parameters.UnmarshalBinary(data); err != nil {
+	if len(data) < 8 {
+		return fmt.Errorf("invalid bfv.Parameter serialization")
+	}
+	if err := p.Parameters.UnmarshalBinary(data[:len(data)-8]); err != nil {
 		return err
 	}
 	dataBfv := data[len(data)-8:]
@@ -223,7 +230,7 @@ func (p Parameters) MarshalBinarySize() int {
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), Sigma: p.Sigma(), T: p.T()})
+	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), Pow2Base: p.Pow2Base(), DNum: p.DNum(), Sigma: p.Sigma(), T: p.T()})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
@@ -0,0 +1,287 @@
+package bfv
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Polynomial is a struct storing the coefficients modulo T of a polynomial
+// that then can be evaluated on the ciphertext.
+type Polynomial struct {
+	Coeffs []uint64
+}
+
+// NewPoly creates a new Poly from the input coefficients, given in increasing degree.
+func NewPoly(coeffs []uint64) (p *Polynomial) {
+	c := make([]uint64, len(coeffs))
+	copy(c, coeffs)
+	return &Polynomial{Coeffs: c}
+}
+
+// Degree returns the degree of the polynomial.
+func (p *Polynomial) Degree() int {
+	return len(p.Coeffs) - 1
+}
+
+// Depth returns the number of sequential ciphertext-ciphertext multiplications needed to evaluate the polynomial.
+func (p *Polynomial) Depth() int {
+	return bits.Len64(uint64(p.Degree()))
+}
+
+// InterpolateLookupTable returns the polynomial of degree at most t-1 that evaluates to f(x) for each x in Z_t,
+// which enables the evaluation of any lookup table on the slots with Evaluator.EvaluatePoly.
+// The plaintext modulus t must be prime, and the interpolation takes O(t^2) operations, so that it is
+// only practical for small plaintext moduli.
+func InterpolateLookupTable(t uint64, f func(x uint64) uint64) (p *Polynomial, err error) {
+
+	if !ring.IsPrime(t) {
+		return nil, fmt.Errorf("cannot InterpolateLookupTable: t=%d is not prime", t)
+	}
+
+	// Since a^(t-1) = 1 for all a != 0, the interpolating polynomial is
+	// f(0) - sum_{k=1}^{t-1} (sum_{a in Z_t} f(a) * a^(t-1-k)) * X^k.
+	coeffs := make([]uint64, t)
+
+	for a := uint64(0); a < t; a++ {
+
+		fa := f(a) % t
+		if fa == 0 {
+			continue
+		}
+
+		// pow = f(a) * a^(t-1-k), with 0^0 = 1
+		pow := fa
+		for k := t - 1; k > 0; k-- {
+			coeffs[k] = (coeffs[k] + t - pow) % t
+			pow = mulMod(pow, a, t)
+		}
+	}
+
+	coeffs[0] = f(0) % t
+
+	return &Polynomial{Coeffs: coeffs}, nil
+}
+
+// polynomialEvaluator is a struct for the evaluation of polynomials modulo T on BFV ciphertexts.
+type polynomialEvaluator struct {
+	Evaluator
+	Encoder
+	params     Parameters
+	slotsIndex map[int][]int
+	ptMul      *PlaintextMul
+	ptRt       *PlaintextRingT
+	powers     map[int]*Ciphertext
+}
+
+// EvaluatePoly evaluates a polynomial with coefficients modulo T on the input Ciphertext with the
+// Paterson-Stockmeyer (baby-step giant-step) algorithm, in ceil(log2(deg+1)) sequential multiplications
+// and O(sqrt(deg) + log2(deg)) non-scalar multiplications.
+// Returns an error if the input ciphertext is not of degree 1 or if the polynomial is empty.
+func (eval *evaluator) EvaluatePoly(ctIn *Ciphertext, pol *Polynomial) (ctOut *Ciphertext, err error) {
+	return eval.evaluatePolyVector(ctIn, []*Polynomial{pol}, nil, nil)
+}
+
+// EvaluatePolyVector evaluates a vector of polynomials with coefficients modulo T on the input Ciphertext
+// with the Paterson-Stockmeyer (baby-step giant-step) algorithm, in ceil(log2(deg+1)) sequential multiplications
+// where deg is the largest degree of the polynomials. The coefficients of the polynomials are encoded slot-wise
+// with the provided encoder, and thus multiplied by plaintexts instead of scalars.
+// Returns an error if the input ciphertext is not of degree 1, if a polynomial is empty or if the slotsIndex
+// map references a polynomial or a slot that does not exist.
+// Inputs:
+// pols: a slice of *Polynomial, indexed from 0 to len(pols)-1.
+// slotsIndex: a map[int][]int indicating the slots of the ciphertext on which each polynomial is evaluated.
+// e.g. slotsIndex[0] = []int{0, 1, 2} evaluates pols[0] on the slots 0, 1 and 2.
+// The slots that are not referenced by slotsIndex are set to zero.
+func (eval *evaluator) EvaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotsIndex map[int][]int) (ctOut *Ciphertext, err error) {
+
+	if encoder == nil {
+		return nil, fmt.Errorf("cannot EvaluatePolyVector: encoder cannot be nil")
+	}
+
+	for i, slots := range slotsIndex {
+		if i < 0 || i >= len(pols) {
+			return nil, fmt.Errorf("cannot EvaluatePolyVector: slotsIndex references the missing polynomial %d", i)
+		}
+		for _, j := range slots {
+			if j < 0 || j >= eval.params.N() {
+				return nil, fmt.Errorf("cannot EvaluatePolyVector: slotsIndex references the invalid slot %d", j)
+			}
+		}
+	}
+
+	return eval.evaluatePolyVector(ctIn, pols, encoder, slotsIndex)
+}
+
+func (eval *evaluator) evaluatePolyVector(ctIn *Ciphertext, pols []*Polynomial, encoder Encoder, slotsIndex map[int][]int) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Degree() != 1 {
+		return nil, fmt.Errorf("cannot EvaluatePoly: input ciphertext must be of degree 1")
+	}
+
+	var length int
+	for _, pol := range pols {
+		if len(pol.Coeffs) == 0 {
+			return nil, fmt.Errorf("cannot EvaluatePoly: polynomial cannot be empty")
+		}
+		if len(pol.Coeffs) > length {
+			length = len(pol.Coeffs)
+		}
+	}
+
+	polyEval := &polynomialEvaluator{
+		Evaluator:  eval,
+		Encoder:    encoder,
+		params:     eval.params,
+		slotsIndex: slotsIndex,
+		powers:     map[int]*Ciphertext{1: ctIn},
+	}
+
+	if encoder != nil {
+		polyEval.ptMul = NewPlaintextMul(eval.params)
+		polyEval.ptRt = NewPlaintextRingT(eval.params)
+	}
+
+	logDegree := bits.Len64(uint64(length - 1))
+	logSplit := logDegree >> 1
+	if logSplit == 0 {
+		logSplit = 1
+	}
+
+	for i := 2; i <= 1<<logSplit && i < length; i++ {
+		polyEval.genPower(i)
+	}
+
+	for i := logSplit + 1; i < logDegree; i++ {
+		polyEval.genPower(1 << i)
+	}
+
+	return polyEval.recurse(pols, 0, length, logSplit), nil
+}
+
+// genPower computes X^n, with X^n = X^(n/2) * X^(n/2) if n is a power of two and
+// X^n = X^a * X^(n-a) otherwise, where a is the largest power of two smaller than n.
+func (polyEval *polynomialEvaluator) genPower(n int) {
+
+	if _, ok := polyEval.powers[n]; ok {
+		return
+	}
+
+	a := 1 << (bits.Len64(uint64(n)) - 1)
+	if a == n {
+		a >>= 1
+	}
+
+	polyEval.genPower(a)
+	polyEval.genPower(n - a)
+
+	polyEval.powers[n] = polyEval.RelinearizeNew(polyEval.MulNew(polyEval.powers[a], polyEval.powers[n-a]))
+}
+
+// recurse splits the coefficients [start, end) of the polynomials as q * X^(2^j) + r until they can
+// be evaluated with the baby-step powers.
+func (polyEval *polynomialEvaluator) recurse(pols []*Polynomial, start, end, logSplit int) (res *Ciphertext) {
+
+	if end-start <= 1<<logSplit {
+		return polyEval.evaluateBabyStep(pols, start, end)
+	}
+
+	nextPower := 1 << logSplit
+	for nextPower<<1 < end-start {
+		nextPower <<= 1
+	}
+
+	res = polyEval.RelinearizeNew(polyEval.MulNew(polyEval.recurse(pols, start+nextPower, end, logSplit), polyEval.powers[nextPower]))
+	polyEval.Add(res, polyEval.recurse(pols, start, start+nextPower, logSplit), res)
+
+	return
+}
+
+// evaluateBabyStep evaluates the linear combination of the baby-step powers with the coefficients [start, end)
+// of the polynomials. Scalar coefficients are taken in the centered representation modulo T to minimize the
+// noise growth, and vector coefficients are encoded slot-wise.
+func (polyEval *polynomialEvaluator) evaluateBabyStep(pols []*Polynomial, start, end int) (res *Ciphertext) {
+
+	// res is the zero ciphertext until the first term is added, so its noise is known and starts at zero
+	// (see noiseEstimator.mulScalar) instead of being unknown.
+	res = NewCiphertext(polyEval.params, 1)
+	res.Noise = 0
+	tmp := NewCiphertext(polyEval.params, 1)
+
+	for i := 1; i < end-start; i++ {
+		if polyEval.Encoder == nil {
+			polyEval.addScalarTimesPower(pols[0], start+i, i, res, tmp)
+		} else if polyEval.encodeCoefficients(pols, start+i) {
+			polyEval.Mul(polyEval.powers[i], polyEval.ptMul, tmp)
+			polyEval.Add(res, tmp, res)
+		}
+	}
+
+	if polyEval.Encoder == nil {
+		if c := coefficient(pols[0], start, polyEval.params.T()); c != 0 {
+			pt := NewPlaintextRingT(polyEval.params)
+			pt.Value.Coeffs[0][0] = c
+			polyEval.Add(res, pt, res)
+		}
+	} else if polyEval.encodeCoefficients(pols, start) {
+		polyEval.Add(res, polyEval.ptRt, res)
+	}
+
+	return
+}
+
+// addScalarTimesPower adds to res the k-th coefficient of pol times X^i.
+func (polyEval *polynomialEvaluator) addScalarTimesPower(pol *Polynomial, k, i int, res, tmp *Ciphertext) {
+
+	t := polyEval.params.T()
+
+	if c := coefficient(pol, k, t); c != 0 {
+		if c > t>>1 {
+			polyEval.MulScalar(polyEval.powers[i], t-c, tmp)
+			polyEval.Sub(res, tmp, res)
+		} else {
+			polyEval.MulScalar(polyEval.powers[i], c, tmp)
+			polyEval.Add(res, tmp, res)
+		}
+	}
+}
+
+// encodeCoefficients encodes slot-wise the k-th coefficients of the polynomials on the internal plaintexts
+// and returns false if all of them are zero.
+func (polyEval *polynomialEvaluator) encodeCoefficients(pols []*Polynomial, k int) (nonZero bool) {
+
+	t := polyEval.params.T()
+
+	values := make([]uint64, polyEval.params.N())
+	for i, slots := range polyEval.slotsIndex {
+		if c := coefficient(pols[i], k, t); c != 0 {
+			for _, j := range slots {
+				values[j] = c
+			}
+			nonZero = true
+		}
+	}
+
+	if nonZero {
+		polyEval.EncodeUintRingT(values, polyEval.ptRt)
+		polyEval.RingTToMul(polyEval.ptRt, polyEval.ptMul)
+	}
+
+	return
+}
+
+// coefficient returns the k-th coefficient of pol modulo t, which is zero if k exceeds the degree of pol.
+func coefficient(pol *Polynomial, k int, t uint64) uint64 {
+	if k >= len(pol.Coeffs) {
+		return 0
+	}
+	return pol.Coeffs[k] % t
+}
+
+// mulMod returns x * y mod m for any modulus m.
+func mulMod(x, y, m uint64) uint64 {
+	hi, lo := bits.Mul64(x, y)
+	_, rem := bits.Div64(hi%m, lo, m)
+	return rem
+}
@@ -0,0 +1,561 @@
+package bgv
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+var flagLongTest = flag.Bool("long", false, "run the long test suite (all parameters). Overrides -short and requires -timeout=0.")
+var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
+
+func testString(opname string, p Parameters) string {
+	return fmt.Sprintf("%s/LogN=%d/logQ=%d/alpha=%d/beta=%d", opname, p.LogN(), p.LogQP(), p.PCount(), p.Beta())
+}
+
+type testContext struct {
+	params      Parameters
+	ringQ       *ring.Ring
+	ringT       *ring.Ring
+	prng        utils.PRNG
+	uSampler    *ring.UniformSampler
+	encoder     Encoder
+	kgen        rlwe.KeyGenerator
+	sk          *rlwe.SecretKey
+	pk          *rlwe.PublicKey
+	rlk         *rlwe.RelinearizationKey
+	encryptorPk Encryptor
+	encryptorSk Encryptor
+	decryptor   Decryptor
+	evaluator   Evaluator
+}
+
+func TestBGV(t *testing.T) {
+
+	defaultParams := DefaultParams // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
+	if testing.Short() {
+		defaultParams = DefaultParams[:2] // the short test suite runs for ring degree N=2^12, 2^13
+	}
+	if *flagParamString != "" {
+		var jsonParams ParametersLiteral
+		json.Unmarshal([]byte(*flagParamString), &jsonParams)
+		defaultParams = []ParametersLiteral{jsonParams} // the custom test suite reads the parameters from the -params flag
+	}
+
+	for _, p := range defaultParams {
+
+		params, err := NewParametersFromLiteral(p)
+		if err != nil {
+			panic(err)
+		}
+		var testctx *testContext
+		if testctx, err = genTestParams(params); err != nil {
+			panic(err)
+		}
+
+		for _, testSet := range []func(testctx *testContext, t *testing.T){
+			testParameters,
+			testEncoder,
+			testEncryptor,
+			testEvaluator,
+			testEvaluatorRescale,
+			testEvaluatorKeySwitch,
+			testEvaluatorRotate,
+			testMarshaller,
+		} {
+			testSet(testctx, t)
+			runtime.GC()
+		}
+	}
+}
+
+func TestBGVPrimePowerT(t *testing.T) {
+
+	// T = 65537^2: the inverses mod T cannot be computed with Fermat's little theorem
+	paramsLit := DefaultParams[1]
+	paramsLit.T = 65537 * 65537
+
+	params, err := NewParametersFromLiteral(paramsLit)
+	require.NoError(t, err)
+
+	testctx, err := genTestParams(params)
+	require.NoError(t, err)
+
+	for _, testSet := range []func(testctx *testContext, t *testing.T){
+		testEncoder,
+		testEvaluator,
+		testEvaluatorRescale,
+	} {
+		testSet(testctx, t)
+		runtime.GC()
+	}
+}
+
+func genTestParams(params Parameters) (testctx *testContext, err error) {
+
+	testctx = new(testContext)
+	testctx.params = params
+
+	if testctx.prng, err = utils.NewPRNG(); err != nil {
+		return nil, err
+	}
+
+	testctx.ringQ = params.RingQ()
+	testctx.ringT = params.RingT()
+
+	testctx.uSampler = ring.NewUniformSampler(testctx.prng, testctx.ringT)
+	testctx.kgen = NewKeyGenerator(testctx.params)
+	testctx.sk, testctx.pk = testctx.kgen.GenKeyPair()
+	if params.PCount() != 0 {
+		testctx.rlk = testctx.kgen.GenRelinearizationKey(testctx.sk, 1)
+	}
+
+	testctx.encoder = NewEncoder(testctx.params)
+	testctx.encryptorPk = NewEncryptor(testctx.params, testctx.pk)
+	testctx.encryptorSk = NewEncryptor(testctx.params, testctx.sk)
+	testctx.decryptor = NewDecryptor(testctx.params, testctx.sk)
+	testctx.evaluator = NewEvaluator(testctx.params, rlwe.EvaluationKey{Rlk: testctx.rlk})
+	return
+}
+
+func newTestVectorsLvl(testctx *testContext, level int, encryptor Encryptor) (coeffs *ring.Poly, plaintext *Plaintext, ciphertext *Ciphertext) {
+
+	coeffs = testctx.uSampler.ReadNew()
+
+	plaintext = NewPlaintext(testctx.params, level)
+
+	testctx.encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+
+	if encryptor != nil {
+		ciphertext = encryptor.EncryptNew(plaintext)
+	}
+
+	return coeffs, plaintext, ciphertext
+}
+
+func newTestVectors(testctx *testContext, encryptor Encryptor) (coeffs *ring.Poly, plaintext *Plaintext, ciphertext *Ciphertext) {
+	return newTestVectorsLvl(testctx, testctx.params.MaxLevel(), encryptor)
+}
+
+func newTestVectorsMul(testctx *testContext) (coeffs *ring.Poly, plaintext *PlaintextMul) {
+
+	coeffs = testctx.uSampler.ReadNew()
+
+	plaintext = NewPlaintextMul(testctx.params, testctx.params.MaxLevel())
+
+	testctx.encoder.EncodeUintMul(coeffs.Coeffs[0], plaintext)
+
+	return coeffs, plaintext
+}
+
+func verifyTestVectors(testctx *testContext, decryptor Decryptor, coeffs *ring.Poly, element Operand, t *testing.T) {
+
+	var coeffsTest []uint64
+
+	switch el := element.(type) {
+	case *Plaintext, *PlaintextMul:
+		coeffsTest = testctx.encoder.DecodeUintNew(el)
+	case *Ciphertext:
+		coeffsTest = testctx.encoder.DecodeUintNew(decryptor.DecryptNew(el))
+	default:
+		t.Error("invalid test object to verify")
+	}
+
+	require.True(t, utils.EqualSliceUint64(coeffs.Coeffs[0], coeffsTest))
+}
+
+func testParameters(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Parameters/CopyNew", testctx.params), func(t *testing.T) {
+		params1, params2 := testctx.params.CopyNew(), testctx.params.CopyNew()
+		assert.True(t, params1.Equals(testctx.params) && params2.Equals(testctx.params))
+		params1.ringT, _ = ring.NewRing(testctx.params.N(), []uint64{7})
+		assert.False(t, params1.Equals(testctx.params))
+		assert.True(t, params2.Equals(testctx.params))
+	})
+
+	t.Run(testString("Parameters/InvalidT", testctx.params), func(t *testing.T) {
+		_, err := NewParameters(testctx.params.Parameters, testctx.params.Q()[0])
+		assert.NotNil(t, err)
+	})
+}
+
+func testEncoder(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Encoder/Encode&Decode/Uint", testctx.params), func(t *testing.T) {
+		values, plaintext, _ := newTestVectors(testctx, nil)
+		verifyTestVectors(testctx, nil, values, plaintext, t)
+	})
+
+	t.Run(testString("Encoder/Encode&Decode/Uint/Level0", testctx.params), func(t *testing.T) {
+		values, plaintext, _ := newTestVectorsLvl(testctx, 0, nil)
+		verifyTestVectors(testctx, nil, values, plaintext, t)
+	})
+
+	t.Run(testString("Encoder/Encode&Decode/Int", testctx.params), func(t *testing.T) {
+
+		T := testctx.params.T()
+		THalf := T >> 1
+		coeffs := testctx.uSampler.ReadNew()
+		coeffsInt := make([]int64, len(coeffs.Coeffs[0]))
+		for i, c := range coeffs.Coeffs[0] {
+			c %= T
+			if c >= THalf {
+				coeffsInt[i] = -int64(T - c)
+			} else {
+				coeffsInt[i] = int64(c)
+			}
+		}
+
+		plaintext := NewPlaintext(testctx.params, testctx.params.MaxLevel())
+		testctx.encoder.EncodeInt(coeffsInt, plaintext)
+		require.True(t, utils.EqualSliceInt64(coeffsInt, testctx.encoder.DecodeIntNew(plaintext)))
+	})
+
+	t.Run(testString("Encoder/Encode&Decode/PlaintextMul", testctx.params), func(t *testing.T) {
+		values, plaintext := newTestVectorsMul(testctx)
+		verifyTestVectors(testctx, nil, values, plaintext, t)
+	})
+}
+
+func testEncryptor(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Encryptor/Encrypt/Pk", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Encryptor/Encrypt/Sk", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorSk)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Encryptor/Encrypt/Sk/Level0", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectorsLvl(testctx, 0, testctx.encryptorSk)
+		require.Equal(t, 0, ciphertext.Level())
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Encryptor/EncryptFromCRP", testctx.params), func(t *testing.T) {
+		values, plaintext, _ := newTestVectors(testctx, nil)
+		crp := ring.NewUniformSampler(testctx.prng, testctx.ringQ).ReadNew()
+		ciphertext := testctx.encryptorSk.EncryptFromCRPNew(plaintext, crp)
+		require.True(t, testctx.ringQ.Equal(crp, ciphertext.Value[1]))
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+}
+
+func testEvaluator(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Evaluator/Add/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorSk)
+		testctx.evaluator.Add(ciphertext1, ciphertext2, ciphertext1)
+		testctx.ringT.Add(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/AddNew/op1=Ciphertext/op2=Plaintext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, plaintext2, _ := newTestVectors(testctx, nil)
+		ciphertext3 := testctx.evaluator.AddNew(ciphertext1, plaintext2)
+		testctx.ringT.Add(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext3, t)
+	})
+
+	t.Run(testString("Evaluator/Add/DifferentScales", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorPk)
+
+		// Changes the scale of the second ciphertext without changing the underlying message
+		scale := uint64(3)
+		testctx.evaluator.MulScalar(ciphertext2, scale, ciphertext2)
+		ciphertext2.Scale = scale
+
+		testctx.evaluator.Add(ciphertext1, ciphertext2, ciphertext1)
+		testctx.ringT.Add(values1, values2, values1)
+		require.Equal(t, uint64(1), ciphertext1.Scale)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/Add/DifferentLevels", testctx.params), func(t *testing.T) {
+		if testctx.params.MaxLevel() == 0 {
+			t.Skip("#Qi is 1")
+		}
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectorsLvl(testctx, 0, testctx.encryptorPk)
+		testctx.evaluator.Add(ciphertext1, ciphertext2, ciphertext1)
+		testctx.ringT.Add(values1, values2, values1)
+		require.Equal(t, 0, ciphertext1.Level())
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/Sub/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorSk)
+		testctx.evaluator.Sub(ciphertext1, ciphertext2, ciphertext1)
+		testctx.ringT.Sub(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/SubNew/op1=Ciphertext/op2=Plaintext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, plaintext2, _ := newTestVectors(testctx, nil)
+		ciphertext3 := testctx.evaluator.SubNew(ciphertext1, plaintext2)
+		testctx.ringT.Sub(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext3, t)
+	})
+
+	t.Run(testString("Evaluator/Neg", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		testctx.evaluator.Neg(ciphertext, ciphertext)
+		testctx.ringT.Neg(values, values)
+		testctx.ringT.Reduce(values, values)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/MulScalarNew", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		ciphertext = testctx.evaluator.MulScalarNew(ciphertext, 12345)
+		testctx.ringT.MulScalar(values, 12345, values)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/Mul/op1=Ciphertext/op2=Plaintext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, plaintext2, _ := newTestVectors(testctx, nil)
+		testctx.evaluator.Mul(ciphertext1, plaintext2, ciphertext1)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/Mul/op1=Ciphertext/op2=PlaintextMul", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, plaintext2 := newTestVectorsMul(testctx)
+		testctx.evaluator.Mul(ciphertext1, plaintext2, ciphertext1)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+	})
+
+	t.Run(testString("Evaluator/MulNew/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorPk)
+		receiver := testctx.evaluator.MulNew(ciphertext1, ciphertext2)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+		require.Equal(t, 2, receiver.Degree())
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+	})
+
+	t.Run(testString("Evaluator/MulSquare/op1=Ciphertext/op2=Ciphertext", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		testctx.evaluator.Mul(ciphertext, ciphertext, ciphertext)
+		testctx.ringT.MulCoeffs(values, values, values)
+		require.Equal(t, 2, ciphertext.Degree())
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/Mul/Relinearize", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorPk)
+
+		receiver := testctx.evaluator.MulNew(ciphertext1, ciphertext2)
+		testctx.ringT.MulCoeffs(values1, values2, values1)
+
+		receiver = testctx.evaluator.RelinearizeNew(receiver)
+		require.Equal(t, 1, receiver.Degree())
+		verifyTestVectors(testctx, testctx.decryptor, values1, receiver, t)
+	})
+}
+
+func testEvaluatorRescale(testctx *testContext, t *testing.T) {
+
+	if testctx.params.MaxLevel() == 0 {
+		t.Skip("#Qi is 1")
+	}
+
+	t.Run(testString("Evaluator/Rescale", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		level := ciphertext.Level()
+		require.NoError(t, testctx.evaluator.Rescale(ciphertext, ciphertext))
+		require.Equal(t, level-1, ciphertext.Level())
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/RescaleNew/Level0", testctx.params), func(t *testing.T) {
+		_, _, ciphertext := newTestVectorsLvl(testctx, 0, testctx.encryptorPk)
+		_, err := testctx.evaluator.RescaleNew(ciphertext)
+		require.Error(t, err)
+	})
+
+	t.Run(testString("Evaluator/MulRelin/Rescale", testctx.params), func(t *testing.T) {
+
+		if testctx.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		values1, _, ciphertext1 := newTestVectors(testctx, testctx.encryptorPk)
+		values2, _, ciphertext2 := newTestVectors(testctx, testctx.encryptorPk)
+
+		for ciphertext1.Level() > 0 {
+			testctx.evaluator.Mul(ciphertext1, ciphertext2, ciphertext1)
+			testctx.evaluator.Relinearize(ciphertext1, ciphertext1)
+			require.NoError(t, testctx.evaluator.Rescale(ciphertext1, ciphertext1))
+			testctx.evaluator.DropLevel(ciphertext2, 1)
+			testctx.ringT.MulCoeffs(values1, values2, values1)
+			verifyTestVectors(testctx, testctx.decryptor, values1, ciphertext1, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/DropLevelNew", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		ciphertext = testctx.evaluator.DropLevelNew(ciphertext, ciphertext.Level())
+		require.Equal(t, 0, ciphertext.Level())
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+}
+
+func testEvaluatorKeySwitch(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	sk2 := testctx.kgen.GenSecretKey()
+	decryptorSk2 := NewDecryptor(testctx.params, sk2)
+	switchKey := testctx.kgen.GenSwitchingKey(testctx.sk, sk2)
+
+	t.Run(testString("Evaluator/KeySwitch/InPlace", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		testctx.evaluator.SwitchKeys(ciphertext, switchKey, ciphertext)
+		verifyTestVectors(testctx, decryptorSk2, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/KeySwitch/New", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		ciphertext = testctx.evaluator.SwitchKeysNew(ciphertext, switchKey)
+		verifyTestVectors(testctx, decryptorSk2, values, ciphertext, t)
+	})
+}
+
+func testEvaluatorRotate(testctx *testContext, t *testing.T) {
+
+	if testctx.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	rots := []int{1, -1, 4, -4, 63, -63}
+	rotkey := testctx.kgen.GenRotationKeysForRotations(rots, true, testctx.sk)
+	evaluator := testctx.evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+	t.Run(testString("Evaluator/RotateRows", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+		evaluator.RotateRows(ciphertext, ciphertext)
+		values.Coeffs[0] = append(values.Coeffs[0][testctx.params.N()>>1:], values.Coeffs[0][:testctx.params.N()>>1]...)
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+
+	t.Run(testString("Evaluator/RotateColumns", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+
+		receiver := NewCiphertext(testctx.params, 1, ciphertext.Level())
+		for _, n := range rots {
+
+			evaluator.RotateColumns(ciphertext, n, receiver)
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], n)
+
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+	})
+
+	t.Run(testString("Evaluator/RotateColumnsNew/Level0", testctx.params), func(t *testing.T) {
+
+		values, _, ciphertext := newTestVectorsLvl(testctx, 0, testctx.encryptorPk)
+
+		for _, n := range rots {
+
+			receiver := evaluator.RotateColumnsNew(ciphertext, n)
+			valuesWant := utils.RotateUint64Slots(values.Coeffs[0], n)
+
+			verifyTestVectors(testctx, testctx.decryptor, &ring.Poly{Coeffs: [][]uint64{valuesWant}}, receiver, t)
+		}
+	})
+
+	rotkey = testctx.kgen.GenRotationKeysForInnerSum(testctx.sk)
+	evaluator = evaluator.WithKey(rlwe.EvaluationKey{Rlk: testctx.rlk, Rtks: rotkey})
+
+	t.Run(testString("Evaluator/Rotate/InnerSum", testctx.params), func(t *testing.T) {
+		values, _, ciphertext := newTestVectors(testctx, testctx.encryptorPk)
+
+		evaluator.InnerSum(ciphertext, ciphertext)
+
+		var sum uint64
+		for _, c := range values.Coeffs[0] {
+			sum += c
+		}
+
+		sum %= testctx.params.T()
+
+		for i := range values.Coeffs[0] {
+			values.Coeffs[0][i] = sum
+		}
+		verifyTestVectors(testctx, testctx.decryptor, values, ciphertext, t)
+	})
+}
+
+func testMarshaller(testctx *testContext, t *testing.T) {
+
+	t.Run(testString("Marshaller/Parameters/Binary", testctx.params), func(t *testing.T) {
+		bytes, err := testctx.params.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Equal(t, testctx.params.MarshalBinarySize(), len(bytes))
+		var p Parameters
+		err = p.UnmarshalBinary(bytes)
+		assert.Nil(t, err)
+		assert.Equal(t, testctx.params, p)
+	})
+
+	t.Run(testString("Marshaller/Parameters/JSON", testctx.params), func(t *testing.T) {
+		data, err := json.Marshal(testctx.params)
+		assert.Nil(t, err)
+		assert.NotNil(t, data)
+
+		var paramsRec Parameters
+		err = json.Unmarshal(data, &paramsRec)
+		assert.Nil(t, err)
+		assert.True(t, testctx.params.Equals(paramsRec))
+	})
+
+	t.Run(testString("Marshaller/Ciphertext", testctx.params), func(t *testing.T) {
+
+		ciphertextWant := NewCiphertextRandom(testctx.prng, testctx.params, 2, testctx.params.MaxLevel())
+		ciphertextWant.Scale = 1234
+
+		marshalledCiphertext, err := ciphertextWant.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, ciphertextWant.GetDataLen(true), len(marshalledCiphertext))
+
+		ciphertextTest := new(Ciphertext)
+		err = ciphertextTest.UnmarshalBinary(marshalledCiphertext)
+		require.NoError(t, err)
+
+		require.Equal(t, ciphertextWant.Scale, ciphertextTest.Scale)
+		for i := range ciphertextWant.Value {
+			require.True(t, testctx.ringQ.Equal(ciphertextWant.Value[i], ciphertextTest.Value[i]))
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Decryptor is an interface wrapping a rlwe.Decryptor.
+type Decryptor interface {
+	DecryptNew(ciphertext *Ciphertext) (plaintext *Plaintext)
+	Decrypt(ciphertext *Ciphertext, plaintext *Plaintext)
+}
+
+type decryptor struct {
+	rlwe.Decryptor
+	params Parameters
+}
+
+// NewDecryptor instantiates a Decryptor for the BGV scheme.
+func NewDecryptor(params Parameters, sk *rlwe.SecretKey) Decryptor {
+	return &decryptor{rlwe.NewDecryptor(params.Parameters, sk), params}
+}
+
+// Decrypt decrypts the ciphertext and write the result in ptOut.
+// The level of the output plaintext is min(ciphertext.Level(), ptOut.Level()).
+func (dec *decryptor) Decrypt(ct *Ciphertext, ptOut *Plaintext) {
+	dec.Decryptor.Decrypt(ct.Ciphertext, ptOut.Plaintext)
+	ptOut.Scale = ct.Scale
+}
+
+// DecryptNew decrypts the ciphertext and returns the result in a newly allocated Plaintext.
+func (dec *decryptor) DecryptNew(ct *Ciphertext) (ptOut *Plaintext) {
+	ptOut = NewPlaintext(dec.params, ct.Level())
+	dec.Decrypt(ct, ptOut)
+	return
+}
@@ -0,0 +1,201 @@
+// Package bgv implements a RNS-accelerated version of the Brakerski-Gentry-Vaikuntanathan leveled homomorphic encryption scheme
+// with modulus switching. It provides modular arithmetic over the integers.
+package bgv
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Encoder is an interface for plaintext encoding and decoding operations. It provides methods to embed []uint64 and []int64 types into
+// the Plaintext and PlaintextMul types and the inverse operations. The batching (i.e. the mapping between the slots and R_T)
+// is the same as the one of the bfv package: the plaintext is first encoded in R_T by a bfv.Encoder and its
+// coefficients are then lifted to R_Q (in the NTT domain).
+type Encoder interface {
+	EncodeUint(coeffs []uint64, pt *Plaintext)
+	EncodeUintMul(coeffs []uint64, pt *PlaintextMul)
+	EncodeInt(coeffs []int64, pt *Plaintext)
+	EncodeIntMul(coeffs []int64, pt *PlaintextMul)
+
+	DecodeUint(pt interface{}, coeffs []uint64)
+	DecodeInt(pt interface{}, coeffs []int64)
+	DecodeUintNew(pt interface{}) (coeffs []uint64)
+	DecodeIntNew(pt interface{}) (coeffs []int64)
+}
+
+// encoder is a structure that stores the parameters to encode values on a plaintext in a SIMD (Single-Instruction Multiple-Data) fashion.
+type encoder struct {
+	params Parameters
+
+	bfvEncoder bfv.Encoder
+	rescaler   *rescaler
+
+	tmpPoly *ring.Poly
+	tmpPtRt *bfv.PlaintextRingT
+}
+
+// NewEncoder creates a new encoder from the provided parameters.
+func NewEncoder(params Parameters) Encoder {
+
+	bfvParams, err := bfv.NewParameters(params.Parameters, params.T())
+	if err != nil {
+		panic(err)
+	}
+
+	return &encoder{
+		params:     params,
+		bfvEncoder: bfv.NewEncoder(bfvParams),
+		rescaler:   newRescaler(params),
+		tmpPoly:    params.RingQ().NewPoly(),
+		tmpPtRt:    &bfv.PlaintextRingT{Plaintext: rlwe.NewPlaintext(params.Parameters, 0)},
+	}
+}
+
+// EncodeUint encodes an uint64 slice of size at most N on a plaintext.
+func (ecd *encoder) EncodeUint(coeffs []uint64, pt *Plaintext) {
+	ecd.bfvEncoder.EncodeUintRingT(coeffs, ecd.tmpPtRt)
+	ecd.liftRingT(pt.Level(), ecd.tmpPtRt.Value, pt.Value)
+	pt.Scale = 1
+}
+
+// EncodeUintMul encodes an uint64 slice of size at most N on a plaintext optimized for ciphertext x plaintext multiplication.
+func (ecd *encoder) EncodeUintMul(coeffs []uint64, pt *PlaintextMul) {
+	ecd.bfvEncoder.EncodeUintRingT(coeffs, ecd.tmpPtRt)
+	ecd.liftRingT(pt.Level(), ecd.tmpPtRt.Value, pt.Value)
+	ecd.params.RingQ().MFormLvl(pt.Level(), pt.Value, pt.Value)
+	pt.Scale = 1
+}
+
+// EncodeInt encodes an int64 slice of size at most N on a plaintext. It also encodes the sign of the given integer (as its inverse modulo the plaintext modulus).
+// The sign will correctly decode as long as the absolute value of the coefficient does not exceed half of the plaintext modulus.
+func (ecd *encoder) EncodeInt(coeffs []int64, pt *Plaintext) {
+	ecd.bfvEncoder.EncodeIntRingT(coeffs, ecd.tmpPtRt)
+	ecd.liftRingT(pt.Level(), ecd.tmpPtRt.Value, pt.Value)
+	pt.Scale = 1
+}
+
+// EncodeIntMul encodes an int64 slice of size at most N on a plaintext optimized for ciphertext x plaintext multiplication.
+func (ecd *encoder) EncodeIntMul(coeffs []int64, pt *PlaintextMul) {
+	ecd.bfvEncoder.EncodeIntRingT(coeffs, ecd.tmpPtRt)
+	ecd.liftRingT(pt.Level(), ecd.tmpPtRt.Value, pt.Value)
+	ecd.params.RingQ().MFormLvl(pt.Level(), pt.Value, pt.Value)
+	pt.Scale = 1
+}
+
+// liftRingT maps the coefficients of pRt in R_T to their centered representative in R_Q and puts them in the NTT domain.
+func (ecd *encoder) liftRingT(level int, pRt, pOut *ring.Poly) {
+
+	ringQ := ecd.params.RingQ()
+	t := ecd.params.T()
+	tHalf := t >> 1
+
+	coeffsRt := pRt.Coeffs[0]
+
+	for i := 0; i < level+1; i++ {
+		qi := ringQ.Modulus[i]
+		tmp := pOut.Coeffs[i]
+		for j, c := range coeffsRt {
+			// The coefficients in R_T might not be fully reduced
+			if c >= t {
+				c -= t
+			}
+
+			if c > tHalf {
+				tmp[j] = qi - t + c
+			} else {
+				tmp[j] = c
+			}
+		}
+	}
+
+	ringQ.NTTLvl(level, pOut, pOut)
+	pOut.IsNTT = true
+}
+
+// decodeRingT scales down the plaintext to level zero, reduces its coefficients mod T and removes
+// its scaling factor, writing the result on the R_T plaintext buffer of the encoder.
+func (ecd *encoder) decodeRingT(p interface{}) {
+
+	ringQ := ecd.params.RingQ()
+
+	var level int
+	var scale uint64
+
+	switch pt := p.(type) {
+	case *Plaintext:
+		level, scale = pt.Level(), pt.Scale
+		ring.CopyValuesLvl(level, pt.Value, ecd.tmpPoly)
+	case *PlaintextMul:
+		level, scale = pt.Level(), pt.Scale
+		ringQ.InvMFormLvl(level, pt.Value, ecd.tmpPoly)
+	default:
+		panic(fmt.Errorf("unsupported plaintext type (%T)", pt))
+	}
+
+	tmp := ecd.tmpPoly
+	tmp.Coeffs = tmp.Coeffs[:level+1]
+
+	for ; level > 0; level-- {
+		ecd.rescaler.rescale(level, tmp, tmp)
+		scale = ecd.rescaler.rescaleScale(level, scale)
+	}
+
+	ringQ.InvNTTLvl(0, tmp, tmp)
+
+	t := ecd.params.T()
+	q0 := ringQ.Modulus[0]
+	q0Half := q0 >> 1
+	q0ModT := q0 % t
+
+	scaleInv := ecd.rescaler.invScale(scale)
+	bredParams := ecd.params.RingT().BredParams[0]
+
+	coeffsRt := ecd.tmpPtRt.Value.Coeffs[0]
+
+	for j, c := range tmp.Coeffs[0] {
+
+		if c > q0Half {
+			// c - q0 mod T
+			c = (c%t + t - q0ModT) % t
+		} else {
+			c %= t
+		}
+
+		coeffsRt[j] = ring.BRed(c, scaleInv, t, bredParams)
+	}
+
+	// Restores the buffer to its full size
+	ecd.tmpPoly.Coeffs = ecd.tmpPoly.Coeffs[:len(ringQ.Modulus)]
+}
+
+// DecodeUint decodes a Plaintext or a PlaintextMul and write the coefficients in coeffs. It panics if p is not Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeUint(p interface{}, coeffs []uint64) {
+	ecd.decodeRingT(p)
+	ecd.bfvEncoder.DecodeUint(ecd.tmpPtRt, coeffs)
+}
+
+// DecodeUintNew decodes a Plaintext or a PlaintextMul and returns the coefficients in a new []uint64.
+// It panics if p is not Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeUintNew(p interface{}) (coeffs []uint64) {
+	coeffs = make([]uint64, ecd.params.N())
+	ecd.DecodeUint(p, coeffs)
+	return
+}
+
+// DecodeInt decodes a Plaintext or a PlaintextMul and write the coefficients in coeffs. It also decodes the sign
+// modulus (by centering the values around the plaintext). It panics if p is not Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeInt(p interface{}, coeffs []int64) {
+	ecd.decodeRingT(p)
+	ecd.bfvEncoder.DecodeInt(ecd.tmpPtRt, coeffs)
+}
+
+// DecodeIntNew decodes a Plaintext or a PlaintextMul and returns the coefficients in a new []int64. It also decodes the sign
+// modulus (by centering the values around the plaintext). It panics if p is not Plaintext or PlaintextMul.
+func (ecd *encoder) DecodeIntNew(p interface{}) (coeffs []int64) {
+	coeffs = make([]int64, ecd.params.N())
+	ecd.DecodeInt(p, coeffs)
+	return
+}
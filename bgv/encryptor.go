@@ -0,0 +1,100 @@
+package bgv
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Encryptor an encryption interface for the BGV scheme.
+type Encryptor interface {
+	Encrypt(plaintext *Plaintext, ciphertext *Ciphertext)
+	EncryptNew(plaintext *Plaintext) *Ciphertext
+	EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ctOut *Ciphertext)
+	EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext
+}
+
+// encryptor encrypts a plaintext m as T * Enc(0) + m, so that the noise of the
+// resulting ciphertext is a multiple of T.
+type encryptor struct {
+	rlwe.Encryptor
+	params Parameters
+
+	tInvModQ *big.Int
+	ptZero   *rlwe.Plaintext
+	pool     *ring.Poly
+}
+
+// NewEncryptor instantiates a new Encryptor for the BGV scheme. The key argument can
+// be either a *rlwe.PublicKey or a *rlwe.SecretKey.
+func NewEncryptor(params Parameters, key interface{}) Encryptor {
+	return newEncryptor(params, rlwe.NewEncryptor(params.Parameters, key))
+}
+
+// NewFastEncryptor instantiates a new Encryptor for the BGV scheme.
+// This encryptor's Encrypt method first encrypts zero in Q and then adds the plaintext.
+// This method is faster than the normal encryptor but result in a noisier ciphertext.
+func NewFastEncryptor(params Parameters, key *rlwe.PublicKey) Encryptor {
+	return newEncryptor(params, rlwe.NewFastEncryptor(params.Parameters, key))
+}
+
+func newEncryptor(params Parameters, enc rlwe.Encryptor) *encryptor {
+	ptZero := rlwe.NewPlaintext(params.Parameters, params.MaxLevel())
+	ptZero.Value.IsNTT = true
+	return &encryptor{
+		Encryptor: enc,
+		params:    params,
+		tInvModQ:  new(big.Int).ModInverse(params.RingT().ModulusBigint, params.RingQ().ModulusBigint),
+		ptZero:    ptZero,
+		pool:      params.RingQ().NewPoly(),
+	}
+}
+
+// Encrypt encrypts the input plaintext and write the result on ctOut.
+// The encryption algorithm depends on how the receiver encryptor was initialized (see
+// NewEncryptor and NewFastEncryptor).
+func (enc *encryptor) Encrypt(plaintext *Plaintext, ctOut *Ciphertext) {
+	enc.Encryptor.Encrypt(enc.ptZero, ctOut.Ciphertext)
+	enc.addPlaintext(plaintext, ctOut)
+}
+
+// EncryptNew encrypts the input plaintext returns the result as a newly allocated ciphertext.
+// The encryption algorithm depends on how the receiver encryptor was initialized (see
+// NewEncryptor and NewFastEncryptor).
+func (enc *encryptor) EncryptNew(plaintext *Plaintext) *Ciphertext {
+	ct := NewCiphertext(enc.params, 1, plaintext.Level())
+	enc.Encrypt(plaintext, ct)
+	return ct
+}
+
+// EncryptFromCRP encrypts the input plaintext and writes the result in ctOut.
+// The passed crp is always treated as being in the NTT domain and is the second
+// element of the output ciphertext.
+func (enc *encryptor) EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ctOut *Ciphertext) {
+	level := utils.MinInt(plaintext.Level(), ctOut.Level())
+	// The crp is multiplied by T^-1 such that the second element of T * Enc(0) is equal to crp.
+	enc.params.RingQ().MulScalarBigintLvl(level, crp, enc.tInvModQ, enc.pool)
+	enc.Encryptor.EncryptFromCRP(enc.ptZero, enc.pool, ctOut.Ciphertext)
+	enc.addPlaintext(plaintext, ctOut)
+}
+
+// EncryptFromCRPNew encrypts the input plaintext and returns the result as a newly allocated ciphertext.
+// The passed crp is always treated as being in the NTT domain and is the second
+// element of the output ciphertext.
+func (enc *encryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) *Ciphertext {
+	ct := NewCiphertext(enc.params, 1, plaintext.Level())
+	enc.EncryptFromCRP(plaintext, crp, ct)
+	return ct
+}
+
+// addPlaintext computes ctOut = T * ctOut + plaintext.
+func (enc *encryptor) addPlaintext(plaintext *Plaintext, ctOut *Ciphertext) {
+	ringQ := enc.params.RingQ()
+	level := utils.MinInt(plaintext.Level(), ctOut.Level())
+	ringQ.MulScalarLvl(level, ctOut.Value[0], enc.params.T(), ctOut.Value[0])
+	ringQ.MulScalarLvl(level, ctOut.Value[1], enc.params.T(), ctOut.Value[1])
+	ringQ.AddLvl(level, ctOut.Value[0], plaintext.Value, ctOut.Value[0])
+	ctOut.Scale = plaintext.Scale
+}
@@ -0,0 +1,677 @@
+package bgv
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Operand is a common interface for Ciphertext and Plaintext types.
+type Operand interface {
+	El() *rlwe.Ciphertext
+	Degree() int
+	Level() int
+	ScalingFactor() uint64
+}
+
+// Evaluator is an interface implementing the public methods of the eval.
+type Evaluator interface {
+	Add(op0, op1 Operand, ctOut *Ciphertext)
+	AddNew(op0, op1 Operand) (ctOut *Ciphertext)
+	Sub(op0, op1 Operand, ctOut *Ciphertext)
+	SubNew(op0, op1 Operand) (ctOut *Ciphertext)
+	Neg(op Operand, ctOut *Ciphertext)
+	NegNew(op Operand) (ctOut *Ciphertext)
+	MulScalar(op Operand, scalar uint64, ctOut *Ciphertext)
+	MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext)
+	Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext)
+	MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext)
+	Relinearize(ct0 *Ciphertext, ctOut *Ciphertext)
+	RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext)
+	Rescale(ctIn, ctOut *Ciphertext) (err error)
+	RescaleNew(ctIn *Ciphertext) (ctOut *Ciphertext, err error)
+	DropLevel(ct0 *Ciphertext, levels int)
+	DropLevelNew(ct0 *Ciphertext, levels int) (ctOut *Ciphertext)
+	SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext)
+	SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingKey) (ctOut *Ciphertext)
+	RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext)
+	RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext)
+	RotateRows(ct0 *Ciphertext, ctOut *Ciphertext)
+	RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext)
+	InnerSum(ct0 *Ciphertext, ctOut *Ciphertext)
+	ShallowCopy() Evaluator
+	WithKey(rlwe.EvaluationKey) Evaluator
+}
+
+// evaluator is a struct that holds the necessary elements to perform the homomorphic operations between ciphertexts and/or plaintexts.
+// It also holds a memory pool used to store intermediate results.
+type evaluator struct {
+	*evaluatorBase
+	*evaluatorBuffers
+	*rlwe.KeySwitcher
+
+	rlk             *rlwe.RelinearizationKey
+	rtks            *rlwe.RotationKeySet
+	permuteNTTIndex map[uint64][]uint64
+}
+
+type evaluatorBase struct {
+	params Parameters
+	ringQ  *ring.Ring
+	t      uint64
+
+	tInvModQ *big.Int
+}
+
+func newEvaluatorBase(params Parameters) *evaluatorBase {
+	ev := new(evaluatorBase)
+	ev.params = params
+	ev.ringQ = params.RingQ()
+	ev.t = params.T()
+	ev.tInvModQ = new(big.Int).ModInverse(params.RingT().ModulusBigint, params.RingQ().ModulusBigint)
+	return ev
+}
+
+type evaluatorBuffers struct {
+	rescaler *rescaler
+	poolQ    [3]*ring.Poly // Memory pool for the MForm of the operands
+	ctxpool  *Ciphertext   // Memory pool for the tensoring and for the operands whose scale must be matched
+}
+
+func newEvaluatorBuffers(evalBase *evaluatorBase) *evaluatorBuffers {
+	evb := new(evaluatorBuffers)
+	params := evalBase.params
+	evb.rescaler = newRescaler(params)
+	evb.poolQ = [3]*ring.Poly{evalBase.ringQ.NewPoly(), evalBase.ringQ.NewPoly(), evalBase.ringQ.NewPoly()}
+	evb.ctxpool = NewCiphertext(params, 2, params.MaxLevel())
+	return evb
+}
+
+// NewEvaluator creates a new Evaluator, that can be used to do homomorphic
+// operations on ciphertexts and/or plaintexts. It stores a memory pool
+// and ciphertexts that will be used for intermediate values.
+func NewEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) Evaluator {
+	eval := new(evaluator)
+	eval.evaluatorBase = newEvaluatorBase(params)
+	eval.evaluatorBuffers = newEvaluatorBuffers(eval.evaluatorBase)
+
+	eval.rlk = evaluationKey.Rlk
+	eval.rtks = evaluationKey.Rtks
+	eval.permuteNTTIndex = *eval.permuteNTTIndexesForKey(eval.rtks)
+
+	if params.PCount() != 0 {
+		eval.KeySwitcher = rlwe.NewKeySwitcher(params.Parameters)
+	}
+
+	return eval
+}
+
+// NewEvaluators creates n evaluators sharing the same read-only data-structures.
+func NewEvaluators(params Parameters, evaluationKey rlwe.EvaluationKey, n int) []Evaluator {
+	if n <= 0 {
+		return []Evaluator{}
+	}
+	evas := make([]Evaluator, n)
+	for i := range evas {
+		if i == 0 {
+			evas[0] = NewEvaluator(params, evaluationKey)
+		} else {
+			evas[i] = evas[i-1].ShallowCopy()
+		}
+	}
+	return evas
+}
+
+func (eval *evaluator) permuteNTTIndexesForKey(rtks *rlwe.RotationKeySet) *map[uint64][]uint64 {
+	if rtks == nil {
+		return &map[uint64][]uint64{}
+	}
+	permuteNTTIndex := make(map[uint64][]uint64, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		permuteNTTIndex[galEl] = eval.ringQ.PermuteNTTIndex(galEl)
+	}
+	return &permuteNTTIndex
+}
+
+// ShallowCopy creates a shallow copy of this evaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluators can be used concurrently.
+func (eval *evaluator) ShallowCopy() Evaluator {
+	var ks *rlwe.KeySwitcher
+	if eval.KeySwitcher != nil {
+		ks = eval.KeySwitcher.ShallowCopy()
+	}
+	return &evaluator{
+		evaluatorBase:    eval.evaluatorBase,
+		evaluatorBuffers: newEvaluatorBuffers(eval.evaluatorBase),
+		KeySwitcher:      ks,
+		rlk:              eval.rlk,
+		rtks:             eval.rtks,
+		permuteNTTIndex:  eval.permuteNTTIndex,
+	}
+}
+
+// WithKey creates a shallow copy of the receiver Evaluator for which the new EvaluationKey is evaluationKey
+// and where the temporary buffers are shared. The receiver and the returned Evaluators cannot be used concurrently.
+func (eval *evaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	var indexes map[uint64][]uint64
+	if evaluationKey.Rtks == eval.rtks {
+		indexes = eval.permuteNTTIndex
+	} else {
+		indexes = *eval.permuteNTTIndexesForKey(evaluationKey.Rtks)
+	}
+	return &evaluator{
+		evaluatorBase:    eval.evaluatorBase,
+		evaluatorBuffers: eval.evaluatorBuffers,
+		KeySwitcher:      eval.KeySwitcher,
+		rlk:              evaluationKey.Rlk,
+		rtks:             evaluationKey.Rtks,
+		permuteNTTIndex:  indexes,
+	}
+}
+
+// getElemAndCheckBinary returns the level of the operation and resizes ctOut to the given degree and level.
+func (eval *evaluator) getElemAndCheckBinary(op0, op1 Operand, ctOut *Ciphertext, opOutMinDegree int) (level int) {
+
+	if op0 == nil || op1 == nil || ctOut == nil {
+		panic("operands cannot be nil")
+	}
+
+	if op0.Degree()+op1.Degree() == 0 {
+		panic("operands cannot be both plaintexts")
+	}
+
+	level = utils.MinInt(op0.Level(), op1.Level())
+
+	if ctOut.Level() < level {
+		panic("cannot evaluate: receiver level is smaller than the level of the operands")
+	}
+
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+
+	if ctOut.Degree() != opOutMinDegree {
+		ctOut.El().Resize(eval.params.Parameters, opOutMinDegree)
+	}
+
+	return
+}
+
+// matchScale returns an element equal to op1 multiplied by the scale of op0 times the inverse of the
+// scale of op1, such that the returned element has the same scaling factor as op0.
+func (eval *evaluator) matchScale(level int, op0, op1 Operand) *rlwe.Ciphertext {
+
+	if op0.ScalingFactor() == op1.ScalingFactor() {
+		return op1.El()
+	}
+
+	r := eval.rescaler
+	factor := r.mulScale(op0.ScalingFactor(), r.invScale(op1.ScalingFactor()))
+
+	el1 := op1.El()
+
+	if eval.ctxpool.Degree() < el1.Degree() {
+		eval.ctxpool.El().Resize(eval.params.Parameters, el1.Degree())
+	}
+
+	tmp := &rlwe.Ciphertext{Value: eval.ctxpool.Value[:el1.Degree()+1]}
+
+	for i := range el1.Value {
+		eval.ringQ.MulScalarLvl(level, el1.Value[i], factor, tmp.Value[i])
+	}
+
+	return tmp
+}
+
+func (eval *evaluator) evaluateInPlace(op0, op1 Operand, ctOut *Ciphertext, evaluate func(int, *ring.Poly, *ring.Poly, *ring.Poly), neg bool) {
+
+	d0, d1 := op0.Degree(), op1.Degree()
+
+	maxDegree := utils.MaxInt(d0, d1)
+	minDegree := utils.MinInt(d0, d1)
+
+	level := eval.getElemAndCheckBinary(op0, op1, ctOut, maxDegree)
+
+	scale := op0.ScalingFactor()
+
+	el0 := op0.El()
+	el1 := eval.matchScale(level, op0, op1)
+
+	for i := 0; i < minDegree+1; i++ {
+		evaluate(level, el0.Value[i], el1.Value[i], ctOut.Value[i])
+	}
+
+	// Copies the remaining coefficients of the operand of largest degree
+	if d0 > d1 {
+		for i := minDegree + 1; i < maxDegree+1; i++ {
+			ring.CopyValuesLvl(level, el0.Value[i], ctOut.Value[i])
+		}
+	} else if d1 > d0 {
+		for i := minDegree + 1; i < maxDegree+1; i++ {
+			if neg {
+				eval.ringQ.NegLvl(level, el1.Value[i], ctOut.Value[i])
+			} else {
+				ring.CopyValuesLvl(level, el1.Value[i], ctOut.Value[i])
+			}
+		}
+	}
+
+	ctOut.Scale = scale
+}
+
+// Add adds op0 to op1 and returns the result in ctOut. If the scales of the operands differ,
+// op1 is multiplied by a factor mod T such that its scale matches the one of op0.
+func (eval *evaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.evaluateInPlace(op0, op1, ctOut, eval.ringQ.AddLvl, false)
+}
+
+// AddNew adds op0 to op1 and returns the result in a newly created element.
+func (eval *evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()))
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// Sub subtracts op1 from op0 and returns the result in ctOut. If the scales of the operands differ,
+// op1 is multiplied by a factor mod T such that its scale matches the one of op0.
+func (eval *evaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	eval.evaluateInPlace(op0, op1, ctOut, eval.ringQ.SubLvl, true)
+}
+
+// SubNew subtracts op1 from op0 and returns the result in a newly created element.
+func (eval *evaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()))
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+// Neg negates op and returns the result in ctOut.
+func (eval *evaluator) Neg(op Operand, ctOut *Ciphertext) {
+
+	if op.Degree() != ctOut.Degree() {
+		panic("cannot Neg: invalid receiver Ciphertext does not match operand degree")
+	}
+
+	level := utils.MinInt(op.Level(), ctOut.Level())
+
+	for i := range op.El().Value {
+		eval.ringQ.NegLvl(level, op.El().Value[i], ctOut.Value[i])
+	}
+
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+	ctOut.Scale = op.ScalingFactor()
+}
+
+// NegNew negates op and returns the result in a newly created element.
+func (eval *evaluator) NegNew(op Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree(), op.Level())
+	eval.Neg(op, ctOut)
+	return
+}
+
+// MulScalar multiplies op by an uint64 scalar and returns the result in ctOut.
+func (eval *evaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) {
+
+	if op.Degree() != ctOut.Degree() {
+		panic("cannot MulScalar: invalid receiver Ciphertext does not match operand degree")
+	}
+
+	level := utils.MinInt(op.Level(), ctOut.Level())
+
+	scalar %= eval.t
+
+	for i := range op.El().Value {
+		eval.ringQ.MulScalarLvl(level, op.El().Value[i], scalar, ctOut.Value[i])
+	}
+
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+	ctOut.Scale = op.ScalingFactor()
+}
+
+// MulScalarNew multiplies op by an uint64 scalar and returns the result in a newly created element.
+func (eval *evaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op.Degree(), op.Level())
+	eval.MulScalar(op, scalar, ctOut)
+	return
+}
+
+// Mul multiplies op0 by op1 and returns the result in ctOut. The output degree is the sum of the
+// degrees of the operands and its scale is the product of their scales mod T.
+func (eval *evaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
+
+	d0, d1 := op0.Degree(), op1.Degree()
+
+	level := eval.getElemAndCheckBinary(op0, op1, ctOut, d0+d1)
+
+	ringQ := eval.ringQ
+
+	scale := eval.rescaler.mulScale(op0.Scale, op1.ScalingFactor())
+
+	switch op1 := op1.(type) {
+	case *PlaintextMul:
+		for i := 0; i < d0+1; i++ {
+			ringQ.MulCoeffsMontgomeryLvl(level, op0.Value[i], op1.Value, ctOut.Value[i])
+		}
+	case *Plaintext:
+		ringQ.MFormLvl(level, op1.Value, eval.poolQ[0])
+		for i := 0; i < d0+1; i++ {
+			ringQ.MulCoeffsMontgomeryLvl(level, op0.Value[i], eval.poolQ[0], ctOut.Value[i])
+		}
+	default:
+		eval.tensor(level, d0, d1, op0.El(), op1.El(), ctOut.El())
+	}
+
+	ctOut.Scale = scale
+}
+
+// tensor computes the tensor product of el0 and el1 (i.e. the coefficients of the polynomial product
+// of the two ciphertexts seen as polynomials in the secret key) and writes the result on elOut.
+// The degrees d0 and d1 of the operands are given explicitly as elOut might share its polynomials with them.
+func (eval *evaluator) tensor(level, d0, d1 int, el0, el1, elOut *rlwe.Ciphertext) {
+
+	ringQ := eval.ringQ
+
+	if d0 == 1 && d1 == 1 {
+
+		c00 := eval.poolQ[0]
+		c01 := eval.poolQ[1]
+		c2 := eval.poolQ[2]
+
+		ringQ.MFormLvl(level, el0.Value[0], c00)
+		ringQ.MFormLvl(level, el0.Value[1], c01)
+
+		// c2 = c01 * c11
+		ringQ.MulCoeffsMontgomeryLvl(level, c01, el1.Value[1], c2)
+
+		// c1 = c00 * c11 + c01 * c10
+		ringQ.MulCoeffsMontgomeryLvl(level, c00, el1.Value[1], elOut.Value[1])
+		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c01, el1.Value[0], elOut.Value[1])
+
+		// c0 = c00 * c10
+		ringQ.MulCoeffsMontgomeryLvl(level, c00, el1.Value[0], elOut.Value[0])
+
+		ring.CopyValuesLvl(level, c2, elOut.Value[2])
+
+		return
+	}
+
+	// General case: the product is computed on newly allocated polynomials
+	// as the receiver might share its polynomials with the operands.
+	el0MForm := make([]*ring.Poly, d0+1)
+	for i := range el0MForm {
+		el0MForm[i] = ringQ.NewPolyLvl(level)
+		ringQ.MFormLvl(level, el0.Value[i], el0MForm[i])
+	}
+
+	res := make([]*ring.Poly, d0+d1+1)
+	for i := range res {
+		res[i] = ringQ.NewPolyLvl(level)
+	}
+
+	for i := 0; i < d0+1; i++ {
+		for j := 0; j < d1+1; j++ {
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, el0MForm[i], el1.Value[j], res[i+j])
+		}
+	}
+
+	for i := range res {
+		ring.CopyValuesLvl(level, res[i], elOut.Value[i])
+	}
+}
+
+// MulNew multiplies op0 by op1 and returns the result in a newly created element.
+func (eval *evaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, op0.Degree()+op1.Degree(), utils.MinInt(op0.Level(), op1.Level()))
+	eval.Mul(op0, op1, ctOut)
+	return
+}
+
+// switchKeysInPlace applies the key-switching procedure on cx and returns the result on p0 and p1.
+// Since the noise of a BGV ciphertext must be a multiple of T, cx is multiplied by T^-1 before
+// the key-switching and the result by T after the key-switching.
+func (eval *evaluator) switchKeysInPlace(level int, cx *ring.Poly, swk *rlwe.SwitchingKey, p0, p1 *ring.Poly) {
+	ringQ := eval.ringQ
+	cxTInv := eval.Pool[3].Q
+	ringQ.MulScalarBigintLvl(level, cx, eval.tInvModQ, cxTInv)
+	cxTInv.IsNTT = true
+	eval.SwitchKeysInPlace(level, cxTInv, swk, p0, p1)
+	ringQ.MulScalarLvl(level, p0, eval.t, p0)
+	ringQ.MulScalarLvl(level, p1, eval.t, p1)
+}
+
+// Relinearize relinearizes the ciphertext ct0 of degree > 1 until it is of degree 1, and returns the result in ctOut.
+//
+// It requires a correct evaluation key as additional input:
+//
+// - it must match the secret-key that was used to create the public key under which the current ct0 is encrypted.
+//
+// - it must be of degree high enough to relinearize the input ciphertext to degree 1 (e.g., a ciphertext
+// of degree 3 will require that the evaluation key stores the keys for both degree 3 and degree 2 ciphertexts).
+func (eval *evaluator) Relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if eval.rlk == nil {
+		panic("evaluator has no relinearization key")
+	}
+
+	if ct0.Degree()-1 > len(eval.rlk.Keys) {
+		panic("cannot Relinearize: input ciphertext degree too large to allow relinearization with the evaluator's relinearization key")
+	}
+
+	if ct0.Degree() < 2 {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0)
+		}
+		return
+	}
+
+	level := utils.MinInt(ct0.Level(), ctOut.Level())
+
+	ringQ := eval.ringQ
+
+	if ctOut != ct0 {
+		ring.CopyValuesLvl(level, ct0.Value[0], ctOut.Value[0])
+		ring.CopyValuesLvl(level, ct0.Value[1], ctOut.Value[1])
+	}
+
+	for deg := ct0.Degree(); deg > 1; deg-- {
+		eval.switchKeysInPlace(level, ct0.Value[deg], eval.rlk.Keys[deg-2], eval.Pool[1].Q, eval.Pool[2].Q)
+		ringQ.AddLvl(level, ctOut.Value[0], eval.Pool[1].Q, ctOut.Value[0])
+		ringQ.AddLvl(level, ctOut.Value[1], eval.Pool[2].Q, ctOut.Value[1])
+	}
+
+	ctOut.Scale = ct0.Scale
+	ctOut.El().Resize(eval.params.Parameters, 1)
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+}
+
+// RelinearizeNew relinearizes the ciphertext ct0 of degree > 1 until it is of degree 1, and returns the result in a newly created ciphertext.
+//
+// It requires a correct evaluation key as additional input:
+//
+// - it must match the secret-key that was used to create the public key under which the current ct0 is encrypted.
+//
+// - it must be of degree high enough to relinearize the input ciphertext to degree 1 (e.g., a ciphertext
+// of degree 3 will require that the evaluation key stores the keys for both degree 3 and degree 2 ciphertexts).
+func (eval *evaluator) RelinearizeNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ct0.Level())
+	eval.Relinearize(ct0, ctOut)
+	return
+}
+
+// Rescale divides ctIn by the last modulus of its moduli chain and returns the result in ctOut, which is one level below ctIn.
+// The message is preserved up to a multiplication by the inverse mod T of the dropped modulus, which is tracked in the scale of ctOut.
+// Returns an error if ctIn.Level() = 0, if ctIn.Degree() != ctOut.Degree() or if ctOut.Level() < ctIn.Level()-1.
+func (eval *evaluator) Rescale(ctIn, ctOut *Ciphertext) (err error) {
+
+	level := ctIn.Level()
+
+	if level == 0 {
+		return errors.New("cannot Rescale: input Ciphertext already at level 0")
+	}
+
+	if ctOut.Degree() != ctIn.Degree() {
+		return errors.New("cannot Rescale: ctIn.Degree() != ctOut.Degree()")
+	}
+
+	if ctOut.Level() < level-1 {
+		return errors.New("cannot Rescale: ctOut.Level() < ctIn.Level()-1")
+	}
+
+	for i := range ctIn.Value {
+		eval.rescaler.rescale(level, ctIn.Value[i], ctOut.Value[i])
+	}
+
+	ctOut.Scale = eval.rescaler.rescaleScale(level, ctIn.Scale)
+
+	return nil
+}
+
+// RescaleNew divides ctIn by the last modulus of its moduli chain and returns the result in a newly created element.
+// Returns an error if ctIn.Level() = 0.
+func (eval *evaluator) RescaleNew(ctIn *Ciphertext) (ctOut *Ciphertext, err error) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level())
+	return ctOut, eval.Rescale(ctIn, ctOut)
+}
+
+// DropLevel reduces the level of ct0 by levels and returns the result in ct0.
+// No rescaling is applied during this procedure and the scale of ct0 is unchanged.
+func (eval *evaluator) DropLevel(ct0 *Ciphertext, levels int) {
+	level := ct0.Level()
+	for i := range ct0.Value {
+		ct0.Value[i].Coeffs = ct0.Value[i].Coeffs[:level+1-levels]
+	}
+}
+
+// DropLevelNew reduces the level of ct0 by levels and returns the result in a newly created element.
+// No rescaling is applied during this procedure and the scale is unchanged.
+func (eval *evaluator) DropLevelNew(ct0 *Ciphertext, levels int) (ctOut *Ciphertext) {
+	ctOut = ct0.CopyNew()
+	eval.DropLevel(ctOut, levels)
+	return
+}
+
+// SwitchKeys applies the key-switching procedure to the ciphertext ct0 and returns the result in ctOut. It requires as an additional input a valid switching-key:
+// it must encrypt the target key under the public key under which ct0 is currently encrypted.
+func (eval *evaluator) SwitchKeys(ct0 *Ciphertext, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot SwitchKeys: input and output Ciphertext must be of degree 1")
+	}
+
+	level := utils.MinInt(ct0.Level(), ctOut.Level())
+
+	eval.switchKeysInPlace(level, ct0.Value[1], switchKey, eval.Pool[1].Q, eval.Pool[2].Q)
+
+	eval.ringQ.AddLvl(level, ct0.Value[0], eval.Pool[1].Q, ctOut.Value[0])
+	ring.CopyValuesLvl(level, eval.Pool[2].Q, ctOut.Value[1])
+
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+	ctOut.Scale = ct0.Scale
+}
+
+// SwitchKeysNew applies the key-switching procedure to the ciphertext ct0 and creates a new ciphertext to store the result. It requires as an additional input a valid switching-key:
+// it must encrypt the target key under the public key under which ct0 is currently encrypted.
+func (eval *evaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *rlwe.SwitchingKey) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ct0.Level())
+	eval.SwitchKeys(ct0, switchkey, ctOut)
+	return
+}
+
+// RotateColumns rotates the columns of ct0 by k positions to the left and returns the result in ctOut. As an additional input it requires a RotationKeys struct:
+//
+// - it must either store all the left and right power-of-2 rotations or the specific rotation that is requested.
+//
+// If only the power-of-two rotations are stored, the numbers k and n/2-k will be decomposed in base-2 and the rotation with the lowest
+// hamming weight will be chosen; then the specific rotation will be computed as a sum of powers of two rotations.
+func (eval *evaluator) RotateColumns(ct0 *Ciphertext, k int, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot RotateColumns: input and or output must be of degree 1")
+	}
+
+	if k == 0 {
+		ctOut.Copy(ct0)
+	} else {
+		eval.permuteNTT(ct0, eval.params.GaloisElementForColumnRotationBy(k), ctOut)
+	}
+}
+
+// RotateColumnsNew applies RotateColumns and returns the result in a new Ciphertext.
+func (eval *evaluator) RotateColumnsNew(ct0 *Ciphertext, k int) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ct0.Level())
+	eval.RotateColumns(ct0, k, ctOut)
+	return
+}
+
+// RotateRows rotates the rows of ct0 and returns the result in ctOut.
+func (eval *evaluator) RotateRows(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot RotateRows: input and/or output must be of degree 1")
+	}
+
+	eval.permuteNTT(ct0, eval.params.GaloisElementForRowRotation(), ctOut)
+}
+
+// RotateRowsNew rotates the rows of ct0 and returns the result a new Ciphertext.
+func (eval *evaluator) RotateRowsNew(ct0 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, ct0.Level())
+	eval.RotateRows(ct0, ctOut)
+	return
+}
+
+// InnerSum computes the inner sum of ct0 and returns the result in ctOut. It requires a rotation key storing all the left powers of two rotations.
+// The resulting vector will be of the form [sum, sum, .., sum, sum].
+func (eval *evaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot InnerSum: input and output must be of degree 1")
+	}
+
+	cTmp := NewCiphertext(eval.params, 1, ct0.Level())
+
+	ctOut.Copy(ct0)
+
+	for i := 1; i < eval.params.N()>>1; i <<= 1 {
+		eval.RotateColumns(ctOut, i, cTmp)
+		eval.Add(cTmp, ctOut, ctOut)
+	}
+
+	eval.RotateRows(ctOut, cTmp)
+	eval.Add(ctOut, cTmp, ctOut)
+}
+
+// permuteNTT applies the Galois automorphism galEl on ct0 followed by a key-switching, and returns the result in ctOut.
+func (eval *evaluator) permuteNTT(ct0 *Ciphertext, galEl uint64, ctOut *Ciphertext) {
+
+	if eval.rtks == nil {
+		panic("evaluator has no rotation keys")
+	}
+
+	rtk, generated := eval.rtks.GetRotationKey(galEl)
+	if !generated {
+		panic(fmt.Sprintf("rotation key k=%d not available", eval.params.InverseGaloisElement(galEl)))
+	}
+
+	level := utils.MinInt(ct0.Level(), ctOut.Level())
+	index, ok := eval.permuteNTTIndex[galEl]
+	if !ok {
+		index = eval.ringQ.PermuteNTTIndex(galEl)
+	}
+
+	pool2Q := eval.Pool[1].Q
+	pool3Q := eval.Pool[2].Q
+
+	eval.switchKeysInPlace(level, ct0.Value[1], rtk, pool2Q, pool3Q)
+
+	eval.ringQ.AddLvl(level, pool2Q, ct0.Value[0], pool2Q)
+
+	eval.ringQ.PermuteNTTWithIndexLvl(level, pool2Q, index, ctOut.Value[0])
+	eval.ringQ.PermuteNTTWithIndexLvl(level, pool3Q, index, ctOut.Value[1])
+
+	eval.DropLevel(ctOut, ctOut.Level()-level)
+	ctOut.Scale = ct0.Scale
+}
@@ -0,0 +1,54 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Plaintext is a Element with only one Poly. It represents a plaintext element in R_Q, in the NTT domain,
+// whose coefficients are the centered lift of the message mod T. The Scale is the factor mod T by which the
+// message is multiplied. This is the generic all-purpose type of plaintext: it will work with all operations.
+type Plaintext struct {
+	*rlwe.Plaintext
+	Scale uint64
+}
+
+// PlaintextMul represents a plaintext element in R_Q, in the NTT and Montgomery domain.
+// A PlaintextMul is a special-purpose plaintext for efficient Ciphertext-Plaintext multiplication. However,
+// other operations on plaintexts are not supported.
+type PlaintextMul Plaintext
+
+// NewPlaintext creates and allocates a new plaintext at the given level, in the NTT domain and with a scale of 1.
+func NewPlaintext(params Parameters, level int) *Plaintext {
+	pt := &Plaintext{Plaintext: rlwe.NewPlaintext(params.Parameters, level), Scale: 1}
+	pt.Value.IsNTT = true
+	return pt
+}
+
+// NewPlaintextMul creates and allocates a new plaintext optimized for ciphertext x plaintext multiplication.
+// The plaintext will be in the NTT and Montgomery domain of RingQ.
+func NewPlaintextMul(params Parameters, level int) *PlaintextMul {
+	pt := &PlaintextMul{Plaintext: rlwe.NewPlaintext(params.Parameters, level), Scale: 1}
+	pt.Value.IsNTT = true
+	pt.Value.IsMForm = true
+	return pt
+}
+
+// ScalingFactor returns the scaling factor of the plaintext.
+func (pt *Plaintext) ScalingFactor() uint64 {
+	return pt.Scale
+}
+
+// SetScalingFactor sets the scaling factor of the plaintext.
+func (pt *Plaintext) SetScalingFactor(scale uint64) {
+	pt.Scale = scale
+}
+
+// ScalingFactor returns the scaling factor of the plaintext.
+func (pt *PlaintextMul) ScalingFactor() uint64 {
+	return pt.Scale
+}
+
+// SetScalingFactor sets the scaling factor of the plaintext.
+func (pt *PlaintextMul) SetScalingFactor(scale uint64) {
+	pt.Scale = scale
+}
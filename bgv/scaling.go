@@ -0,0 +1,91 @@
+package bgv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// rescaler is a struct storing the pre-computed constants and buffers
+// needed to divide polynomials of R_Q by the last modulus of their level
+// while preserving their value mod T.
+type rescaler struct {
+	ringQ *ring.Ring
+	ringT *ring.Ring
+
+	tInvModQ *big.Int // T^-1 mod Q
+	qInvModT []uint64 // q_i^-1 mod T
+
+	pool [2]*ring.Poly
+}
+
+func newRescaler(params Parameters) *rescaler {
+
+	ringQ := params.RingQ()
+	ringT := params.RingT()
+	t := params.T()
+
+	tInvModQ := new(big.Int).ModInverse(ringT.ModulusBigint, ringQ.ModulusBigint)
+
+	qInvModT := make([]uint64, len(ringQ.Modulus))
+	for i, qi := range ringQ.Modulus {
+		qInvModT[i] = modInverse(qi%t, t)
+	}
+
+	return &rescaler{
+		ringQ:    ringQ,
+		ringT:    ringT,
+		tInvModQ: tInvModQ,
+		qInvModT: qInvModT,
+		pool:     [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()},
+	}
+}
+
+// shallowCopy returns a copy of the receiver sharing the pre-computed constants but with re-allocated buffers.
+func (r *rescaler) shallowCopy() *rescaler {
+	return &rescaler{
+		ringQ:    r.ringQ,
+		ringT:    r.ringT,
+		tInvModQ: r.tInvModQ,
+		qInvModT: r.qInvModT,
+		pool:     [2]*ring.Poly{r.ringQ.NewPoly(), r.ringQ.NewPoly()},
+	}
+}
+
+// rescale computes p1 = (p0 - delta)/q_level with delta = 0 mod T and delta = p0 mod q_level.
+// The input must be in the NTT domain and the output is returned in the NTT domain at level-1.
+// The value of p1 mod T is equal to the value of p0 mod T multiplied by q_level^-1.
+func (r *rescaler) rescale(level int, p0, p1 *ring.Poly) {
+	ringQ := r.ringQ
+	ringQ.MulScalarBigintLvl(level, p0, r.tInvModQ, r.pool[0])
+	ringQ.DivRoundByLastModulusNTTLvl(level, r.pool[0], r.pool[1], p1)
+	ringQ.MulScalarLvl(level-1, p1, r.ringT.Modulus[0], p1)
+	p1.Coeffs = p1.Coeffs[:level]
+}
+
+// rescaleScale returns the updated scale after a rescale of an element at the given level.
+func (r *rescaler) rescaleScale(level int, scale uint64) uint64 {
+	return r.mulScale(scale, r.qInvModT[level])
+}
+
+// mulScale returns a * b mod T.
+func (r *rescaler) mulScale(a, b uint64) uint64 {
+	return ring.BRed(a, b, r.ringT.Modulus[0], r.ringT.BredParams[0])
+}
+
+// invScale returns a^-1 mod T.
+func (r *rescaler) invScale(a uint64) uint64 {
+	t := r.ringT.Modulus[0]
+	return modInverse(a%t, t)
+}
+
+// modInverse returns a^-1 mod t for a coprime with t. Since t can be a prime power, the inverse is computed
+// with the extended Euclidean algorithm instead of Fermat's little theorem.
+func modInverse(a, t uint64) uint64 {
+	inv := new(big.Int).ModInverse(new(big.Int).SetUint64(a), new(big.Int).SetUint64(t))
+	if inv == nil {
+		panic(fmt.Sprintf("cannot invert %d mod t=%d: not coprime", a, t))
+	}
+	return inv.Uint64()
+}
@@ -85,6 +85,13 @@ func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
 	return
 }
 
+// CheckSecurity returns an error if the CKKS parameters params do not reach a classical security of lambda bits for
+// a secret key of Hamming weight H (see rlwe.Parameters.CheckSecurity). The same check is done by
+// ckks.NewParametersFromLiteral when the fields Lambda and H of the ckks.ParametersLiteral are set.
+func (p *Parameters) CheckSecurity(params ckks.Parameters, lambda int) error {
+	return params.CheckSecurity(lambda, p.H)
+}
+
 // RotationsForBootstrapping returns the list of rotations performed during the Bootstrapping operation.
 func (p *Parameters) RotationsForBootstrapping(LogN, LogSlots int) (rotations []int) {
 
@@ -111,13 +118,17 @@ func (p *Parameters) RotationsForBootstrapping(LogN, LogSlots int) (rotations []
 }
 
 // DefaultCKKSParameters are default parameters for the bootstrapping.
-// To be used in conjonction with DefaultParameters.
+// To be used in conjonction with DefaultParameters. The Lambda field of each set is the classical security it
+// reaches for the Hamming weight H of the secret of the corresponding DefaultParameters, which is checked by
+// ckks.NewParametersFromLiteral.
 var DefaultCKKSParameters = []ckks.ParametersLiteral{
 	{
 		LogN:         16,
 		LogSlots:     15,
 		DefaultScale: 1 << 40,
 		Sigma:        rlwe.DefaultSigma,
+		Lambda:       128,
+		H:            192,
 		Q: []uint64{
 			0x10000000006e0001, // 60 Q0
 			0x10000140001,      // 40
@@ -158,6 +169,8 @@ var DefaultCKKSParameters = []ckks.ParametersLiteral{
 		LogSlots:     15,
 		DefaultScale: 1 << 45,
 		Sigma:        rlwe.DefaultSigma,
+		Lambda:       128,
+		H:            192,
 		Q: []uint64{
 			0x10000000006e0001, // 60 Q0
 			0x2000000a0001,     // 45
@@ -196,6 +209,8 @@ var DefaultCKKSParameters = []ckks.ParametersLiteral{
 		LogSlots:     15,
 		DefaultScale: 1 << 30,
 		Sigma:        rlwe.DefaultSigma,
+		Lambda:       128,
+		H:            192,
 		Q: []uint64{
 			0x80000000080001,   // 55 Q0
 			0xffffffffffc0001,  // 60
@@ -233,6 +248,8 @@ var DefaultCKKSParameters = []ckks.ParametersLiteral{
 		LogSlots:     15,
 		DefaultScale: 1 << 40,
 		Sigma:        rlwe.DefaultSigma,
+		Lambda:       126, // dense secret with logQP=1792, slightly above the HE Standard bound of 1762 for logN=16
+		H:            32768,
 		Q: []uint64{
 			0x4000000120001, // 60 Q0
 			0x10000140001,
@@ -277,6 +294,8 @@ var DefaultCKKSParameters = []ckks.ParametersLiteral{
 		LogSlots:     14,
 		DefaultScale: 1 << 25,
 		Sigma:        rlwe.DefaultSigma,
+		Lambda:       128,
+		H:            192,
 		Q: []uint64{
 			0x1fff90001,       // 32 Q0
 			0x4000000420001,   // 50
@@ -11,6 +11,7 @@ import (
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var flagLongTest = flag.Bool("long", false, "run the long test suite (all parameters + secure bootstrapping). Overrides -short and requires -timeout=0.")
@@ -40,6 +41,26 @@ func TestBootstrapParametersMarshalling(t *testing.T) {
 	assert.Equal(t, bootstrapParams, *bootstrapParamsNew)
 }
 
+func TestBootstrapParametersSecurity(t *testing.T) {
+	for i := range DefaultParameters {
+
+		ckksParams := DefaultCKKSParameters[i]
+		btpParams := DefaultParameters[i]
+
+		// Each set declares the security it reaches for the Hamming weight of the bootstrapping secret
+		require.NotZero(t, ckksParams.Lambda)
+		require.Equal(t, btpParams.H, ckksParams.H)
+
+		params, err := ckks.NewParametersFromLiteral(ckksParams)
+		require.NoError(t, err)
+		require.NoError(t, btpParams.CheckSecurity(params, ckksParams.Lambda))
+
+		ckksParams.Lambda = 192
+		_, err = ckks.NewParametersFromLiteral(ckksParams)
+		require.Error(t, err)
+	}
+}
+
 func TestBootstrap(t *testing.T) {
 
 	if runtime.GOARCH == "wasm" {
@@ -59,6 +80,7 @@ func TestBootstrap(t *testing.T) {
 	if !*flagLongTest {
 		ckksParams.LogN = 13
 		ckksParams.LogSlots = 12
+		ckksParams.Lambda = 0
 	}
 
 	params, err := ckks.NewParametersFromLiteral(ckksParams)
@@ -147,6 +169,28 @@ func testbootstrap(params ckks.Parameters, btpParams Parameters, t *testing.T) {
 		for i := range ciphertexts {
 			verifyTestVectors(params, encoder, decryptor, values, ciphertexts[i], params.LogSlots(), 0, t)
 		}
+
+		t.Run("ParallelBootstrapper", func(t *testing.T) {
+
+			pbtp := NewParallelBootstrapper(btp, 2)
+
+			ciphertexts := make([]*ckks.Ciphertext, 3)
+			for i := range ciphertexts {
+				ciphertexts[i] = encryptor.EncryptNew(plaintext)
+			}
+
+			ciphertexts, err := pbtp.Bootstrapp(ciphertexts)
+			require.NoError(t, err)
+
+			for i := range ciphertexts {
+				verifyTestVectors(params, encoder, decryptor, values, ciphertexts[i], params.LogSlots(), 0, t)
+			}
+
+			// A panicking bootstrapping is returned as an error instead of crashing the worker
+			ciphertexts, err = pbtp.Bootstrapp([]*ckks.Ciphertext{nil})
+			require.Error(t, err)
+			require.Nil(t, ciphertexts[0])
+		})
 	})
 }
 
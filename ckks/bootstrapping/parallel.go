@@ -0,0 +1,49 @@
+package bootstrapping
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ParallelBootstrapper is a pool of workers that bootstraps slices of ciphertexts across goroutines, each worker
+// holding its own shallow copy of a Bootstrapper. Since each copy allocates its own buffers, the memory footprint
+// grows linearly with the number of workers. The outputs are always in the order of the inputs.
+//
+// A ParallelBootstrapper must not be used concurrently by several goroutines.
+type ParallelBootstrapper struct {
+	bootstrappers []*Bootstrapper
+}
+
+// NewParallelBootstrapper creates a new ParallelBootstrapper with the given number of workers, each holding a
+// shallow copy of btp.
+func NewParallelBootstrapper(btp *Bootstrapper, workers int) *ParallelBootstrapper {
+
+	if workers < 1 {
+		panic("cannot NewParallelBootstrapper: workers must be at least 1")
+	}
+
+	bootstrappers := make([]*Bootstrapper, workers)
+	for i := range bootstrappers {
+		bootstrappers[i] = btp.ShallowCopy()
+	}
+
+	return &ParallelBootstrapper{bootstrappers: bootstrappers}
+}
+
+// Workers returns the number of workers of the ParallelBootstrapper.
+func (p *ParallelBootstrapper) Workers() int {
+	return len(p.bootstrappers)
+}
+
+// Bootstrapp bootstraps each ctIn[i] and returns the results in new ciphertexts, in the order of ctIn.
+// If the bootstrapping of a ciphertext panics, the panic is recovered and returned as a *utils.TaskPanic once the other
+// ciphertexts are bootstrapped, and the corresponding output is nil.
+func (p *ParallelBootstrapper) Bootstrapp(ctIn []*ckks.Ciphertext) (ctOut []*ckks.Ciphertext, err error) {
+
+	ctOut = make([]*ckks.Ciphertext, len(ctIn))
+	err = utils.RunParallel(len(p.bootstrappers), len(ctIn), func(worker, i int) {
+		ctOut[i] = p.bootstrappers[worker].Bootstrapp(ctIn[i])
+	})
+
+	return
+}
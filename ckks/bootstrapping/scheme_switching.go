@@ -0,0 +1,250 @@
+package bootstrapping
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// SchemeSwitcher is a type for switching ciphertexts between the CKKS and the BFV schemes. It re-uses the homomorphic
+// encoding matrices, the EvalMod polynomial and the evaluation keys of a Bootstrapper:
+//
+//   - CKKSToBFV evaluates SlotsToCoeffs, which moves the slots of the CKKS ciphertext in its coefficients, and switches
+//     its modulus to the modulus of the BFV parameters, rounding the messages to integers mod T.
+//
+//   - BFVToCKKS switches the modulus of the BFV ciphertext to Q0 and evaluates the first half of the bootstrapping
+//     circuit (ModUp, CoeffsToSlots and EvalMod), which moves the coefficients of the BFV plaintext in the slots.
+//
+// Both parameter sets must have the same ring degree and the ciphertexts must be encrypted under the same secret key
+// (see SecretKeyBFV).
+//
+// The coefficients are mapped to the slots in the bit-reversed order of the homomorphic encoding matrices: for n = Slots
+// and gap = N/(2n), the coefficient of index k*N/2 + i*gap is stored in the slot of index BitReverse(i) of the k-th
+// half. If the CKKS parameters use full packing (LogSlots = LogN-1), the two halves are the CKKS ciphertexts ctReal
+// and ctImag. Otherwise, only the coefficients of index multiple of gap are switched and the two halves are the slots
+// [0, n) and [n, 2n) of the single ciphertext ctReal, which must be encoded and decoded with LogSlots+1.
+type SchemeSwitcher struct {
+	*Bootstrapper
+	paramsBFV bfv.Parameters
+}
+
+// NewSchemeSwitcher creates a new SchemeSwitcher between the CKKS parameters of the Bootstrapper btp and paramsBFV.
+func NewSchemeSwitcher(btp *Bootstrapper, paramsBFV bfv.Parameters) (switcher *SchemeSwitcher, err error) {
+
+	if btp.params.RingType() != ring.Standard {
+		return nil, fmt.Errorf("cannot NewSchemeSwitcher: CKKS parameters must use the standard ring")
+	}
+
+	if btp.params.N() != paramsBFV.N() {
+		return nil, fmt.Errorf("cannot NewSchemeSwitcher: CKKS and BFV parameters must have the same ring degree")
+	}
+
+	if float64(paramsBFV.T()) >= btp.params.QiFloat64(0) {
+		return nil, fmt.Errorf("cannot NewSchemeSwitcher: BFV plaintext modulus must be smaller than Q0")
+	}
+
+	return &SchemeSwitcher{Bootstrapper: btp, paramsBFV: paramsBFV}, nil
+}
+
+// ShallowCopy creates a shallow copy of this SchemeSwitcher in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// SchemeSwitcher can be used concurrently.
+func (switcher *SchemeSwitcher) ShallowCopy() *SchemeSwitcher {
+	return &SchemeSwitcher{Bootstrapper: switcher.Bootstrapper.ShallowCopy(), paramsBFV: switcher.paramsBFV}
+}
+
+// CKKSToBFV switches the CKKS ciphertexts (ctReal, ctImag) to a BFV ciphertext whose plaintext coefficients are the
+// values of their slots rounded to the nearest integer mod T. ctImag must be nil if the CKKS parameters use sparse
+// packing. The input ciphertexts must be at least at the starting level of the SlotsToCoeffs step of the
+// bootstrapping parameters, and their values should be close to integers: their distance to the nearest integer
+// adds to the noise of the output ciphertext, whose Noise field assumes exact integers.
+func (switcher *SchemeSwitcher) CKKSToBFV(ctReal, ctImag *ckks.Ciphertext) (ctOut *bfv.Ciphertext) {
+
+	levelStart := switcher.SlotsToCoeffsParameters.LevelStart
+
+	if ctReal.Level() < levelStart || (ctImag != nil && ctImag.Level() < levelStart) {
+		panic("cannot CKKSToBFV: ciphertext level is smaller than SlotsToCoeffs starting level")
+	}
+
+	if ctImag != nil && switcher.params.LogSlots() < switcher.params.MaxLogSlots() {
+		panic("cannot CKKSToBFV: ctImag must be nil for sparse packing")
+	}
+
+	ctReal = ctReal.CopyNew()
+	switcher.DropLevel(ctReal, ctReal.Level()-levelStart)
+
+	if ctImag != nil {
+		ctImag = ctImag.CopyNew()
+		switcher.DropLevel(ctImag, ctImag.Level()-levelStart)
+	}
+
+	ct := switcher.SlotsToCoeffsNew(ctReal, ctImag, switcher.stcMatrices)
+
+	// The SlotsToCoeffs matrices of the bootstrapping also scale the values by a constant
+	ct.Scale *= switcher.SlotsToCoeffsParameters.Scaling
+
+	// The phase Delta * m mod Q_l is multiplied by the integer c = round(Q_l/(T * Delta)), so that it becomes
+	// (Q_l/T) * m mod Q_l, and then switched from Q_l to the modulus of the BFV parameters.
+	level := ct.Level()
+	ringQ := switcher.params.RingQ()
+
+	c := new(big.Float).SetInt(switcher.params.QLvl(level))
+	c.Quo(c, new(big.Float).SetFloat64(float64(switcher.paramsBFV.T())*ct.Scale))
+	c.Add(c, big.NewFloat(0.5))
+	mul, _ := c.Int(nil)
+
+	if mul.Sign() == 0 {
+		panic("cannot CKKSToBFV: ciphertext scale is larger than Q_l/T")
+	}
+
+	ctOut = bfv.NewCiphertext(switcher.paramsBFV, 1)
+
+	for i := range ct.Value {
+		ringQ.InvNTTLvl(level, ct.Value[i], ct.Value[i])
+		switchModulus(ringQ, level, ct.Value[i], mul, switcher.paramsBFV.RingQ(), switcher.paramsBFV.MaxLevel(), ctOut.Value[i])
+	}
+
+	// Heuristic bound on t * ct(s) mod Q: the error of the CKKS ciphertext is of the order of sqrt(N) relative to its
+	// scale and the rounding of the modulus switching adds an error of norm sqrt(N * (1 + h)).
+	N := float64(switcher.params.N())
+	logQ := float64(switcher.paramsBFV.QBigInt().BitLen())
+	logT := math.Log2(float64(switcher.paramsBFV.T()))
+	ctOut.Noise = math.Max(logQ+math.Log2(6*math.Sqrt(N))-math.Log2(ct.Scale), logT+math.Log2(6*math.Sqrt(N*float64(1+switcher.H)))) + 1
+
+	return
+}
+
+// BFVToCKKS switches the BFV ciphertext ctIn to the CKKS ciphertexts (ctReal, ctImag), whose slots store the
+// coefficients of the plaintext of ctIn in their centered representation in (-T/2, T/2]. ctImag is nil if the CKKS
+// parameters use sparse packing. The output ciphertexts are at the level following the EvalMod step of the
+// bootstrapping parameters, and their precision degrades with the ratio between the messages and T: the messages
+// must be smaller than T/MessageRatio in absolute value.
+func (switcher *SchemeSwitcher) BFVToCKKS(ctIn *bfv.Ciphertext) (ctReal, ctImag *ckks.Ciphertext) {
+
+	if ctIn.Degree() != 1 {
+		panic("cannot BFVToCKKS: input ciphertext must be of degree 1")
+	}
+
+	ringQ := switcher.params.RingQ()
+
+	// Switches the modulus from Q to Q0: the phase becomes (Q0/T) * m mod Q0, which is seen as m * (Q0/T)/(Q0/MessageRatio)
+	// at the scale Q0/MessageRatio of the input of the bootstrapping.
+	ct := ckks.NewCiphertext(switcher.params, 1, 0, switcher.q0OverMessageRatio)
+	for i := range ct.Value {
+		switchModulus(switcher.paramsBFV.RingQ(), switcher.paramsBFV.MaxLevel(), ctIn.Value[i], big.NewInt(1), ringQ, 0, ct.Value[i])
+		ringQ.NTTLvl(0, ct.Value[i], ct.Value[i])
+	}
+
+	ct = switcher.modUpFromQ0(ct)
+
+	if (switcher.evalModPoly.ScalingFactor()/switcher.evalModPoly.MessageRatio())/ct.Scale > 1 {
+		switcher.ScaleUp(ct, math.Round((switcher.evalModPoly.ScalingFactor()/switcher.evalModPoly.MessageRatio())/ct.Scale), ct)
+	}
+
+	switcher.Trace(ct, switcher.params.LogSlots(), switcher.params.LogN()-1, ct)
+
+	ctReal, ctImag = switcher.CoeffsToSlotsNew(ct, switcher.ctsMatrices)
+
+	// Rescales the output of EvalMod, m * Q0/(T * Q0/MessageRatio), back to m.
+	ratio := switcher.params.QiFloat64(0) / (float64(switcher.paramsBFV.T()) * switcher.q0OverMessageRatio)
+
+	ctReal = switcher.EvalModNew(ctReal, switcher.evalModPoly)
+	ctReal.Scale *= ratio
+
+	if ctImag != nil {
+		ctImag = switcher.EvalModNew(ctImag, switcher.evalModPoly)
+		ctImag.Scale *= ratio
+	}
+
+	return
+}
+
+// SecretKeyBFV returns the secret key of paramsBFV that has the same coefficients as the secret key sk of params,
+// so that the ciphertexts encrypted under sk and under the returned key can be switched by a SchemeSwitcher.
+func SecretKeyBFV(params ckks.Parameters, sk *rlwe.SecretKey, paramsBFV bfv.Parameters) (skBFV *rlwe.SecretKey) {
+
+	ringQ := params.RingQ()
+	q0 := ringQ.Modulus[0]
+
+	coeffs := ringQ.NewPolyLvl(0)
+	ringQ.InvNTTLvl(0, sk.Value.Q, coeffs)
+	ringQ.InvMFormLvl(0, coeffs, coeffs)
+
+	ringQP := paramsBFV.RingQP()
+	levelQ, levelP := paramsBFV.QCount()-1, paramsBFV.PCount()-1
+
+	skBFV = rlwe.NewSecretKey(paramsBFV.Parameters)
+
+	setCoeffs := func(moduli []uint64, p *ring.Poly) {
+		for i, qi := range moduli {
+			for j, c := range coeffs.Coeffs[0] {
+				if c > q0>>1 {
+					p.Coeffs[i][j] = qi - (q0-c)%qi
+				} else {
+					p.Coeffs[i][j] = c % qi
+				}
+			}
+		}
+	}
+
+	setCoeffs(paramsBFV.Q(), skBFV.Value.Q)
+	if levelP > -1 {
+		setCoeffs(paramsBFV.P(), skBFV.Value.P)
+	}
+
+	ringQP.NTTLvl(levelQ, levelP, skBFV.Value, skBFV.Value)
+	ringQP.MFormLvl(levelQ, levelP, skBFV.Value, skBFV.Value)
+
+	return
+}
+
+// switchModulus writes on polyOut the polynomial round(mul * polyIn * Q'/Q) mod Q', where Q is the modulus of ringIn at
+// levelIn and Q' the modulus of ringOut at levelOut. The input polynomial is taken in its centered representation and
+// both polynomials are in the coefficient domain.
+func switchModulus(ringIn *ring.Ring, levelIn int, polyIn *ring.Poly, mul *big.Int, ringOut *ring.Ring, levelOut int, polyOut *ring.Poly) {
+
+	QIn := big.NewInt(1)
+	for _, qi := range ringIn.Modulus[:levelIn+1] {
+		QIn.Mul(QIn, new(big.Int).SetUint64(qi))
+	}
+
+	QOut := big.NewInt(1)
+	for _, qi := range ringOut.Modulus[:levelOut+1] {
+		QOut.Mul(QOut, new(big.Int).SetUint64(qi))
+	}
+
+	QInHalf := new(big.Int).Rsh(QIn, 1)
+
+	coeffs := make([]*big.Int, ringIn.N)
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+
+	ringIn.PolyToBigintCenteredLvl(levelIn, polyIn, coeffs)
+
+	for _, c := range coeffs {
+
+		// c = mul * c mod Q, centered
+		c.Mul(c, mul)
+		c.Mod(c, QIn)
+		if c.Cmp(QInHalf) > 0 {
+			c.Sub(c, QIn)
+		}
+
+		// c = round(c * Q'/Q)
+		c.Mul(c, QOut)
+		if c.Sign() < 0 {
+			c.Sub(c, QInHalf)
+		} else {
+			c.Add(c, QInHalf)
+		}
+		c.Quo(c, QIn)
+	}
+
+	ringOut.SetCoefficientsBigintLvl(levelOut, coeffs, polyOut)
+}
@@ -0,0 +1,154 @@
+package bootstrapping
+
+import (
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemeSwitching(t *testing.T) {
+
+	if runtime.GOARCH == "wasm" {
+		t.Skip("skipping scheme switching tests for GOARCH=wasm")
+	}
+
+	if !*testBootstrapping {
+		t.Skip("skipping scheme switching tests (add -test-bootstrapping to run the scheme switching tests)")
+	}
+
+	paramSet := 0
+
+	ckksParams := DefaultCKKSParameters[paramSet]
+	bootstrapParams := DefaultParameters[paramSet]
+
+	// Insecure params for fast testing only
+	ckksParams.LogN = 13
+	ckksParams.LogSlots = 12
+	ckksParams.Lambda = 0
+
+	paramsBFV, err := bfv.NewParametersFromLiteral(bfv.PN13QP218)
+	require.NoError(t, err)
+
+	for _, logSlots := range []int{12, 11} {
+
+		ckksParams.LogSlots = logSlots
+
+		params, err := ckks.NewParametersFromLiteral(ckksParams)
+		require.NoError(t, err)
+
+		testSchemeSwitching(params, bootstrapParams, paramsBFV, t)
+		runtime.GC()
+	}
+}
+
+func testSchemeSwitching(params ckks.Parameters, btpParams Parameters, paramsBFV bfv.Parameters, t *testing.T) {
+
+	kgen := ckks.NewKeyGenerator(params)
+	sk := kgen.GenSecretKeySparse(btpParams.H)
+	rlk := kgen.GenRelinearizationKey(sk, 2)
+	rotkeys := kgen.GenRotationKeysForRotations(btpParams.RotationsForBootstrapping(params.LogN(), params.LogSlots()), true, sk)
+
+	btp, err := NewBootstrapper(params, btpParams, rlwe.EvaluationKey{Rlk: rlk, Rtks: rotkeys})
+	require.NoError(t, err)
+
+	switcher, err := NewSchemeSwitcher(btp, paramsBFV)
+	require.NoError(t, err)
+
+	encoder := ckks.NewEncoder(params)
+	encryptor := ckks.NewEncryptor(params, sk)
+	decryptor := ckks.NewDecryptor(params, sk)
+
+	skBFV := SecretKeyBFV(params, sk, paramsBFV)
+	encoderBFV := bfv.NewEncoder(paramsBFV)
+	encryptorBFV := bfv.NewEncryptor(paramsBFV, skBFV)
+	decryptorBFV := bfv.NewDecryptor(paramsBFV, skBFV)
+
+	N := params.N()
+	T := paramsBFV.T()
+
+	// Number of decoded slots per CKKS ciphertext
+	logSlots := params.LogSlots()
+	if logSlots < params.MaxLogSlots() {
+		logSlots++
+	}
+	slots := 1 << logSlots
+
+	// coeffIndex returns the index of the coefficient of the BFV plaintext stored at the position idx of the
+	// concatenation of the decoded slots of the CKKS ciphertexts
+	n, gap := params.Slots(), N/(2*params.Slots())
+	coeffIndex := func(idx int) int {
+		return (idx/n)*(N>>1) + int(utils.BitReverse64(uint64(idx%n), uint64(params.LogSlots())))*gap
+	}
+
+	t.Run(ParamsToString(params, "SchemeSwitching/BFVToCKKS/"), func(t *testing.T) {
+
+		// Messages in [-100, 100]
+		values := make([]int64, N)
+		ptRt := bfv.NewPlaintextRingT(paramsBFV)
+		for j := range values {
+			values[j] = int64(utils.RandUint64()%201) - 100
+			ptRt.Value.Coeffs[0][j] = uint64((values[j] + int64(T)) % int64(T))
+		}
+
+		pt := bfv.NewPlaintext(paramsBFV)
+		encoderBFV.ScaleUp(ptRt, pt)
+
+		ctReal, ctImag := switcher.BFVToCKKS(encryptorBFV.EncryptNew(pt))
+		require.Equal(t, ctImag == nil, params.LogSlots() < params.MaxLogSlots())
+
+		for k, ct := range []*ckks.Ciphertext{ctReal, ctImag} {
+			if ct == nil {
+				continue
+			}
+
+			have := encoder.Decode(decryptor.DecryptNew(ct), logSlots)
+			for i := range have {
+				want := float64(values[coeffIndex(k*slots+i)])
+				require.Less(t, math.Abs(real(have[i])-want), 0.1)
+			}
+		}
+	})
+
+	t.Run(ParamsToString(params, "SchemeSwitching/CKKSToBFV/"), func(t *testing.T) {
+
+		// Integers in [-1000, 1000] with an additive error smaller than 2^-10
+		want := make([]int64, N)
+		cts := make([]*ckks.Ciphertext, 2*n/slots)
+		for k := range cts {
+			values := make([]float64, slots)
+			for i := range values {
+				j := coeffIndex(k*slots + i)
+				want[j] = int64(utils.RandUint64()%2001) - 1000
+				values[i] = float64(want[j]) + utils.RandFloat64(-1, 1)/1024
+			}
+			pt := ckks.NewPlaintext(params, params.MaxLevel(), params.DefaultScale())
+			encoder.Encode(values, pt, logSlots)
+			cts[k] = encryptor.EncryptNew(pt)
+		}
+
+		var ctImag *ckks.Ciphertext
+		if len(cts) == 2 {
+			ctImag = cts[1]
+		}
+
+		ctOut := switcher.ShallowCopy().CKKSToBFV(cts[0], ctImag)
+
+		ptRt := bfv.NewPlaintextRingT(paramsBFV)
+		encoderBFV.DecodeRingT(decryptorBFV.DecryptNew(ctOut), ptRt)
+
+		for j, c := range ptRt.Value.Coeffs[0] {
+			have := int64(c)
+			if c > T>>1 {
+				have -= int64(T)
+			}
+
+			require.Equal(t, want[j], have)
+		}
+	})
+}
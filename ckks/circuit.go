@@ -0,0 +1,532 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// CircuitOperation is the type of operation performed by a CircuitNode.
+type CircuitOperation int
+
+// The operations supported by a Circuit.
+const (
+	CircuitInput = CircuitOperation(iota)
+	CircuitAdd
+	CircuitSub
+	CircuitMulRelin
+	CircuitMultByConst
+	CircuitRotate
+	CircuitInnerSum
+	CircuitEvaluatePoly
+	CircuitLinearTransform
+)
+
+var circuitOperationNames = []string{"Input", "Add", "Sub", "MulRelin", "MultByConst", "Rotate", "InnerSum", "EvaluatePoly", "LinearTransform"}
+
+func (op CircuitOperation) String() string {
+	if int(op) < len(circuitOperationNames) {
+		return circuitOperationNames[op]
+	}
+	return fmt.Sprintf("CircuitOperation(%d)", int(op))
+}
+
+// CircuitNode is a node of a Circuit, that is the ciphertext resulting from an operation on the ciphertexts of its
+// operand nodes. Nodes are created with the methods of the Circuit they belong to.
+type CircuitNode struct {
+	Operation CircuitOperation
+	Operands  []*CircuitNode
+
+	circuit *Circuit
+	id      int
+
+	name     string
+	level    int
+	k        int
+	n        int
+	constant interface{}
+	pol      *Polynomial
+	lt       LinearTransform
+
+	// set by the analysis
+	product bool
+	final   int
+	raw     int
+}
+
+// Level returns the level of the ciphertext of the node once evaluated. It is only valid after the analysis of the
+// circuit (Circuit.Analyze or Circuit.Evaluate).
+func (node *CircuitNode) Level() int {
+	return node.final
+}
+
+// Circuit is the description of a computation over ckks ciphertexts as a directed acyclic graph of CircuitNodes.
+// A Circuit is described once with its methods and can then be analyzed, to obtain its depth and the exact rotation
+// keys it requires, and evaluated any number of times with an Evaluator.
+//
+// The relinearizations and the rescalings are placed by the circuit: the outputs of MulRelin are evaluated lazily,
+// so that sums and differences of products are relinearized and rescaled a single time, when they are used by another
+// operation or as an output. All the other operations return ciphertexts at the default scale of the parameters.
+// Before an addition or a subtraction of ciphertexts at different levels, the ciphertext at the highest level is
+// brought to the scale of the other one, so that the small scale deviations due to the rescalings do not add up.
+type Circuit struct {
+	params  Parameters
+	nodes   []*CircuitNode
+	inputs  map[string]*CircuitNode
+	outputs map[string]*CircuitNode
+
+	// analysis is the result of the last analysis, reset when the circuit is modified.
+	analysis *CircuitAnalysis
+}
+
+// CircuitAnalysis stores the result of the analysis of a Circuit.
+type CircuitAnalysis struct {
+	// Depth is the number of levels consumed along the longest path of the circuit, from an input to an output.
+	Depth int
+	// Rotations is the sorted list of the rotations performed by the circuit, which can be given to
+	// KeyGenerator.GenRotationKeysForRotations.
+	Rotations []int
+	// GaloisElements is the sorted list of the Galois elements of the rotation keys required by the circuit.
+	GaloisElements []uint64
+	// Relinearizations is the number of relinearizations (and rescalings of products) performed by the circuit.
+	Relinearizations int
+	// NeedsRelinearizationKey is true if the circuit requires a relinearization key.
+	NeedsRelinearizationKey bool
+}
+
+// NewCircuit creates a new empty Circuit for the given parameters.
+func NewCircuit(params Parameters) *Circuit {
+	return &Circuit{
+		params:  params,
+		inputs:  make(map[string]*CircuitNode),
+		outputs: make(map[string]*CircuitNode),
+	}
+}
+
+func (c *Circuit) newNode(op CircuitOperation, operands ...*CircuitNode) (node *CircuitNode) {
+	for _, operand := range operands {
+		if operand == nil || operand.circuit != c {
+			panic(fmt.Sprintf("cannot %s: operand does not belong to the circuit", op))
+		}
+	}
+	node = &CircuitNode{Operation: op, Operands: operands, circuit: c, id: len(c.nodes)}
+	c.nodes = append(c.nodes, node)
+	c.analysis = nil
+	return
+}
+
+// Input adds a new input ciphertext to the circuit, given by its name at the evaluation, and at level at most level.
+func (c *Circuit) Input(name string, level int) (node *CircuitNode) {
+
+	if _, ok := c.inputs[name]; ok {
+		panic(fmt.Sprintf("cannot Input: input %q already exists", name))
+	}
+
+	if level < 0 || level > c.params.MaxLevel() {
+		panic("cannot Input: invalid level")
+	}
+
+	node = c.newNode(CircuitInput)
+	node.name = name
+	node.level = level
+	c.inputs[name] = node
+	return
+}
+
+// Add adds a node for op0 + op1.
+func (c *Circuit) Add(op0, op1 *CircuitNode) *CircuitNode {
+	return c.newNode(CircuitAdd, op0, op1)
+}
+
+// Sub adds a node for op0 - op1.
+func (c *Circuit) Sub(op0, op1 *CircuitNode) *CircuitNode {
+	return c.newNode(CircuitSub, op0, op1)
+}
+
+// MulRelin adds a node for op0 * op1. The product consumes one level.
+func (c *Circuit) MulRelin(op0, op1 *CircuitNode) *CircuitNode {
+	return c.newNode(CircuitMulRelin, op0, op1)
+}
+
+// MultByConst adds a node for op * constant, with constant of any type accepted by Evaluator.MultByConst.
+// The product consumes one level.
+func (c *Circuit) MultByConst(op *CircuitNode, constant interface{}) (node *CircuitNode) {
+	node = c.newNode(CircuitMultByConst, op)
+	node.constant = constant
+	return
+}
+
+// Rotate adds a node for the rotation of op by k positions to the left.
+func (c *Circuit) Rotate(op *CircuitNode, k int) (node *CircuitNode) {
+	node = c.newNode(CircuitRotate, op)
+	node.k = k
+	return
+}
+
+// InnerSum adds a node for Evaluator.InnerSumLog(op, batch, n).
+func (c *Circuit) InnerSum(op *CircuitNode, batch, n int) (node *CircuitNode) {
+	node = c.newNode(CircuitInnerSum, op)
+	node.k = batch
+	node.n = n
+	return
+}
+
+// EvaluatePoly adds a node for the evaluation of pol on op. The evaluation consumes pol.Depth() levels.
+func (c *Circuit) EvaluatePoly(op *CircuitNode, pol *Polynomial) (node *CircuitNode) {
+	node = c.newNode(CircuitEvaluatePoly, op)
+	node.pol = pol
+	return
+}
+
+// LinearTransform adds a node for the evaluation of the encoded linear transform lt on op.
+// The evaluation consumes one level.
+func (c *Circuit) LinearTransform(op *CircuitNode, lt LinearTransform) (node *CircuitNode) {
+	node = c.newNode(CircuitLinearTransform, op)
+	node.lt = lt
+	return
+}
+
+// Output sets node as an output of the circuit, returned under the given name at the evaluation.
+func (c *Circuit) Output(name string, node *CircuitNode) {
+
+	if node == nil || node.circuit != c {
+		panic("cannot Output: node does not belong to the circuit")
+	}
+
+	if _, ok := c.outputs[name]; ok {
+		panic(fmt.Sprintf("cannot Output: output %q already exists", name))
+	}
+
+	c.outputs[name] = node
+	c.analysis = nil
+}
+
+// Analyze analyzes the circuit and returns its depth, the rotations it requires and the number of relinearizations
+// it performs. The analysis is cached until the circuit is modified. It returns an error if the circuit has no output
+// or if it consumes more levels than its inputs have.
+func (c *Circuit) Analyze() (analysis *CircuitAnalysis, err error) {
+
+	if c.analysis != nil {
+		return c.analysis, nil
+	}
+
+	if len(c.outputs) == 0 {
+		return nil, fmt.Errorf("cannot Analyze: circuit has no output")
+	}
+
+	// Marks the nodes on which the outputs depend; the nodes are in topological order.
+	used := c.usedNodes()
+
+	// The products, and the sums and differences of products at the same level, are kept unrelinearized and
+	// unrescaled (raw) until a consumer, or an output, requires their final value.
+	finalized := make([]bool, len(c.nodes))
+	for _, node := range c.outputs {
+		finalized[node.id] = true
+	}
+
+	analysis = &CircuitAnalysis{}
+
+	// depth[i] is the number of levels consumed along the longest path from an input to the final value of node i.
+	depth := make([]int, len(c.nodes))
+
+	rotations := make(map[uint64]int)
+	addRotations := func(ks ...int) {
+		for _, k := range ks {
+			galEl := c.params.GaloisElementForColumnRotationBy(k)
+			if k&(c.params.MaxSlots()-1) == 0 {
+				continue
+			}
+			if _, ok := rotations[galEl]; !ok {
+				rotations[galEl] = k
+			}
+		}
+	}
+
+	for _, node := range c.nodes {
+
+		if !used[node.id] {
+			continue
+		}
+
+		node.product = false
+
+		var consumed int
+		for _, op := range node.Operands {
+			depth[node.id] = utils.MaxInt(depth[node.id], depth[op.id])
+		}
+
+		switch node.Operation {
+		case CircuitInput:
+			node.final = node.level
+
+		case CircuitAdd, CircuitSub:
+			op0, op1 := node.Operands[0], node.Operands[1]
+			if op0.product && op1.product && op0.raw == op1.raw {
+				node.product = true
+				node.raw = op0.raw
+				node.final = op0.raw - 1
+			} else {
+				finalized[op0.id], finalized[op1.id] = true, true
+				node.final = utils.MinInt(op0.final, op1.final)
+			}
+
+		case CircuitMulRelin:
+			op0, op1 := node.Operands[0], node.Operands[1]
+			finalized[op0.id], finalized[op1.id] = true, true
+			node.product = true
+			node.raw = utils.MinInt(op0.final, op1.final)
+			node.final = node.raw - 1
+			consumed = 1
+
+		case CircuitMultByConst:
+			finalized[node.Operands[0].id] = true
+			node.final = node.Operands[0].final - 1
+			consumed = 1
+
+		case CircuitRotate:
+			finalized[node.Operands[0].id] = true
+			node.final = node.Operands[0].final
+			addRotations(node.k)
+
+		case CircuitInnerSum:
+			finalized[node.Operands[0].id] = true
+			node.final = node.Operands[0].final
+			addRotations(c.params.RotationsForInnerSumLog(node.k, node.n)...)
+
+		case CircuitEvaluatePoly:
+			finalized[node.Operands[0].id] = true
+			node.final = node.Operands[0].final - node.pol.Depth()
+			consumed = node.pol.Depth()
+			if node.pol.Depth() > 1 {
+				analysis.NeedsRelinearizationKey = true
+			}
+
+		case CircuitLinearTransform:
+			finalized[node.Operands[0].id] = true
+			node.final = utils.MinInt(node.Operands[0].final, node.lt.Level) - 1
+			consumed = 1
+			addRotations(c.params.RotationsForLinearTransformN1(node.lt.Vec, node.lt.LogSlots, node.lt.N1)...)
+		}
+
+		depth[node.id] += consumed
+
+		if node.final < 0 {
+			return nil, fmt.Errorf("cannot Analyze: node %d (%s) exceeds the levels of the inputs", node.id, node.Operation)
+		}
+	}
+
+	for _, node := range c.nodes {
+		if used[node.id] {
+			if node.product && finalized[node.id] {
+				analysis.Relinearizations++
+			}
+			analysis.Depth = utils.MaxInt(analysis.Depth, depth[node.id])
+		}
+	}
+	if analysis.Relinearizations > 0 {
+		analysis.NeedsRelinearizationKey = true
+	}
+
+	analysis.GaloisElements = make([]uint64, 0, len(rotations))
+	analysis.Rotations = make([]int, 0, len(rotations))
+	for galEl, k := range rotations {
+		analysis.GaloisElements = append(analysis.GaloisElements, galEl)
+		analysis.Rotations = append(analysis.Rotations, k)
+	}
+	sort.Slice(analysis.GaloisElements, func(i, j int) bool { return analysis.GaloisElements[i] < analysis.GaloisElements[j] })
+	sort.Ints(analysis.Rotations)
+
+	c.analysis = analysis
+
+	return
+}
+
+// usedNodes returns a slice marking the nodes on which the outputs of the circuit depend.
+func (c *Circuit) usedNodes() (used []bool) {
+	used = make([]bool, len(c.nodes))
+	for _, node := range c.outputs {
+		used[node.id] = true
+	}
+	for i := len(c.nodes) - 1; i >= 0; i-- {
+		if used[i] {
+			for _, operand := range c.nodes[i].Operands {
+				used[operand.id] = true
+			}
+		}
+	}
+	return
+}
+
+// Evaluate analyzes and evaluates the circuit with eval on the given named input ciphertexts, which must be at the
+// default scale of the parameters and at least at the level of their input node. It returns the named outputs, at
+// the level computed by the analysis and at the default scale of the parameters. The inputs are not modified.
+// The evaluator must have been created with the keys given by the analysis.
+// Once the circuit has been analyzed, Evaluate can be called concurrently with distinct evaluators.
+func (c *Circuit) Evaluate(eval Evaluator, inputs map[string]*Ciphertext) (outputs map[string]*Ciphertext, err error) {
+
+	if _, err = c.Analyze(); err != nil {
+		return nil, err
+	}
+
+	used := c.usedNodes()
+	scale := c.params.DefaultScale()
+
+	// raw[i] stores the unrelinearized and unrescaled value of the product nodes, final[i] the value of all the others.
+	raw := make([]*Ciphertext, len(c.nodes))
+	final := make([]*Ciphertext, len(c.nodes))
+
+	value := func(node *CircuitNode) (ct *Ciphertext, err error) {
+		if final[node.id] == nil {
+			ct = raw[node.id].CopyNew()
+			eval.Relinearize(ct, ct)
+			if err = eval.Rescale(ct, scale, ct); err != nil {
+				return nil, err
+			}
+			final[node.id] = ct
+		}
+		return final[node.id], nil
+	}
+
+	operands := func(node *CircuitNode) (cts []*Ciphertext, err error) {
+		cts = make([]*Ciphertext, len(node.Operands))
+		for i, operand := range node.Operands {
+			if cts[i], err = value(operand); err != nil {
+				return nil, err
+			}
+		}
+		return
+	}
+
+	for _, node := range c.nodes {
+
+		if !used[node.id] {
+			continue
+		}
+
+		if node.product {
+
+			var ct0, ct1 *Ciphertext
+			if node.Operation == CircuitMulRelin {
+				var cts []*Ciphertext
+				if cts, err = operands(node); err != nil {
+					return nil, err
+				}
+				ct0, ct1 = cts[0], cts[1]
+			} else {
+				ct0, ct1 = raw[node.Operands[0].id], raw[node.Operands[1].id]
+			}
+
+			switch node.Operation {
+			case CircuitMulRelin:
+				raw[node.id] = eval.MulNew(ct0, ct1)
+			case CircuitAdd:
+				raw[node.id] = eval.AddNew(ct0, ct1)
+			case CircuitSub:
+				raw[node.id] = eval.SubNew(ct0, ct1)
+			}
+
+			if level := raw[node.id].Level(); level > node.raw {
+				eval.DropLevel(raw[node.id], level-node.raw)
+			}
+
+			continue
+		}
+
+		var ct *Ciphertext
+
+		if node.Operation == CircuitInput {
+
+			in, ok := inputs[node.name]
+			if !ok {
+				return nil, fmt.Errorf("cannot Evaluate: missing input %q", node.name)
+			}
+
+			if in.Level() < node.level {
+				return nil, fmt.Errorf("cannot Evaluate: input %q is at level %d but should be at least at level %d", node.name, in.Level(), node.level)
+			}
+
+			final[node.id] = eval.DropLevelNew(in, in.Level()-node.level)
+			continue
+		}
+
+		var cts []*Ciphertext
+		if cts, err = operands(node); err != nil {
+			return nil, err
+		}
+
+		switch node.Operation {
+		case CircuitAdd, CircuitSub:
+			for i := range cts {
+				if cts[i], err = c.matchScale(eval, cts[i], cts[1-i]); err != nil {
+					return nil, err
+				}
+			}
+			if node.Operation == CircuitAdd {
+				ct = eval.AddNew(cts[0], cts[1])
+			} else {
+				ct = eval.SubNew(cts[0], cts[1])
+			}
+		case CircuitMultByConst:
+			ct = eval.MultByConstNew(cts[0], node.constant)
+			if err = eval.Rescale(ct, scale, ct); err != nil {
+				return nil, err
+			}
+		case CircuitRotate:
+			ct = eval.RotateNew(cts[0], node.k)
+		case CircuitInnerSum:
+			ct = NewCiphertext(c.params, 1, cts[0].Level(), cts[0].Scale)
+			eval.InnerSumLog(cts[0], node.k, node.n, ct)
+		case CircuitEvaluatePoly:
+			if ct, err = eval.EvaluatePoly(cts[0], node.pol, scale); err != nil {
+				return nil, err
+			}
+		case CircuitLinearTransform:
+			ct = eval.LinearTransformNew(cts[0], node.lt)[0]
+			if err = eval.Rescale(ct, scale, ct); err != nil {
+				return nil, err
+			}
+		}
+
+		if ct.Level() < node.final {
+			return nil, fmt.Errorf("cannot Evaluate: node %d (%s) consumed more levels than expected", node.id, node.Operation)
+		}
+
+		eval.DropLevel(ct, ct.Level()-node.final)
+		final[node.id] = ct
+	}
+
+	outputs = make(map[string]*Ciphertext, len(c.outputs))
+	for name, node := range c.outputs {
+		if outputs[name], err = value(node); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// matchScale returns ct brought to the level and the scale of ctRef if ct is at a higher level and at a different
+// scale: ct is multiplied by the integer round(q_l * ctRef.Scale / ct.Scale) at the level l = ctRef.Level() + 1 and
+// rescaled by q_l. Otherwise ct is returned unchanged.
+func (c *Circuit) matchScale(eval Evaluator, ct, ctRef *Ciphertext) (*Ciphertext, error) {
+
+	if ct.Level() <= ctRef.Level() || ct.Scale == ctRef.Scale {
+		return ct, nil
+	}
+
+	level := ctRef.Level() + 1
+	qi := c.params.QiFloat64(level)
+	constant := math.Round(qi * ctRef.Scale / ct.Scale)
+
+	tmp := eval.DropLevelNew(ct, ct.Level()-level)
+	eval.MultByGaussianInteger(tmp, uint64(constant), uint64(0), tmp)
+	tmp.Scale *= constant
+
+	if err := eval.Rescale(tmp, ctRef.Scale, tmp); err != nil {
+		return nil, err
+	}
+
+	return tmp, nil
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"math"
 	"math/cmplx"
+	"math/rand"
 	"runtime"
+	"sort"
 	"testing"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -98,16 +100,24 @@ func TestCKKS(t *testing.T) {
 			testEvaluatorMultByConstAndAdd,
 			testEvaluatorMul,
 			testEvaluatorMulAndAdd,
+			testManagedEvaluator,
+			testCircuit,
 			testFunctions,
 			testDecryptPublic,
 			testEvaluatePoly,
+			testComparison,
+			testInverse,
 			testChebyshevInterpolator,
 			testSwitchKeys,
 			testBridge,
+			testDimensionSwitcher,
 			testAutomorphisms,
 			testInnerSum,
 			testReplicate,
+			testPacking,
 			testLinearTransform,
+			testMatrixMultiplication,
+			testParallelEvaluator,
 			testMarshaller,
 		} {
 			testSet(tc, t)
@@ -116,6 +126,127 @@ func TestCKKS(t *testing.T) {
 	}
 }
 
+func TestBitonicNetwork(t *testing.T) {
+
+	// The bitonic networks require more levels than the default parameters provide.
+	logQ := []int{55}
+	for i := 0; i < 33; i++ {
+		logQ = append(logQ, 40)
+	}
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{
+		LogN:         10,
+		LogSlots:     3,
+		LogQ:         logQ,
+		LogP:         []int{61, 61},
+		DefaultScale: 1 << 40,
+		Sigma:        rlwe.DefaultSigma,
+		RingType:     ring.Standard,
+	})
+	require.NoError(t, err)
+
+	tc, err := genTestParams(params, 0)
+	require.NoError(t, err)
+
+	sgn, err := NewSignPolynomial(1.0/16, 12, 15)
+	require.NoError(t, err)
+
+	rotKey := tc.kgen.GenRotationKeysForRotations(params.RotationsForArgmax(params.Slots()), false, tc.sk)
+	eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+	// Distinct values of the grid -7/16 + j/8, which differ by at least 2 * sgn.Epsilon.
+	newSortVectors := func() (values []float64, ciphertext *Ciphertext) {
+		values = make([]float64, params.Slots())
+		for i, j := range rand.Perm(8)[:params.Slots()] {
+			values[i] = -7.0/16 + float64(j)/8
+		}
+		ciphertext = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, params.MaxLevel(), params.DefaultScale(), params.LogSlots()))
+		return
+	}
+
+	verifySortVectors := func(want []float64, ciphertext *Ciphertext, t *testing.T) {
+		have := tc.encoder.Decode(tc.decryptor.DecryptNew(ciphertext), params.LogSlots())
+		for i := range want {
+			require.InDelta(t, want[i], real(have[i]), 1.0/256)
+		}
+	}
+
+	for _, n := range []int{2, 4} {
+
+		for _, descending := range []bool{false, true} {
+
+			t.Run(GetTestName(params, fmt.Sprintf("BitonicNetwork/Sort/n=%d/descending=%t", n, descending)), func(t *testing.T) {
+
+				bn := GenBitonicSort(tc.encoder, n, params.MaxLevel(), sgn, descending)
+
+				require.Equal(t, params.RotationsForBitonicNetwork(n), bn.Rotations())
+
+				values, ciphertext := newSortVectors()
+
+				sorted := append([]float64{}, values[:n]...)
+				sort.Float64s(sorted)
+				if descending {
+					sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+				}
+				copy(values, sorted)
+
+				ciphertext, err := eval.Sort(ciphertext, bn)
+				require.NoError(t, err)
+				require.Equal(t, params.MaxLevel()-bn.Depth(), ciphertext.Level())
+
+				verifySortVectors(values, ciphertext, t)
+			})
+		}
+	}
+
+	for _, nk := range [][2]int{{4, 2}, {8, 1}} {
+
+		n, k := nk[0], nk[1]
+
+		t.Run(GetTestName(params, fmt.Sprintf("BitonicNetwork/TopK/n=%d/k=%d", n, k)), func(t *testing.T) {
+
+			bn := GenBitonicTopK(tc.encoder, n, k, params.MaxLevel(), sgn)
+
+			values, ciphertext := newSortVectors()
+
+			sorted := append([]float64{}, values[:n]...)
+			sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+			ciphertext, err := eval.Sort(ciphertext, bn)
+			require.NoError(t, err)
+
+			verifySortVectors(sorted[:k], ciphertext, t)
+		})
+	}
+
+	t.Run(GetTestName(params, "BitonicNetwork/Argmax"), func(t *testing.T) {
+
+		n := 4
+
+		bn := GenBitonicTopK(tc.encoder, n, 1, params.MaxLevel(), sgn)
+
+		values, ciphertext := newSortVectors()
+
+		want := make([]float64, n)
+		argmax := 0
+		for i := range want {
+			if values[i] > values[argmax] {
+				argmax = i
+			}
+		}
+		want[argmax] = 1
+
+		ciphertext, err := eval.Argmax(ciphertext, bn)
+		require.NoError(t, err)
+		require.Equal(t, params.MaxLevel()-bn.ArgmaxDepth(), ciphertext.Level())
+
+		verifySortVectors(want, ciphertext, t)
+
+		_, err = eval.Argmax(ciphertext, GenBitonicTopK(tc.encoder, n, 2, params.MaxLevel(), sgn))
+		require.Error(t, err)
+	})
+}
+
 func genTestParams(defaultParam Parameters, hw int) (tc *testContext, err error) {
 
 	tc = new(testContext)
@@ -724,6 +855,23 @@ func testEvaluatorMulAndAdd(tc *testContext, t *testing.T) {
 		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
 	})
 
+	t.Run(GetTestName(tc.params, "Evaluator/MulRelinAndAdd/ct1*ct2->ct0"), func(t *testing.T) {
+
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values2, _, ciphertext2 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+		values3, _, ciphertext3 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+
+		for i := range values1 {
+			values1[i] += values2[i] * values3[i]
+		}
+
+		tc.evaluator.MulRelinAndAdd(ciphertext2, ciphertext3, ciphertext1)
+
+		require.Equal(t, ciphertext1.Degree(), 1)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values1, ciphertext1, tc.params.LogSlots(), 0, t)
+	})
+
 	t.Run(GetTestName(tc.params, "Evaluator/MulAndAdd/ct1*ct1->ct0"), func(t *testing.T) {
 
 		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
@@ -745,6 +893,254 @@ func testEvaluatorMulAndAdd(tc *testContext, t *testing.T) {
 	})
 }
 
+func testManagedEvaluator(tc *testContext, t *testing.T) {
+
+	if tc.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	eval := NewManagedEvaluator(tc.params, rlwe.EvaluationKey{Rlk: tc.rlk})
+	maxLevel := tc.params.MaxLevel()
+
+	// The ManagedEvaluator can be used in place of an Evaluator
+	var _ Evaluator = eval
+
+	newManagedVectors := func(level int, scale float64) (values []complex128, ciphertext *Ciphertext) {
+		values, _, _ = newTestVectors(tc, nil, complex(-1, -1), complex(1, 1), t)
+		ciphertext = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, level, scale, tc.params.LogSlots()))
+		return
+	}
+
+	t.Run(GetTestName(tc.params, "ManagedEvaluator/Scales"), func(t *testing.T) {
+		require.Equal(t, tc.params.DefaultScale(), eval.ScaleAt(0))
+		for l := 1; l < maxLevel+1; l++ {
+			require.InDelta(t, 1, eval.ScaleAt(l)*eval.ScaleAt(l)/tc.params.QiFloat64(l)/eval.ScaleAt(l-1), 1e-12)
+		}
+	})
+
+	t.Run(GetTestName(tc.params, "ManagedEvaluator/LazyRescale"), func(t *testing.T) {
+
+		values0, ct0 := newManagedVectors(maxLevel, eval.ScaleAt(maxLevel))
+		values1, ct1 := newManagedVectors(maxLevel, eval.ScaleAt(maxLevel))
+
+		// x*y + y*y + x, where the products are added before being rescaled
+		ctOut := eval.MulRelinNew(ct0, ct1)
+		require.Equal(t, maxLevel, ctOut.Level())
+		eval.Add(ctOut, eval.MulRelinNew(ct1, ct1), ctOut)
+		require.Equal(t, maxLevel, ctOut.Level())
+		ctOut = eval.AddNew(ct0, ctOut)
+		require.Equal(t, maxLevel, ctOut.Level())
+
+		for i := range values0 {
+			values0[i] += values0[i]*values1[i] + values1[i]*values1[i]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values0, ctOut, tc.params.LogSlots(), 0, t)
+
+		if maxLevel > 0 {
+			// The output must be at least at the settled level
+			if maxLevel > 1 {
+				require.Error(t, eval.Settle(ctOut, NewCiphertext(tc.params, 1, maxLevel-2, 0)))
+			}
+
+			ctSettled := NewCiphertext(tc.params, 1, maxLevel, 0)
+			require.NoError(t, eval.Settle(ctOut, ctSettled))
+			require.Equal(t, maxLevel-1, ctSettled.Level())
+
+			require.NoError(t, eval.Settle(ctOut, ctOut))
+			require.Equal(t, maxLevel-1, ctOut.Level())
+			require.InDelta(t, 1, ctOut.Scale/eval.ScaleAt(maxLevel-1), 1.0/(1<<20))
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values0, ctOut, tc.params.LogSlots(), 0, t)
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values0, ctSettled, tc.params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(GetTestName(tc.params, "ManagedEvaluator/AlignLevels"), func(t *testing.T) {
+
+		if maxLevel < 3 {
+			t.Skip("not enough levels")
+		}
+
+		values, ct := newManagedVectors(maxLevel, eval.ScaleAt(maxLevel))
+		valuesWant := make([]complex128, len(values))
+
+		// x^(maxLevel-1) + (3.5 - 0.5i) * x - x
+		ctPow := ct
+		for i := range values {
+			valuesWant[i] = values[i]
+		}
+		for k := 0; k < maxLevel-2; k++ {
+			ctPow = eval.MulRelinNew(ctPow, ct)
+			for i := range values {
+				valuesWant[i] *= values[i]
+			}
+		}
+
+		// The last product is not rescaled, and the other operands are aligned on it
+		require.Equal(t, 3, ctPow.Level())
+
+		ctOut := eval.AddNew(ctPow, eval.MultByConstNew(ct, complex(3.5, -0.5)))
+		eval.Sub(ctOut, ct, ctOut)
+		require.Equal(t, 3, ctOut.Level())
+		require.InDelta(t, 1, ctOut.Scale/(eval.ScaleAt(3)*eval.ScaleAt(3)), 1.0/(1<<20))
+
+		c := complex(3.5, -0.5)
+		if tc.params.RingType() == ring.ConjugateInvariant {
+			c = complex(3.5, 0)
+		}
+		for i := range values {
+			valuesWant[i] += (c - 1) * values[i]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, valuesWant, ctOut, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "ManagedEvaluator/UnmanagedScale"), func(t *testing.T) {
+
+		if maxLevel < 1 {
+			t.Skip("not enough levels")
+		}
+
+		values0, ct0 := newManagedVectors(maxLevel, eval.ScaleAt(maxLevel))
+		values1, ct1 := newManagedVectors(maxLevel, tc.params.DefaultScale())
+
+		ctOut := eval.AddNew(ct0, ct1)
+		require.Equal(t, maxLevel-1, ctOut.Level())
+		require.InDelta(t, 1, ctOut.Scale/eval.ScaleAt(maxLevel-1), 1.0/(1<<20))
+
+		for i := range values0 {
+			values0[i] += values1[i]
+		}
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values0, ctOut, tc.params.LogSlots(), 0, t)
+	})
+}
+
+func testCircuit(tc *testContext, t *testing.T) {
+
+	if tc.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	params := tc.params
+	slots := params.Slots()
+	maxLevel := params.MaxLevel()
+
+	t.Run(GetTestName(params, "Circuit/Analyze"), func(t *testing.T) {
+
+		circuit := NewCircuit(params)
+		x := circuit.Input("x", maxLevel)
+		y := circuit.Input("y", maxLevel)
+
+		// (x*y + y*y) is relinearized and rescaled once, then rotated
+		sum := circuit.Add(circuit.MulRelin(x, y), circuit.MulRelin(y, y))
+		circuit.Output("out", circuit.InnerSum(circuit.Rotate(sum, 3), 1, 4))
+
+		rotations := []int{3}
+		for _, k := range params.RotationsForInnerSumLog(1, 4) {
+			if k != 3 {
+				rotations = append(rotations, k)
+			}
+		}
+		sort.Ints(rotations)
+
+		// Unused nodes are not analyzed
+		circuit.Rotate(x, 5)
+
+		analysis, err := circuit.Analyze()
+		require.NoError(t, err)
+		require.Equal(t, 1, analysis.Depth)
+		require.Equal(t, 1, analysis.Relinearizations)
+		require.True(t, analysis.NeedsRelinearizationKey)
+		require.Equal(t, rotations, analysis.Rotations)
+		require.Len(t, analysis.GaloisElements, len(rotations))
+		require.Equal(t, maxLevel-1, sum.Level())
+
+		// The depth is the longest path of the circuit, independently of the levels of the inputs
+		if maxLevel > 2 {
+			circuit = NewCircuit(params)
+			a := circuit.Input("a", maxLevel)
+			b := circuit.Input("b", 2)
+			circuit.Output("a", circuit.MultByConst(a, 2))
+			circuit.Output("b", circuit.MulRelin(circuit.MultByConst(b, 2), b))
+			analysis, err = circuit.Analyze()
+			require.NoError(t, err)
+			require.Equal(t, 2, analysis.Depth)
+		}
+
+		// A circuit deeper than its inputs is rejected
+		circuit = NewCircuit(params)
+		z := circuit.Input("z", 0)
+		circuit.Output("out", circuit.MultByConst(z, 2))
+		_, err = circuit.Analyze()
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(params, "Circuit/Evaluate"), func(t *testing.T) {
+
+		if maxLevel < 3 {
+			t.Skip("not enough levels")
+		}
+
+		values0, _, ciphertext0 := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+
+		diagMatrix := map[int][]complex128{-1: make([]complex128, slots), 0: make([]complex128, slots), 2: make([]complex128, slots)}
+		for i := 0; i < slots; i++ {
+			diagMatrix[-1][i] = complex(0.5, 0)
+			diagMatrix[0][i] = complex(1, 0)
+			diagMatrix[2][i] = complex(-0.25, 0)
+		}
+		linTransf := GenLinearTransform(tc.encoder, diagMatrix, maxLevel, params.QiFloat64(maxLevel-2), params.LogSlots())
+
+		coeffs := []complex128{0.5, 1, 0.25}
+		pol := NewPoly(coeffs)
+
+		circuit := NewCircuit(params)
+		x := circuit.Input("x", maxLevel)
+		y := circuit.Input("y", maxLevel)
+
+		// out0 = 0.5 * rot(x*y - y*y, 1) + x
+		prod := circuit.Sub(circuit.MulRelin(x, y), circuit.MulRelin(y, y))
+		circuit.Output("out0", circuit.Add(circuit.MultByConst(circuit.Rotate(prod, 1), 0.5), x))
+
+		// out1 = LT(pol(x))
+		circuit.Output("out1", circuit.LinearTransform(circuit.EvaluatePoly(x, pol), linTransf))
+
+		analysis, err := circuit.Analyze()
+		require.NoError(t, err)
+		require.Equal(t, pol.Depth()+1, analysis.Depth)
+		require.Equal(t, 1, analysis.Relinearizations)
+
+		rotKey := tc.kgen.GenRotationKeysForRotations(analysis.Rotations, false, tc.sk)
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		_, err = circuit.Evaluate(eval, map[string]*Ciphertext{"x": ciphertext0})
+		require.Error(t, err)
+
+		outputs, err := circuit.Evaluate(eval, map[string]*Ciphertext{"x": ciphertext0, "y": ciphertext1})
+		require.NoError(t, err)
+
+		want0 := make([]complex128, slots)
+		want1 := make([]complex128, slots)
+		polX := make([]complex128, slots)
+		for i := range polX {
+			polX[i] = coeffs[0] + coeffs[1]*values0[i] + coeffs[2]*values0[i]*values0[i]
+		}
+		for i := range want0 {
+			j := (i + 1) % slots
+			want0[i] = 0.5*(values0[j]*values1[j]-values1[j]*values1[j]) + values0[i]
+			want1[i] = 0.5*polX[(i-1+slots)%slots] + polX[i] - 0.25*polX[(i+2)%slots]
+		}
+
+		require.Equal(t, maxLevel-2, outputs["out0"].Level())
+		require.Equal(t, maxLevel-pol.Depth()-1, outputs["out1"].Level())
+
+		verifyTestVectors(params, tc.encoder, tc.decryptor, want0, outputs["out0"], params.LogSlots(), 0, t)
+		verifyTestVectors(params, tc.encoder, tc.decryptor, want1, outputs["out1"], params.LogSlots(), 0, t)
+	})
+}
+
 func testFunctions(tc *testContext, t *testing.T) {
 
 	t.Run(GetTestName(tc.params, "Evaluator/PowerOf2"), func(t *testing.T) {
@@ -910,6 +1306,313 @@ func testEvaluatePoly(tc *testContext, t *testing.T) {
 	})
 }
 
+func testComparison(tc *testContext, t *testing.T) {
+
+	sgn, err := NewSignPolynomial(1.0/16, 16, 15)
+	require.NoError(t, err)
+
+	newComparisonVectors := func(a, b float64) (values []complex128, ciphertext *Ciphertext) {
+		values = make([]complex128, tc.params.Slots())
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(a, b), 0)
+		}
+		ciphertext = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+		return
+	}
+
+	// Values of op0 - op1 in [-1/2, -epsilon] U [epsilon, 1/2]
+	newComparisonPair := func() (values0, values1 []complex128, ciphertext0, ciphertext1 *Ciphertext) {
+		values0, ciphertext0 = newComparisonVectors(0, 0.5)
+		values1 = make([]complex128, len(values0))
+		for i := range values1 {
+			d := utils.RandFloat64(sgn.Epsilon, 0.5)
+			if i&1 == 0 {
+				d = -d
+			}
+			values1[i] = values0[i] + complex(d, 0)
+		}
+		ciphertext1 = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values1, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+		return
+	}
+
+	// The precision is bounded by the noise of the polynomial evaluation, amplified by the slope of the
+	// approximation around +/- epsilon, and is therefore lower than for the other tests.
+	verifyComparison := func(valuesWant []complex128, ciphertext *Ciphertext, t *testing.T) {
+		precStats := GetPrecisionStats(tc.params, tc.encoder, tc.decryptor, valuesWant, ciphertext, tc.params.LogSlots(), 0)
+		if *printPrecisionStats {
+			t.Log(precStats.String())
+		}
+		require.GreaterOrEqual(t, precStats.MeanPrecision.Real, 12.0)
+	}
+
+	t.Run(GetTestName(tc.params, "Comparison/SignPolynomial"), func(t *testing.T) {
+
+		for k := 0; k < 1<<12; k++ {
+			x := sgn.Epsilon + (1-sgn.Epsilon)*float64(k)/(1<<12)
+			require.LessOrEqual(t, math.Abs(sgn.Evaluate(x)-1), math.Exp2(-float64(sgn.Alpha)))
+			require.LessOrEqual(t, math.Abs(sgn.Evaluate(-x)+1), math.Exp2(-float64(sgn.Alpha)))
+		}
+
+		require.Equal(t, len(sgn.Polys)*4, sgn.Depth())
+
+		_, err := NewSignPolynomial(1.0/16, 16, 8)
+		require.Error(t, err)
+
+		if tc.params.MaxLevel() < sgn.Depth()+1 {
+			require.Error(t, sgn.CheckDepth(tc.params))
+		} else {
+			require.NoError(t, sgn.CheckDepth(tc.params))
+		}
+	})
+
+	t.Run(GetTestName(tc.params, "Comparison/Sign"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < sgn.Depth() {
+			t.Skip("skipping test for params max level < sgn.Depth()")
+		}
+
+		values, ciphertext := newComparisonVectors(-1, 1)
+
+		for i := range values {
+			if math.Abs(real(values[i])) < sgn.Epsilon {
+				values[i] = complex(sgn.Epsilon, 0)
+			}
+		}
+
+		ciphertext = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+
+		for i := range values {
+			values[i] = complex(math.Copysign(1, real(values[i])), 0)
+		}
+
+		ciphertext, err = tc.evaluator.Sign(ciphertext, sgn)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sgn.Depth(), ciphertext.Level())
+		require.Equal(t, tc.params.DefaultScale(), ciphertext.Scale)
+
+		verifyComparison(values, ciphertext, t)
+
+		_, err = tc.evaluator.Sign(tc.evaluator.DropLevelNew(ciphertext, ciphertext.Level()), sgn)
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(tc.params, "Comparison/Compare"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < sgn.Depth() {
+			t.Skip("skipping test for params max level < sgn.Depth()")
+		}
+
+		values0, values1, ciphertext0, ciphertext1 := newComparisonPair()
+
+		valuesWant := make([]complex128, len(values0))
+		for i := range valuesWant {
+			if real(values0[i]) > real(values1[i]) {
+				valuesWant[i] = 1
+			}
+		}
+
+		ciphertext, err := tc.evaluator.Compare(ciphertext0, ciphertext1, sgn)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sgn.Depth(), ciphertext.Level())
+
+		verifyComparison(valuesWant, ciphertext, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Comparison/MaxMin"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < sgn.Depth()+1 {
+			t.Skip("skipping test for params max level < sgn.Depth() + 1")
+		}
+
+		values0, values1, ciphertext0, ciphertext1 := newComparisonPair()
+
+		valuesMax := make([]complex128, len(values0))
+		valuesMin := make([]complex128, len(values0))
+		for i := range values0 {
+			valuesMax[i] = complex(math.Max(real(values0[i]), real(values1[i])), 0)
+			valuesMin[i] = complex(math.Min(real(values0[i]), real(values1[i])), 0)
+		}
+
+		ciphertext, err := tc.evaluator.Max(ciphertext0, ciphertext1, sgn)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sgn.Depth()-1, ciphertext.Level())
+		require.Equal(t, tc.params.DefaultScale(), ciphertext.Scale)
+		verifyComparison(valuesMax, ciphertext, t)
+
+		ciphertext, err = tc.evaluator.Min(ciphertext0, ciphertext1, sgn)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.DefaultScale(), ciphertext.Scale)
+		verifyComparison(valuesMin, ciphertext, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Comparison/ReLU"), func(t *testing.T) {
+
+		if tc.params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		if tc.params.MaxLevel() < sgn.Depth()+1 {
+			t.Skip("skipping test for params max level < sgn.Depth() + 1")
+		}
+
+		values, ciphertext := newComparisonVectors(-1, 1)
+
+		for i := range values {
+			if math.Abs(real(values[i])) < sgn.Epsilon {
+				values[i] = complex(sgn.Epsilon, 0)
+			}
+		}
+
+		ciphertext = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+
+		for i := range values {
+			values[i] = complex(math.Max(real(values[i]), 0), 0)
+		}
+
+		ciphertext, err := tc.evaluator.ReLU(ciphertext, sgn)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.DefaultScale(), ciphertext.Scale)
+		verifyComparison(values, ciphertext, t)
+	})
+}
+
+func testInverse(tc *testContext, t *testing.T) {
+
+	ia, err := NewInverseApproximation(0.5, 2, 20)
+	require.NoError(t, err)
+
+	sa, err := NewSqrtApproximation(0.25, 1, 20)
+	require.NoError(t, err)
+
+	t.Run(GetTestName(tc.params, "Inverse/Approximation"), func(t *testing.T) {
+
+		for k := 0; k <= 1<<12; k++ {
+			x := ia.A + (ia.B-ia.A)*float64(k)/(1<<12)
+			require.LessOrEqual(t, math.Abs(ia.Evaluate(x)*x-1), math.Exp2(-float64(ia.LogPrecision)))
+			x = sa.A + (sa.B-sa.A)*float64(k)/(1<<12)
+			require.LessOrEqual(t, math.Abs(sa.Evaluate(x)*math.Sqrt(x)-1), math.Exp2(-float64(sa.LogPrecision)))
+		}
+
+		iaNeg, err := NewInverseApproximation(-2, -0.5, 20)
+		require.NoError(t, err)
+		require.Equal(t, ia.Steps, iaNeg.Steps)
+		require.InDelta(t, -2.0, iaNeg.Evaluate(-0.5), 1e-6)
+
+		_, err = NewInverseApproximation(-1, 1, 20)
+		require.Error(t, err)
+
+		_, err = NewSqrtApproximation(0, 1, 20)
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(tc.params, "Inverse/Inverse"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < ia.Depth() {
+			t.Skip("skipping test for params max level < ia.Depth()")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(0.5, 0), complex(2, 0), t)
+
+		// Negative interval
+		iaNeg, err := NewInverseApproximation(-2, -0.5, 20)
+		require.NoError(t, err)
+
+		ciphertextNeg := tc.evaluator.NegNew(ciphertext)
+
+		for i := range values {
+			values[i] = 1 / values[i]
+		}
+
+		ciphertext, err = tc.evaluator.Inverse(ciphertext, ia)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-ia.Depth(), ciphertext.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+
+		for i := range values {
+			values[i] = -values[i]
+		}
+
+		ciphertextNeg, err = tc.evaluator.Inverse(ciphertextNeg, iaNeg)
+		require.NoError(t, err)
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertextNeg, tc.params.LogSlots(), 0, t)
+
+		_, err = tc.evaluator.Inverse(tc.evaluator.DropLevelNew(ciphertextNeg, ciphertextNeg.Level()), ia)
+		require.Error(t, err)
+	})
+
+	t.Run(GetTestName(tc.params, "Inverse/Div"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < ia.Depth()+1 {
+			t.Skip("skipping test for params max level < ia.Depth() + 1")
+		}
+
+		values0, _, ciphertext0 := newTestVectors(tc, tc.encryptorSk, complex(-1, 0), complex(1, 0), t)
+		values1, _, ciphertext1 := newTestVectors(tc, tc.encryptorSk, complex(0.5, 0), complex(2, 0), t)
+
+		for i := range values0 {
+			values0[i] /= values1[i]
+		}
+
+		ciphertext, err := tc.evaluator.Div(ciphertext0, ciphertext1, ia)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-ia.Depth()-1, ciphertext.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values0, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Inverse/InvSqrt"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < sa.Depth() {
+			t.Skip("skipping test for params max level < sa.Depth()")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(0.25, 0), complex(1, 0), t)
+
+		for i := range values {
+			values[i] = complex(1/math.Sqrt(real(values[i])), 0)
+		}
+
+		ciphertext, err := tc.evaluator.InvSqrt(ciphertext, sa)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sa.Depth(), ciphertext.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+
+	t.Run(GetTestName(tc.params, "Inverse/Sqrt"), func(t *testing.T) {
+
+		if tc.params.MaxLevel() < sa.Depth()+1 {
+			t.Skip("skipping test for params max level < sa.Depth() + 1")
+		}
+
+		values, _, ciphertext := newTestVectors(tc, tc.encryptorSk, complex(0.25, 0), complex(1, 0), t)
+
+		for i := range values {
+			values[i] = complex(math.Sqrt(real(values[i])), 0)
+		}
+
+		ciphertext, err := tc.evaluator.Sqrt(ciphertext, sa)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel()-sa.Depth()-1, ciphertext.Level())
+
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values, ciphertext, tc.params.LogSlots(), 0, t)
+	})
+}
+
 func testChebyshevInterpolator(tc *testContext, t *testing.T) {
 
 	var err error
@@ -1077,6 +1780,56 @@ func testBridge(tc *testContext, t *testing.T) {
 	})
 }
 
+func testDimensionSwitcher(tc *testContext, t *testing.T) {
+
+	t.Run(GetTestName(tc.params, "DimensionSwitcher"), func(t *testing.T) {
+
+		if tc.params.RingType() != ring.Standard {
+			t.Skip("only tested for params.RingType() == ring.Standard")
+		}
+
+		if tc.params.PCount() == 0 {
+			t.Skip("#Pi is empty")
+		}
+
+		paramsLarge := tc.params
+		paramsSmall, err := paramsLarge.SmallDimensionParameters(paramsLarge.LogN()-1, paramsLarge.MaxLevel())
+		require.NoError(t, err)
+		require.Equal(t, paramsLarge.LogN()-2, paramsSmall.LogSlots())
+
+		kgenSmall := NewKeyGenerator(paramsSmall)
+		skSmall := kgenSmall.GenSecretKey()
+		encoderSmall := NewEncoder(paramsSmall)
+		decryptorSmall := NewDecryptor(paramsSmall, skSmall)
+
+		swkLtS, swkStL := NewKeyGenerator(paramsLarge).GenSwitchingKeysForDimensionSwitch(tc.sk, skSmall)
+
+		switcher, err := NewDimensionSwitcher(paramsLarge, paramsSmall, swkLtS, swkStL)
+		require.NoError(t, err)
+
+		logSlots := paramsSmall.LogSlots()
+
+		values := make([]complex128, 1<<logSlots)
+		for i := range values {
+			values[i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+		}
+
+		ctLarge := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values, paramsLarge.MaxLevel(), paramsLarge.DefaultScale(), logSlots))
+
+		ctSmall := switcher.LargeToSmallNew(ctLarge)
+		require.Equal(t, paramsSmall.N(), ctSmall.Value[0].Degree())
+
+		verifyTestVectors(paramsSmall, encoderSmall, decryptorSmall, values, ctSmall, logSlots, 0, t)
+
+		ctLarge = switcher.SmallToLargeNew(ctSmall)
+
+		verifyTestVectors(paramsLarge, tc.encoder, tc.decryptor, values, ctLarge, logSlots, 0, t)
+
+		_, err = NewDimensionSwitcher(paramsSmall, paramsLarge, swkLtS, swkStL)
+		require.Error(t, err)
+	})
+}
+
 func testAutomorphisms(tc *testContext, t *testing.T) {
 
 	params := tc.params
@@ -1330,6 +2083,68 @@ func testReplicate(tc *testContext, t *testing.T) {
 	})
 }
 
+func testPacking(tc *testContext, t *testing.T) {
+
+	if tc.params.PCount() == 0 {
+		t.Skip("method is unsuported when params.PCount() == 0")
+	}
+
+	if tc.params.RingType() != ring.Standard {
+		t.Skip("method is only supported for ring.Standard")
+	}
+
+	t.Run(GetTestName(tc.params, "Pack&Unpack"), func(t *testing.T) {
+
+		logSlotsOut := tc.params.LogSlots()
+		logSlotsIn := logSlotsOut - 2
+
+		sp := GenSlotsPacking(tc.params, logSlotsIn, logSlotsOut)
+
+		rotKey := tc.kgen.GenRotationKeys(sp.GaloisElements(tc.params), tc.sk)
+		eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+		// The last input is left empty
+		values := make([][]complex128, 1<<(logSlotsOut-logSlotsIn))
+		cts := make([]*Ciphertext, len(values)-1)
+		for j := range values {
+			values[j] = make([]complex128, 1<<logSlotsIn)
+			if j < len(cts) {
+				for i := range values[j] {
+					values[j][i] = complex(utils.RandFloat64(-1, 1), utils.RandFloat64(-1, 1))
+				}
+				cts[j] = tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(values[j], tc.params.MaxLevel(), tc.params.DefaultScale(), logSlotsIn))
+			}
+		}
+
+		ctPacked, err := eval.Pack(cts, sp)
+		require.NoError(t, err)
+		require.Equal(t, tc.params.MaxLevel(), ctPacked.Level())
+
+		// The packed plaintext interleaves the coefficients of the input plaintexts
+		ringQ := tc.params.RingQ()
+		want := ringQ.NewPoly()
+		for j, ct := range cts {
+			pt := tc.decryptor.DecryptNew(ct)
+			ringQ.InvNTT(pt.Value, pt.Value)
+			ringQ.MultByMonomial(pt.Value, j*(tc.params.N()>>(logSlotsOut+1)), pt.Value)
+			ringQ.Add(want, pt.Value, want)
+		}
+		verifyTestVectors(tc.params, tc.encoder, tc.decryptor, tc.encoder.Decode(&Plaintext{Plaintext: &rlwe.Plaintext{Value: want}, Scale: ctPacked.Scale}, logSlotsOut), ctPacked, logSlotsOut, 0, t)
+
+		ctUnpacked, err := eval.Unpack(ctPacked, sp)
+		require.NoError(t, err)
+		require.Len(t, ctUnpacked, len(values))
+
+		for j := range ctUnpacked {
+			require.Equal(t, ctPacked.Level(), ctUnpacked[j].Level())
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, values[j], ctUnpacked[j], logSlotsIn, 0, t)
+		}
+
+		_, err = eval.Pack(append(cts, cts...), sp)
+		require.Error(t, err)
+	})
+}
+
 func testLinearTransform(tc *testContext, t *testing.T) {
 
 	if tc.params.PCount() == 0 {
@@ -1430,6 +2245,178 @@ func testLinearTransform(tc *testContext, t *testing.T) {
 	})
 }
 
+func testParallelEvaluator(tc *testContext, t *testing.T) {
+
+	if tc.params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	params := tc.params
+	slots := params.Slots()
+
+	diagMatrix := map[int][]complex128{0: make([]complex128, slots), 1: make([]complex128, slots)}
+	for i := 0; i < slots; i++ {
+		diagMatrix[0][i] = complex(0.5, 0)
+		diagMatrix[1][i] = complex(-1, 0)
+	}
+	linTransf := GenLinearTransform(tc.encoder, diagMatrix, params.MaxLevel(), params.DefaultScale(), params.LogSlots())
+
+	rotKey := tc.kgen.GenRotationKeysForRotations(append(linTransf.Rotations(), 3), false, tc.sk)
+	eval := NewParallelEvaluator(tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey}), 3)
+	require.Equal(t, 3, eval.Workers())
+
+	n := 5
+	values := make([][]complex128, n)
+	ciphertexts := make([]*Ciphertext, n)
+	for i := range ciphertexts {
+		values[i], _, ciphertexts[i] = newTestVectors(tc, tc.encryptorSk, complex(-1, -1), complex(1, 1), t)
+	}
+
+	t.Run(GetTestName(params, "ParallelEvaluator/MulRelin"), func(t *testing.T) {
+
+		op1 := make([]*Ciphertext, n)
+		for i := range op1 {
+			op1[i] = ciphertexts[(i+1)%n]
+		}
+
+		ctOut := eval.MulRelinNew(ciphertexts, op1)
+		require.Len(t, ctOut, n)
+
+		for i := range ctOut {
+			require.Equal(t, 1, ctOut[i].Degree())
+			want := make([]complex128, slots)
+			for j := range want {
+				want[j] = values[i][j] * values[(i+1)%n][j]
+			}
+			verifyTestVectors(params, tc.encoder, tc.decryptor, want, ctOut[i], params.LogSlots(), 0, t)
+		}
+
+		require.NoError(t, eval.Rescale(ctOut, params.DefaultScale(), ctOut))
+		for i := range ctOut {
+			require.Equal(t, params.MaxLevel()-1, ctOut[i].Level())
+		}
+	})
+
+	t.Run(GetTestName(params, "ParallelEvaluator/Rotate"), func(t *testing.T) {
+
+		ctOut := make([]*Ciphertext, n)
+		for i := range ctOut {
+			ctOut[i] = NewCiphertext(params, 1, params.MaxLevel(), params.DefaultScale())
+		}
+
+		eval.Rotate(ciphertexts, 3, ctOut)
+
+		for i := range ctOut {
+			verifyTestVectors(params, tc.encoder, tc.decryptor, utils.RotateComplex128Slice(values[i], 3), ctOut[i], params.LogSlots(), 0, t)
+		}
+	})
+
+	t.Run(GetTestName(params, "ParallelEvaluator/Panic"), func(t *testing.T) {
+
+		// The panic of a worker is raised again with its stack trace
+		ctOut := []*Ciphertext{NewCiphertext(params, 2, params.MaxLevel(), params.DefaultScale())}
+		r := func() (r interface{}) {
+			defer func() { r = recover() }()
+			eval.Rotate(ciphertexts[:1], 3, ctOut)
+			return
+		}()
+
+		taskPanic, ok := r.(*utils.TaskPanic)
+		require.True(t, ok)
+		require.Contains(t, string(taskPanic.Stack), "(*evaluator).Rotate")
+	})
+
+	t.Run(GetTestName(params, "ParallelEvaluator/LinearTransform"), func(t *testing.T) {
+
+		ctOut := eval.LinearTransformNew(ciphertexts, linTransf)
+		require.Len(t, ctOut, n)
+
+		for i := range ctOut {
+			want := make([]complex128, slots)
+			for j := range want {
+				want[j] = 0.5*values[i][j] - values[i][(j+1)%slots]
+			}
+			verifyTestVectors(params, tc.encoder, tc.decryptor, want, ctOut[i][0], params.LogSlots(), 0, t)
+		}
+	})
+}
+
+func testMatrixMultiplication(tc *testContext, t *testing.T) {
+
+	for _, dims := range [][3]int{{5, 7, 6}, {2, 7, 8}} {
+
+		rows, inner, cols := dims[0], dims[1], dims[2]
+
+		t.Run(GetTestName(tc.params, fmt.Sprintf("MatrixMultiplication/%dx%dx%d", rows, inner, cols)), func(t *testing.T) {
+
+			if tc.params.PCount() == 0 {
+				t.Skip("method is unsuported when params.PCount() == 0")
+			}
+
+			if tc.params.MaxLevel() < 3 {
+				t.Skip("skipping test for params max level < 3")
+			}
+
+			mm := GenMatrixMultiplication(tc.encoder, rows, inner, cols, tc.params.MaxLevel(), 2.0)
+
+			require.Equal(t, 8, mm.Left.Dim)
+
+			rotKey := tc.kgen.GenRotationKeys(mm.GaloisElements(tc.params), tc.sk)
+			eval := tc.evaluator.WithKey(rlwe.EvaluationKey{Rlk: tc.rlk, Rtks: rotKey})
+
+			newMatrix := func(rows, cols int) (m [][]float64) {
+				m = make([][]float64, rows)
+				for i := range m {
+					m[i] = make([]float64, cols)
+					for j := range m[i] {
+						m[i][j] = utils.RandFloat64(-1, 1)
+					}
+				}
+				return
+			}
+
+			A, B := newMatrix(rows, inner), newMatrix(inner, cols)
+
+			C := make([][]float64, rows)
+			for i := range C {
+				C[i] = make([]float64, cols)
+				for j := range C[i] {
+					for k := 0; k < inner; k++ {
+						C[i][j] += A[i][k] * B[k][j]
+					}
+				}
+			}
+
+			ctA := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(mm.Left.Pack(A), tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+			ctB := tc.encryptorSk.EncryptNew(tc.encoder.EncodeNew(mm.Right.Pack(B), tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots()))
+
+			ctC := eval.MulMatrixNew(ctA, ctB, mm)
+
+			require.Equal(t, tc.params.MaxLevel()-3, ctC.Level())
+
+			verifyTestVectors(tc.params, tc.encoder, tc.decryptor, mm.Product.Pack(C), ctC, tc.params.LogSlots(), 0, t)
+
+			// Operands above mm.Level are dropped to mm.Level
+			if tc.params.MaxLevel() > 3 {
+				mmLow := GenMatrixMultiplication(tc.encoder, rows, inner, cols, tc.params.MaxLevel()-1, 2.0)
+				ctC = eval.MulMatrixNew(ctA, ctB, mmLow)
+				require.Equal(t, tc.params.MaxLevel(), ctA.Level())
+				require.Equal(t, tc.params.MaxLevel()-4, ctC.Level())
+				verifyTestVectors(tc.params, tc.encoder, tc.decryptor, mm.Product.Pack(C), ctC, tc.params.LogSlots(), 0, t)
+			}
+
+			have := mm.Product.Unpack(tc.encoder.Decode(tc.decryptor.DecryptNew(ctC), tc.params.LogSlots()))
+			require.Len(t, have, rows)
+			for i := range have {
+				require.Len(t, have[i], cols)
+				for j := range have[i] {
+					require.InDelta(t, C[i][j], real(have[i][j]), 1e-3)
+				}
+			}
+		})
+	}
+}
+
 func testMarshaller(testctx *testContext, t *testing.T) {
 
 	t.Run(GetTestName(testctx.params, "Marshaller/Parameters/Binary"), func(t *testing.T) {
@@ -0,0 +1,478 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+)
+
+// SignPolynomial is a composite minimax approximation of the sign function, that is, a sequence of odd
+// polynomials p_0, ..., p_{k-1} of low degree such that p_{k-1}(...p_0(x)) is within 2^-Alpha of sign(x)
+// for all x in [-1, -Epsilon] U [Epsilon, 1].
+//
+// Each p_i is the minimax approximation of sign(x) over [-1, -e_i] U [e_i, 1] of the given degree, given in
+// Chebyshev basis over [-1, 1]. Except for the last one, it is normalized to map [e_i, 1] into [e_{i+1}, 1],
+// where e_0 = Epsilon, so that it can directly be fed to the next polynomial.
+// Inputs with an absolute value smaller than Epsilon are mapped to an undetermined value in [-1, 1].
+type SignPolynomial struct {
+	Epsilon float64
+	Alpha   int
+	Polys   []*Polynomial
+}
+
+// maxSignPolynomials is the maximum number of polynomials of a SignPolynomial.
+const maxSignPolynomials = 64
+
+// NewSignPolynomial generates a composite minimax approximation of the sign function with an error of at most
+// 2^-alpha on [-1, -epsilon] U [epsilon, 1], using polynomials of the given odd degree.
+// Higher degrees require fewer polynomials but more levels per polynomial; degrees of the form 2^k - 1 make
+// the best use of the levels.
+// Returns an error if the parameters are invalid or if the approximation does not converge.
+func NewSignPolynomial(epsilon float64, alpha int, degree int) (sp *SignPolynomial, err error) {
+
+	if epsilon <= 0 || epsilon >= 1 {
+		return nil, fmt.Errorf("cannot NewSignPolynomial: epsilon must be in (0, 1)")
+	}
+
+	if alpha < 1 || alpha > 40 {
+		return nil, fmt.Errorf("cannot NewSignPolynomial: alpha must be in [1, 40]")
+	}
+
+	if degree < 3 || degree&1 == 0 {
+		return nil, fmt.Errorf("cannot NewSignPolynomial: degree must be odd and at least 3")
+	}
+
+	sp = &SignPolynomial{Epsilon: epsilon, Alpha: alpha}
+
+	target := math.Exp2(-float64(alpha))
+
+	var coeffs []float64
+	var maxErr float64
+	for e := epsilon; ; {
+
+		if len(sp.Polys) == maxSignPolynomials {
+			return nil, fmt.Errorf("cannot NewSignPolynomial: more than %d polynomials required", maxSignPolynomials)
+		}
+
+		if coeffs, maxErr, err = minimaxSign(e, degree); err != nil {
+			return nil, fmt.Errorf("cannot NewSignPolynomial: %s", err)
+		}
+
+		// The last polynomial is not normalized, since its output is within maxErr of 1.
+		if maxErr <= target {
+			sp.Polys = append(sp.Polys, newOddChebyshevPoly(coeffs, 1))
+			break
+		}
+
+		next := (1 - maxErr) / (1 + maxErr)
+
+		if next <= e {
+			return nil, fmt.Errorf("cannot NewSignPolynomial: approximation does not converge")
+		}
+
+		sp.Polys = append(sp.Polys, newOddChebyshevPoly(coeffs, 1/(1+maxErr)))
+
+		e = next
+	}
+
+	return
+}
+
+// Depth returns the number of levels consumed by Sign, Step and Compare.
+// Max, Min and ReLU consume one additional level.
+func (sp *SignPolynomial) Depth() (depth int) {
+	for _, pol := range sp.Polys {
+		depth += pol.Depth()
+	}
+	return
+}
+
+// CheckDepth returns an error if the parameters do not provide enough levels to evaluate
+// Max, Min and ReLU on fresh ciphertexts.
+func (sp *SignPolynomial) CheckDepth(params Parameters) (err error) {
+	if params.MaxLevel() < sp.Depth()+1 {
+		return fmt.Errorf("%d levels < %d levels required by the sign approximation", params.MaxLevel(), sp.Depth()+1)
+	}
+	return nil
+}
+
+// Evaluate evaluates the composite polynomial on x in the clear.
+func (sp *SignPolynomial) Evaluate(x float64) float64 {
+	for _, pol := range sp.Polys {
+		x = evaluateChebyshev(pol.Coeffs, x)
+	}
+	return x
+}
+
+// stepPolys returns the polynomials of the approximation of (1 + sign(x))/2.
+func (sp *SignPolynomial) stepPolys() (polys []*Polynomial) {
+
+	polys = make([]*Polynomial, len(sp.Polys))
+	copy(polys, sp.Polys)
+
+	last := sp.Polys[len(sp.Polys)-1]
+
+	step := NewPoly(last.Coeffs)
+	step.A, step.B, step.Basis = last.A, last.B, last.Basis
+
+	step.Coeffs[0] += 1
+	for i := range step.Coeffs {
+		step.Coeffs[i] /= 2
+	}
+
+	polys[len(polys)-1] = step
+
+	return
+}
+
+// Sign evaluates the SignPolynomial on ctIn and returns the result on a new ciphertext with the scale of ctIn.
+// The values of ctIn must be in [-1, 1]. Consumes sgn.Depth() levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) Sign(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+	return eval.evaluateComposite(ctIn, sgn.Polys, ctIn.Scale)
+}
+
+// Step evaluates (1 + sign(x))/2 on ctIn and returns the result on a new ciphertext with the scale of ctIn.
+// The values of ctIn must be in [-1, 1]. Consumes sgn.Depth() levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) Step(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+	return eval.evaluateComposite(ctIn, sgn.stepPolys(), ctIn.Scale)
+}
+
+// Compare returns a new ciphertext whose values are 1 if op0 > op1, 0 if op0 < op1 and 1/2 if op0 = op1.
+// The values of op0 - op1 must be in [-1, 1]. Consumes sgn.Depth() levels.
+// Returns an error if the operands do not have enough levels.
+func (eval *evaluator) Compare(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+	return eval.Step(eval.SubNew(op0, op1), sgn)
+}
+
+// Max returns a new ciphertext with the slot-wise maximum of op0 and op1, with the scale of op1.
+// The values of op0 - op1 must be in [-1, 1]. Consumes sgn.Depth() + 1 levels.
+// Returns an error if the operands do not have enough levels.
+func (eval *evaluator) Max(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+
+	// max(a, b) = b + (a - b) * step(a - b)
+	if ctOut, err = eval.mulByStep(eval.SubNew(op0, op1), sgn, op1.Scale); err != nil {
+		return nil, err
+	}
+
+	eval.Add(ctOut, op1, ctOut)
+
+	return
+}
+
+// Min returns a new ciphertext with the slot-wise minimum of op0 and op1, with the scale of op0.
+// The values of op0 - op1 must be in [-1, 1]. Consumes sgn.Depth() + 1 levels.
+// Returns an error if the operands do not have enough levels.
+func (eval *evaluator) Min(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+
+	// min(a, b) = a - (a - b) * step(a - b)
+	if ctOut, err = eval.mulByStep(eval.SubNew(op0, op1), sgn, op0.Scale); err != nil {
+		return nil, err
+	}
+
+	eval.Sub(op0, ctOut, ctOut)
+
+	return
+}
+
+// ReLU returns a new ciphertext with the slot-wise max(x, 0) of ctIn, with the scale of ctIn.
+// The values of ctIn must be in [-1, 1]. Consumes sgn.Depth() + 1 levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) ReLU(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error) {
+	return eval.mulByStep(ctIn, sgn, ctIn.Scale)
+}
+
+// mulByStep returns x * step(x) with the given scale.
+func (eval *evaluator) mulByStep(ctIn *Ciphertext, sgn *SignPolynomial, scale float64) (ctOut *Ciphertext, err error) {
+	return eval.mulByStepOf(ctIn, ctIn, sgn, scale)
+}
+
+// mulByStepOf returns op * step(ctIn) with the given scale. op and ctIn must be at the same level.
+func (eval *evaluator) mulByStepOf(op, ctIn *Ciphertext, sgn *SignPolynomial, scale float64) (ctOut *Ciphertext, err error) {
+
+	if err = checkEnoughLevels(ctIn.Level(), sgn.Depth()+1, 1); err != nil {
+		return nil, err
+	}
+
+	// The step is evaluated at a scale such that the rescaling of its product with op gives exactly the target scale.
+	level := ctIn.Level() - sgn.Depth()
+
+	var step *Ciphertext
+	if step, err = eval.evaluateComposite(ctIn, sgn.stepPolys(), scale*eval.params.QiFloat64(level)/op.Scale); err != nil {
+		return nil, err
+	}
+
+	if step.Level() != level {
+		return nil, fmt.Errorf("cannot mulByStep: step evaluated at level %d instead of %d", step.Level(), level)
+	}
+
+	ctOut = eval.DropLevelNew(op, op.Level()-level)
+
+	eval.MulRelin(ctOut, step, ctOut)
+
+	if err = eval.Rescale(ctOut, scale, ctOut); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// evaluateComposite evaluates the polynomials in sequence on ctIn. The intermediate results are kept at the
+// scale of ctIn and the last one is returned at targetScale.
+func (eval *evaluator) evaluateComposite(ctIn *Ciphertext, polys []*Polynomial, targetScale float64) (ctOut *Ciphertext, err error) {
+
+	var depth int
+	for _, pol := range polys {
+		depth += pol.Depth()
+	}
+
+	if err = checkEnoughLevels(ctIn.Level(), depth, 1); err != nil {
+		return nil, err
+	}
+
+	ctOut = ctIn
+	for i, pol := range polys {
+
+		scale := ctIn.Scale
+		if i == len(polys)-1 {
+			scale = targetScale
+		}
+
+		if ctOut, err = eval.EvaluatePoly(ctOut, pol, scale); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// newOddChebyshevPoly returns the polynomial scale * sum_i coeffs[i] * T_{2i+1}(x) in Chebyshev basis over [-1, 1].
+func newOddChebyshevPoly(coeffs []float64, scale float64) (pol *Polynomial) {
+
+	c := make([]complex128, 2*len(coeffs))
+	for i := range coeffs {
+		c[2*i+1] = complex(scale*coeffs[i], 0)
+	}
+
+	pol = NewPoly(c)
+	pol.A = -1
+	pol.B = 1
+	pol.Basis = ChebyshevBasis
+
+	return
+}
+
+// evaluateChebyshev returns sum_i coeffs[i] * T_i(x).
+func evaluateChebyshev(coeffs []complex128, x float64) (y float64) {
+	Tprev, T := 1.0, x
+	for i := range coeffs {
+		y += real(coeffs[i]) * Tprev
+		Tprev, T = T, 2*x*T-Tprev
+	}
+	return
+}
+
+// oddChebyshev evaluates T_1(x), T_3(x), ..., T_{2n-1}(x) on T.
+func oddChebyshev(x float64, T []float64) {
+	Tprev, Tcurr := 1.0, x
+	for i := 0; i < 2*len(T); i++ {
+		if i&1 == 1 {
+			T[i>>1] = Tprev
+		}
+		Tprev, Tcurr = Tcurr, 2*x*Tcurr-Tprev
+	}
+}
+
+// minimaxPrecisionFloor is the error below which the minimax approximation is considered exact.
+var minimaxPrecisionFloor = math.Exp2(-48)
+
+// minimaxSign computes with the Remez algorithm the coefficients c_i of the odd polynomial
+// p(x) = sum_i c_i * T_{2i+1}(x) of the given degree that minimizes max |p(x) - 1| over [epsilon, 1].
+// Returns the coefficients and this maximum error.
+func minimaxSign(epsilon float64, degree int) (coeffs []float64, maxErr float64, err error) {
+
+	n := (degree + 1) >> 1
+
+	// Reference points initialized to the Chebyshev extrema of [epsilon, 1]
+	points := make([]float64, n+1)
+	for j := range points {
+		points[j] = epsilon + (1-epsilon)*(1-math.Cos(float64(j)*math.Pi/float64(n)))/2
+	}
+
+	// Dense grid on which the extrema of the error are searched, refined near the endpoints
+	grid := make([]float64, 128*degree+1)
+	for k := range grid {
+		grid[k] = epsilon + (1-epsilon)*(1-math.Cos(float64(k)*math.Pi/float64(len(grid)-1)))/2
+	}
+
+	T := make([]float64, n)
+
+	errorAt := func(x float64) float64 {
+		oddChebyshev(x, T)
+		var y float64
+		for i := range coeffs {
+			y += coeffs[i] * T[i]
+		}
+		return y - 1
+	}
+
+	matrix := make([][]float64, n+1)
+	for i := range matrix {
+		matrix[i] = make([]float64, n+2)
+	}
+
+	for iter := 0; iter < 100; iter++ {
+
+		// Solves sum_i c_i * T_{2i+1}(x_j) + (-1)^j * E = 1
+		for j := range points {
+			oddChebyshev(points[j], T)
+			copy(matrix[j], T)
+			matrix[j][n] = float64(1 - 2*(j&1))
+			matrix[j][n+1] = 1
+		}
+
+		var sol []float64
+		if sol, err = solveLinearSystem(matrix); err != nil {
+			return nil, 0, err
+		}
+
+		coeffs = sol[:n]
+		levelErr := math.Abs(sol[n])
+
+		// Locates the local extrema of the error, merges the consecutive ones of the same sign
+		// and refines their position.
+		type extremum struct{ x, e float64 }
+		var extrema []extremum
+
+		eprev, ecurr := 0.0, errorAt(grid[0])
+		for k := range grid {
+
+			var enext float64
+			if k < len(grid)-1 {
+				enext = errorAt(grid[k+1])
+			}
+
+			if k == 0 || k == len(grid)-1 || (ecurr-eprev)*(enext-ecurr) <= 0 {
+
+				x, e := grid[k], ecurr
+
+				if k != 0 && k != len(grid)-1 {
+					x, e = refineExtremum(errorAt, grid[k-1], grid[k+1], ecurr > 0)
+				}
+
+				if len(extrema) != 0 && (extrema[len(extrema)-1].e > 0) == (e > 0) {
+					if math.Abs(e) > math.Abs(extrema[len(extrema)-1].e) {
+						extrema[len(extrema)-1] = extremum{x, e}
+					}
+				} else {
+					extrema = append(extrema, extremum{x, e})
+				}
+			}
+
+			eprev, ecurr = ecurr, enext
+		}
+
+		for len(extrema) > n+1 {
+			if math.Abs(extrema[0].e) < math.Abs(extrema[len(extrema)-1].e) {
+				extrema = extrema[1:]
+			} else {
+				extrema = extrema[:len(extrema)-1]
+			}
+		}
+
+		maxErr = 0
+		for j := range extrema {
+			maxErr = math.Max(maxErr, math.Abs(extrema[j].e))
+		}
+
+		// The error cannot equioscillate once it reaches the precision of float64.
+		if maxErr <= minimaxPrecisionFloor {
+			break
+		}
+
+		if len(extrema) < n+1 {
+			return nil, 0, fmt.Errorf("minimax approximation did not equioscillate")
+		}
+
+		for j := range extrema {
+			points[j] = extrema[j].x
+		}
+
+		if maxErr-levelErr <= 1e-9*maxErr {
+			break
+		}
+	}
+
+	return coeffs, maxErr, nil
+}
+
+// refineExtremum returns the position and value of the extremum of f in [a, b], which is a maximum if max
+// is true and a minimum otherwise, using a golden-section search.
+func refineExtremum(f func(float64) float64, a, b float64, max bool) (x, y float64) {
+
+	sign := 1.0
+	if !max {
+		sign = -1.0
+	}
+
+	invPhi := (math.Sqrt(5) - 1) / 2
+
+	c, d := b-invPhi*(b-a), a+invPhi*(b-a)
+	fc, fd := sign*f(c), sign*f(d)
+
+	for i := 0; i < 48; i++ {
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - invPhi*(b-a)
+			fc = sign * f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invPhi*(b-a)
+			fd = sign * f(d)
+		}
+	}
+
+	x = (a + b) / 2
+	return x, f(x)
+}
+
+// solveLinearSystem solves the linear system given by the augmented matrix using a Gaussian elimination with
+// partial pivoting. The matrix is modified in place.
+func solveLinearSystem(matrix [][]float64) (sol []float64, err error) {
+
+	n := len(matrix)
+
+	for i := 0; i < n; i++ {
+
+		pivot := i
+		for j := i + 1; j < n; j++ {
+			if math.Abs(matrix[j][i]) > math.Abs(matrix[pivot][i]) {
+				pivot = j
+			}
+		}
+
+		if matrix[pivot][i] == 0 {
+			return nil, fmt.Errorf("singular linear system")
+		}
+
+		matrix[i], matrix[pivot] = matrix[pivot], matrix[i]
+
+		for j := i + 1; j < n; j++ {
+			ratio := matrix[j][i] / matrix[i][i]
+			for k := i; k < n+1; k++ {
+				matrix[j][k] -= ratio * matrix[i][k]
+			}
+		}
+	}
+
+	sol = make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sol[i] = matrix[i][n]
+		for j := i + 1; j < n; j++ {
+			sol[i] -= matrix[i][j] * sol[j]
+		}
+		sol[i] /= matrix[i][i]
+	}
+
+	return
+}
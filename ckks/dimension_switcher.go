@@ -0,0 +1,154 @@
+package ckks
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// DimensionSwitcher is a type for switching ciphertexts between a ring of large degree N and a ring of small
+// degree n, for example to evaluate the tail of a circuit, which only uses a few levels, faster in the smaller
+// ring. The parameters of the small ring can be derived from the ones of the large ring with
+// Parameters.SmallDimensionParameters.
+//
+// A ciphertext of the large ring with at most n/2 slots encrypts a polynomial in Y = X^(N/n), which is
+// re-encrypted under the secret key of the small ring mapped to Y, and whose coefficients of degree multiple of
+// N/n are then extracted. The slots of the ciphertext are preserved.
+type DimensionSwitcher struct {
+	rlwe.KeySwitcher
+
+	paramsLarge, paramsSmall Parameters
+
+	*SwkLargeToSmall
+	*SwkSmallToLarge
+}
+
+// NewDimensionSwitcher instantiates a new DimensionSwitcher between the parameters paramsLarge and paramsSmall,
+// with the switching keys generated with KeyGenerator.GenSwitchingKeysForDimensionSwitch. Either key can be nil.
+// The method returns an error if both parameters are not of the standard ring type, if the ring degree of
+// paramsSmall is not smaller than the one of paramsLarge, or if the moduli of paramsSmall are not a prefix of
+// the ones of paramsLarge.
+func NewDimensionSwitcher(paramsLarge, paramsSmall Parameters, swkLargeToSmall *SwkLargeToSmall, swkSmallToLarge *SwkSmallToLarge) (DimensionSwitcher, error) {
+
+	if paramsLarge.RingType() != ring.Standard || paramsSmall.RingType() != ring.Standard {
+		return DimensionSwitcher{}, fmt.Errorf("cannot NewDimensionSwitcher: ring types must be ring.Standard")
+	}
+
+	if paramsSmall.LogN() >= paramsLarge.LogN() {
+		return DimensionSwitcher{}, fmt.Errorf("cannot NewDimensionSwitcher: the ring degree of paramsSmall must be smaller than the one of paramsLarge")
+	}
+
+	if !isPrefix(paramsSmall.Q(), paramsLarge.Q()) || !isPrefix(paramsSmall.P(), paramsLarge.P()) {
+		return DimensionSwitcher{}, fmt.Errorf("cannot NewDimensionSwitcher: the moduli of paramsSmall must be a prefix of the ones of paramsLarge")
+	}
+
+	return DimensionSwitcher{
+		KeySwitcher:     *rlwe.NewKeySwitcher(paramsLarge.Parameters),
+		paramsLarge:     paramsLarge,
+		paramsSmall:     paramsSmall,
+		SwkLargeToSmall: swkLargeToSmall,
+		SwkSmallToLarge: swkSmallToLarge,
+	}, nil
+}
+
+// LargeToSmall switches the provided ciphertext `ctIn` from the large ring to the small ring and writes the
+// result into `ctOut`. The output is at level min(ctIn.Level(), ctOut.Level()) and has the scale of the input.
+// The input must have at most n/2 slots, where n is the ring degree of the small ring.
+// The security is changed from Z[X]/(X^N+1) to Z[X]/(X^n+1).
+// The method panics if the DimensionSwitcher was not initialized with a SwkLargeToSmall key or if the ring
+// degrees of the ciphertexts do not match the ones of the DimensionSwitcher.
+func (switcher *DimensionSwitcher) LargeToSmall(ctIn, ctOut *Ciphertext) {
+
+	if switcher.SwkLargeToSmall == nil {
+		panic("no SwkLargeToSmall provided to this DimensionSwitcher")
+	}
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot LargeToSmall: input and output must be of degree 1")
+	}
+
+	if len(ctIn.Value[0].Coeffs[0]) != switcher.paramsLarge.N() || len(ctOut.Value[0].Coeffs[0]) != switcher.paramsSmall.N() {
+		panic("cannot LargeToSmall: ctIn must be of the large ring degree and ctOut of the small ring degree")
+	}
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+
+	ringQLarge := switcher.paramsLarge.RingQ()
+
+	switcher.SwitchKeysInPlace(level, ctIn.Value[1], &switcher.SwkLargeToSmall.SwitchingKey, switcher.Pool[1].Q, switcher.Pool[2].Q)
+	ringQLarge.AddLvl(level, switcher.Pool[1].Q, ctIn.Value[0], switcher.Pool[1].Q)
+
+	ctLarge := &rlwe.Ciphertext{Value: []*ring.Poly{switcher.Pool[1].Q, switcher.Pool[2].Q}}
+	rlwe.SwitchCiphertextRingDegreeNTT(ctLarge, switcher.paramsSmall.RingQ(), ringQLarge, ctOut.Ciphertext)
+
+	ctOut.Scale = ctIn.Scale
+}
+
+// SmallToLarge switches the provided ciphertext `ctIn` from the small ring to the large ring and writes the
+// result into `ctOut`. The output is at level min(ctIn.Level(), ctOut.Level()) and has the scale of the input.
+// The security is changed from Z[X]/(X^n+1) to Z[X]/(X^N+1).
+// The method panics if the DimensionSwitcher was not initialized with a SwkSmallToLarge key or if the ring
+// degrees of the ciphertexts do not match the ones of the DimensionSwitcher.
+func (switcher *DimensionSwitcher) SmallToLarge(ctIn, ctOut *Ciphertext) {
+
+	if switcher.SwkSmallToLarge == nil {
+		panic("no SwkSmallToLarge provided to this DimensionSwitcher")
+	}
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot SmallToLarge: input and output must be of degree 1")
+	}
+
+	if len(ctIn.Value[0].Coeffs[0]) != switcher.paramsSmall.N() || len(ctOut.Value[0].Coeffs[0]) != switcher.paramsLarge.N() {
+		panic("cannot SmallToLarge: ctIn must be of the small ring degree and ctOut of the large ring degree")
+	}
+
+	level := utils.MinInt(ctIn.Level(), ctOut.Level())
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+
+	for i := range ctOut.Value {
+		ring.MapSmallDimensionToLargerDimensionNTT(&ring.Poly{Coeffs: ctIn.Value[i].Coeffs[:level+1]}, ctOut.Value[i])
+	}
+
+	switcher.SwitchKeysInPlace(level, ctOut.Value[1], &switcher.SwkSmallToLarge.SwitchingKey, switcher.Pool[1].Q, switcher.Pool[2].Q)
+	switcher.paramsLarge.RingQ().AddLvl(level, ctOut.Value[0], switcher.Pool[1].Q, ctOut.Value[0])
+	ring.CopyValuesLvl(level, switcher.Pool[2].Q, ctOut.Value[1])
+
+	ctOut.Scale = ctIn.Scale
+}
+
+// LargeToSmallNew switches the provided ciphertext `ctIn` from the large ring to the small ring and returns the
+// result on a new ciphertext (see LargeToSmall).
+func (switcher *DimensionSwitcher) LargeToSmallNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(switcher.paramsSmall, 1, utils.MinInt(ctIn.Level(), switcher.paramsSmall.MaxLevel()), ctIn.Scale)
+	switcher.LargeToSmall(ctIn, ctOut)
+	return
+}
+
+// SmallToLargeNew switches the provided ciphertext `ctIn` from the small ring to the large ring and returns the
+// result on a new ciphertext (see SmallToLarge).
+func (switcher *DimensionSwitcher) SmallToLargeNew(ctIn *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(switcher.paramsLarge, 1, ctIn.Level(), ctIn.Scale)
+	switcher.SmallToLarge(ctIn, ctOut)
+	return
+}
+
+// isPrefix returns true if a is a prefix of b.
+func isPrefix(a, b []uint64) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
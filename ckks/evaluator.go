@@ -101,6 +101,22 @@ type Evaluator interface {
 
 	// Inversion
 	InverseNew(ctIn *Ciphertext, steps int) (ctOut *Ciphertext)
+	Inverse(ctIn *Ciphertext, ia *InverseApproximation) (ctOut *Ciphertext, err error)
+	Div(op0, op1 *Ciphertext, ia *InverseApproximation) (ctOut *Ciphertext, err error)
+	InvSqrt(ctIn *Ciphertext, sa *SqrtApproximation) (ctOut *Ciphertext, err error)
+	Sqrt(ctIn *Ciphertext, sa *SqrtApproximation) (ctOut *Ciphertext, err error)
+
+	// Comparison
+	Sign(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+	Step(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+	Compare(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+	Max(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+	Min(op0, op1 *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+	ReLU(ctIn *Ciphertext, sgn *SignPolynomial) (ctOut *Ciphertext, err error)
+
+	// Sorting
+	Sort(ctIn *Ciphertext, bn BitonicNetwork) (ctOut *Ciphertext, err error)
+	Argmax(ctIn *Ciphertext, bn BitonicNetwork) (ctOut *Ciphertext, err error)
 
 	// Linear Transformations
 	LinearTransformNew(ctIn *Ciphertext, linearTransform interface{}) (ctOut []*Ciphertext)
@@ -108,6 +124,9 @@ type Evaluator interface {
 	MultiplyByDiagMatrix(ctIn *Ciphertext, matrix LinearTransform, c2DecompQP []rlwe.PolyQP, ctOut *Ciphertext)
 	MultiplyByDiagMatrixBSGS(ctIn *Ciphertext, matrix LinearTransform, c2DecompQP []rlwe.PolyQP, ctOut *Ciphertext)
 
+	// Matrix Multiplication
+	MulMatrixNew(ctLeft, ctRight *Ciphertext, mm MatrixMultiplication) (ctOut *Ciphertext)
+
 	// Inner sum
 	InnerSumLog(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext)
 	InnerSum(ctIn *Ciphertext, batch, n int, ctOut *Ciphertext)
@@ -121,6 +140,10 @@ type Evaluator interface {
 	Trace(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int, ctOut *Ciphertext)
 	TraceNew(ctIn *Ciphertext, logSlotsStart, logSlotsEnd int) (ctOut *Ciphertext)
 
+	// Packing
+	Pack(cts []*Ciphertext, sp SlotsPacking) (ctOut *Ciphertext, err error)
+	Unpack(ctIn *Ciphertext, sp SlotsPacking) (ctOut []*Ciphertext, err error)
+
 	// =============================
 	// === Ciphertext Management ===
 	// =============================
@@ -1353,7 +1376,7 @@ func (eval *evaluator) mulRelinAndAdd(op0, op1 Operand, relin bool, ctOut *Ciphe
 
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[0], c0) // c0 = c[0]*c[0]
 		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[1], c1) // c1 = c[0]*c[1]
-		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c00, tmp1.Value[1], c1) // c1 = c[0]*c[1]
+		ringQ.MulCoeffsMontgomeryAndAddLvl(level, c01, tmp1.Value[0], c1) // c1 += c[1]*c[0]
 
 		if relin {
 			c2.IsNTT = true
@@ -0,0 +1,294 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// maxApproximationSteps is the maximum number of iterations of an InverseApproximation or a SqrtApproximation.
+const maxApproximationSteps = 64
+
+// InverseApproximation parameterizes the Goldschmidt iterations that compute 1/x with a relative error of at most
+// 2^-LogPrecision for all x in the interval [A, B], which must not contain zero.
+//
+// The values are first normalized to y = 2x/(A+B), which lies in [1-r, 1+r] with r = (B-A)/|A+B|, and 1/x is
+// computed as 2/(A+B) * prod_{i<Steps} (1 + e^(2^i)) with e = 1 - y, whose relative error is |e|^(2^Steps).
+// The number of steps is the smallest one that reaches the target precision.
+type InverseApproximation struct {
+	A, B         float64
+	LogPrecision int
+	Steps        int
+}
+
+// NewInverseApproximation returns the InverseApproximation of 1/x on the interval [a, b] with a relative error of at
+// most 2^-logPrecision. Returns an error if the interval contains zero, if logPrecision is not in [1, 52] or if the
+// interval is too close to zero to reach the target precision.
+func NewInverseApproximation(a, b float64, logPrecision int) (ia *InverseApproximation, err error) {
+
+	if a > b || (a <= 0 && b >= 0) {
+		return nil, fmt.Errorf("cannot NewInverseApproximation: interval [%f, %f] is empty or contains zero", a, b)
+	}
+
+	if logPrecision < 1 || logPrecision > 52 {
+		return nil, fmt.Errorf("cannot NewInverseApproximation: logPrecision must be in [1, 52]")
+	}
+
+	ia = &InverseApproximation{A: a, B: b, LogPrecision: logPrecision, Steps: 1}
+
+	// |e|^(2^steps) <= 2^-logPrecision <=> 2^steps * log2(1/r) >= logPrecision
+	logR := math.Log2((b - a) / math.Abs(a+b))
+	for math.Exp2(float64(ia.Steps))*-logR < float64(logPrecision) {
+		if ia.Steps++; ia.Steps > maxApproximationSteps {
+			return nil, fmt.Errorf("cannot NewInverseApproximation: interval [%f, %f] is too close to zero", a, b)
+		}
+	}
+
+	return
+}
+
+// Depth returns the number of levels consumed by Inverse. Div consumes one additional level.
+func (ia *InverseApproximation) Depth() int {
+	if ia.Steps == 1 {
+		return 1
+	}
+	return ia.Steps + 1
+}
+
+// Evaluate evaluates the approximation of 1/x in the clear.
+func (ia *InverseApproximation) Evaluate(x float64) (y float64) {
+	c := 2 / (ia.A + ia.B)
+	e := 1 - c*x
+	y = c * (1 + e)
+	for i := 1; i < ia.Steps; i++ {
+		e *= e
+		y *= 1 + e
+	}
+	return
+}
+
+// SqrtApproximation parameterizes the Newton iterations that compute 1/sqrt(x) with a relative error of at most
+// 2^-LogPrecision for all x in the interval [A, B], with 0 < A <= B.
+//
+// The iterations are y_{k+1} = y_k * (3 - x * y_k^2) / 2, starting from the constant y_0 that minimizes the relative
+// error after the first iteration on [A, B]. Since y_0 is a constant, the first iteration is an affine function of x.
+// The number of steps is the smallest one that reaches the target precision.
+type SqrtApproximation struct {
+	A, B         float64
+	LogPrecision int
+	Steps        int
+	y0           float64
+}
+
+// NewSqrtApproximation returns the SqrtApproximation of 1/sqrt(x) and sqrt(x) on the interval [a, b] with a relative
+// error of at most 2^-logPrecision. Returns an error if the interval is not included in (0, inf), if logPrecision is
+// not in [1, 52] or if the interval is too close to zero to reach the target precision.
+func NewSqrtApproximation(a, b float64, logPrecision int) (sa *SqrtApproximation, err error) {
+
+	if a > b || a <= 0 {
+		return nil, fmt.Errorf("cannot NewSqrtApproximation: interval [%f, %f] is empty or not positive", a, b)
+	}
+
+	if logPrecision < 1 || logPrecision > 52 {
+		return nil, fmt.Errorf("cannot NewSqrtApproximation: logPrecision must be in [1, 52]")
+	}
+
+	// An iteration maps r = x * y_k^2 to g(r) = r * (3 - r)^2 / 4, which is increasing on [0, 1], decreasing on
+	// [1, 3] and equal to 1 at r = 1. The initial value y_0 = sqrt(t) is chosen such that g(a*t) = g(b*t), which
+	// maximizes min(g(r)) over [a*t, b*t], and thus the precision after the first iteration.
+	g := func(r float64) float64 {
+		return r * (3 - r) * (3 - r) / 4
+	}
+
+	tMin, tMax := 1/b, math.Min(1/a, 3/b)
+	for i := 0; i < 64; i++ {
+		if t := (tMin + tMax) / 2; g(a*t) < g(b*t) {
+			tMin = t
+		} else {
+			tMax = t
+		}
+	}
+
+	sa = &SqrtApproximation{A: a, B: b, LogPrecision: logPrecision, y0: math.Sqrt(tMin)}
+
+	// After the first iteration, all the values of r are in [min(g(a*t), g(b*t)), 1], and the worst relative
+	// error |1 - sqrt(r)| is reached at one of the endpoints of the interval.
+	target := math.Exp2(-float64(logPrecision))
+	ra, rb := a*tMin, b*tMin
+	for {
+
+		if sa.Steps++; sa.Steps > maxApproximationSteps {
+			return nil, fmt.Errorf("cannot NewSqrtApproximation: interval [%f, %f] is too close to zero", a, b)
+		}
+
+		ra, rb = g(ra), g(rb)
+
+		if math.Max(math.Abs(1-math.Sqrt(ra)), math.Abs(1-math.Sqrt(rb))) <= target {
+			break
+		}
+	}
+
+	return
+}
+
+// Depth returns the number of levels consumed by InvSqrt. Sqrt consumes one additional level.
+func (sa *SqrtApproximation) Depth() int {
+	return 1 + 3*(sa.Steps-1)
+}
+
+// Evaluate evaluates the approximation of 1/sqrt(x) in the clear.
+func (sa *SqrtApproximation) Evaluate(x float64) (y float64) {
+	y = sa.y0
+	for i := 0; i < sa.Steps; i++ {
+		y = y * (3 - x*y*y) / 2
+	}
+	return
+}
+
+// Inverse evaluates the InverseApproximation on ctIn and returns the result on a new ciphertext with a scale close
+// to the one of ctIn. The values of ctIn must be real and in [ia.A, ia.B]. Consumes ia.Depth() levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) Inverse(ctIn *Ciphertext, ia *InverseApproximation) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < ia.Depth() {
+		return nil, fmt.Errorf("cannot Inverse: %d levels < %d levels required by the approximation", ctIn.Level(), ia.Depth())
+	}
+
+	c := 2 / (ia.A + ia.B)
+
+	// e = 1 - c * x
+	e := NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale)
+	if err = eval.multByConstThenRescale(ctIn, -c, ctIn.Scale, e); err != nil {
+		return nil, err
+	}
+	eval.AddConst(e, 1, e)
+
+	// ctOut = c * (1 + e) = 2c - c^2 * x
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale)
+	if err = eval.multByConstThenRescale(ctIn, -c*c, ctIn.Scale, ctOut); err != nil {
+		return nil, err
+	}
+	eval.AddConst(ctOut, 2*c, ctOut)
+
+	for i := 1; i < ia.Steps; i++ {
+
+		// e = e^(2^i)
+		eval.MulRelin(e, e, e)
+		if err = eval.Rescale(e, ctIn.Scale, e); err != nil {
+			return nil, err
+		}
+
+		// ctOut = ctOut * (1 + e)
+		if err = eval.mulThenRescale(ctOut, eval.AddConstNew(e, 1), ctIn.Scale, ctOut); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Div returns a new ciphertext with the slot-wise division op0/op1, with a scale close to the one of op0.
+// The values of op1 must be real and in [ia.A, ia.B]. Consumes ia.Depth() + 1 levels.
+// Returns an error if the operands do not have enough levels.
+func (eval *evaluator) Div(op0, op1 *Ciphertext, ia *InverseApproximation) (ctOut *Ciphertext, err error) {
+
+	if level := utils.MinInt(op0.Level(), op1.Level()-ia.Depth()); level < 1 {
+		return nil, fmt.Errorf("cannot Div: %d levels < %d levels required", op1.Level(), ia.Depth()+1)
+	}
+
+	if ctOut, err = eval.Inverse(op1, ia); err != nil {
+		return nil, err
+	}
+
+	return ctOut, eval.mulThenRescale(op0, ctOut, op0.Scale, ctOut)
+}
+
+// InvSqrt evaluates the SqrtApproximation on ctIn and returns 1/sqrt(ctIn) on a new ciphertext with a scale close
+// to the one of ctIn. The values of ctIn must be real and in [sa.A, sa.B]. Consumes sa.Depth() levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) InvSqrt(ctIn *Ciphertext, sa *SqrtApproximation) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < sa.Depth() {
+		return nil, fmt.Errorf("cannot InvSqrt: %d levels < %d levels required by the approximation", ctIn.Level(), sa.Depth())
+	}
+
+	// First iteration: y = y0 * (3 - x * y0^2) / 2 = 1.5 * y0 - 0.5 * y0^3 * x
+	ctOut = NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale)
+	if err = eval.multByConstThenRescale(ctIn, -0.5*sa.y0*sa.y0*sa.y0, ctIn.Scale, ctOut); err != nil {
+		return nil, err
+	}
+	eval.AddConst(ctOut, 1.5*sa.y0, ctOut)
+
+	if sa.Steps == 1 {
+		return
+	}
+
+	// xHalf = -x/2
+	xHalf := NewCiphertext(eval.params, 1, ctIn.Level(), ctIn.Scale)
+	if err = eval.multByConstThenRescale(ctIn, -0.5, ctIn.Scale, xHalf); err != nil {
+		return nil, err
+	}
+
+	tmp := NewCiphertext(eval.params, 1, ctOut.Level(), ctIn.Scale)
+
+	for i := 1; i < sa.Steps; i++ {
+
+		// tmp = -x/2 * y
+		if err = eval.mulThenRescale(xHalf, ctOut, ctIn.Scale, tmp); err != nil {
+			return nil, err
+		}
+
+		// tmp = 1.5 - x/2 * y^2
+		if err = eval.mulThenRescale(tmp, ctOut, ctIn.Scale, tmp); err != nil {
+			return nil, err
+		}
+		eval.AddConst(tmp, 1.5, tmp)
+
+		// y = y * (1.5 - x/2 * y^2)
+		if err = eval.mulThenRescale(ctOut, tmp, ctIn.Scale, ctOut); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Sqrt returns sqrt(ctIn) = ctIn * InvSqrt(ctIn) on a new ciphertext with a scale close to the one of ctIn.
+// The values of ctIn must be real and in [sa.A, sa.B]. Consumes sa.Depth() + 1 levels.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) Sqrt(ctIn *Ciphertext, sa *SqrtApproximation) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < sa.Depth()+1 {
+		return nil, fmt.Errorf("cannot Sqrt: %d levels < %d levels required", ctIn.Level(), sa.Depth()+1)
+	}
+
+	if ctOut, err = eval.InvSqrt(ctIn, sa); err != nil {
+		return nil, err
+	}
+
+	return ctOut, eval.mulThenRescale(ctIn, ctOut, ctIn.Scale, ctOut)
+}
+
+// multByConstThenRescale computes ctOut = constant * ctIn and rescales the result, consuming exactly one level.
+// ctOut can be ctIn.
+func (eval *evaluator) multByConstThenRescale(ctIn *Ciphertext, constant float64, minScale float64, ctOut *Ciphertext) (err error) {
+
+	level := ctIn.Level()
+
+	eval.MultByConst(ctIn, constant, ctOut)
+
+	// Integer constants do not scale the ciphertext, in which case the level is dropped instead.
+	if constant == math.Trunc(constant) {
+		eval.DropLevel(ctOut, ctOut.Level()-level+1)
+		return nil
+	}
+
+	return eval.Rescale(ctOut, minScale, ctOut)
+}
+
+// mulThenRescale computes ctOut = op0 * op1 with relinearization and rescales the result. ctOut can be op0 or op1.
+func (eval *evaluator) mulThenRescale(op0, op1 *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+	eval.MulRelin(op0, op1, ctOut)
+	return eval.Rescale(ctOut, minScale, ctOut)
+}
@@ -6,6 +6,7 @@ import "github.com/ldsec/lattigo/v2/rlwe"
 type KeyGenerator interface {
 	rlwe.KeyGenerator
 	GenSwitchingKeysForBridge(skCKKS, skCI *rlwe.SecretKey) (*SwkComplexToReal, *SwkRealToComplex)
+	GenSwitchingKeysForDimensionSwitch(skLarge, skSmall *rlwe.SecretKey) (*SwkLargeToSmall, *SwkSmallToLarge)
 }
 
 // SwkComplexToReal is a SwitchingKey to switch from the standard domain to the conjugate invariant domain.
@@ -18,6 +19,16 @@ type SwkRealToComplex struct {
 	rlwe.SwitchingKey
 }
 
+// SwkLargeToSmall is a SwitchingKey to switch from a ring of large degree to a ring of small degree.
+type SwkLargeToSmall struct {
+	rlwe.SwitchingKey
+}
+
+// SwkSmallToLarge is a SwitchingKey to switch from a ring of small degree to a ring of large degree.
+type SwkSmallToLarge struct {
+	rlwe.SwitchingKey
+}
+
 type keyGenerator struct {
 	rlwe.KeyGenerator
 
@@ -31,6 +42,14 @@ func (keygen *keyGenerator) GenSwitchingKeysForBridge(skStd, skConjugateInvarian
 	return &SwkComplexToReal{*swkStdToCi}, &SwkRealToComplex{*swkCitoStd}
 }
 
+// GenSwitchingKeysForDimensionSwitch generates the necessary switching keys to switch from the ring of the key
+// generator to a ring of smaller degree and vice-versa. skLarge must be a secret key of the parameters of the key
+// generator and skSmall a secret key of parameters whose moduli are a prefix of the ones of the key generator
+// (see Parameters.SmallDimensionParameters).
+func (keygen *keyGenerator) GenSwitchingKeysForDimensionSwitch(skLarge, skSmall *rlwe.SecretKey) (*SwkLargeToSmall, *SwkSmallToLarge) {
+	return &SwkLargeToSmall{*keygen.GenSwitchingKey(skLarge, skSmall)}, &SwkSmallToLarge{*keygen.GenSwitchingKey(skSmall, skLarge)}
+}
+
 // NewKeyGenerator creates a rlwe.KeyGenerator instance from the CKKS parameters.
 func NewKeyGenerator(params Parameters) KeyGenerator {
 	return &keyGenerator{rlwe.NewKeyGenerator(params.Parameters), &params}
@@ -87,7 +87,7 @@ func (eval *evaluator) RotateHoistedNew(ctIn *Ciphertext, rotations []int) (ctOu
 // It is much faster than sequential calls to Rotate.
 func (eval *evaluator) RotateHoisted(ctIn *Ciphertext, rotations []int, ctOut map[int]*Ciphertext) {
 	levelQ := ctIn.Level()
-	eval.DecomposeNTT(levelQ, eval.params.PCount()-1, eval.params.PCount(), ctIn.Value[1], eval.PoolDecompQP)
+	eval.DecomposeNTT(levelQ, eval.params.PCount()-1, eval.params.Alpha(), ctIn.Value[1], eval.PoolDecompQP)
 	for _, i := range rotations {
 		ctOut[i].Value[0].Coeffs = ctOut[i].Value[0].Coeffs[:levelQ+1]
 		ctOut[i].Value[1].Coeffs = ctOut[i].Value[1].Coeffs[:levelQ+1]
@@ -438,7 +438,7 @@ func (eval *evaluator) LinearTransformNew(ctIn *Ciphertext, linearTransform inte
 
 		minLevel := utils.MinInt(maxLevel, ctIn.Level())
 
-		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.PCount(), ctIn.Value[1], eval.PoolDecompQP)
+		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.Alpha(), ctIn.Value[1], eval.PoolDecompQP)
 
 		for i, LT := range LTs {
 			ctOut[i] = NewCiphertext(eval.params, 1, minLevel, ctIn.Scale)
@@ -453,7 +453,7 @@ func (eval *evaluator) LinearTransformNew(ctIn *Ciphertext, linearTransform inte
 	case LinearTransform:
 
 		minLevel := utils.MinInt(LTs.Level, ctIn.Level())
-		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.PCount(), ctIn.Value[1], eval.PoolDecompQP)
+		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.Alpha(), ctIn.Value[1], eval.PoolDecompQP)
 
 		ctOut = []*Ciphertext{NewCiphertext(eval.params, 1, minLevel, ctIn.Scale)}
 
@@ -482,7 +482,7 @@ func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform interfa
 
 		minLevel := utils.MinInt(maxLevel, ctIn.Level())
 
-		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.PCount(), ctIn.Value[1], eval.PoolDecompQP)
+		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.Alpha(), ctIn.Value[1], eval.PoolDecompQP)
 
 		for i, LT := range LTs {
 			if LT.N1 == 0 {
@@ -494,7 +494,7 @@ func (eval *evaluator) LinearTransform(ctIn *Ciphertext, linearTransform interfa
 
 	case LinearTransform:
 		minLevel := utils.MinInt(LTs.Level, ctIn.Level())
-		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.PCount(), ctIn.Value[1], eval.PoolDecompQP)
+		eval.DecomposeNTT(minLevel, eval.params.PCount()-1, eval.params.Alpha(), ctIn.Value[1], eval.PoolDecompQP)
 		if LTs.N1 == 0 {
 			eval.MultiplyByDiagMatrix(ctIn, LTs, eval.PoolDecompQP, ctOut[0])
 		} else {
@@ -578,10 +578,10 @@ func (eval *evaluator) InnerSumLog(ctIn *Ciphertext, batchSize, n int, ctOut *Ci
 			// Starts by decomposing the input ciphertext
 			if i == 0 {
 				// If first iteration, then copies directly from the input ciphertext that hasn't been rotated
-				eval.DecomposeNTT(levelQ, levelP, levelP+1, ctIn.Value[1], eval.PoolDecompQP)
+				eval.DecomposeNTT(levelQ, levelP, eval.params.DecompAlpha(levelP), ctIn.Value[1], eval.PoolDecompQP)
 			} else {
 				// Else copies from the rotated input ciphertext
-				eval.DecomposeNTT(levelQ, levelP, levelP+1, tmpc1, eval.PoolDecompQP)
+				eval.DecomposeNTT(levelQ, levelP, eval.params.DecompAlpha(levelP), tmpc1, eval.PoolDecompQP)
 			}
 
 			// If the binary reading scans a 1
@@ -688,7 +688,7 @@ func (eval *evaluator) InnerSum(ctIn *Ciphertext, batchSize, n int, ctOut *Ciphe
 		tmp1QP := eval.Pool[2]
 
 		// Basis decomposition
-		eval.DecomposeNTT(levelQ, levelP, levelP+1, ctIn.Value[1], eval.PoolDecompQP)
+		eval.DecomposeNTT(levelQ, levelP, eval.params.DecompAlpha(levelP), ctIn.Value[1], eval.PoolDecompQP)
 
 		// Pre-rotates all [1, ..., n-1] rotations
 		// Hoisted rotation without division by P
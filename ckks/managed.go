@@ -0,0 +1,337 @@
+package ckks
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// managedScaleTolerance is the relative tolerance under which two scales are considered equal by the ManagedEvaluator.
+// The scales of the ciphertexts are tracked exactly, and deviate from the managed scales by the rounding of the integer
+// factors used to match them (a relative error of at most 1/(2*factor)), which is doubled by each product.
+const managedScaleTolerance = 1.0 / (1 << 20)
+
+// ManagedEvaluator is an opt-in wrapper of Evaluator that manages the levels and the scales of the ciphertexts.
+//
+// Each level l is assigned the scale ScaleAt(l), such that ScaleAt(l)^2 / q_l = ScaleAt(l-1): the product of two
+// ciphertexts at the scale of their level is rescaled by the modulus q_l exactly to the scale of the next level.
+// The scales are computed from ScaleAt(0) = params.DefaultScale() upwards, which keeps them close to the moduli.
+//
+// The ciphertexts given to the ManagedEvaluator should be encrypted at the scale of their level. The products are
+// rescaled lazily: the output of MulRelin and MultByConst keeps the level of its operands at the scale ScaleAt(l)^2,
+// so that products can be added together before a single rescaling, which happens when they are used as an operand
+// of a product or with Settle. Before an addition or a product, the levels and the scales of the operands are
+// aligned automatically, by rescaling them, dropping their levels or matching their scales with an integer
+// multiplication. The inputs are never modified.
+//
+// The managed methods take ciphertexts as operands: the methods given a plaintext operand are the ones of the embedded
+// Evaluator, whose outputs are not aligned.
+//
+// The Scale field of the output ciphertexts is always the exact scale of their message: the integer factors used to
+// match the scales are rounded, and the rounded values are tracked in the Scale. The Scale is thus equal to the
+// managed scale of the level only up to a small relative error (see managedScaleTolerance).
+//
+// All the other methods of the embedded Evaluator can still be used, and the ManagedEvaluator satisfies the Evaluator
+// interface, but their outputs must be brought back to a managed scale with Settle before being given to the ManagedEvaluator if they changed the scale of the ciphertext.
+type ManagedEvaluator struct {
+	Evaluator
+	params Parameters
+	scales []float64
+}
+
+// NewManagedEvaluator creates a new ManagedEvaluator wrapping a new Evaluator created with the given evaluation key.
+func NewManagedEvaluator(params Parameters, evaluationKey rlwe.EvaluationKey) (eval *ManagedEvaluator) {
+
+	eval = &ManagedEvaluator{
+		Evaluator: NewEvaluator(params, evaluationKey),
+		params:    params,
+		scales:    make([]float64, params.MaxLevel()+1),
+	}
+
+	eval.scales[0] = params.DefaultScale()
+	for l := 1; l < len(eval.scales); l++ {
+		eval.scales[l] = math.Sqrt(eval.scales[l-1] * params.QiFloat64(l))
+	}
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of this ManagedEvaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated.
+func (eval *ManagedEvaluator) ShallowCopy() Evaluator {
+	return &ManagedEvaluator{Evaluator: eval.Evaluator.ShallowCopy(), params: eval.params, scales: eval.scales}
+}
+
+// WithKey creates a shallow copy of this ManagedEvaluator in which the read-only data-structures are
+// shared with the receiver but the EvaluationKey is evaluationKey.
+func (eval *ManagedEvaluator) WithKey(evaluationKey rlwe.EvaluationKey) Evaluator {
+	return &ManagedEvaluator{Evaluator: eval.Evaluator.WithKey(evaluationKey), params: eval.params, scales: eval.scales}
+}
+
+// ScaleAt returns the managed scale of the ciphertexts at the given level.
+func (eval *ManagedEvaluator) ScaleAt(level int) float64 {
+	return eval.scales[level]
+}
+
+// AddNew adds op0 to op1 after aligning their levels and scales if both are ciphertexts, and returns the result in a
+// newly created element.
+func (eval *ManagedEvaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), 0)
+	eval.Add(op0, op1, ctOut)
+	return
+}
+
+// Add adds op0 to op1 after aligning their levels and scales if both are ciphertexts, and returns the result in ctOut.
+func (eval *ManagedEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
+	ct0, ok0 := op0.(*Ciphertext)
+	ct1, ok1 := op1.(*Ciphertext)
+	if ok0 && ok1 {
+		op0, op1 = eval.align(ct0, ct1)
+	}
+	eval.Evaluator.Add(op0, op1, ctOut)
+}
+
+// SubNew subtracts op1 from op0 after aligning their levels and scales if both are ciphertexts, and returns the result
+// in a newly created element.
+func (eval *ManagedEvaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, utils.MaxInt(op0.Degree(), op1.Degree()), utils.MinInt(op0.Level(), op1.Level()), 0)
+	eval.Sub(op0, op1, ctOut)
+	return
+}
+
+// Sub subtracts op1 from op0 after aligning their levels and scales if both are ciphertexts, and returns the result in
+// ctOut.
+func (eval *ManagedEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
+	ct0, ok0 := op0.(*Ciphertext)
+	ct1, ok1 := op1.(*Ciphertext)
+	if ok0 && ok1 {
+		op0, op1 = eval.align(ct0, ct1)
+	}
+	eval.Evaluator.Sub(op0, op1, ctOut)
+}
+
+// MulRelinNew multiplies op0 by op1 with relinearization and returns the result in a newly created element.
+// If both operands are ciphertexts, they are first rescaled if needed and brought to the same level l; the output is
+// at level l and at the scale ScaleAt(l)^2, and is rescaled lazily.
+func (eval *ManagedEvaluator) MulRelinNew(op0, op1 Operand) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, 1, utils.MinInt(op0.Level(), op1.Level()), 0)
+	eval.MulRelin(op0, op1, ctOut)
+	return
+}
+
+// MulRelin multiplies op0 by op1 with relinearization and returns the result in ctOut.
+// If both operands are ciphertexts, they are first rescaled if needed and brought to the same level l; the output is
+// at level l and at the scale ScaleAt(l)^2, and is rescaled lazily.
+func (eval *ManagedEvaluator) MulRelin(op0, op1 Operand, ctOut *Ciphertext) {
+
+	ct0, ok0 := op0.(*Ciphertext)
+	ct1, ok1 := op1.(*Ciphertext)
+	if !ok0 || !ok1 {
+		eval.Evaluator.MulRelin(op0, op1, ctOut)
+		return
+	}
+
+	level := utils.MinInt(eval.settledLevel(ct0), eval.settledLevel(ct1))
+
+	op0 = eval.settleCopy(ct0, level)
+	op1 = op0
+	if ct1 != ct0 {
+		op1 = eval.settleCopy(ct1, level)
+	}
+
+	eval.Evaluator.MulRelin(op0, op1, ctOut)
+}
+
+// MultByConstNew multiplies ctIn by the constant and returns the result in a newly created element.
+// The constant can be a uint64, int64, int, float64 or complex128. As for MulRelin, the output is at the level l of the
+// rescaled input and at the scale ScaleAt(l)^2.
+func (eval *ManagedEvaluator) MultByConstNew(ctIn *Ciphertext, constant interface{}) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ctIn.Degree(), ctIn.Level(), 0)
+	eval.MultByConst(ctIn, constant, ctOut)
+	return
+}
+
+// MultByConst multiplies ctIn by the constant and returns the result in ctOut.
+// The constant can be a uint64, int64, int, float64 or complex128. As for MulRelin, the output is at the level l of the
+// rescaled input and at the scale ScaleAt(l)^2.
+func (eval *ManagedEvaluator) MultByConst(ctIn *Ciphertext, constant interface{}, ctOut *Ciphertext) {
+
+	level := eval.settledLevel(ctIn)
+	ct := eval.settleCopy(ctIn, level)
+
+	var c complex128
+	switch constant := constant.(type) {
+	case complex128:
+		c = constant
+	case float64:
+		c = complex(constant, 0)
+	case uint64:
+		c = complex(float64(constant), 0)
+	case int64:
+		c = complex(float64(constant), 0)
+	case int:
+		c = complex(float64(constant), 0)
+	default:
+		panic("cannot MultByConst: constant must be a uint64, int64, int, float64 or complex128")
+	}
+
+	if eval.params.RingType() == ring.ConjugateInvariant {
+		c = complex(real(c), 0)
+	}
+
+	// round(c * ScaleAt(l)), so that the output scale is ScaleAt(l)^2
+	scaleUp := func(c float64) *big.Int {
+		v := new(big.Float).SetFloat64(c * eval.scales[level])
+		if c < 0 {
+			v.Sub(v, big.NewFloat(0.5))
+		} else {
+			v.Add(v, big.NewFloat(0.5))
+		}
+		i, _ := v.Int(nil)
+		return i
+	}
+
+	if ctOut.Level() > level {
+		eval.DropLevel(ctOut, ctOut.Level()-level)
+	}
+
+	eval.MultByGaussianInteger(ct, scaleUp(real(c)), scaleUp(imag(c)), ctOut)
+	ctOut.Scale = ct.Scale * eval.scales[level]
+}
+
+// Settle brings ctIn to the managed scale of its level, rescaling it by one modulus if it holds a lazily rescaled
+// product, or consuming one level to match its scale otherwise, and returns the result in ctOut.
+// Returns an error if ctIn cannot be brought to a managed scale, or if the level of ctOut is smaller than the level
+// of the result.
+func (eval *ManagedEvaluator) Settle(ctIn *Ciphertext, ctOut *Ciphertext) (err error) {
+
+	level := eval.settledLevel(ctIn)
+
+	if level < 0 {
+		return fmt.Errorf("cannot Settle: ciphertext is not at a managed scale and cannot be rescaled")
+	}
+
+	if ctOut != ctIn && ctOut.Level() < level {
+		return fmt.Errorf("cannot Settle: ctOut level (%d) is smaller than the settled level (%d)", ctOut.Level(), level)
+	}
+
+	ct := eval.settleCopy(ctIn, level)
+
+	if ct != ctOut {
+		eval.DropLevel(ctOut, ctOut.Level()-level)
+		ctOut.Copy(ct)
+	}
+
+	return
+}
+
+// align returns op0 and op1, or copies of them, at the same level and the same scale.
+func (eval *ManagedEvaluator) align(op0, op1 *Ciphertext) (ct0, ct1 *Ciphertext) {
+
+	level := utils.MinInt(op0.Level(), op1.Level())
+
+	if op0.Level() == op1.Level() && eval.isScale(op0.Scale, op1.Scale) {
+		return op0, op1
+	}
+
+	// The operand of higher level is settled at the level of the other operand
+	ct0, ct1 = op0, op1
+	if op0.Level() > level {
+		ct0 = eval.settleCopy(op0, level)
+	}
+	if op1.Level() > level {
+		ct1 = eval.settleCopy(op1, level)
+	}
+
+	if eval.isScale(ct0.Scale, ct1.Scale) {
+		return
+	}
+
+	// A lazily rescaled product and a ciphertext at the scale of the level are aligned by an integer multiplication
+	// of the latter by round(product scale / scale), which does not consume a level. The rounded factor is tracked
+	// in the scale of the aligned ciphertext.
+	scale, product := eval.scales[level], eval.scales[level]*eval.scales[level]
+
+	switch {
+	case eval.isScale(ct0.Scale, product) && eval.isScale(ct1.Scale, scale):
+		ct1 = eval.ScaleUpNew(ct1, math.Round(ct0.Scale/ct1.Scale))
+	case eval.isScale(ct1.Scale, product) && eval.isScale(ct0.Scale, scale):
+		ct0 = eval.ScaleUpNew(ct0, math.Round(ct1.Scale/ct0.Scale))
+	default:
+		if level == 0 {
+			panic("cannot align: scales of the operands do not match at level 0")
+		}
+		ct0, ct1 = eval.settleCopy(ct0, level-1), eval.settleCopy(ct1, level-1)
+	}
+
+	return
+}
+
+// settledLevel returns the level at which op can be brought to the managed scale: the level of op if it is already at
+// the managed scale of its level, and the level below otherwise.
+func (eval *ManagedEvaluator) settledLevel(op *Ciphertext) int {
+	if eval.isScale(op.Scale, eval.scales[op.Level()]) {
+		return op.Level()
+	}
+	return op.Level() - 1
+}
+
+// settleCopy returns op, or a copy of op, at the given level and at the managed scale of this level, up to the
+// managedScaleTolerance.
+func (eval *ManagedEvaluator) settleCopy(op *Ciphertext, level int) (ct *Ciphertext) {
+
+	if level < 0 {
+		panic("cannot settle: ciphertext is not at a managed scale and cannot be rescaled")
+	}
+
+	if op.Level() == level && eval.isScale(op.Scale, eval.scales[level]) {
+		return op
+	}
+
+	ct = op.CopyNew()
+	eval.settle(ct, level)
+	return
+}
+
+// settle brings ct in place to the given level and to the managed scale of this level.
+func (eval *ManagedEvaluator) settle(ct *Ciphertext, level int) {
+
+	if ct.Level() < level {
+		panic("cannot settle: ciphertext level is smaller than the target level")
+	}
+
+	for ct.Level() > level {
+
+		l := ct.Level()
+		qi := eval.params.QiFloat64(l)
+
+		switch {
+		case ct.Scale/qi >= eval.scales[l-1]*(1-managedScaleTolerance):
+			// Rescales by q_l, which is exact for a lazily rescaled product
+			if err := eval.Evaluator.Rescale(ct, ct.Scale/qi, ct); err != nil {
+				panic(err)
+			}
+		case l == level+1:
+			// Matches the scale with an integer multiplication followed by a rescaling by q_l
+			eval.ScaleUp(ct, math.Round(eval.scales[level]*qi/ct.Scale), ct)
+			if err := eval.Evaluator.Rescale(ct, ct.Scale/qi, ct); err != nil {
+				panic(err)
+			}
+		default:
+			eval.DropLevel(ct, l-level-1)
+		}
+	}
+
+	if !eval.isScale(ct.Scale, eval.scales[level]) {
+		panic("cannot settle: ciphertext scale is too large for its level")
+	}
+}
+
+// isScale checks if the two scales are equal up to the managedScaleTolerance.
+func (eval *ManagedEvaluator) isScale(a, b float64) bool {
+	return math.Abs(a/b-1) < managedScaleTolerance
+}
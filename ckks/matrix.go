@@ -0,0 +1,307 @@
+package ckks
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// MatrixPacking describes how a Rows x Cols matrix is packed in the slots of a plaintext.
+// The matrix is zero-padded to a Dim x Dim matrix stored in row-major order, whose first Period rows are
+// repeated Dim/Period times vertically. The resulting Dim^2 values are then replicated to fill the 2^LogSlots slots,
+// so that slot rotations act cyclically on the packed matrix.
+type MatrixPacking struct {
+	Rows     int // Rows is the number of rows of the matrix
+	Cols     int // Cols is the number of columns of the matrix
+	Dim      int // Dim is the dimension of the padded square matrix, a power of two
+	Period   int // Period is the number of rows after which the padded rows repeat, a power of two dividing Dim
+	LogSlots int // LogSlots is the log of the number of slots of the plaintext
+}
+
+// Pack returns the vector of 2^LogSlots values in which the matrix is packed, to be encoded with an Encoder.
+// matrix.(type) can be either [][]complex128 or [][]float64, and must have Rows rows of Cols elements.
+func (mp MatrixPacking) Pack(matrix interface{}) (values []complex128) {
+
+	var at func(i, j int) complex128
+	var rows int
+
+	switch m := matrix.(type) {
+	case [][]complex128:
+		rows = len(m)
+		at = func(i, j int) complex128 {
+			if len(m[i]) != mp.Cols {
+				panic("cannot Pack: invalid number of columns")
+			}
+			return m[i][j]
+		}
+	case [][]float64:
+		rows = len(m)
+		at = func(i, j int) complex128 {
+			if len(m[i]) != mp.Cols {
+				panic("cannot Pack: invalid number of columns")
+			}
+			return complex(m[i][j], 0)
+		}
+	default:
+		panic("cannot Pack: matrix must be either [][]complex128 or [][]float64")
+	}
+
+	if rows != mp.Rows {
+		panic("cannot Pack: invalid number of rows")
+	}
+
+	values = make([]complex128, 1<<mp.LogSlots)
+
+	for i := 0; i < mp.Dim; i++ {
+		if r := i & (mp.Period - 1); r < mp.Rows {
+			for j := 0; j < mp.Cols; j++ {
+				values[i*mp.Dim+j] = at(r, j)
+			}
+		}
+	}
+
+	for i := mp.Dim * mp.Dim; i < len(values); i++ {
+		values[i] = values[i-mp.Dim*mp.Dim]
+	}
+
+	return
+}
+
+// Unpack returns the Rows x Cols matrix packed in values.
+func (mp MatrixPacking) Unpack(values []complex128) (matrix [][]complex128) {
+	matrix = make([][]complex128, mp.Rows)
+	for i := range matrix {
+		matrix[i] = make([]complex128, mp.Cols)
+		copy(matrix[i], values[i*mp.Dim:i*mp.Dim+mp.Cols])
+	}
+	return
+}
+
+// MatrixMultiplication is a type for the product of encrypted matrices of fixed dimensions.
+// It stores the plaintext linear transformations of the diagonal method of Jiang et al.
+// ("Secure Outsourced Matrix Computation and Application to Neural Networks", CCS 2018), and
+// can be evaluated on a pair of ciphertexts by using the evaluator.MulMatrixNew method.
+//
+// The product of a Rows x Inner matrix A by a Inner x Cols matrix B is computed as
+// sum_{k < Period} phi^k(sigma(A)) * psi^k(tau(B)), where sigma, tau and phi^k are linear transformations
+// and psi^k is a rotation by k*Dim. If Period < Dim, that is for a matrix A with fewer rows than the other
+// dimensions, the result is summed over the Dim/Period vertical repetitions of A, which reduces the number
+// of multiplications from Dim to Period.
+type MatrixMultiplication struct {
+	Left    MatrixPacking     // Left is the packing of the left operand
+	Right   MatrixPacking     // Right is the packing of the right operand
+	Product MatrixPacking     // Product is the packing of the result
+	Level   int               // Level is the level at which the operands are expected
+	Sigma   LinearTransform   // Sigma is the linear transformation A[i][j] -> A[i][i+j] applied on the left operand
+	Tau     LinearTransform   // Tau is the linear transformation B[i][j] -> B[i+j][j] applied on the right operand
+	Phi     []LinearTransform // Phi[k-1] is the column rotation A[i][j] -> A[i][j+k]
+}
+
+// GenMatrixMultiplication allocates and encodes a new MatrixMultiplication for the product of a rows x inner matrix
+// by a inner x cols matrix, whose operands are encrypted at the given level.
+// BSGSRatio is the maximum ratio between the inner and outer loop of the baby-step giant-step algorithm used
+// to evaluate Sigma and Tau (see GenLinearTransformBSGS).
+// The method will panic if the padded matrices do not fit in the slots or if level < 3.
+func GenMatrixMultiplication(encoder Encoder, rows, inner, cols, level int, BSGSRatio float64) (mm MatrixMultiplication) {
+
+	enc, ok := encoder.(*encoderComplex128)
+	if !ok {
+		panic("encoder should be an encoderComplex128")
+	}
+
+	params := enc.params
+
+	if rows < 1 || inner < 1 || cols < 1 {
+		panic("cannot GenMatrixMultiplication: dimensions must be positive")
+	}
+
+	if level < 3 {
+		panic("cannot GenMatrixMultiplication: level must be at least 3")
+	}
+
+	dim := 1 << bits.Len64(uint64(utils.MaxInt(rows, utils.MaxInt(inner, cols))-1))
+	period := 1 << bits.Len64(uint64(rows-1))
+
+	logSlots := params.LogSlots()
+
+	if dim*dim > 1<<logSlots {
+		panic(fmt.Sprintf("cannot GenMatrixMultiplication: padded dimension %d x %d exceeds the number of slots", dim, dim))
+	}
+
+	mm.Left = MatrixPacking{Rows: rows, Cols: inner, Dim: dim, Period: period, LogSlots: logSlots}
+	mm.Right = MatrixPacking{Rows: inner, Cols: cols, Dim: dim, Period: dim, LogSlots: logSlots}
+	mm.Product = MatrixPacking{Rows: rows, Cols: cols, Dim: dim, Period: period, LogSlots: logSlots}
+	mm.Level = level
+
+	// The plaintext matrices are encoded at the scale of the modulus consumed by the following rescaling,
+	// so that the transformations do not change the scale of the ciphertexts.
+	mm.Sigma = GenLinearTransformBSGS(encoder, permutationDiagonals(dim, logSlots, func(i, j int) (int, int) {
+		return i, (i + j) & (dim - 1)
+	}), level, params.QiFloat64(level), BSGSRatio, logSlots)
+
+	mm.Tau = GenLinearTransformBSGS(encoder, permutationDiagonals(dim, logSlots, func(i, j int) (int, int) {
+		return (i + j) & (dim - 1), j
+	}), level, params.QiFloat64(level), BSGSRatio, logSlots)
+
+	mm.Phi = make([]LinearTransform, period-1)
+	for k := 1; k < period; k++ {
+		mm.Phi[k-1] = GenLinearTransform(encoder, permutationDiagonals(dim, logSlots, func(i, j int) (int, int) {
+			return i, (j + k) & (dim - 1)
+		}), level-1, params.QiFloat64(level-1), logSlots)
+	}
+
+	return
+}
+
+// Rotations returns the list of rotations needed for the evaluation of the matrix multiplication.
+func (mm *MatrixMultiplication) Rotations() (rotations []int) {
+
+	dim, period := mm.Left.Dim, mm.Left.Period
+	slots := 1 << mm.Left.LogSlots
+
+	rotIndex := make(map[int]bool)
+
+	for _, LT := range append([]LinearTransform{mm.Sigma, mm.Tau}, mm.Phi...) {
+		for _, k := range LT.Rotations() {
+			rotIndex[k] = true
+		}
+	}
+
+	// psi^k
+	for k := 1; k < period; k++ {
+		rotIndex[k*dim] = true
+	}
+
+	// Sum of the vertical repetitions of the left operand
+	for i := 1; i < dim/period; i <<= 1 {
+		rotIndex[i*period*dim] = true
+	}
+
+	for k := range rotIndex {
+		if k&(slots-1) != 0 {
+			rotations = append(rotations, k)
+		}
+	}
+
+	sort.Ints(rotations)
+
+	return
+}
+
+// GaloisElements returns the list of Galois elements of the rotation keys needed for the evaluation of the matrix multiplication.
+func (mm *MatrixMultiplication) GaloisElements(params Parameters) (galEls []uint64) {
+	rotations := mm.Rotations()
+	galEls = make([]uint64, len(rotations))
+	for i, k := range rotations {
+		galEls[i] = params.GaloisElementForColumnRotationBy(k)
+	}
+	return
+}
+
+// MulMatrixNew computes the product of the matrices encrypted in ctLeft and ctRight, packed according to
+// mm.Left and mm.Right, and returns the result, packed according to mm.Product, on a new ciphertext.
+// The input ciphertexts must be at least at level mm.Level. Input ciphertexts above mm.Level are first dropped
+// to mm.Level on a copy, so that the input ciphertexts are not modified. The output ciphertext is at level
+// mm.Level - 3 with the scale ctLeft.Scale * ctRight.Scale / q_{mm.Level-2}.
+// The rotation keys for mm.Rotations() and the relinearization key must be provided to the evaluator.
+func (eval *evaluator) MulMatrixNew(ctLeft, ctRight *Ciphertext, mm MatrixMultiplication) (ctOut *Ciphertext) {
+
+	if ctLeft.Level() < mm.Level || ctRight.Level() < mm.Level {
+		panic("cannot MulMatrixNew: input ciphertexts must be at least at level mm.Level")
+	}
+
+	// The plaintext transformations are encoded at the scale of q_{mm.Level}, which is the modulus
+	// that the following rescalings must drop.
+	if ctLeft.Level() > mm.Level {
+		ctLeft = eval.DropLevelNew(ctLeft, ctLeft.Level()-mm.Level)
+	}
+
+	if ctRight.Level() > mm.Level {
+		ctRight = eval.DropLevelNew(ctRight, ctRight.Level()-mm.Level)
+	}
+
+	dim, period := mm.Left.Dim, mm.Left.Period
+
+	// sigma(A) and tau(B)
+	ctSigma := eval.LinearTransformNew(ctLeft, mm.Sigma)[0]
+	if err := eval.Rescale(ctSigma, ctLeft.Scale, ctSigma); err != nil {
+		panic(err)
+	}
+
+	ctTau := eval.LinearTransformNew(ctRight, mm.Tau)[0]
+	if err := eval.Rescale(ctTau, ctRight.Scale, ctTau); err != nil {
+		panic(err)
+	}
+
+	// phi^k(sigma(A)) for 0 < k < period
+	ctPhi := []*Ciphertext{}
+	if period > 1 {
+		ctPhi = eval.LinearTransformNew(ctSigma, mm.Phi)
+		for _, ct := range ctPhi {
+			if err := eval.Rescale(ct, ctSigma.Scale, ct); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	// psi^k(tau(B)) for 0 < k < period
+	rotations := make([]int, period-1)
+	for k := 1; k < period; k++ {
+		rotations[k-1] = k * dim
+	}
+	ctPsi := eval.RotateHoistedNew(ctTau, rotations)
+
+	level := mm.Level - 2
+
+	ctOut = NewCiphertext(eval.params, 2, level, ctSigma.Scale*ctTau.Scale)
+
+	eval.MulAndAdd(ctSigma, ctTau, ctOut)
+	for k := 1; k < period; k++ {
+		eval.MulAndAdd(ctPhi[k-1], ctPsi[k*dim], ctOut)
+	}
+
+	ctOut = eval.RelinearizeNew(ctOut)
+
+	if err := eval.Rescale(ctOut, ctOut.Scale/eval.params.QiFloat64(level), ctOut); err != nil {
+		panic(err)
+	}
+
+	// Sum of the partial products computed on the vertical repetitions of A
+	if period < dim {
+		eval.InnerSumLog(ctOut, period*dim, dim/period, ctOut)
+	}
+
+	return
+}
+
+// permutationDiagonals returns the non-zero diagonals, on 2^logSlots slots, of the linear transformation that maps
+// the dim x dim matrix packed in row-major order and replicated in the slots to M[i][j] = M[perm(i, j)].
+func permutationDiagonals(dim, logSlots int, perm func(i, j int) (int, int)) (diags map[int][]complex128) {
+
+	slots := 1 << logSlots
+	size := dim * dim
+
+	diags = make(map[int][]complex128)
+
+	for i := 0; i < dim; i++ {
+		for j := 0; j < dim; j++ {
+
+			pi, pj := perm(i, j)
+
+			x := i*dim + j
+			rot := (pi*dim + pj - x + size) % size
+
+			if _, ok := diags[rot]; !ok {
+				diags[rot] = make([]complex128, slots)
+			}
+
+			for k := x; k < slots; k += size {
+				diags[rot][k] = 1
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,181 @@
+package ckks
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// SlotsPacking is a type for the packing of several ciphertexts with 2^LogSlotsIn slots into one ciphertext with
+// 2^LogSlotsOut slots, and for the unpacking of the latter. It can be evaluated by using the Evaluator.Pack and
+// Evaluator.Unpack methods.
+//
+// A ciphertext with 2^LogSlotsIn slots encrypts a polynomial m(Y) in Y = X^(N/2^(LogSlotsIn+1)) = Z^k, where
+// Z = X^(N/2^(LogSlotsOut+1)) and k = 2^(LogSlotsOut-LogSlotsIn). Pack maps the inputs m_0, ..., m_{k-1} to
+// sum_j Z^j * m_j(Z^k), which interleaves their coefficients, with monomial multiplications only. Conversely,
+// the j-th output of Unpack is Trace(Z^-j * m), where the Trace sums the automorphisms that fix Y and removes
+// the other powers of Z. Both do not consume any level and do not change the scale.
+//
+// The packed ciphertext has 2^LogSlotsOut slots, which are an invertible linear map, and not the concatenation,
+// of the slots of the inputs. It is thus meant to store or to add the inputs, and to be unpacked before any
+// slot-wise multiplication.
+type SlotsPacking struct {
+	LogSlotsIn  int // LogSlotsIn is the log2 of the number of slots of the unpacked ciphertexts
+	LogSlotsOut int // LogSlotsOut is the log2 of the number of slots of the packed ciphertext
+
+	// xPow[j] and xInvPow[j] are Z^j and Z^-j in the NTT and Montgomery domain of the ring of degree
+	// 2^(LogSlotsOut+1), which are mapped to the ring of degree N with ring.MapSmallDimensionToLargerDimensionNTT.
+	xPow    []*ring.Poly
+	xInvPow []*ring.Poly
+}
+
+// GenSlotsPacking generates a new SlotsPacking between ciphertexts with 2^logSlotsIn and 2^logSlotsOut slots.
+// The method will panic if the ring type of the parameters is not ring.Standard or if
+// 0 <= logSlotsIn < logSlotsOut <= MaxLogSlots does not hold.
+func GenSlotsPacking(params Parameters, logSlotsIn, logSlotsOut int) (sp SlotsPacking) {
+
+	if params.RingType() != ring.Standard {
+		panic("cannot GenSlotsPacking: ring type must be ring.Standard")
+	}
+
+	if logSlotsIn < 0 || logSlotsIn >= logSlotsOut || logSlotsOut > params.MaxLogSlots() {
+		panic(fmt.Sprintf("cannot GenSlotsPacking: invalid logSlotsIn=%d and logSlotsOut=%d", logSlotsIn, logSlotsOut))
+	}
+
+	sp = SlotsPacking{LogSlotsIn: logSlotsIn, LogSlotsOut: logSlotsOut}
+
+	gap := params.N() >> (logSlotsOut + 1)
+
+	sp.xPow = make([]*ring.Poly, 1<<(logSlotsOut-logSlotsIn))
+	sp.xInvPow = make([]*ring.Poly, len(sp.xPow))
+
+	for j := range sp.xPow {
+		sp.xPow[j] = genMonomialNTT(params.RingQ(), j*gap, gap)
+		sp.xInvPow[j] = genMonomialNTT(params.RingQ(), -j*gap, gap)
+	}
+
+	return
+}
+
+// genMonomialNTT returns X^e, for -N < e < N, in the NTT and Montgomery domain. Since X^e is a polynomial in
+// X^gap, only one out of gap NTT coefficients is stored, which is a polynomial of degree N/gap.
+func genMonomialNTT(ringQ *ring.Ring, e, gap int) (pol *ring.Poly) {
+
+	monomial := ringQ.NewPoly()
+
+	for i, qi := range ringQ.Modulus {
+		one := ring.MForm(1, qi, ringQ.BredParams[i])
+		if e < 0 {
+			monomial.Coeffs[i][ringQ.N+e] = qi - one
+		} else {
+			monomial.Coeffs[i][e] = one
+		}
+	}
+
+	ringQ.NTT(monomial, monomial)
+
+	pol = ring.NewPoly(ringQ.N/gap, len(ringQ.Modulus))
+	for i := range ringQ.Modulus {
+		for w := range pol.Coeffs[i] {
+			pol.Coeffs[i][w] = monomial.Coeffs[i][w*gap]
+		}
+	}
+
+	return
+}
+
+// GaloisElements returns the list of Galois elements of the rotation keys needed for the evaluation of Unpack.
+// Pack does not need any rotation key.
+func (sp *SlotsPacking) GaloisElements(params Parameters) (galEls []uint64) {
+	rotations := params.RotationsForTrace(sp.LogSlotsIn, sp.LogSlotsOut)
+	galEls = make([]uint64, len(rotations))
+	for i, k := range rotations {
+		galEls[i] = params.GaloisElementForColumnRotationBy(k)
+	}
+	return
+}
+
+// mulByMonomial multiplies ctIn by the monomial mono, given in the ring of smaller degree, and adds the result
+// on ctOut if add is true, or writes it on ctOut otherwise.
+func (eval *evaluator) mulByMonomial(level int, ctIn *Ciphertext, mono *ring.Poly, add bool, ctOut *Ciphertext) {
+
+	ringQ := eval.params.RingQ()
+
+	buff := eval.poolQMul[0]
+	ring.MapSmallDimensionToLargerDimensionNTT(&ring.Poly{Coeffs: mono.Coeffs[:level+1]}, buff)
+
+	for i := range ctOut.Value {
+		if add {
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, ctIn.Value[i], buff, ctOut.Value[i])
+		} else {
+			ringQ.MulCoeffsMontgomeryLvl(level, ctIn.Value[i], buff, ctOut.Value[i])
+		}
+	}
+}
+
+// Pack packs the ciphertexts cts with 2^sp.LogSlotsIn slots into a new ciphertext with 2^sp.LogSlotsOut slots,
+// where cts[j] is multiplied by Z^j (see SlotsPacking). The missing inputs after len(cts) are zero.
+// The ciphertexts must be of degree one and have the same scale, and the output is at the smallest of their
+// levels.
+// Returns an error if cts is empty or has more than 2^(sp.LogSlotsOut-sp.LogSlotsIn) elements, or if the
+// degrees or the scales of the ciphertexts are not compatible.
+func (eval *evaluator) Pack(cts []*Ciphertext, sp SlotsPacking) (ctOut *Ciphertext, err error) {
+
+	if len(cts) == 0 || len(cts) > len(sp.xPow) {
+		return nil, fmt.Errorf("cannot Pack: the number of ciphertexts must be in [1, %d]", len(sp.xPow))
+	}
+
+	level := cts[0].Level()
+	for _, ct := range cts {
+		if ct.Degree() != 1 {
+			return nil, fmt.Errorf("cannot Pack: ciphertexts must be of degree 1")
+		}
+		if ct.Scale != cts[0].Scale {
+			return nil, fmt.Errorf("cannot Pack: ciphertexts must have the same scale")
+		}
+		level = utils.MinInt(level, ct.Level())
+	}
+
+	ctOut = NewCiphertext(eval.params, 1, level, cts[0].Scale)
+
+	// Z^0 = 1
+	ring.CopyValuesLvl(level, cts[0].Value[0], ctOut.Value[0])
+	ring.CopyValuesLvl(level, cts[0].Value[1], ctOut.Value[1])
+
+	for j := 1; j < len(cts); j++ {
+		eval.mulByMonomial(level, cts[j], sp.xPow[j], true, ctOut)
+	}
+
+	return
+}
+
+// Unpack unpacks the ciphertext ctIn with 2^sp.LogSlotsOut slots into 2^(sp.LogSlotsOut-sp.LogSlotsIn) new
+// ciphertexts with 2^sp.LogSlotsIn slots, the j-th of which is the j-th input of Pack (see SlotsPacking).
+// The outputs are at the level of ctIn. It requires the rotation keys for sp.GaloisElements(params).
+// Returns an error if ctIn is not of degree one.
+func (eval *evaluator) Unpack(ctIn *Ciphertext, sp SlotsPacking) (ctOut []*Ciphertext, err error) {
+
+	if ctIn.Degree() != 1 {
+		return nil, fmt.Errorf("cannot Unpack: ciphertext must be of degree 1")
+	}
+
+	level := ctIn.Level()
+
+	ctOut = make([]*Ciphertext, len(sp.xInvPow))
+	for j := range ctOut {
+
+		ctOut[j] = NewCiphertext(eval.params, 1, level, ctIn.Scale)
+
+		if j == 0 {
+			ctOut[j].Copy(ctIn)
+		} else {
+			eval.mulByMonomial(level, ctIn, sp.xInvPow[j], false, ctOut[j])
+		}
+
+		// The Trace also divides the sum of the automorphisms by their number
+		eval.Trace(ctOut[j], sp.LogSlotsIn, sp.LogSlotsOut, ctOut[j])
+	}
+
+	return
+}
@@ -0,0 +1,152 @@
+package ckks
+
+import (
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ParallelEvaluator is a pool of workers that evaluates batches of independent operations on ciphertexts across
+// goroutines. Each worker holds its own shallow copy of an Evaluator, and thus its own memory buffers, which are
+// reused from one operation to the next. The outputs are always in the order of the inputs.
+//
+// A panic raised by a worker is recovered and re-raised in the goroutine calling the ParallelEvaluator, once all the
+// other operations of the batch are completed, as a *utils.TaskPanic that holds the stack trace of the worker. Run
+// instead returns it as an error.
+//
+// A ParallelEvaluator must not be used concurrently by several goroutines.
+type ParallelEvaluator struct {
+	evaluators []Evaluator
+}
+
+// NewParallelEvaluator creates a new ParallelEvaluator with the given number of workers, each holding a shallow copy
+// of eval.
+func NewParallelEvaluator(eval Evaluator, workers int) *ParallelEvaluator {
+
+	if workers < 1 {
+		panic("cannot NewParallelEvaluator: workers must be at least 1")
+	}
+
+	evaluators := make([]Evaluator, workers)
+	for i := range evaluators {
+		evaluators[i] = eval.ShallowCopy()
+	}
+
+	return &ParallelEvaluator{evaluators: evaluators}
+}
+
+// Workers returns the number of workers of the ParallelEvaluator.
+func (p *ParallelEvaluator) Workers() int {
+	return len(p.evaluators)
+}
+
+// Run calls task(eval, i) for each i in [0, n) across the workers, where eval is the Evaluator of the worker running
+// the task. The tasks must be independent and must only use the Evaluator they are given.
+// Run returns an error if a task panicked, as utils.RunParallel does.
+func (p *ParallelEvaluator) Run(n int, task func(eval Evaluator, i int)) (err error) {
+	return utils.RunParallel(len(p.evaluators), n, func(worker, i int) {
+		task(p.evaluators[worker], i)
+	})
+}
+
+// run calls Run and panics with its error, if any, which holds the stack trace of the panicking task.
+func (p *ParallelEvaluator) run(n int, task func(eval Evaluator, i int)) {
+	if err := p.Run(n, task); err != nil {
+		panic(err)
+	}
+}
+
+// MulRelin computes ctOut[i] = op0[i] * op1[i] with relinearization for each i.
+func (p *ParallelEvaluator) MulRelin(op0, op1, ctOut []*Ciphertext) {
+
+	if len(op0) != len(op1) || len(op0) != len(ctOut) {
+		panic("cannot MulRelin: op0, op1 and ctOut must have the same length")
+	}
+
+	p.run(len(op0), func(eval Evaluator, i int) {
+		eval.MulRelin(op0[i], op1[i], ctOut[i])
+	})
+}
+
+// MulRelinNew returns op0[i] * op1[i] with relinearization for each i in new ciphertexts.
+func (p *ParallelEvaluator) MulRelinNew(op0, op1 []*Ciphertext) (ctOut []*Ciphertext) {
+
+	if len(op0) != len(op1) {
+		panic("cannot MulRelinNew: op0 and op1 must have the same length")
+	}
+
+	ctOut = make([]*Ciphertext, len(op0))
+	p.run(len(op0), func(eval Evaluator, i int) {
+		ctOut[i] = eval.MulRelinNew(op0[i], op1[i])
+	})
+
+	return
+}
+
+// Rotate rotates each ctIn[i] by k positions to the left and returns the result in ctOut[i].
+func (p *ParallelEvaluator) Rotate(ctIn []*Ciphertext, k int, ctOut []*Ciphertext) {
+
+	if len(ctIn) != len(ctOut) {
+		panic("cannot Rotate: ctIn and ctOut must have the same length")
+	}
+
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		eval.Rotate(ctIn[i], k, ctOut[i])
+	})
+}
+
+// RotateNew returns the rotations of each ctIn[i] by k positions to the left in new ciphertexts.
+func (p *ParallelEvaluator) RotateNew(ctIn []*Ciphertext, k int) (ctOut []*Ciphertext) {
+
+	ctOut = make([]*Ciphertext, len(ctIn))
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		ctOut[i] = eval.RotateNew(ctIn[i], k)
+	})
+
+	return
+}
+
+// Rescale rescales each ctIn[i] with Evaluator.Rescale and returns the result in ctOut[i].
+// It returns the error of the first rescaling that failed, in the order of the inputs.
+func (p *ParallelEvaluator) Rescale(ctIn []*Ciphertext, minScale float64, ctOut []*Ciphertext) (err error) {
+
+	if len(ctIn) != len(ctOut) {
+		panic("cannot Rescale: ctIn and ctOut must have the same length")
+	}
+
+	errs := make([]error, len(ctIn))
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		errs[i] = eval.Rescale(ctIn[i], minScale, ctOut[i])
+	})
+
+	for _, err = range errs {
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// LinearTransform evaluates the linear transform(s) on each ctIn[i] and returns the result in ctOut[i], as
+// Evaluator.LinearTransform does. linearTransform can either be a LinearTransform or a []LinearTransform.
+func (p *ParallelEvaluator) LinearTransform(ctIn []*Ciphertext, linearTransform interface{}, ctOut [][]*Ciphertext) {
+
+	if len(ctIn) != len(ctOut) {
+		panic("cannot LinearTransform: ctIn and ctOut must have the same length")
+	}
+
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		eval.LinearTransform(ctIn[i], linearTransform, ctOut[i])
+	})
+}
+
+// LinearTransformNew returns the evaluation of the linear transform(s) on each ctIn[i] in new ciphertexts, as
+// Evaluator.LinearTransformNew does. linearTransform can either be a LinearTransform or a []LinearTransform.
+func (p *ParallelEvaluator) LinearTransformNew(ctIn []*Ciphertext, linearTransform interface{}) (ctOut [][]*Ciphertext) {
+
+	ctOut = make([][]*Ciphertext, len(ctIn))
+	p.run(len(ctIn), func(eval Evaluator, i int) {
+		ctOut[i] = eval.LinearTransformNew(ctIn[i], linearTransform)
+	})
+
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
@@ -310,10 +311,14 @@ type ParametersLiteral struct {
 	P            []uint64
 	LogQ         []int   `json:",omitempty"`
 	LogP         []int   `json:",omitempty"`
+	Pow2Base     int     `json:",omitempty"` // Base-2 logarithm of the key-switching digit decomposition (0 to disable)
+	DNum         int     `json:",omitempty"` // Number of elements of the key-switching RNS decomposition (0 for one element per #P moduli Q)
 	Sigma        float64 // Gaussian sampling variance
 	LogSlots     int
 	DefaultScale float64
 	RingType     ring.Type
+	Lambda       int `json:",omitempty"` // Required classical security in bits (0 to disable the check, see rlwe.Parameters.CheckSecurity)
+	H            int `json:",omitempty"` // Hamming weight of the secret for the security check (0 for a uniform ternary secret)
 }
 
 // DefaultParams is a set of default CKKS parameters ensuring 128 bit security in a classic setting.
@@ -353,7 +358,7 @@ func NewParameters(rlweParams rlwe.Parameters, logSlot int, defaultScale float64
 // NewParametersFromLiteral instantiate a set of CKKS parameters from a ParametersLiteral specification.
 // It returns the empty parameters Parameters{} and a non-nil error if the specified parameters are invalid.
 func NewParametersFromLiteral(pl ParametersLiteral) (Parameters, error) {
-	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, Sigma: pl.Sigma, RingType: pl.RingType})
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{LogN: pl.LogN, Q: pl.Q, P: pl.P, LogQ: pl.LogQ, LogP: pl.LogP, Pow2Base: pl.Pow2Base, DNum: pl.DNum, Sigma: pl.Sigma, Lambda: pl.Lambda, H: pl.H, RingType: pl.RingType})
 	if err != nil {
 		return Parameters{}, err
 	}
@@ -382,6 +387,49 @@ func (p Parameters) StandardParameters() (pckks Parameters, err error) {
 	return
 }
 
+// SmallDimensionParameters returns the parameters with ring degree 2^logN, the first maxLevel+1 moduli Q and the
+// moduli P of the receiver, which can be used with the receiver by a DimensionSwitcher to evaluate the tail of a
+// circuit in a smaller ring. The number of slots is the minimum of the one of the receiver and 2^(logN-1), and
+// the default scale is the one of the receiver.
+// The method returns an error if the receiver is not of the standard ring type, if logN is not in
+// [MinLogN, LogN) or if maxLevel is not in [0, MaxLevel]. Since the ring degree is reduced, the security of the
+// returned parameters must be checked by the caller.
+func (p Parameters) SmallDimensionParameters(logN, maxLevel int) (pSmall Parameters, err error) {
+
+	if p.RingType() != ring.Standard {
+		return Parameters{}, fmt.Errorf("cannot SmallDimensionParameters: ring type must be ring.Standard")
+	}
+
+	if logN < rlwe.MinLogN || logN >= p.LogN() {
+		return Parameters{}, fmt.Errorf("cannot SmallDimensionParameters: logN must be in [%d, %d)", rlwe.MinLogN, p.LogN())
+	}
+
+	if maxLevel < 0 || maxLevel > p.MaxLevel() {
+		return Parameters{}, fmt.Errorf("cannot SmallDimensionParameters: maxLevel must be in [0, %d]", p.MaxLevel())
+	}
+
+	// The elements of the key-switching decomposition keep at most Alpha moduli Q
+	var dnum int
+	if p.DNum() != 0 {
+		dnum = (maxLevel + p.Alpha()) / p.Alpha()
+	}
+
+	rlweParams, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{
+		LogN:     logN,
+		Q:        p.Q()[:maxLevel+1],
+		P:        p.P(),
+		Pow2Base: p.Pow2Base(),
+		DNum:     dnum,
+		Sigma:    p.Sigma(),
+		RingType: ring.Standard,
+	})
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	return NewParameters(rlweParams, utils.MinInt(p.LogSlots(), logN-1), p.DefaultScale())
+}
+
 // LogSlots returns the log of the number of slots
 func (p Parameters) LogSlots() int {
 	return p.logSlots
@@ -508,17 +556,62 @@ func (p Parameters) RotationsForTrace(logSlotsStart, logSlotsEnd int) (rotations
 // with the provided list of non-zero diagonals, logSlots encoding and BSGSratio.
 // If BSGSratio == 0, then provides the rotations needed for an evaluation without the BSGS approach.
 func (p Parameters) RotationsForLinearTransform(nonZeroDiags interface{}, logSlots int, BSGSratio float64) (rotations []int) {
-	slots := 1 << logSlots
 	if BSGSratio == 0 {
+		return p.RotationsForLinearTransformN1(nonZeroDiags, logSlots, 0)
+	}
+	return p.RotationsForLinearTransformN1(nonZeroDiags, logSlots, FindBestBSGSSplit(nonZeroDiags, 1<<logSlots, BSGSratio))
+}
+
+// RotationsForLinearTransformN1 generates the list of rotations needed for the evaluation of a linear transform
+// with the provided list of non-zero diagonals, logSlots encoding and number N1 of inner loops of the BSGS approach,
+// as stored in LinearTransform.N1. If N1 == 0, then provides the rotations needed for an evaluation without the BSGS approach.
+func (p Parameters) RotationsForLinearTransformN1(nonZeroDiags interface{}, logSlots, N1 int) (rotations []int) {
+	slots := 1 << logSlots
+	if N1 == 0 {
 		_, _, rotN2 := BsgsIndex(nonZeroDiags, slots, slots)
 		return rotN2
 	}
 
-	N1 := FindBestBSGSSplit(nonZeroDiags, slots, BSGSratio)
 	_, rotN1, rotN2 := BsgsIndex(nonZeroDiags, slots, N1)
 	return append(rotN1, rotN2...)
 }
 
+// RotationsForBitonicNetwork generates the rotations that will be performed by the `Evaluator.Sort` operation
+// on any BitonicNetwork of n values, that is, the rotations by +/- 2^i for 2^i < n.
+func (p Parameters) RotationsForBitonicNetwork(n int) (rotations []int) {
+	slots := p.Slots()
+	for d := 1; d < n; d <<= 1 {
+		rotations = append(rotations, d)
+		if slots-d != d {
+			rotations = append(rotations, slots-d)
+		}
+	}
+	sort.Ints(rotations)
+	return
+}
+
+// RotationsForArgmax generates the rotations that will be performed by the `Evaluator.Argmax` operation on a
+// top-1 BitonicNetwork of n values.
+func (p Parameters) RotationsForArgmax(n int) (rotations []int) {
+
+	rotIndex := make(map[int]bool)
+	for _, k := range p.RotationsForBitonicNetwork(n) {
+		rotIndex[k] = true
+	}
+
+	for _, k := range p.RotationsForReplicateLog(1, n) {
+		rotIndex[k] = true
+	}
+
+	for k := range rotIndex {
+		rotations = append(rotations, k)
+	}
+
+	sort.Ints(rotations)
+
+	return
+}
+
 // Equals compares two sets of parameters for equality.
 func (p Parameters) Equals(other Parameters) bool {
 	res := p.Parameters.Equals(other.Parameters)
@@ -556,8 +649,11 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary decodes a []byte into a parameter set struct
 func (p *Parameters) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 9 {
+		return fmt.Errorf("invalid ckks.Parameter serialization")
+	}
 	var rlweParams rlwe.Parameters
-	if err := rlweParams.UnmarshalBinary(data); err != nil {
+	if err := rlweParams.UnmarshalBinary(data[:len(data)-9]); err != nil {
 		return err
 	}
 	logSlots := int(data[len(data)-9])
@@ -573,7 +669,7 @@ func (p Parameters) MarshalBinarySize() int {
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), Sigma: p.Sigma(), LogSlots: p.logSlots, DefaultScale: p.defaultScale, RingType: p.RingType()})
+	return json.Marshal(ParametersLiteral{LogN: p.LogN(), Q: p.Q(), P: p.P(), Pow2Base: p.Pow2Base(), DNum: p.DNum(), Sigma: p.Sigma(), LogSlots: p.logSlots, DefaultScale: p.defaultScale, RingType: p.RingType()})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
@@ -0,0 +1,274 @@
+package ckks
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BitonicNetwork is a type for the sorting and the top-k selection of the first N slots of ciphertexts with a
+// bitonic network of compare-exchange stages, evaluated with the composite approximation of the sign function
+// of a SignPolynomial. It can be evaluated on a ciphertext by using the evaluator.Sort method.
+//
+// At each stage, each participating slot i is compared with the slot i XOR d for a power of two d, and
+// replaced by either the minimum or the maximum of both values. A stage evaluates the linear transformations
+// Diff (x[i XOR d] - x[i] on the participating slots and 0 elsewhere) and Cmp (Diff multiplied by +1 for
+// the slots taking the maximum and -1 for the slots taking the minimum), and then x + Diff * step(Cmp).
+// It consumes Sign.Depth() + 2 levels and does not change the scale of the ciphertext.
+//
+// Values whose difference is smaller than Sign.Epsilon are not guaranteed to be ordered, and are replaced by
+// values in between them.
+type BitonicNetwork struct {
+	N      int             // N is the number of sorted values, a power of two
+	K      int             // K is the number of values in the output: N for a sort, and less for a top-k selection
+	Level  int             // Level is the level at which the input ciphertext is expected
+	Sign   *SignPolynomial // Sign is the approximation of the sign function used by the comparisons
+	Stages []BitonicStage  // Stages are the compare-exchange stages of the network
+	mask   *Plaintext      // mask extracts the first slot after the stages of a top-1 network (see Argmax)
+}
+
+// BitonicStage is a compare-exchange stage of a BitonicNetwork.
+type BitonicStage struct {
+	Distance int             // Distance is the distance between the compared slots
+	Diff     LinearTransform // Diff maps x to x[i XOR Distance] - x[i] on the participating slots
+	Cmp      LinearTransform // Cmp maps x to +/- (x[i XOR Distance] - x[i]), whose sign selects the maximum
+}
+
+// GenBitonicSort allocates and encodes a new BitonicNetwork that sorts the first n slots of ciphertexts at the
+// given level in ascending or descending order. The other slots are left unchanged.
+// The network has log(n)(log(n)+1)/2 stages.
+// The method will panic if n is not a power of two in [2, slots] or if level < Depth().
+func GenBitonicSort(encoder Encoder, n, level int, sgn *SignPolynomial, descending bool) (bn BitonicNetwork) {
+	return genBitonicNetwork(encoder, n, n, level, sgn, descending)
+}
+
+// GenBitonicTopK allocates and encodes a new BitonicNetwork that stores the k largest values of the first n
+// slots of ciphertexts at the given level, in descending order, in their first k slots. The values of the slots
+// k to n-1 are not specified and the other slots are left unchanged.
+// The network sorts blocks of k values, and then repeatedly keeps the largest values of pairs of blocks, for
+// a total of log(k)(log(k)+1)/2 + log(n/k)(log(k)+1) stages. A top-1 network can also be used by Argmax.
+// The method will panic if k and n are not powers of two with 1 <= k <= n <= slots or if level < Depth().
+func GenBitonicTopK(encoder Encoder, n, k, level int, sgn *SignPolynomial) (bn BitonicNetwork) {
+	return genBitonicNetwork(encoder, n, k, level, sgn, true)
+}
+
+func genBitonicNetwork(encoder Encoder, n, k, level int, sgn *SignPolynomial, descending bool) (bn BitonicNetwork) {
+
+	enc, ok := encoder.(*encoderComplex128)
+	if !ok {
+		panic("encoder should be an encoderComplex128")
+	}
+
+	params := enc.params
+
+	if n < 2 || n&(n-1) != 0 || n > params.Slots() {
+		panic("cannot GenBitonicNetwork: n must be a power of two in [2, slots]")
+	}
+
+	if k < 1 || k&(k-1) != 0 || k > n {
+		panic("cannot GenBitonicNetwork: k must be a power of two in [1, n]")
+	}
+
+	bn = BitonicNetwork{N: n, K: k, Level: level, Sign: sgn}
+
+	// Each stage is given by the distance between the compared slots, and for each slot, 0 if it does not
+	// participate, +1 if it takes the maximum and -1 if it takes the minimum.
+	type stage struct {
+		distance int
+		sigma    func(i int) int
+	}
+
+	var stages []stage
+
+	// Compare-exchange of the pairs of slots at the given distance. The lower slot takes the minimum if the
+	// block is sorted in ascending order, and the maximum otherwise.
+	compareExchange := func(distance int, ascending, participates func(i int) bool) stage {
+		return stage{distance, func(i int) int {
+			if !participates(i) {
+				return 0
+			}
+			if (i&distance == 0) == ascending(i) {
+				return -1
+			}
+			return 1
+		}}
+	}
+
+	all := func(i int) bool { return true }
+
+	// Bitonic sort of the blocks of size k, in alternating orders. The first block is sorted in ascending order
+	// if and only if the network is a sort in ascending order.
+	for m := 2; m <= k; m <<= 1 {
+		m := m
+		for d := m >> 1; d > 0; d >>= 1 {
+			stages = append(stages, compareExchange(d, func(i int) bool { return (i&m == 0) != descending }, all))
+		}
+	}
+
+	// The blocks b*s/k for b = 0, 1, ... are live in the round of stride s. The lower block of each pair of live
+	// blocks keeps the slot-wise maximum of both, which is a bitonic sequence of its k largest values, and is
+	// then sorted in the opposite order of its neighbor live block of the next round.
+	for s := k; s < n; s <<= 1 {
+		s := s
+		stages = append(stages, compareExchange(s, func(i int) bool { return false }, func(i int) bool { return i&(s-1) < k }))
+		for d := k >> 1; d > 0; d >>= 1 {
+			stages = append(stages, compareExchange(d, func(i int) bool { return i&(s<<1) != 0 }, func(i int) bool { return i&((s<<1)-1) < k }))
+		}
+	}
+
+	if level < len(stages)*(sgn.Depth()+2) {
+		panic(fmt.Sprintf("cannot GenBitonicNetwork: level %d < %d levels required by the network", level, len(stages)*(sgn.Depth()+2)))
+	}
+
+	logSlots := params.LogSlots()
+	slots := params.Slots()
+
+	bn.Stages = make([]BitonicStage, len(stages))
+	for j, st := range stages {
+
+		d := st.distance
+
+		// The diagonals d and -d are the same if d = slots/2.
+		diff, cmp := map[int][]float64{}, map[int][]float64{}
+		for _, k := range []int{0, d, (slots - d) & (slots - 1)} {
+			diff[k], cmp[k] = make([]float64, slots), make([]float64, slots)
+		}
+
+		for i := 0; i < n; i++ {
+			if sigma := st.sigma(i); sigma != 0 {
+
+				partner := d
+				if i&d != 0 {
+					partner = slots - d
+				}
+
+				diff[partner][i], diff[0][i] = 1, -1
+				cmp[partner][i], cmp[0][i] = float64(sigma), -float64(sigma)
+			}
+		}
+
+		// The plaintext matrices are encoded at the scale of the modulus consumed by the following rescaling,
+		// so that the transformations do not change the scale of the ciphertexts.
+		lvl := level - j*(sgn.Depth()+2)
+
+		bn.Stages[j] = BitonicStage{
+			Distance: d,
+			Diff:     GenLinearTransform(encoder, diff, lvl, params.QiFloat64(lvl), logSlots),
+			Cmp:      GenLinearTransform(encoder, cmp, lvl, params.QiFloat64(lvl), logSlots),
+		}
+	}
+
+	if k == 1 {
+		lvl := level - bn.Depth()
+		mask := make([]float64, slots)
+		mask[0] = 1
+		bn.mask = encoder.EncodeNew(mask, lvl, params.QiFloat64(lvl), logSlots)
+	}
+
+	return
+}
+
+// Depth returns the number of levels consumed by Sort.
+func (bn *BitonicNetwork) Depth() int {
+	return len(bn.Stages) * (bn.Sign.Depth() + 2)
+}
+
+// ArgmaxDepth returns the number of levels consumed by Argmax.
+func (bn *BitonicNetwork) ArgmaxDepth() int {
+	return bn.Depth() + 1 + bn.Sign.Depth()
+}
+
+// Rotations returns the list of rotations needed for the evaluation of the network with Sort.
+// It is equal to Parameters.RotationsForBitonicNetwork(N).
+func (bn *BitonicNetwork) Rotations() (rotations []int) {
+
+	rotIndex := make(map[int]bool)
+	for _, st := range bn.Stages {
+		for _, LT := range []LinearTransform{st.Diff, st.Cmp} {
+			for _, k := range LT.Rotations() {
+				if k != 0 {
+					rotIndex[k] = true
+				}
+			}
+		}
+	}
+
+	for k := range rotIndex {
+		rotations = append(rotations, k)
+	}
+
+	sort.Ints(rotations)
+
+	return
+}
+
+// Sort evaluates the BitonicNetwork on ctIn and returns the result on a new ciphertext with the scale of ctIn,
+// that is, either the first bn.N slots of ctIn sorted, or the bn.K largest of them in descending order.
+// The first bn.N slots of ctIn must be real and in an interval of length 1. ctIn must be at least at level
+// bn.Level, and the output ciphertext is at level bn.Level - bn.Depth().
+// The rotation keys for bn.Rotations() and the relinearization key must be provided to the evaluator.
+// Returns an error if ctIn does not have enough levels.
+func (eval *evaluator) Sort(ctIn *Ciphertext, bn BitonicNetwork) (ctOut *Ciphertext, err error) {
+
+	if ctIn.Level() < bn.Level {
+		return nil, fmt.Errorf("cannot Sort: input ciphertext level %d < %d", ctIn.Level(), bn.Level)
+	}
+
+	ctOut = eval.DropLevelNew(ctIn, ctIn.Level()-bn.Level)
+
+	for _, st := range bn.Stages {
+
+		cts := eval.LinearTransformNew(ctOut, []LinearTransform{st.Diff, st.Cmp})
+
+		for _, ct := range cts {
+			if err = eval.Rescale(ct, ctOut.Scale, ct); err != nil {
+				return nil, err
+			}
+		}
+
+		var delta *Ciphertext
+		if delta, err = eval.mulByStepOf(cts[0], cts[1], bn.Sign, ctOut.Scale); err != nil {
+			return nil, err
+		}
+
+		eval.DropLevel(ctOut, ctOut.Level()-delta.Level())
+		eval.Add(ctOut, delta, ctOut)
+	}
+
+	return
+}
+
+// Argmax evaluates the top-1 BitonicNetwork bn on ctIn and returns a new ciphertext whose first bn.N slots are
+// 1 for the slots of ctIn equal to their maximum and 0 for the others, with the scale of ctIn.
+// The values of all the slots of ctIn must be real and in an interval of length 1 - bn.Sign.Epsilon, and two
+// distinct values among the first bn.N slots must differ by at least 2 * bn.Sign.Epsilon.
+// The other slots of the output ciphertext are not specified. The output ciphertext is at level
+// bn.Level - bn.ArgmaxDepth().
+// The rotation keys for Parameters.RotationsForArgmax(bn.N) and the relinearization key must be provided to
+// the evaluator.
+// Returns an error if bn is not a top-1 network or if ctIn does not have enough levels.
+func (eval *evaluator) Argmax(ctIn *Ciphertext, bn BitonicNetwork) (ctOut *Ciphertext, err error) {
+
+	if bn.K != 1 {
+		return nil, fmt.Errorf("cannot Argmax: network must be a top-1 network")
+	}
+
+	var max *Ciphertext
+	if max, err = eval.Sort(ctIn, bn); err != nil {
+		return nil, err
+	}
+
+	// Replicates the maximum in the first bn.N slots
+	eval.MulRelin(max, bn.mask, max)
+	if err = eval.Rescale(max, ctIn.Scale, max); err != nil {
+		return nil, err
+	}
+
+	eval.ReplicateLog(max, 1, bn.N, max)
+
+	// step(x - max + epsilon) is 1 if x = max and 0 if x <= max - 2 * epsilon
+	ctOut = eval.DropLevelNew(ctIn, ctIn.Level()-max.Level())
+	eval.Sub(ctOut, max, ctOut)
+	eval.AddConst(ctOut, bn.Sign.Epsilon, ctOut)
+
+	return eval.Step(ctOut, bn.Sign)
+}
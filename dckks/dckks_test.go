@@ -1,6 +1,7 @@
 package dckks
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -766,6 +767,18 @@ func testMarshalling(testCtx *testContext, t *testing.T) {
 			}
 
 		}
+
+		buff := new(bytes.Buffer)
+		n, err := refreshshare.WriteTo(buff)
+		require.NoError(t, err)
+		require.Equal(t, int64(buff.Len()), n)
+
+		resRefreshShare = new(MaskedTransformShare)
+		m, err := resRefreshShare.ReadFrom(buff)
+		require.NoError(t, err)
+		require.Equal(t, n, m)
+		require.True(t, refreshshare.e2sShare.Value.Equals(resRefreshShare.e2sShare.Value))
+		require.True(t, refreshshare.s2eShare.Value.Equals(resRefreshShare.s2eShare.Value))
 	})
 }
 
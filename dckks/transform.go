@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"encoding/binary"
+	"io"
 
 	"github.com/ldsec/lattigo/v2/ckks"
 	"github.com/ldsec/lattigo/v2/drlwe"
@@ -65,6 +66,47 @@ func (share *MaskedTransformShare) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// maskedTransformShareTag is the tag of the header written by MaskedTransformShare.WriteTo.
+const maskedTransformShareTag = "MTSH"
+
+// WriteTo writes the target share on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (share *MaskedTransformShare) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, maskedTransformShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for _, s := range []*drlwe.CKSShare{&share.e2sShare, &share.s2eShare} {
+		inc, err = s.WriteTo(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a share written by WriteTo from r on the target share.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (share *MaskedTransformShare) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, maskedTransformShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for _, s := range []*drlwe.CKSShare{&share.e2sShare, &share.s2eShare} {
+		inc, err = s.ReadFrom(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 // NewMaskedTransformProtocol creates a new instance of the PermuteProtocol.
 func NewMaskedTransformProtocol(params ckks.Parameters, precision int, sigmaSmudging float64) (rfp *MaskedTransformProtocol) {
 
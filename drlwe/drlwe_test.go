@@ -1,9 +1,11 @@
 package drlwe
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"math/bits"
@@ -19,17 +21,18 @@ import (
 var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
 
 func testString(params rlwe.Parameters, opname string) string {
-	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d",
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d/Pw2=%d",
 		opname,
 		params.LogN(),
 		params.LogQ(),
 		params.LogP(),
 		params.QCount(),
-		params.PCount())
+		params.PCount(),
+		params.Pow2Base())
 }
 
 // TestParams is a set of test parameters for the correctness of the rlwe pacakge.
-var TestParams = []rlwe.ParametersLiteral{rlwe.TestPN12QP109, rlwe.TestPN13QP218, rlwe.TestPN14QP438, rlwe.TestPN15QP880, rlwe.TestPN16QP240, rlwe.TestPN17QP360}
+var TestParams = []rlwe.ParametersLiteral{rlwe.TestPN12QP109, rlwe.TestPN12QP109Pw2, rlwe.TestPN13QP218, rlwe.TestPN14QP438, rlwe.TestPN15QP880, rlwe.TestPN16QP240, rlwe.TestPN17QP360}
 
 type testContext struct {
 	params                 rlwe.Parameters
@@ -59,7 +62,7 @@ func newTestContext(params rlwe.Parameters) testContext {
 func TestDRLWE(t *testing.T) {
 	defaultParams := TestParams // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
 	if testing.Short() {
-		defaultParams = TestParams[:2] // the short test suite runs for ring degree N=2^12, 2^13
+		defaultParams = TestParams[:3] // the short test suite runs for ring degree N=2^12 (with and without the base-2^16 digit decomposition) and N=2^13
 	}
 
 	if *flagParamString != "" {
@@ -82,6 +85,7 @@ func TestDRLWE(t *testing.T) {
 			testPublicKeySwitching,
 			testRelinKeyGen,
 			testRotKeyGen,
+			testThreshold,
 			testMarshalling,
 		} {
 			testSet(textCtx, t)
@@ -126,6 +130,39 @@ func testPublicKeyGen(testCtx testContext, t *testing.T) {
 		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].Q.Level(), ringQ, pk.Value[0].Q))
 		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(pk.Value[0].P.Level(), ringP, pk.Value[0].P))
 	})
+
+	t.Run(testString(params, "PublicKeyGen/Seeded"), func(t *testing.T) {
+
+		ckg := NewCKGProtocol(params)
+
+		share0 := ckg.AllocateShares()
+		share1 := ckg.AllocateShares()
+
+		seed := []byte{'l', 'a', 't', 't', 'i', 'g', 'o', 'c', 'k', 'g'}
+		crs, err := utils.NewKeyedPRNG(seed)
+		require.NoError(t, err)
+
+		crp := ckg.SampleCRP(crs)
+
+		ckg.GenShare(testCtx.sk0, crp, share0)
+		ckg.GenShare(testCtx.sk1, crp, share1)
+		ckg.AggregateShares(share0, share1, share0)
+
+		pk := rlwe.NewPublicKey(params)
+		ckg.GenPublicKey(share0, crp, pk)
+
+		// The seeded public key expands to the same key
+		spk := rlwe.NewSeededPublicKey(params)
+		ckg.GenSeededPublicKey(share0, seed, spk)
+
+		data, err := spk.MarshalBinary()
+		require.NoError(t, err)
+
+		spkTest := new(rlwe.SeededPublicKey)
+		require.NoError(t, spkTest.UnmarshalBinary(data))
+		require.True(t, spk.Equals(spkTest))
+		require.True(t, pk.Equals(spkTest.ExpandNew(params)))
+	})
 }
 
 func testKeySwitching(testCtx testContext, t *testing.T) {
@@ -271,10 +308,10 @@ func testRelinKeyGen(testCtx testContext, t *testing.T) {
 
 		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
 		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, poly, swk.Value[j][0], poly)
-			}
+		// Only the lowest base-2^Pow2Base digit of each element of the RNS basis is summed.
+		decompPw2 := params.DecompPw2(levelQ, levelP)
+		for j := decompPw2; j < len(swk.Value); j += decompPw2 {
+			ringQP.AddLvl(levelQ, levelP, poly, swk.Value[j][0], poly)
 		}
 
 		// sOut * P
@@ -316,7 +353,11 @@ func testRotKeyGen(testCtx testContext, t *testing.T) {
 		share1 := rtg.AllocateShares()
 		share2 := rtg.AllocateShares()
 
-		crp := rtg.SampleCRP(testCtx.crs)
+		seed := []byte{'l', 'a', 't', 't', 'i', 'g', 'o', 'r', 't', 'g'}
+		crs, err := utils.NewKeyedPRNG(seed)
+		require.NoError(t, err)
+
+		crp := rtg.SampleCRP(crs)
 
 		galEl := params.GaloisElementForRowRotation()
 
@@ -330,6 +371,11 @@ func testRotKeyGen(testCtx testContext, t *testing.T) {
 		rotKeySet := rlwe.NewRotationKeySet(params, []uint64{galEl})
 		rtg.GenRotationKey(share0, crp, rotKeySet.Keys[galEl])
 
+		// The seeded rotation key expands to the same key
+		seededRotKey := rlwe.NewSeededSwitchingKey(params, levelQ, levelP)
+		rtg.GenSeededRotationKey(share0, seed, seededRotKey)
+		require.True(t, rotKeySet.Keys[galEl].Equals(seededRotKey.ExpandNew(params)))
+
 		skIn := testCtx.skIdeal.CopyNew()
 		skOut := testCtx.skIdeal.CopyNew()
 		galElInv := ring.ModExp(galEl, uint64(2*params.N()-1), uint64(2*params.N()))
@@ -347,10 +393,10 @@ func testRotKeyGen(testCtx testContext, t *testing.T) {
 
 		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
 		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
-			}
+		// Only the lowest base-2^Pow2Base digit of each element of the RNS basis is summed.
+		decompPw2 := params.DecompPw2(levelQ, levelP)
+		for j := decompPw2; j < len(swk.Value); j += decompPw2 {
+			ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
 		}
 
 		// sOut * P
@@ -372,6 +418,122 @@ func testRotKeyGen(testCtx testContext, t *testing.T) {
 	})
 }
 
+func testThreshold(testCtx testContext, t *testing.T) {
+
+	params := testCtx.params
+	ringQ := params.RingQ()
+	levelQ, levelP := params.QCount()-1, params.PCount()-1
+
+	for _, threshold := range []int{2, 3} {
+
+		t.Run(testString(params, fmt.Sprintf("Threshold/t=%d", threshold)), func(t *testing.T) {
+
+			type Party struct {
+				*Thresholdizer
+				*Combiner
+				gen  *ShamirPolynomial
+				sk   *rlwe.SecretKey
+				tsk  *ShamirSecretShare
+				tsks []*ShamirSecretShare
+				spk  ShamirPublicPoint
+			}
+
+			nParties := 3
+			sks := []*rlwe.SecretKey{testCtx.sk0, testCtx.sk1, testCtx.sk2}
+
+			spks := make([]ShamirPublicPoint, nParties)
+			for i := range spks {
+				spks[i] = ShamirPublicPoint(i + 1)
+			}
+
+			P := make([]*Party, nParties)
+			for i := range P {
+				p := new(Party)
+				p.Thresholdizer = NewThresholdizer(params)
+				p.sk = sks[i]
+				p.spk = spks[i]
+				p.tsk = p.AllocateThresholdSecretShare()
+
+				others := make([]ShamirPublicPoint, 0, nParties-1)
+				for _, spk := range spks {
+					if spk != p.spk {
+						others = append(others, spk)
+					}
+				}
+				p.Combiner = NewCombiner(params, p.spk, others, threshold)
+
+				var err error
+				p.gen, err = p.GenShamirPolynomial(threshold, p.sk)
+				require.NoError(t, err)
+
+				p.tsks = make([]*ShamirSecretShare, nParties)
+				for j := range p.tsks {
+					p.tsks[j] = p.AllocateThresholdSecretShare()
+				}
+				P[i] = p
+			}
+
+			// Each party generates a share for every other party and aggregates the shares it receives
+			for _, pi := range P {
+				for _, pj := range P {
+					pi.GenShamirSecretShare(pj.spk, pi.gen, pj.tsks[pi.spk-1])
+				}
+			}
+			for _, pi := range P {
+				for _, share := range pi.tsks {
+					pi.Thresholdizer.AggregateShares(pi.tsk, share, pi.tsk)
+				}
+			}
+
+			// Every subset of threshold active parties recovers an additive sharing of the ideal secret
+			actives := P[nParties-threshold:]
+			activePoints := make([]ShamirPublicPoint, len(actives))
+			for i, p := range actives {
+				activePoints[i] = p.spk
+			}
+
+			skRec := rlwe.NewSecretKey(params)
+			skTmp := rlwe.NewSecretKey(params)
+			for _, p := range actives {
+				p.GenAdditiveShare(activePoints, p.spk, p.tsk, skTmp)
+				params.RingQP().AddLvl(levelQ, levelP, skRec.Value, skTmp.Value, skRec.Value)
+			}
+			require.True(t, testCtx.skIdeal.Value.Equals(skRec.Value))
+
+			// A point listed twice among the active points would be counted twice in the Lagrange coefficients
+			for _, dup := range activePoints {
+				require.Panics(t, func() {
+					actives[0].GenAdditiveShare(append(activePoints, dup), actives[0].spk, actives[0].tsk, skTmp)
+				})
+			}
+
+			// The additive shares can be used in the regular N-out-of-N protocols
+			ciphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+			testCtx.uniformSampler.Read(ciphertext.Value[1])
+			ringQ.MulCoeffsMontgomeryAndSub(ciphertext.Value[1], testCtx.skIdeal.Value.Q, ciphertext.Value[0])
+			ciphertext.Value[0].IsNTT = true
+			ciphertext.Value[1].IsNTT = true
+
+			cks := NewCKSProtocol(params, rlwe.DefaultSigma)
+			zero := rlwe.NewSecretKey(params)
+			shareAgg := cks.AllocateShare(ciphertext.Level())
+			share := cks.AllocateShare(ciphertext.Level())
+			for _, p := range actives {
+				p.GenAdditiveShare(activePoints, p.spk, p.tsk, skTmp)
+				cks.GenShare(skTmp, zero, ciphertext, share)
+				cks.AggregateShares(shareAgg, share, shareAgg)
+			}
+
+			ksCiphertext := &rlwe.Ciphertext{Value: []*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}}
+			cks.KeySwitch(shareAgg, ciphertext, ksCiphertext)
+
+			ringQ.InvNTT(ksCiphertext.Value[0], ksCiphertext.Value[0])
+			log2Bound := bits.Len64(uint64(threshold) * uint64(math.Floor(rlwe.DefaultSigma*6)) * uint64(params.N()))
+			require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(ksCiphertext.Value[0].Level(), ringQ, ksCiphertext.Value[0]))
+		})
+	}
+}
+
 func testMarshalling(testCtx testContext, t *testing.T) {
 
 	params := testCtx.params
@@ -522,6 +684,97 @@ func testMarshalling(testCtx testContext, t *testing.T) {
 			require.Equal(t, resRTGShare.Value[i].P.Coeffs, val.P.Coeffs)
 		}
 	})
+
+	t.Run(testString(params, "Marshalling/Threshold"), func(t *testing.T) {
+
+		thr := NewThresholdizer(testCtx.params)
+		gen, err := thr.GenShamirPolynomial(2, testCtx.sk0)
+		require.NoError(t, err)
+
+		share := thr.AllocateThresholdSecretShare()
+		thr.GenShamirSecretShare(1, gen, share)
+
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+
+		resShare := new(ShamirSecretShare)
+		err = resShare.UnmarshalBinary(data)
+		require.NoError(t, err)
+
+		require.True(t, share.Equals(resShare.PolyQP))
+	})
+
+	t.Run(testString(params, "Streaming/Shares"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		// streamThrough writes shareIn on a buffer, reads it back on shareOut and
+		// checks that both ends report the same number of bytes.
+		streamThrough := func(shareIn io.WriterTo, shareOut io.ReaderFrom) {
+			buff := new(bytes.Buffer)
+			n, err := shareIn.WriteTo(buff)
+			require.NoError(t, err)
+			require.Equal(t, int64(buff.Len()), n)
+			m, err := shareOut.ReadFrom(buff)
+			require.NoError(t, err)
+			require.Equal(t, n, m)
+		}
+
+		ckg := NewCKGProtocol(params)
+		ckgShare := ckg.AllocateShares()
+		ckg.GenShare(testCtx.sk0, ckg.SampleCRP(testCtx.crs), ckgShare)
+		ckgShareTest := new(CKGShare)
+		streamThrough(ckgShare, ckgShareTest)
+		require.True(t, ckgShare.Value.Equals(ckgShareTest.Value))
+
+		rkg := NewRKGProtocol(params, rlwe.DefaultSigma)
+		ephSk, rkgShare, _ := rkg.AllocateShares()
+		rkg.GenShareRoundOne(testCtx.sk0, rkg.SampleCRP(testCtx.crs), ephSk, rkgShare)
+		rkgShareTest := new(RKGShare)
+		streamThrough(rkgShare, rkgShareTest)
+		require.Equal(t, len(rkgShare.Value), len(rkgShareTest.Value))
+		for i := range rkgShare.Value {
+			require.True(t, rkgShare.Value[i][0].Equals(rkgShareTest.Value[i][0]))
+			require.True(t, rkgShare.Value[i][1].Equals(rkgShareTest.Value[i][1]))
+		}
+
+		rtg := NewRTGProtocol(params)
+		rtgShare := rtg.AllocateShares()
+		rtg.GenShare(testCtx.sk1, params.GaloisElementForColumnRotationBy(64), rtg.SampleCRP(testCtx.crs), rtgShare)
+		rtgShareTest := new(RTGShare)
+		streamThrough(rtgShare, rtgShareTest)
+		require.Equal(t, len(rtgShare.Value), len(rtgShareTest.Value))
+		for i := range rtgShare.Value {
+			require.True(t, rtgShare.Value[i].Equals(rtgShareTest.Value[i]))
+		}
+
+		pcks := NewPCKSProtocol(params, params.Sigma())
+		pcksShare := pcks.AllocateShare(ciphertext.Level())
+		_, pkOut := testCtx.kgen.GenKeyPair()
+		pcks.GenShare(testCtx.sk0, pkOut, ciphertext, pcksShare)
+		pcksShareTest := new(PCKSShare)
+		streamThrough(pcksShare, pcksShareTest)
+		require.True(t, pcksShare.Value[0].Equals(pcksShareTest.Value[0]))
+		require.True(t, pcksShare.Value[1].Equals(pcksShareTest.Value[1]))
+
+		cks := NewCKSProtocol(params, params.Sigma())
+		cksShare := cks.AllocateShare(ciphertext.Level())
+		cks.GenShare(testCtx.sk0, testCtx.sk1, ciphertext, cksShare)
+		cksShareTest := new(CKSShare)
+		streamThrough(cksShare, cksShareTest)
+		require.True(t, cksShare.Value.Equals(cksShareTest.Value))
+
+		thr := NewThresholdizer(params)
+		gen, err := thr.GenShamirPolynomial(2, testCtx.sk0)
+		require.NoError(t, err)
+		thrShare := thr.AllocateThresholdSecretShare()
+		thr.GenShamirSecretShare(1, gen, thrShare)
+		thrShareTest := new(ShamirSecretShare)
+		streamThrough(thrShare, thrShareTest)
+		require.True(t, thrShare.Equals(thrShareTest.PolyQP))
+	})
 }
 
 // Returns the ceil(log2) of the sum of the absolute value of all the coefficients
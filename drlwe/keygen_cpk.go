@@ -2,6 +2,8 @@
 package drlwe
 
 import (
+	"io"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -96,3 +98,36 @@ func (ckg *CKGProtocol) GenPublicKey(roundShare *CKGShare, crp CKGCRP, pubkey *r
 	pubkey.Value[0].Copy(roundShare.Value)
 	pubkey.Value[1].Copy(rlwe.PolyQP(crp))
 }
+
+// GenSeededPublicKey populates the input seeded public key with the result of the protocol, in the compressed
+// form of a rlwe.SeededPublicKey. The CRP must have been sampled with SampleCRP from a CRS utils.NewKeyedPRNG(seed)
+// in its initial state, so that the key expands to the one populated by GenPublicKey.
+func (ckg *CKGProtocol) GenSeededPublicKey(roundShare *CKGShare, seed []byte, pubkey *rlwe.SeededPublicKey) {
+	pubkey.Seed = append(pubkey.Seed[:0], seed...)
+	pubkey.Value.Copy(roundShare.Value)
+}
+
+// ckgShareTag is the tag of the header written by CKGShare.WriteTo.
+const ckgShareTag = "CKGS"
+
+// WriteTo writes the target element on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (share *CKGShare) WriteTo(w io.Writer) (n int64, err error) {
+	if n, err = utils.WriteHeader(w, ckgShareTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = share.Value.WriteToStream(w)
+	return n + inc, err
+}
+
+// ReadFrom reads an element written by WriteTo from r on the target element.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (share *CKGShare) ReadFrom(r io.Reader) (n int64, err error) {
+	if n, err = utils.ReadHeader(r, ckgShareTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = share.Value.ReadFromStream(r)
+	return n + inc, err
+}
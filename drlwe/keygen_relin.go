@@ -2,6 +2,7 @@ package drlwe
 
 import (
 	"errors"
+	"io"
 	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -60,9 +61,10 @@ func NewRKGProtocol(params rlwe.Parameters, ephSkPr float64) *RKGProtocol {
 func (ekg *RKGProtocol) AllocateShares() (ephSk *rlwe.SecretKey, r1 *RKGShare, r2 *RKGShare) {
 	ephSk = rlwe.NewSecretKey(ekg.params)
 	r1, r2 = new(RKGShare), new(RKGShare)
-	r1.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
-	r2.Value = make([][2]rlwe.PolyQP, ekg.params.Beta())
-	for i := 0; i < ekg.params.Beta(); i++ {
+	decompCount := ekg.params.DecompCount(ekg.params.QCount()-1, ekg.params.PCount()-1)
+	r1.Value = make([][2]rlwe.PolyQP, decompCount)
+	r2.Value = make([][2]rlwe.PolyQP, decompCount)
+	for i := 0; i < decompCount; i++ {
 		r1.Value[i][0] = ekg.params.RingQP().NewPoly()
 		r1.Value[i][1] = ekg.params.RingQP().NewPoly()
 		r2.Value[i][0] = ekg.params.RingQP().NewPoly()
@@ -74,7 +76,7 @@ func (ekg *RKGProtocol) AllocateShares() (ephSk *rlwe.SecretKey, r1 *RKGShare, r
 // SampleCRP samples a common random polynomial to be used in the RKG protocol from the provided
 // common reference string.
 func (ekg *RKGProtocol) SampleCRP(crs CRS) RKGCRP {
-	crp := make([]rlwe.PolyQP, ekg.params.Beta())
+	crp := make([]rlwe.PolyQP, ekg.params.DecompCount(ekg.params.QCount()-1, ekg.params.PCount()-1))
 	us := rlwe.NewUniformSamplerQP(ekg.params, crs, ekg.params.RingQP())
 	for i := range crp {
 		crp[i] = ekg.params.RingQP().NewPoly()
@@ -104,40 +106,54 @@ func (ekg *RKGProtocol) GenShareRoundOne(sk *rlwe.SecretKey, crp RKGCRP, ephSkOu
 	ringQP.NTTLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 	ringQP.MFormLvl(levelQ, levelP, ephSkOut.Value, ephSkOut.Value)
 
-	for i := 0; i < ekg.params.Beta(); i++ {
-		// h = e
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][0].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][0].Q, levelP, nil, shareOut.Value[i][0].P)
-		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][0], shareOut.Value[i][0])
+	alpha := ekg.params.DecompAlpha(levelP)
+	decompRNS := ekg.params.DecompRNS(levelQ, levelP)
+	decompPw2 := ekg.params.DecompPw2(levelQ, levelP)
 
-		// h = sk*CrtBaseDecompQi + e
-		for j := 0; j < ekg.params.PCount(); j++ {
-			index := i*ekg.params.PCount() + j
+	for j := 0; j < decompPw2; j++ {
+		for i := 0; i < decompRNS; i++ {
 
-			// Handles the case where nb pj does not divides nb qi
-			if index >= ekg.params.QCount() {
-				break
-			}
+			idx := i*decompPw2 + j
+
+			// h = e
+			ekg.gaussianSamplerQ.Read(shareOut.Value[idx][0].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[idx][0].Q, levelP, nil, shareOut.Value[idx][0].P)
+			ringQP.NTTLvl(levelQ, levelP, shareOut.Value[idx][0], shareOut.Value[idx][0])
+
+			// h = sk*CrtBaseDecompQi*2^(j*Pow2Base) + e
+			for k := 0; k < alpha; k++ {
+				index := i*alpha + k
 
-			qi := ringQ.Modulus[index]
-			skP := ekg.tmpPoly1.Q.Coeffs[index]
-			h := shareOut.Value[i][0].Q.Coeffs[index]
+				// Handles the case where nb pj does not divides nb qi
+				if index >= ekg.params.QCount() {
+					break
+				}
 
-			for w := 0; w < ringQ.N; w++ {
-				h[w] = ring.CRed(h[w]+skP[w], qi)
+				qi := ringQ.Modulus[index]
+				skP := ekg.tmpPoly1.Q.Coeffs[index]
+				h := shareOut.Value[idx][0].Q.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					h[w] = ring.CRed(h[w]+skP[w], qi)
+				}
 			}
-		}
 
-		// h = sk*CrtBaseDecompQi + -u*a + e
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, ephSkOut.Value, crp[i], shareOut.Value[i][0])
+			// h = sk*CrtBaseDecompQi*2^(j*Pow2Base) + -u*a + e
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, ephSkOut.Value, crp[idx], shareOut.Value[idx][0])
 
-		// Second Element
-		// e_2i
-		ekg.gaussianSamplerQ.Read(shareOut.Value[i][1].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i][1].Q, levelP, nil, shareOut.Value[i][1].P)
-		ringQP.NTTLvl(levelQ, levelP, shareOut.Value[i][1], shareOut.Value[i][1])
-		// s*a + e_2i
-		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, crp[i], shareOut.Value[i][1])
+			// Second Element
+			// e_2i
+			ekg.gaussianSamplerQ.Read(shareOut.Value[idx][1].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[idx][1].Q, levelP, nil, shareOut.Value[idx][1].P)
+			ringQP.NTTLvl(levelQ, levelP, shareOut.Value[idx][1], shareOut.Value[idx][1])
+			// s*a + e_2i
+			ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, crp[idx], shareOut.Value[idx][1])
+		}
+
+		// P*s*2^((j+1)*Pow2Base)
+		if pow2Base := ekg.params.Pow2Base(); pow2Base != 0 {
+			ringQ.MulScalar(ekg.tmpPoly1.Q, 1<<pow2Base, ekg.tmpPoly1.Q)
+		}
 	}
 }
 
@@ -159,7 +175,7 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 
 	// Each sample is of the form [-u*a_i + s*w_i + e_i]
 	// So for each element of the base decomposition w_i :
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := 0; i < ekg.params.DecompCount(levelQ, levelP); i++ {
 
 		// Computes [(sum samples)*sk + e_1i, sk*a + e_2i]
 
@@ -185,7 +201,7 @@ func (ekg *RKGProtocol) GenShareRoundTwo(ephSk, sk *rlwe.SecretKey, round1 *RKGS
 // AggregateShares combines two RKG shares into a single one
 func (ekg *RKGProtocol) AggregateShares(share1, share2, shareOut *RKGShare) {
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := 0; i < ekg.params.DecompCount(levelQ, levelP); i++ {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][0], share2.Value[i][0], shareOut.Value[i][0])
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i][1], share2.Value[i][1], shareOut.Value[i][1])
 	}
@@ -194,7 +210,7 @@ func (ekg *RKGProtocol) AggregateShares(share1, share2, shareOut *RKGShare) {
 // GenRelinearizationKey computes the generated RLK from the public shares and write the result in evalKeyOut
 func (ekg *RKGProtocol) GenRelinearizationKey(round1 *RKGShare, round2 *RKGShare, evalKeyOut *rlwe.RelinearizationKey) {
 	ringQP, levelQ, levelP := ekg.params.RingQP(), ekg.params.QCount()-1, ekg.params.PCount()-1
-	for i := 0; i < ekg.params.Beta(); i++ {
+	for i := 0; i < ekg.params.DecompCount(levelQ, levelP); i++ {
 		ringQP.AddLvl(levelQ, levelP, round2.Value[i][0], round2.Value[i][1], evalKeyOut.Keys[0].Value[i][0])
 		evalKeyOut.Keys[0].Value[i][1].Copy(round1.Value[i][1])
 		ringQP.MFormLvl(levelQ, levelP, evalKeyOut.Keys[0].Value[i][0], evalKeyOut.Keys[0].Value[i][0])
@@ -252,3 +268,67 @@ func (share *RKGShare) UnmarshalBinary(data []byte) (err error) {
 
 	return nil
 }
+
+// rkgShareTag is the tag of the header written by RKGShare.WriteTo.
+const rkgShareTag = "RKGS"
+
+// WriteTo writes the target element on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (share *RKGShare) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, rkgShareTag); err != nil {
+		return
+	}
+
+	if len(share.Value) > 0xFFFF {
+		return n, errors.New("RKGShare: uint16 overflow on length")
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint16(w, uint16(len(share.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for i := range share.Value {
+		for j := range share.Value[i] {
+			inc, err = share.Value[i][j].WriteToStream(w)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads an element written by WriteTo from r on the target element.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (share *RKGShare) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, rkgShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint16
+	size, inc, err = utils.ReadUint16(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(share.Value) != int(size) {
+		share.Value = make([][2]rlwe.PolyQP, size)
+	}
+
+	for i := range share.Value {
+		for j := range share.Value[i] {
+			inc, err = share.Value[i][j].ReadFromStream(r)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
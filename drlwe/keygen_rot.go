@@ -2,6 +2,7 @@ package drlwe
 
 import (
 	"errors"
+	"io"
 
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
@@ -50,7 +51,7 @@ func NewRTGProtocol(params rlwe.Parameters) *RTGProtocol {
 // AllocateShares allocates a party's share in the RTG protocol
 func (rtg *RTGProtocol) AllocateShares() (rtgShare *RTGShare) {
 	rtgShare = new(RTGShare)
-	rtgShare.Value = make([]rlwe.PolyQP, rtg.params.Beta())
+	rtgShare.Value = make([]rlwe.PolyQP, rtg.params.DecompCount(rtg.params.QCount()-1, rtg.params.PCount()-1))
 	for i := range rtgShare.Value {
 		rtgShare.Value[i] = rtg.params.RingQP().NewPoly()
 	}
@@ -60,7 +61,7 @@ func (rtg *RTGProtocol) AllocateShares() (rtgShare *RTGShare) {
 // SampleCRP samples a common random polynomial to be used in the RTG protocol from the provided
 // common reference string.
 func (rtg *RTGProtocol) SampleCRP(crs CRS) RTGCRP {
-	crp := make([]rlwe.PolyQP, rtg.params.Beta())
+	crp := make([]rlwe.PolyQP, rtg.params.DecompCount(rtg.params.QCount()-1, rtg.params.PCount()-1))
 	us := rlwe.NewUniformSamplerQP(rtg.params, crs, rtg.params.RingQP())
 	for i := range crp {
 		crp[i] = rtg.params.RingQP().NewPoly()
@@ -87,57 +88,80 @@ func (rtg *RTGProtocol) GenShare(sk *rlwe.SecretKey, galEl uint64, crp RTGCRP, s
 
 	var index int
 
-	for i := 0; i < rtg.params.Beta(); i++ {
+	alpha := rtg.params.DecompAlpha(levelP)
+	decompRNS := rtg.params.DecompRNS(levelQ, levelP)
+	decompPw2 := rtg.params.DecompPw2(levelQ, levelP)
 
-		// e
-		rtg.gaussianSamplerQ.Read(shareOut.Value[i].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[i].Q, levelP, nil, shareOut.Value[i].P)
-		ringQP.NTTLazyLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
-		ringQP.MFormLvl(levelQ, levelP, shareOut.Value[i], shareOut.Value[i])
+	for j := 0; j < decompPw2; j++ {
+		for i := 0; i < decompRNS; i++ {
 
-		// a is the CRP
+			idx := i*decompPw2 + j
 
-		// e + sk_in * (qiBarre*qiStar) * 2^w
-		// (qiBarre*qiStar)%qi = 1, else 0
-		for j := 0; j < rtg.params.PCount(); j++ {
+			// e
+			rtg.gaussianSamplerQ.Read(shareOut.Value[idx].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(shareOut.Value[idx].Q, levelP, nil, shareOut.Value[idx].P)
+			ringQP.NTTLazyLvl(levelQ, levelP, shareOut.Value[idx], shareOut.Value[idx])
+			ringQP.MFormLvl(levelQ, levelP, shareOut.Value[idx], shareOut.Value[idx])
 
-			index = i*rtg.params.PCount() + j
+			// a is the CRP
 
-			// Handles the case where nb pj does not divides nb qi
-			if index >= rtg.params.QCount() {
-				break
-			}
+			// e + sk_in * (qiBarre*qiStar) * 2^(j*Pow2Base)
+			// (qiBarre*qiStar)%qi = 1, else 0
+			for k := 0; k < alpha; k++ {
+
+				index = i*alpha + k
+
+				// Handles the case where nb pj does not divides nb qi
+				if index >= rtg.params.QCount() {
+					break
+				}
 
-			qi := ringQ.Modulus[index]
-			tmp0 := rtg.tmpPoly0.Q.Coeffs[index]
-			tmp1 := shareOut.Value[i].Q.Coeffs[index]
+				qi := ringQ.Modulus[index]
+				tmp0 := rtg.tmpPoly0.Q.Coeffs[index]
+				tmp1 := shareOut.Value[idx].Q.Coeffs[index]
 
-			for w := 0; w < ringQ.N; w++ {
-				tmp1[w] = ring.CRed(tmp1[w]+tmp0[w], qi)
+				for w := 0; w < ringQ.N; w++ {
+					tmp1[w] = ring.CRed(tmp1[w]+tmp0[w], qi)
+				}
 			}
+
+			// sk_in * (qiBarre*qiStar) * 2^(j*Pow2Base) - a*sk + e
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[idx], rtg.tmpPoly1, shareOut.Value[idx])
 		}
 
-		// sk_in * (qiBarre*qiStar) * 2^w - a*sk + e
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, crp[i], rtg.tmpPoly1, shareOut.Value[i])
+		// sk_in * P * 2^((j+1)*Pow2Base)
+		if pow2Base := rtg.params.Pow2Base(); pow2Base != 0 {
+			ringQ.MulScalar(rtg.tmpPoly0.Q, 1<<pow2Base, rtg.tmpPoly0.Q)
+		}
 	}
 }
 
 // Aggregate aggregates two shares in the Rotation Key Generation protocol
 func (rtg *RTGProtocol) Aggregate(share1, share2, shareOut *RTGShare) {
 	ringQP, levelQ, levelP := rtg.params.RingQP(), rtg.params.QCount()-1, rtg.params.PCount()-1
-	for i := 0; i < rtg.params.Beta(); i++ {
+	for i := 0; i < rtg.params.DecompCount(levelQ, levelP); i++ {
 		ringQP.AddLvl(levelQ, levelP, share1.Value[i], share2.Value[i], shareOut.Value[i])
 	}
 }
 
 // GenRotationKey finalizes the RTG protocol and populates the input RotationKey with the computed collective SwitchingKey.
 func (rtg *RTGProtocol) GenRotationKey(share *RTGShare, crp RTGCRP, rotKey *rlwe.SwitchingKey) {
-	for i := 0; i < rtg.params.Beta(); i++ {
+	for i := range share.Value {
 		rotKey.Value[i][0].CopyValues(share.Value[i])
 		rotKey.Value[i][1].CopyValues(crp[i])
 	}
 }
 
+// GenSeededRotationKey populates the input seeded rotation key with the result of the protocol, in the compressed
+// form of a rlwe.SeededSwitchingKey. The CRP must have been sampled with SampleCRP from a CRS utils.NewKeyedPRNG(seed)
+// in its initial state, so that the key expands to the one populated by GenRotationKey.
+func (rtg *RTGProtocol) GenSeededRotationKey(share *RTGShare, seed []byte, rotKey *rlwe.SeededSwitchingKey) {
+	rotKey.Seed = append(rotKey.Seed[:0], seed...)
+	for i := range share.Value {
+		rotKey.Value[i].CopyValues(share.Value[i])
+	}
+}
+
 // MarshalBinary encode the target element on a slice of byte.
 func (share *RTGShare) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, 1+share.Value[0].GetDataLen(true)*len(share.Value))
@@ -171,3 +195,63 @@ func (share *RTGShare) UnmarshalBinary(data []byte) (err error) {
 
 	return nil
 }
+
+// rtgShareTag is the tag of the header written by RTGShare.WriteTo.
+const rtgShareTag = "RTGS"
+
+// WriteTo writes the target element on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (share *RTGShare) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, rtgShareTag); err != nil {
+		return
+	}
+
+	if len(share.Value) > 0xFFFF {
+		return n, errors.New("RTGShare: uint16 overflow on length")
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint16(w, uint16(len(share.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for i := range share.Value {
+		inc, err = share.Value[i].WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads an element written by WriteTo from r on the target element.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (share *RTGShare) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, rtgShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint16
+	size, inc, err = utils.ReadUint16(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(share.Value) != int(size) {
+		share.Value = make([]rlwe.PolyQP, size)
+	}
+
+	for i := range share.Value {
+		inc, err = share.Value[i].ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -1,6 +1,8 @@
 package drlwe
 
 import (
+	"io"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -174,3 +176,47 @@ func (share *PCKSShare) UnmarshalBinary(data []byte) (err error) {
 	}
 	return
 }
+
+// pcksShareTag is the tag of the header written by PCKSShare.WriteTo.
+const pcksShareTag = "PCKS"
+
+// WriteTo writes the target PCKS share on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (share *PCKSShare) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, pcksShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for i := range share.Value {
+		inc, err = share.Value[i].WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a PCKS share written by WriteTo from r on the target PCKS share.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (share *PCKSShare) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, pcksShareTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for i := range share.Value {
+		if share.Value[i] == nil {
+			share.Value[i] = new(ring.Poly)
+		}
+		inc, err = share.Value[i].ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -1,6 +1,8 @@
 package drlwe
 
 import (
+	"io"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/rlwe"
 	"github.com/ldsec/lattigo/v2/utils"
@@ -151,3 +153,31 @@ func (cks *CKSProtocol) KeySwitch(combined *CKSShare, ct, ctOut *rlwe.Ciphertext
 	cks.params.RingQ().AddLvl(el.Level(), el.Value[0], combined.Value, elOut.Value[0])
 	ring.CopyValuesLvl(el.Level(), el.Value[1], elOut.Value[1])
 }
+
+// cksShareTag is the tag of the header written by CKSShare.WriteTo.
+const cksShareTag = "CKSS"
+
+// WriteTo writes the target CKS share on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (ckss *CKSShare) WriteTo(w io.Writer) (n int64, err error) {
+	if n, err = utils.WriteHeader(w, cksShareTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = ckss.Value.WriteToStream(w)
+	return n + inc, err
+}
+
+// ReadFrom reads a CKS share written by WriteTo from r on the target CKS share.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (ckss *CKSShare) ReadFrom(r io.Reader) (n int64, err error) {
+	if n, err = utils.ReadHeader(r, cksShareTag); err != nil {
+		return
+	}
+	if ckss.Value == nil {
+		ckss.Value = new(ring.Poly)
+	}
+	var inc int64
+	inc, err = ckss.Value.ReadFromStream(r)
+	return n + inc, err
+}
@@ -0,0 +1,287 @@
+package drlwe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// ThresholdizerProtocol is an interface describing the local steps of a generic
+// RLWE t-out-of-N-threshold secret-sharing of the parties' secret-keys.
+type ThresholdizerProtocol interface {
+	GenShamirPolynomial(threshold int, secret *rlwe.SecretKey) (*ShamirPolynomial, error)
+	AllocateThresholdSecretShare() *ShamirSecretShare
+	GenShamirSecretShare(recipient ShamirPublicPoint, secretPoly *ShamirPolynomial, shareOut *ShamirSecretShare)
+	AggregateShares(share1, share2, outShare *ShamirSecretShare)
+}
+
+// CombinerProtocol is an interface describing the local step of a generic RLWE
+// t-out-of-N-threshold protocol, in which a party converts its Shamir secret-share
+// into a t-out-of-t additive secret-share of the ideal secret-key.
+type CombinerProtocol interface {
+	GenAdditiveShare(actives []ShamirPublicPoint, ownPoint ShamirPublicPoint, ownShare *ShamirSecretShare, skOut *rlwe.SecretKey)
+}
+
+// ShamirPublicPoint is a type for Shamir public point associated with a party identity within
+// the t-out-of-N-threshold scheme. It must be non-zero, distinct among the parties and smaller
+// than all the moduli of the ring QP.
+type ShamirPublicPoint uint64
+
+// ShamirPolynomial is a type for a party's secret polynomial over RingQP. Its constant
+// coefficient is the party's secret-key.
+type ShamirPolynomial struct {
+	Coeffs []rlwe.PolyQP
+}
+
+// ShamirSecretShare is a type for a party's secret share, i.e., the evaluation of the
+// (aggregated) Shamir polynomial at the party's public point.
+type ShamirSecretShare struct {
+	rlwe.PolyQP
+}
+
+// Thresholdizer is the structure containing the parameters and the sampler for the
+// generation of the Shamir secret-shares of a party's secret-key.
+type Thresholdizer struct {
+	params         rlwe.Parameters
+	uniformSampler rlwe.UniformSamplerQP
+}
+
+// Combiner is the structure containing the precomputations for the conversion of
+// Shamir secret-shares into t-out-of-t additive secret-shares.
+type Combiner struct {
+	params    rlwe.Parameters
+	threshold int
+	ownPoint  ShamirPublicPoint
+
+	// one[i] is 1 in Montgomery form modulo the i-th modulus of QP.
+	one []uint64
+	// invDiff[j][i] is (others[j] - ownPoint)^-1 in Montgomery form modulo the i-th modulus of QP.
+	invDiff map[ShamirPublicPoint][]uint64
+	// points[j][i] is others[j] in Montgomery form modulo the i-th modulus of QP.
+	points map[ShamirPublicPoint][]uint64
+
+	tmp []uint64
+}
+
+// NewThresholdizer creates a new Thresholdizer instance from parameters.
+func NewThresholdizer(params rlwe.Parameters) *Thresholdizer {
+	thr := new(Thresholdizer)
+	thr.params = params
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	thr.uniformSampler = rlwe.NewUniformSamplerQP(params, prng, params.RingQP())
+	return thr
+}
+
+// GenShamirPolynomial generates a new secret ShamirPolynomial to be used in the Thresholdizer.GenShamirSecretShare method.
+// It does so by sampling a random polynomial of degree threshold - 1 and with its constant term equal to secret.
+func (thr *Thresholdizer) GenShamirPolynomial(threshold int, secret *rlwe.SecretKey) (*ShamirPolynomial, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold should be >= 1")
+	}
+	gen := &ShamirPolynomial{Coeffs: make([]rlwe.PolyQP, threshold)}
+	gen.Coeffs[0] = secret.Value.CopyNew()
+	for i := 1; i < threshold; i++ {
+		gen.Coeffs[i] = thr.params.RingQP().NewPoly()
+		thr.uniformSampler.Read(&gen.Coeffs[i])
+	}
+	return gen, nil
+}
+
+// AllocateThresholdSecretShare allocates a ShamirSecretShare struct.
+func (thr *Thresholdizer) AllocateThresholdSecretShare() *ShamirSecretShare {
+	return &ShamirSecretShare{thr.params.RingQP().NewPoly()}
+}
+
+// GenShamirSecretShare generates a secret share for the given recipient, identified by its ShamirPublicPoint.
+// The result is stored in shareOut and should be sent to this party.
+func (thr *Thresholdizer) GenShamirSecretShare(recipient ShamirPublicPoint, secretPoly *ShamirPolynomial, shareOut *ShamirSecretShare) {
+	ringQ, ringP := thr.params.RingQ(), thr.params.RingP()
+	levelQ, levelP := thr.params.QCount()-1, thr.params.PCount()-1
+
+	// Horner evaluation of the secret polynomial at the recipient's point
+	n := len(secretPoly.Coeffs)
+	thr.params.RingQP().CopyValuesLvl(levelQ, levelP, secretPoly.Coeffs[n-1], shareOut.PolyQP)
+	for i := n - 2; i >= 0; i-- {
+		ringQ.MulScalarLvl(levelQ, shareOut.Q, uint64(recipient), shareOut.Q)
+		if ringP != nil {
+			ringP.MulScalarLvl(levelP, shareOut.P, uint64(recipient), shareOut.P)
+		}
+		thr.params.RingQP().AddLvl(levelQ, levelP, shareOut.PolyQP, secretPoly.Coeffs[i], shareOut.PolyQP)
+	}
+}
+
+// AggregateShares aggregates two ShamirSecretShare and stores the result in outShare.
+func (thr *Thresholdizer) AggregateShares(share1, share2, outShare *ShamirSecretShare) {
+	levelQ, levelP := thr.params.QCount()-1, thr.params.PCount()-1
+	thr.params.RingQP().AddLvl(levelQ, levelP, share1.PolyQP, share2.PolyQP, outShare.PolyQP)
+}
+
+// NewCombiner creates a new Combiner for a party identified by its ShamirPublicPoint own.
+// The others argument lists the public points of all the other parties of the setup, and
+// threshold is the number of parties required to complete a protocol.
+func NewCombiner(params rlwe.Parameters, own ShamirPublicPoint, others []ShamirPublicPoint, threshold int) *Combiner {
+	cmb := new(Combiner)
+	cmb.params = params
+	cmb.threshold = threshold
+	cmb.ownPoint = own
+
+	moduli := cmb.moduli()
+	bredParams := cmb.bredParams()
+
+	cmb.one = make([]uint64, len(moduli))
+	cmb.tmp = make([]uint64, len(moduli))
+	for i, qi := range moduli {
+		cmb.one[i] = ring.MForm(1, qi, bredParams[i])
+	}
+
+	cmb.invDiff = make(map[ShamirPublicPoint][]uint64, len(others))
+	cmb.points = make(map[ShamirPublicPoint][]uint64, len(others))
+	for _, spk := range others {
+		if spk == own {
+			panic(fmt.Sprintf("own point %d is listed among the other parties' points", own))
+		}
+		cmb.points[spk] = make([]uint64, len(moduli))
+		cmb.invDiff[spk] = make([]uint64, len(moduli))
+		for i, qi := range moduli {
+			xj := ring.BRedAdd(uint64(spk), qi, bredParams[i])
+			xi := ring.BRedAdd(uint64(own), qi, bredParams[i])
+			if xj == xi {
+				panic(fmt.Sprintf("points %d and %d are equal modulo %d", spk, own, qi))
+			}
+			cmb.points[spk][i] = ring.MForm(xj, qi, bredParams[i])
+			cmb.invDiff[spk][i] = ring.MForm(ring.ModExp(xj+qi-xi, qi-2, qi), qi, bredParams[i])
+		}
+	}
+
+	return cmb
+}
+
+// GenAdditiveShare generates a t-out-of-t additive share of the ideal secret-key from a party's
+// aggregated ShamirSecretShare, given the list of the public points of the t active parties
+// (which must include ownPoint). The result is a regular rlwe.SecretKey that can be used as input
+// in any of the N-out-of-N protocols (e.g., CKSProtocol, PCKSProtocol and RTGProtocol) run among
+// the active parties.
+// The method panics if actives lists the same point twice.
+func (cmb *Combiner) GenAdditiveShare(actives []ShamirPublicPoint, ownPoint ShamirPublicPoint, ownShare *ShamirSecretShare, skOut *rlwe.SecretKey) {
+
+	if len(actives) < cmb.threshold {
+		panic("not enough active players to combine threshold shares")
+	}
+
+	if ownPoint != cmb.ownPoint {
+		panic("ownPoint does not match the point of the Combiner")
+	}
+
+	moduli := cmb.moduli()
+	mredParams := cmb.mredParams()
+
+	// Lagrange coefficient prod_{j != i} x_j / (x_j - x_i), modulo each modulus of QP
+	copy(cmb.tmp, cmb.one)
+	isActive := false
+	seen := make(map[ShamirPublicPoint]bool, len(actives))
+	for _, spk := range actives {
+		if seen[spk] {
+			panic(fmt.Sprintf("active point %d is listed twice", spk))
+		}
+		seen[spk] = true
+		if spk == cmb.ownPoint {
+			isActive = true
+			continue
+		}
+		points, okPoint := cmb.points[spk]
+		invDiff, okDiff := cmb.invDiff[spk]
+		if !okPoint || !okDiff {
+			panic(fmt.Sprintf("unknown public point %d", spk))
+		}
+		for i, qi := range moduli {
+			cmb.tmp[i] = ring.MRed(cmb.tmp[i], points[i], qi, mredParams[i])
+			cmb.tmp[i] = ring.MRed(cmb.tmp[i], invDiff[i], qi, mredParams[i])
+		}
+	}
+
+	if !isActive {
+		panic("ownPoint is not among the active points")
+	}
+
+	ringQ, ringP := cmb.params.RingQ(), cmb.params.RingP()
+	levelQ := cmb.params.QCount() - 1
+	for i := 0; i < levelQ+1; i++ {
+		ring.MulScalarMontgomeryVec(ownShare.Q.Coeffs[i], skOut.Value.Q.Coeffs[i], cmb.tmp[i], ringQ.Modulus[i], ringQ.MredParams[i])
+	}
+	if ringP != nil {
+		for i := 0; i < cmb.params.PCount(); i++ {
+			ring.MulScalarMontgomeryVec(ownShare.P.Coeffs[i], skOut.Value.P.Coeffs[i], cmb.tmp[levelQ+1+i], ringP.Modulus[i], ringP.MredParams[i])
+		}
+	}
+}
+
+func (cmb *Combiner) moduli() (moduli []uint64) {
+	moduli = append(moduli, cmb.params.RingQ().Modulus...)
+	if ringP := cmb.params.RingP(); ringP != nil {
+		moduli = append(moduli, ringP.Modulus...)
+	}
+	return
+}
+
+func (cmb *Combiner) bredParams() (bredParams [][]uint64) {
+	bredParams = append(bredParams, cmb.params.RingQ().BredParams...)
+	if ringP := cmb.params.RingP(); ringP != nil {
+		bredParams = append(bredParams, ringP.BredParams...)
+	}
+	return
+}
+
+func (cmb *Combiner) mredParams() (mredParams []uint64) {
+	mredParams = append(mredParams, cmb.params.RingQ().MredParams...)
+	if ringP := cmb.params.RingP(); ringP != nil {
+		mredParams = append(mredParams, ringP.MredParams...)
+	}
+	return
+}
+
+// MarshalBinary encodes a ShamirSecretShare on a slice of bytes.
+func (s *ShamirSecretShare) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, s.GetDataLen(true))
+	_, err = s.PolyQP.WriteTo(data)
+	return
+}
+
+// UnmarshalBinary decodes marshaled ShamirSecretShare on the target ShamirSecretShare.
+func (s *ShamirSecretShare) UnmarshalBinary(data []byte) (err error) {
+	_, err = s.DecodePolyNew(data)
+	return
+}
+
+// shamirSecretShareTag is the tag of the header written by ShamirSecretShare.WriteTo.
+const shamirSecretShareTag = "SHSS"
+
+// WriteTo writes the target ShamirSecretShare on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (s *ShamirSecretShare) WriteTo(w io.Writer) (n int64, err error) {
+	if n, err = utils.WriteHeader(w, shamirSecretShareTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = s.PolyQP.WriteToStream(w)
+	return n + inc, err
+}
+
+// ReadFrom reads a ShamirSecretShare written by WriteTo from r on the target ShamirSecretShare.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (s *ShamirSecretShare) ReadFrom(r io.Reader) (n int64, err error) {
+	if n, err = utils.ReadHeader(r, shamirSecretShareTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = s.PolyQP.ReadFromStream(r)
+	return n + inc, err
+}
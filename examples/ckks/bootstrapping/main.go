@@ -24,7 +24,7 @@ func main() {
 	var plaintext *ckks.Plaintext
 
 	// Bootstrapping parameters
-	// Four sets of parameters (index 0 to 3) ensuring 128 bit of security
+	// Five sets of parameters (index 0 to 4), whose security is given by their Lambda field,
 	// are available in github.com/ldsec/lattigo/v2/ckks/bootstrap_params
 	// LogSlots is hardcoded to 15 in the parameters, but can be changed from 1 to 15.
 	// When changing logSlots make sure that the number of levels allocated to CtS and StC is
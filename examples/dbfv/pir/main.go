@@ -136,7 +136,6 @@ func main() {
 	encoder := bfv.NewEncoder(params)
 	l.Println("> Memory alloc Phase")
 	encInputs := make([]*bfv.Ciphertext, N)
-	plainMask := make([]*bfv.PlaintextMul, N)
 
 	// Ciphertexts to be retrieved
 	for i := range encInputs {
@@ -145,12 +144,7 @@ func main() {
 
 	// Plaintext masks: plainmask[i] = encode([0, ..., 0, 1_i, 0, ..., 0])
 	// (zero with a 1 at the i-th position).
-	for i := range plainMask {
-		maskCoeffs := make([]uint64, params.N())
-		maskCoeffs[i] = 1
-		plainMask[i] = bfv.NewPlaintextMul(params)
-		encoder.EncodeUintMul(maskCoeffs, plainMask[i])
-	}
+	plainMask := bfv.GenQueryExpansion(encoder, params, N).Masks
 
 	// Ciphertexts encrypted under CKG and stored in the cloud
 	l.Println("> Encrypt Phase")
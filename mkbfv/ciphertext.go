@@ -0,0 +1,29 @@
+// Package mkbfv implements the multi-key variant of the BFV scheme, in which each party encrypts under its own
+// public key and the ciphertexts grow in dimension as the computations combine the data of several parties.
+package mkbfv
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+)
+
+// Ciphertext is a multi-key BFV ciphertext.
+type Ciphertext struct {
+	*mkrlwe.Ciphertext
+}
+
+// NewCiphertext returns a new Ciphertext involving the parties ids.
+func NewCiphertext(params bfv.Parameters, ids []string) *Ciphertext {
+	return &Ciphertext{mkrlwe.NewCiphertext(params.Parameters, ids, params.MaxLevel())}
+}
+
+// NewCiphertextFromBFV returns the multi-key Ciphertext of the party id corresponding to the ciphertext ct,
+// encrypted under the public key of the party. The returned Ciphertext shares the backing polynomials of ct.
+func NewCiphertextFromBFV(id string, ct *bfv.Ciphertext) *Ciphertext {
+	return &Ciphertext{mkrlwe.NewCiphertextFromRLWE(id, ct.Ciphertext)}
+}
+
+// CopyNew returns a deep copy of the ciphertext.
+func (ct *Ciphertext) CopyNew() *Ciphertext {
+	return &Ciphertext{ct.Ciphertext.CopyNew()}
+}
@@ -0,0 +1,28 @@
+package mkbfv
+
+import (
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// PartialDecryptionProtocol is a structure storing the parameters for the distributed decryption of multi-key ciphertexts.
+type PartialDecryptionProtocol struct {
+	mkrlwe.PartialDecryptionProtocol
+}
+
+// NewPartialDecryptionProtocol creates a new PartialDecryptionProtocol whose shares are smudged with a Gaussian
+// noise of standard deviation sigmaSmudging.
+func NewPartialDecryptionProtocol(params bfv.Parameters, sigmaSmudging float64) *PartialDecryptionProtocol {
+	return &PartialDecryptionProtocol{*mkrlwe.NewPartialDecryptionProtocol(params.Parameters, sigmaSmudging)}
+}
+
+// GenShare computes the share of the party id, of secret key sk, for the decryption of ct.
+func (pdp *PartialDecryptionProtocol) GenShare(id string, sk *rlwe.SecretKey, ct *Ciphertext, shareOut *mkrlwe.PartialDecryptionShare) {
+	pdp.PartialDecryptionProtocol.GenShare(id, sk, ct.Ciphertext, shareOut)
+}
+
+// DecryptBFV recovers the plaintext of ct from the aggregation combined of the shares of all the parties involved in ct.
+func (pdp *PartialDecryptionProtocol) DecryptBFV(combined *mkrlwe.PartialDecryptionShare, ct *Ciphertext, ptOut *bfv.Plaintext) {
+	pdp.PartialDecryptionProtocol.Decrypt(combined, ct.Ciphertext, ptOut.Plaintext)
+}
@@ -0,0 +1,205 @@
+package mkbfv
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+)
+
+// Evaluator is an interface implementing the homomorphic operations on multi-key BFV ciphertexts.
+// The output ciphertexts of the binary operations involve the union of the parties of their operands.
+type Evaluator interface {
+	Add(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	Sub(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	Neg(ct *Ciphertext, ctOut *Ciphertext)
+	NegNew(ct *Ciphertext) (ctOut *Ciphertext)
+	MulRelin(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	ShallowCopy() Evaluator
+}
+
+type evaluator struct {
+	*mkrlwe.Evaluator
+	params            bfv.Parameters
+	ringQ             *ring.Ring
+	ringQMul          *ring.Ring
+	baseconverterQ1Q2 *ring.FastBasisExtender
+	pHalf             *big.Int
+}
+
+// NewEvaluator creates a new Evaluator, relinearizing with the EvaluationKeys of evks.
+func NewEvaluator(params bfv.Parameters, evks *mkrlwe.EvaluationKeySet) Evaluator {
+	return &evaluator{
+		Evaluator:         mkrlwe.NewEvaluator(params.Parameters, evks),
+		params:            params,
+		ringQ:             params.RingQ(),
+		ringQMul:          params.RingQMul(),
+		baseconverterQ1Q2: ring.NewFastBasisExtender(params.RingQ(), params.RingQMul()),
+		pHalf:             new(big.Int).Rsh(params.RingQMul().ModulusBigint, 1),
+	}
+}
+
+// ShallowCopy creates a shallow copy of this evaluator in which the read-only data-structures are
+// shared with the receiver.
+func (eval *evaluator) ShallowCopy() Evaluator {
+	return &evaluator{
+		Evaluator:         eval.Evaluator.ShallowCopy(),
+		params:            eval.params,
+		ringQ:             eval.ringQ,
+		ringQMul:          eval.ringQMul,
+		baseconverterQ1Q2: eval.baseconverterQ1Q2.ShallowCopy(),
+		pHalf:             eval.pHalf,
+	}
+}
+
+// Add adds ct0 to ct1 and returns the result in ctOut.
+func (eval *evaluator) Add(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Add(ct0.Ciphertext, ct1.Ciphertext, ctOut.Ciphertext)
+}
+
+// AddNew adds ct0 to ct1 and returns the result in a new Ciphertext.
+func (eval *evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, mkrlwe.MergeIDs(ct0.IDs, ct1.IDs))
+	eval.Add(ct0, ct1, ctOut)
+	return
+}
+
+// Sub subtracts ct1 from ct0 and returns the result in ctOut.
+func (eval *evaluator) Sub(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Sub(ct0.Ciphertext, ct1.Ciphertext, ctOut.Ciphertext)
+}
+
+// SubNew subtracts ct1 from ct0 and returns the result in a new Ciphertext.
+func (eval *evaluator) SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, mkrlwe.MergeIDs(ct0.IDs, ct1.IDs))
+	eval.Sub(ct0, ct1, ctOut)
+	return
+}
+
+// Neg negates ct and returns the result in ctOut.
+func (eval *evaluator) Neg(ct *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Neg(ct.Ciphertext, ctOut.Ciphertext)
+}
+
+// NegNew negates ct and returns the result in a new Ciphertext.
+func (eval *evaluator) NegNew(ct *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct.IDs)
+	eval.Neg(ct, ctOut)
+	return
+}
+
+// MulRelin multiplies ct0 by ct1, relinearizes the result with the EvaluationKeys of the parties and returns it in ctOut.
+func (eval *evaluator) MulRelin(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	eval.Relinearize(eval.tensorAndRescale(ct0, ct1), ctOut.Ciphertext)
+}
+
+// MulRelinNew multiplies ct0 by ct1, relinearizes the result and returns it in a new Ciphertext.
+func (eval *evaluator) MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, mkrlwe.MergeIDs(ct0.IDs, ct1.IDs))
+	eval.MulRelin(ct0, ct1, ctOut)
+	return
+}
+
+// tensorAndRescale returns the degree two multi-key ciphertext (ct0 x ct1) * (t/Q).
+func (eval *evaluator) tensorAndRescale(ct0, ct1 *Ciphertext) (ct *mkrlwe.TensoredCiphertext) {
+
+	ringQ, ringQMul := eval.ringQ, eval.ringQMul
+
+	ids := mkrlwe.MergeIDs(ct0.IDs, ct1.IDs)
+
+	ct = mkrlwe.NewTensoredCiphertext(eval.params.Parameters, ids, eval.params.MaxLevel())
+
+	// Extends the basis of the components from Q to QMul and transforms them to the NTT domain,
+	// in the Montgomery domain for the components of ct0
+	a, aQMul := eval.modUpAndNTT(ct0.Components(ids), true)
+	b, bQMul := eval.modUpAndNTT(ct1.Components(ids), false)
+
+	// Accumulates sum_k a[i_k] * b[j_k] in Q and QMul, and scales the result by t/Q
+	tensor := func(pOut *ring.Poly, pairs ...[2]int) {
+
+		cQ, cQMul := ringQ.NewPoly(), ringQMul.NewPoly()
+
+		for _, pair := range pairs {
+			if i, j := pair[0], pair[1]; a[i] != nil && b[j] != nil {
+				ringQ.MulCoeffsMontgomeryAndAdd(a[i], b[j], cQ)
+				ringQMul.MulCoeffsMontgomeryAndAdd(aQMul[i], bQMul[j], cQMul)
+			}
+		}
+
+		eval.quantize(cQ, cQMul, pOut)
+	}
+
+	// c_0 = a_0 * b_0
+	tensor(ct.Value[0], [2]int{0, 0})
+
+	// c_i = a_0 * b_i + a_i * b_0
+	for i := 1; i < len(a); i++ {
+		if a[i] != nil || b[i] != nil {
+			tensor(ct.Value[i], [2]int{0, i}, [2]int{i, 0})
+		}
+	}
+
+	// c_ij = a_i * b_j + a_j * b_i
+	for i := 1; i < len(a); i++ {
+		for j := i; j < len(a); j++ {
+			if (a[i] != nil && b[j] != nil) || (i != j && a[j] != nil && b[i] != nil) {
+				ct.Tensor[i-1][j-1] = ringQ.NewPoly()
+				if i == j {
+					tensor(ct.Tensor[i-1][j-1], [2]int{i, i})
+				} else {
+					tensor(ct.Tensor[i-1][j-1], [2]int{i, j}, [2]int{j, i})
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// modUpAndNTT returns the NTT of the non-nil polynomials of value in Q and QMul, in the Montgomery domain if mForm is true.
+func (eval *evaluator) modUpAndNTT(value []*ring.Poly, mForm bool) (pQ, pQMul []*ring.Poly) {
+
+	ringQ, ringQMul := eval.ringQ, eval.ringQMul
+	levelQ, levelQMul := len(ringQ.Modulus)-1, len(ringQMul.Modulus)-1
+
+	pQ, pQMul = make([]*ring.Poly, len(value)), make([]*ring.Poly, len(value))
+
+	for i, p := range value {
+		if p != nil {
+			pQ[i], pQMul[i] = ringQ.NewPoly(), ringQMul.NewPoly()
+			eval.baseconverterQ1Q2.ModUpQtoP(levelQ, levelQMul, p, pQMul[i])
+			ringQ.NTTLazy(p, pQ[i])
+			ringQMul.NTTLazy(pQMul[i], pQMul[i])
+			if mForm {
+				ringQ.MForm(pQ[i], pQ[i])
+				ringQMul.MForm(pQMul[i], pQMul[i])
+			}
+		}
+	}
+
+	return
+}
+
+// quantize computes round(t/Q * c) from c in the NTT domain of Q and QMul, and returns the result on pOut.
+func (eval *evaluator) quantize(cQ, cQMul, pOut *ring.Poly) {
+
+	ringQ, ringQMul := eval.ringQ, eval.ringQMul
+	levelQ, levelQMul := len(ringQ.Modulus)-1, len(ringQMul.Modulus)-1
+
+	ringQ.InvNTTLazy(cQ, cQ)
+	ringQMul.InvNTTLazy(cQMul, cQMul)
+
+	// Divides (c mod QQMul) by Q in QMul
+	eval.baseconverterQ1Q2.ModDownQPtoP(levelQ, levelQMul, cQ, cQMul, cQMul)
+
+	// Centers c/Q by (QMul-1)/2 and extends it to the basis Q
+	ringQMul.AddScalarBigint(cQMul, eval.pHalf, cQMul)
+	eval.baseconverterQ1Q2.ModUpPtoQ(levelQMul, levelQ, cQMul, pOut)
+	ringQ.SubScalarBigint(pOut, eval.pHalf, pOut)
+
+	ringQ.MulScalar(pOut, eval.params.T(), pOut)
+}
@@ -0,0 +1,217 @@
+package mkbfv
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/bfv"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+var flagLongTest = flag.Bool("long", false, "run the long test suite (all parameters). Overrides -short and requires -timeout=0.")
+var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
+
+func testString(opname string, params bfv.Parameters) string {
+	return fmt.Sprintf("%s/LogN=%d/logQ=%d/alpha=%d/beta=%d", opname, params.LogN(), params.LogQP(), params.PCount(), params.Beta())
+}
+
+type party struct {
+	id        string
+	sk        *rlwe.SecretKey
+	encryptor bfv.Encryptor
+}
+
+type testContext struct {
+	params    bfv.Parameters
+	encoder   bfv.Encoder
+	kgen      rlwe.KeyGenerator
+	mkKgen    *mkrlwe.KeyGenerator
+	crp       mkrlwe.CRP
+	evks      *mkrlwe.EvaluationKeySet
+	evaluator Evaluator
+	uSampler  *ring.UniformSampler
+}
+
+func TestMKBFV(t *testing.T) {
+
+	defaultParams := bfv.DefaultParams[:4]
+	if testing.Short() {
+		defaultParams = bfv.DefaultParams[:2]
+	}
+	if *flagLongTest {
+		defaultParams = append(bfv.DefaultParams, bfv.DefaultPostQuantumParams...)
+	}
+	if *flagParamString != "" {
+		var jsonParams bfv.ParametersLiteral
+		json.Unmarshal([]byte(*flagParamString), &jsonParams)
+		defaultParams = []bfv.ParametersLiteral{jsonParams}
+	}
+
+	for _, p := range defaultParams {
+
+		params, err := bfv.NewParametersFromLiteral(p)
+		if err != nil {
+			panic(err)
+		}
+
+		tc := genTestParams(params)
+
+		for _, testSet := range []func(tc *testContext, t *testing.T){
+			testAddSub,
+			testMulRelin,
+			testJoin,
+		} {
+			testSet(tc, t)
+			runtime.GC()
+		}
+	}
+}
+
+func genTestParams(params bfv.Parameters) (tc *testContext) {
+
+	tc = new(testContext)
+	tc.params = params
+	tc.encoder = bfv.NewEncoder(params)
+	tc.kgen = bfv.NewKeyGenerator(params)
+	tc.mkKgen = mkrlwe.NewKeyGenerator(params.Parameters)
+
+	crs, err := utils.NewKeyedPRNG([]byte{'t', 'e', 's', 't'})
+	if err != nil {
+		panic(err)
+	}
+	tc.crp = tc.mkKgen.SampleCRP(crs)
+
+	tc.evks = mkrlwe.NewEvaluationKeySet()
+	tc.evaluator = NewEvaluator(params, tc.evks)
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	tc.uSampler = ring.NewUniformSampler(prng, params.RingT())
+
+	return
+}
+
+// newParty generates the keys of a new party and adds its EvaluationKey to the set of the test context.
+func (tc *testContext) newParty(id string) *party {
+	sk, pk := tc.kgen.GenKeyPair()
+	tc.evks.Add(tc.mkKgen.GenEvaluationKey(id, sk, tc.crp))
+	return &party{id: id, sk: sk, encryptor: bfv.NewEncryptor(tc.params, pk)}
+}
+
+func (tc *testContext) newTestVectors(p *party) (coeffs *ring.Poly, ct *Ciphertext) {
+	coeffs = tc.uSampler.ReadNew()
+	pt := bfv.NewPlaintext(tc.params)
+	tc.encoder.EncodeUint(coeffs.Coeffs[0], pt)
+	return coeffs, NewCiphertextFromBFV(p.id, p.encryptor.EncryptNew(pt))
+}
+
+// decrypt runs the PartialDecryptionProtocol among the parties involved in ct and decodes the result.
+func (tc *testContext) decrypt(ct *Ciphertext, parties ...*party) []uint64 {
+
+	pdp := NewPartialDecryptionProtocol(tc.params, 6.36)
+
+	combined := pdp.AllocateShare(ct.Level())
+	share := pdp.AllocateShare(ct.Level())
+
+	for i, p := range parties {
+		if i == 0 {
+			pdp.GenShare(p.id, p.sk, ct, combined)
+		} else {
+			pdp.GenShare(p.id, p.sk, ct, share)
+			pdp.AggregateShares(combined, share, combined)
+		}
+	}
+
+	pt := bfv.NewPlaintext(tc.params)
+	pdp.DecryptBFV(combined, ct, pt)
+
+	return tc.encoder.DecodeUintNew(pt)
+}
+
+func testAddSub(tc *testContext, t *testing.T) {
+
+	ringT := tc.params.RingT()
+
+	alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+	coeffs0, ct0 := tc.newTestVectors(alice)
+	coeffs1, ct1 := tc.newTestVectors(bob)
+
+	t.Run(testString("MKBFV/Add", tc.params), func(t *testing.T) {
+		ct := tc.evaluator.AddNew(ct0, ct1)
+		require.Equal(t, []string{"alice", "bob"}, ct.IDs)
+		want := ringT.NewPoly()
+		ringT.Add(coeffs0, coeffs1, want)
+		require.Equal(t, want.Coeffs[0], tc.decrypt(ct, alice, bob))
+	})
+
+	t.Run(testString("MKBFV/Sub", tc.params), func(t *testing.T) {
+		ct := tc.evaluator.SubNew(ct0, ct1)
+		ct = tc.evaluator.SubNew(ct, tc.evaluator.NegNew(ct1))
+		require.Equal(t, coeffs0.Coeffs[0], tc.decrypt(ct, alice, bob))
+	})
+}
+
+func testMulRelin(tc *testContext, t *testing.T) {
+
+	ringT := tc.params.RingT()
+
+	alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+	coeffs0, ct0 := tc.newTestVectors(alice)
+	coeffs1, ct1 := tc.newTestVectors(bob)
+
+	t.Run(testString("MKBFV/MulRelin/Square", tc.params), func(t *testing.T) {
+		ct := tc.evaluator.MulRelinNew(ct0, ct0)
+		require.Equal(t, []string{"alice"}, ct.IDs)
+		want := ringT.NewPoly()
+		ringT.MulCoeffs(coeffs0, coeffs0, want)
+		require.Equal(t, want.Coeffs[0], tc.decrypt(ct, alice))
+	})
+
+	t.Run(testString("MKBFV/MulRelin/TwoParties", tc.params), func(t *testing.T) {
+		ct := tc.evaluator.MulRelinNew(ct0, ct1)
+		require.Equal(t, []string{"alice", "bob"}, ct.IDs)
+		want := ringT.NewPoly()
+		ringT.MulCoeffs(coeffs0, coeffs1, want)
+		require.Equal(t, want.Coeffs[0], tc.decrypt(ct, alice, bob))
+	})
+}
+
+func testJoin(tc *testContext, t *testing.T) {
+
+	ringT := tc.params.RingT()
+
+	t.Run(testString("MKBFV/Join", tc.params), func(t *testing.T) {
+
+		alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+		coeffs0, ct0 := tc.newTestVectors(alice)
+		coeffs1, ct1 := tc.newTestVectors(bob)
+
+		ct := tc.evaluator.AddNew(ct0, ct1)
+
+		// carol joins after the data of alice and bob has been uploaded and combined
+		carol := tc.newParty("carol")
+		coeffs2, ct2 := tc.newTestVectors(carol)
+
+		ct = tc.evaluator.MulRelinNew(ct, ct2)
+		require.Equal(t, []string{"alice", "bob", "carol"}, ct.IDs)
+
+		want := ringT.NewPoly()
+		ringT.Add(coeffs0, coeffs1, want)
+		ringT.MulCoeffs(want, coeffs2, want)
+
+		require.Equal(t, want.Coeffs[0], tc.decrypt(ct, carol, alice, bob))
+	})
+}
@@ -0,0 +1,30 @@
+// Package mkckks implements the multi-key variant of the CKKS scheme, in which each party encrypts under its own
+// public key and the ciphertexts grow in dimension as the computations combine the data of several parties.
+package mkckks
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+)
+
+// Ciphertext is a multi-key CKKS ciphertext.
+type Ciphertext struct {
+	*mkrlwe.Ciphertext
+	Scale float64
+}
+
+// NewCiphertext returns a new Ciphertext at the given level and scale, involving the parties ids.
+func NewCiphertext(params ckks.Parameters, ids []string, level int, scale float64) *Ciphertext {
+	return &Ciphertext{Ciphertext: mkrlwe.NewCiphertextNTT(params.Parameters, ids, level), Scale: scale}
+}
+
+// NewCiphertextFromCKKS returns the multi-key Ciphertext of the party id corresponding to the ciphertext ct,
+// encrypted under the public key of the party. The returned Ciphertext shares the backing polynomials of ct.
+func NewCiphertextFromCKKS(id string, ct *ckks.Ciphertext) *Ciphertext {
+	return &Ciphertext{Ciphertext: mkrlwe.NewCiphertextFromRLWE(id, ct.Ciphertext), Scale: ct.Scale}
+}
+
+// CopyNew returns a deep copy of the ciphertext.
+func (ct *Ciphertext) CopyNew() *Ciphertext {
+	return &Ciphertext{Ciphertext: ct.Ciphertext.CopyNew(), Scale: ct.Scale}
+}
@@ -0,0 +1,29 @@
+package mkckks
+
+import (
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// PartialDecryptionProtocol is a structure storing the parameters for the distributed decryption of multi-key ciphertexts.
+type PartialDecryptionProtocol struct {
+	mkrlwe.PartialDecryptionProtocol
+}
+
+// NewPartialDecryptionProtocol creates a new PartialDecryptionProtocol whose shares are smudged with a Gaussian
+// noise of standard deviation sigmaSmudging.
+func NewPartialDecryptionProtocol(params ckks.Parameters, sigmaSmudging float64) *PartialDecryptionProtocol {
+	return &PartialDecryptionProtocol{*mkrlwe.NewPartialDecryptionProtocol(params.Parameters, sigmaSmudging)}
+}
+
+// GenShare computes the share of the party id, of secret key sk, for the decryption of ct.
+func (pdp *PartialDecryptionProtocol) GenShare(id string, sk *rlwe.SecretKey, ct *Ciphertext, shareOut *mkrlwe.PartialDecryptionShare) {
+	pdp.PartialDecryptionProtocol.GenShare(id, sk, ct.Ciphertext, shareOut)
+}
+
+// DecryptCKKS recovers the plaintext of ct from the aggregation combined of the shares of all the parties involved in ct.
+func (pdp *PartialDecryptionProtocol) DecryptCKKS(combined *mkrlwe.PartialDecryptionShare, ct *Ciphertext, ptOut *ckks.Plaintext) {
+	pdp.PartialDecryptionProtocol.Decrypt(combined, ct.Ciphertext, ptOut.Plaintext)
+	ptOut.Scale = ct.Scale
+}
@@ -0,0 +1,207 @@
+package mkckks
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Evaluator is an interface implementing the homomorphic operations on multi-key CKKS ciphertexts.
+// The output ciphertexts of the binary operations involve the union of the parties of their operands.
+type Evaluator interface {
+	Add(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	Sub(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	Neg(ct *Ciphertext, ctOut *Ciphertext)
+	NegNew(ct *Ciphertext) (ctOut *Ciphertext)
+	MulRelin(ct0, ct1 *Ciphertext, ctOut *Ciphertext)
+	MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext)
+	Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error)
+	ShallowCopy() Evaluator
+}
+
+type evaluator struct {
+	*mkrlwe.Evaluator
+	params ckks.Parameters
+	pool   *ring.Poly
+}
+
+// NewEvaluator creates a new Evaluator, relinearizing with the EvaluationKeys of evks.
+func NewEvaluator(params ckks.Parameters, evks *mkrlwe.EvaluationKeySet) Evaluator {
+	return &evaluator{Evaluator: mkrlwe.NewEvaluator(params.Parameters, evks), params: params, pool: params.RingQ().NewPoly()}
+}
+
+// ShallowCopy creates a shallow copy of this evaluator in which the read-only data-structures are
+// shared with the receiver.
+func (eval *evaluator) ShallowCopy() Evaluator {
+	return &evaluator{Evaluator: eval.Evaluator.ShallowCopy(), params: eval.params, pool: eval.params.RingQ().NewPoly()}
+}
+
+// Add adds ct0 to ct1 and returns the result in ctOut.
+// The operands are expected to have the same scale, and ctOut takes the largest of them.
+func (eval *evaluator) Add(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Add(ct0.Ciphertext, ct1.Ciphertext, ctOut.Ciphertext)
+	ctOut.Scale = math.Max(ct0.Scale, ct1.Scale)
+}
+
+// AddNew adds ct0 to ct1 and returns the result in a new Ciphertext.
+func (eval *evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextForBinary(ct0, ct1)
+	eval.Add(ct0, ct1, ctOut)
+	return
+}
+
+// Sub subtracts ct1 from ct0 and returns the result in ctOut.
+// The operands are expected to have the same scale, and ctOut takes the largest of them.
+func (eval *evaluator) Sub(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Sub(ct0.Ciphertext, ct1.Ciphertext, ctOut.Ciphertext)
+	ctOut.Scale = math.Max(ct0.Scale, ct1.Scale)
+}
+
+// SubNew subtracts ct1 from ct0 and returns the result in a new Ciphertext.
+func (eval *evaluator) SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextForBinary(ct0, ct1)
+	eval.Sub(ct0, ct1, ctOut)
+	return
+}
+
+// Neg negates ct and returns the result in ctOut.
+func (eval *evaluator) Neg(ct *Ciphertext, ctOut *Ciphertext) {
+	eval.Evaluator.Neg(ct.Ciphertext, ctOut.Ciphertext)
+	ctOut.Scale = ct.Scale
+}
+
+// NegNew negates ct and returns the result in a new Ciphertext.
+func (eval *evaluator) NegNew(ct *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, ct.IDs, ct.Level(), ct.Scale)
+	eval.Neg(ct, ctOut)
+	return
+}
+
+// MulRelin multiplies ct0 by ct1, relinearizes the result with the EvaluationKeys of the parties and returns it in ctOut.
+// The scale of ctOut is the product of the scales of the operands, and the result is not rescaled.
+func (eval *evaluator) MulRelin(ct0, ct1 *Ciphertext, ctOut *Ciphertext) {
+	ct := eval.tensor(ct0, ct1)
+	eval.Relinearize(ct, ctOut.Ciphertext)
+	ctOut.Scale = ct0.Scale * ct1.Scale
+}
+
+// MulRelinNew multiplies ct0 by ct1, relinearizes the result and returns it in a new Ciphertext.
+func (eval *evaluator) MulRelinNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextForBinary(ct0, ct1)
+	eval.MulRelin(ct0, ct1, ctOut)
+	return
+}
+
+// Rescale divides ctIn by the last moduli of the moduli chain as long as the scale does not go below minScale,
+// and returns the result in ctOut, which must involve the same parties as ctIn.
+// Returns an error if minScale <= 0, if the scale of ctIn is 0, or if ctIn is at level 0.
+func (eval *evaluator) Rescale(ctIn *Ciphertext, minScale float64, ctOut *Ciphertext) (err error) {
+
+	ringQ := eval.params.RingQ()
+
+	if minScale <= 0 {
+		return errors.New("cannot Rescale: minScale is 0")
+	}
+
+	if ctIn.Scale == 0 {
+		return errors.New("cannot Rescale: ciphertext scale is 0")
+	}
+
+	if ctIn.Level() == 0 {
+		return errors.New("cannot Rescale: input Ciphertext already at level 0")
+	}
+
+	if len(ctIn.Value) != len(ctOut.Value) {
+		return errors.New("cannot Rescale: ctIn and ctOut do not involve the same parties")
+	}
+
+	level := ctIn.Level()
+	scale := ctIn.Scale
+
+	var nbRescales int
+	for level-nbRescales > 0 && scale/float64(ringQ.Modulus[level-nbRescales]) >= minScale/2 {
+		scale /= float64(ringQ.Modulus[level-nbRescales])
+		nbRescales++
+	}
+
+	if nbRescales > 0 {
+		for i := range ctOut.Value {
+			ringQ.DivRoundByLastModulusManyNTTLvl(level, nbRescales, ctIn.Value[i], eval.pool, ctOut.Value[i])
+			ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1-nbRescales]
+			ctOut.Value[i].IsNTT = true
+		}
+	} else {
+		ctOut.Ciphertext.Copy(ctIn.Ciphertext)
+	}
+
+	ctOut.Scale = scale
+
+	return nil
+}
+
+// tensor returns the degree two multi-key ciphertext ct0 x ct1, at the smallest level of the operands.
+func (eval *evaluator) tensor(ct0, ct1 *Ciphertext) (ct *mkrlwe.TensoredCiphertext) {
+
+	ringQ := eval.params.RingQ()
+
+	ids := mkrlwe.MergeIDs(ct0.IDs, ct1.IDs)
+	level := utils.MinInt(ct0.Level(), ct1.Level())
+
+	ct = mkrlwe.NewTensoredCiphertext(eval.params.Parameters, ids, level)
+
+	a, b := ct0.Components(ids), ct1.Components(ids)
+
+	// Montgomery form of the components of ct0
+	for i := range a {
+		if a[i] != nil {
+			tmp := ringQ.NewPolyLvl(level)
+			ringQ.MFormLvl(level, a[i], tmp)
+			a[i] = tmp
+		}
+	}
+
+	mulAndAdd := func(p0, p1, pOut *ring.Poly) {
+		if p0 != nil && p1 != nil {
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, p0, p1, pOut)
+		}
+	}
+
+	// c_0 = a_0 * b_0
+	mulAndAdd(a[0], b[0], ct.Value[0])
+
+	// c_i = a_0 * b_i + a_i * b_0
+	for i := 1; i < len(a); i++ {
+		mulAndAdd(a[0], b[i], ct.Value[i])
+		mulAndAdd(a[i], b[0], ct.Value[i])
+	}
+
+	// c_ij = a_i * b_j + a_j * b_i
+	for i := range ids {
+		for j := i; j < len(ids); j++ {
+			if (a[i+1] != nil && b[j+1] != nil) || (i != j && a[j+1] != nil && b[i+1] != nil) {
+				ct.Tensor[i][j] = ringQ.NewPolyLvl(level)
+				mulAndAdd(a[i+1], b[j+1], ct.Tensor[i][j])
+				if i != j {
+					mulAndAdd(a[j+1], b[i+1], ct.Tensor[i][j])
+				}
+				ct.Tensor[i][j].IsNTT = true
+			}
+		}
+	}
+
+	for i := range ct.Value {
+		ct.Value[i].IsNTT = true
+	}
+
+	return
+}
+
+func (eval *evaluator) newCiphertextForBinary(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	return NewCiphertext(eval.params, mkrlwe.MergeIDs(ct0.IDs, ct1.IDs), utils.MinInt(ct0.Level(), ct1.Level()), 0)
+}
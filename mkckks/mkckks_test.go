@@ -0,0 +1,288 @@
+package mkckks
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ckks"
+	"github.com/ldsec/lattigo/v2/mkrlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+var flagLongTest = flag.Bool("long", false, "run the long test suite (all parameters). Overrides -short and requires -timeout=0.")
+var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
+var printPrecisionStats = flag.Bool("print-precision", false, "print precision stats")
+var minPrec float64 = 15.0
+
+func testString(opname string, params ckks.Parameters) string {
+	return fmt.Sprintf("%s/RingType=%s/logN=%d/logSlots=%d/logQ=%d/levels=%d/alpha=%d/beta=%d",
+		opname,
+		params.RingType(),
+		params.LogN(),
+		params.LogSlots(),
+		params.LogQP(),
+		params.MaxLevel()+1,
+		params.PCount(),
+		params.Beta())
+}
+
+type party struct {
+	id        string
+	sk        *rlwe.SecretKey
+	encryptor ckks.Encryptor
+	evk       *mkrlwe.EvaluationKey
+}
+
+type testContext struct {
+	params    ckks.Parameters
+	encoder   ckks.Encoder
+	kgen      rlwe.KeyGenerator
+	mkKgen    *mkrlwe.KeyGenerator
+	crp       mkrlwe.CRP
+	evks      *mkrlwe.EvaluationKeySet
+	evaluator Evaluator
+}
+
+func TestMKCKKS(t *testing.T) {
+
+	var testParams []ckks.ParametersLiteral
+	switch {
+	case *flagParamString != "": // the custom test suite reads the parameters from the -params flag
+		testParams = append(testParams, ckks.ParametersLiteral{})
+		json.Unmarshal([]byte(*flagParamString), &testParams[0])
+	case *flagLongTest:
+		testParams = append(ckks.DefaultParams, ckks.DefaultConjugateInvariantParams...)
+	case testing.Short():
+		testParams = append(ckks.DefaultParams[:2], ckks.DefaultConjugateInvariantParams[:2]...)
+	default:
+		testParams = append(ckks.DefaultParams[:4], ckks.DefaultConjugateInvariantParams[:4]...)
+	}
+
+	for _, paramsLiteral := range testParams[:] {
+
+		params, err := ckks.NewParametersFromLiteral(paramsLiteral)
+		if err != nil {
+			panic(err)
+		}
+
+		tc := genTestParams(params)
+
+		for _, testSet := range []func(tc *testContext, t *testing.T){
+			testAddSub,
+			testMulRelin,
+			testJoin,
+			testMarshalling,
+		} {
+			testSet(tc, t)
+			runtime.GC()
+		}
+	}
+}
+
+func genTestParams(params ckks.Parameters) (tc *testContext) {
+
+	tc = new(testContext)
+	tc.params = params
+	tc.encoder = ckks.NewEncoder(params)
+	tc.kgen = ckks.NewKeyGenerator(params)
+	tc.mkKgen = mkrlwe.NewKeyGenerator(params.Parameters)
+
+	crs, err := utils.NewKeyedPRNG([]byte{'t', 'e', 's', 't'})
+	if err != nil {
+		panic(err)
+	}
+	tc.crp = tc.mkKgen.SampleCRP(crs)
+
+	tc.evks = mkrlwe.NewEvaluationKeySet()
+	tc.evaluator = NewEvaluator(params, tc.evks)
+
+	return
+}
+
+// newParty generates the keys of a new party and adds its EvaluationKey to the set of the test context.
+func (tc *testContext) newParty(id string) *party {
+	sk, pk := tc.kgen.GenKeyPair()
+	p := &party{id: id, sk: sk, encryptor: ckks.NewEncryptor(tc.params, pk)}
+	p.evk = tc.mkKgen.GenEvaluationKey(id, sk, tc.crp)
+	tc.evks.Add(p.evk)
+	return p
+}
+
+func (tc *testContext) newTestVectors(p *party, a, b complex128) (values []complex128, ct *Ciphertext) {
+
+	values = make([]complex128, tc.params.Slots())
+	for i := range values {
+		values[i] = complex(utils.RandFloat64(real(a), real(b)), utils.RandFloat64(imag(a), imag(b)))
+	}
+
+	if tc.params.RingType() == ring.ConjugateInvariant {
+		for i := range values {
+			values[i] = complex(real(values[i]), 0)
+		}
+	}
+
+	pt := tc.encoder.EncodeNew(values, tc.params.MaxLevel(), tc.params.DefaultScale(), tc.params.LogSlots())
+
+	return values, NewCiphertextFromCKKS(p.id, p.encryptor.EncryptNew(pt))
+}
+
+// decrypt runs the PartialDecryptionProtocol among the parties involved in ct.
+func (tc *testContext) decrypt(ct *Ciphertext, parties ...*party) *ckks.Plaintext {
+
+	pdp := NewPartialDecryptionProtocol(tc.params, 3.2)
+
+	combined := pdp.AllocateShare(ct.Level())
+	share := pdp.AllocateShare(ct.Level())
+
+	for i, p := range parties {
+		if i == 0 {
+			pdp.GenShare(p.id, p.sk, ct, combined)
+		} else {
+			pdp.GenShare(p.id, p.sk, ct, share)
+			pdp.AggregateShares(combined, share, combined)
+		}
+	}
+
+	pt := ckks.NewPlaintext(tc.params, ct.Level(), ct.Scale)
+	pdp.DecryptCKKS(combined, ct, pt)
+
+	return pt
+}
+
+func (tc *testContext) verifyTestVectors(valuesWant []complex128, pt *ckks.Plaintext, t *testing.T) {
+
+	precStats := ckks.GetPrecisionStats(tc.params, tc.encoder, nil, valuesWant, pt, tc.params.LogSlots(), 0)
+
+	if *printPrecisionStats {
+		t.Log(precStats.String())
+	}
+
+	require.GreaterOrEqual(t, precStats.MeanPrecision.Real, minPrec)
+	require.GreaterOrEqual(t, precStats.MeanPrecision.Imag, minPrec)
+}
+
+func testAddSub(tc *testContext, t *testing.T) {
+
+	alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+	values0, ct0 := tc.newTestVectors(alice, -1-1i, 1+1i)
+	values1, ct1 := tc.newTestVectors(bob, -1-1i, 1+1i)
+
+	t.Run(testString("MKCKKS/Add", tc.params), func(t *testing.T) {
+
+		ct := tc.evaluator.AddNew(ct0, ct1)
+		require.Equal(t, []string{"alice", "bob"}, ct.IDs)
+
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = values0[i] + values1[i]
+		}
+
+		tc.verifyTestVectors(want, tc.decrypt(ct, alice, bob), t)
+	})
+
+	t.Run(testString("MKCKKS/Sub", tc.params), func(t *testing.T) {
+
+		ct := tc.evaluator.SubNew(ct1, ct0)
+		ct = tc.evaluator.SubNew(ct, tc.evaluator.NegNew(ct0))
+
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = values1[i]
+		}
+
+		tc.verifyTestVectors(want, tc.decrypt(ct, alice, bob), t)
+	})
+}
+
+func testMulRelin(tc *testContext, t *testing.T) {
+
+	alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+	values0, ct0 := tc.newTestVectors(alice, -1-1i, 1+1i)
+	values1, ct1 := tc.newTestVectors(bob, -1-1i, 1+1i)
+
+	t.Run(testString("MKCKKS/MulRelin/Square", tc.params), func(t *testing.T) {
+
+		ct := tc.evaluator.MulRelinNew(ct0, ct0)
+		require.NoError(t, tc.evaluator.Rescale(ct, tc.params.DefaultScale(), ct))
+		require.Equal(t, []string{"alice"}, ct.IDs)
+		require.Equal(t, tc.params.MaxLevel()-1, ct.Level())
+
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = values0[i] * values0[i]
+		}
+
+		tc.verifyTestVectors(want, tc.decrypt(ct, alice), t)
+	})
+
+	t.Run(testString("MKCKKS/MulRelin/TwoParties", tc.params), func(t *testing.T) {
+
+		ct := tc.evaluator.MulRelinNew(ct0, ct1)
+		require.NoError(t, tc.evaluator.Rescale(ct, tc.params.DefaultScale(), ct))
+		require.Equal(t, []string{"alice", "bob"}, ct.IDs)
+
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = values0[i] * values1[i]
+		}
+
+		tc.verifyTestVectors(want, tc.decrypt(ct, alice, bob), t)
+	})
+}
+
+func testJoin(tc *testContext, t *testing.T) {
+
+	t.Run(testString("MKCKKS/Join", tc.params), func(t *testing.T) {
+
+		alice, bob := tc.newParty("alice"), tc.newParty("bob")
+
+		values0, ct0 := tc.newTestVectors(alice, -1-1i, 1+1i)
+		values1, ct1 := tc.newTestVectors(bob, -1-1i, 1+1i)
+
+		ct := tc.evaluator.AddNew(ct0, ct1)
+
+		// carol joins after the data of alice and bob has been uploaded and combined
+		carol := tc.newParty("carol")
+		values2, ct2 := tc.newTestVectors(carol, -1-1i, 1+1i)
+
+		ct = tc.evaluator.MulRelinNew(ct, ct2)
+		require.NoError(t, tc.evaluator.Rescale(ct, tc.params.DefaultScale(), ct))
+		require.Equal(t, []string{"alice", "bob", "carol"}, ct.IDs)
+
+		want := make([]complex128, len(values0))
+		for i := range want {
+			want[i] = (values0[i] + values1[i]) * values2[i]
+		}
+
+		tc.verifyTestVectors(want, tc.decrypt(ct, carol, alice, bob), t)
+	})
+}
+
+func testMarshalling(tc *testContext, t *testing.T) {
+
+	t.Run(testString("MKCKKS/Marshalling/PartialDecryptionShare", tc.params), func(t *testing.T) {
+
+		alice := tc.newParty("alice")
+		_, ct := tc.newTestVectors(alice, -1-1i, 1+1i)
+
+		pdp := NewPartialDecryptionProtocol(tc.params, 3.2)
+		share := pdp.AllocateShare(ct.Level())
+		pdp.GenShare(alice.id, alice.sk, ct, share)
+
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+
+		shareNew := new(mkrlwe.PartialDecryptionShare)
+		require.NoError(t, shareNew.UnmarshalBinary(data))
+		require.True(t, tc.params.RingQ().Equal(share.Value, shareNew.Value))
+	})
+}
@@ -0,0 +1,159 @@
+package mkrlwe
+
+import (
+	"sort"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Ciphertext is a multi-key RLWE ciphertext, decrypting under the concatenation of the secret keys of the parties
+// listed in IDs as Value[0] + sum_i Value[i+1] * s_{IDs[i]}.
+// The IDs are sorted, so that two ciphertexts involving the same parties have their components in the same order.
+type Ciphertext struct {
+	Value []*ring.Poly
+	IDs   []string
+}
+
+// NewCiphertext returns a new Ciphertext at the given level, with zero components for the parties ids.
+func NewCiphertext(params rlwe.Parameters, ids []string, level int) *Ciphertext {
+	ct := new(Ciphertext)
+	ct.IDs = MergeIDs(ids, nil)
+	ct.Value = make([]*ring.Poly, len(ct.IDs)+1)
+	for i := range ct.Value {
+		ct.Value[i] = ring.NewPoly(params.N(), level+1)
+	}
+	return ct
+}
+
+// NewCiphertextNTT returns a new Ciphertext as NewCiphertext, with its components flagged as being in the NTT domain.
+func NewCiphertextNTT(params rlwe.Parameters, ids []string, level int) *Ciphertext {
+	ct := NewCiphertext(params, ids, level)
+	for i := range ct.Value {
+		ct.Value[i].IsNTT = true
+	}
+	return ct
+}
+
+// NewCiphertextFromRLWE returns the multi-key Ciphertext of the party id corresponding to the degree one RLWE
+// ciphertext ct, for example a fresh encryption under the public key of the party.
+// The returned Ciphertext shares the backing polynomials of ct.
+func NewCiphertextFromRLWE(id string, ct *rlwe.Ciphertext) *Ciphertext {
+	if ct.Degree() != 1 {
+		panic("cannot NewCiphertextFromRLWE: input ciphertext must be of degree 1")
+	}
+	return &Ciphertext{Value: []*ring.Poly{ct.Value[0], ct.Value[1]}, IDs: []string{id}}
+}
+
+// Level returns the level of the ciphertext.
+func (ct *Ciphertext) Level() int {
+	return ct.Value[0].Level()
+}
+
+// Index returns the index in ct.Value of the component of the party id, and false if the party is not involved
+// in the ciphertext.
+func (ct *Ciphertext) Index(id string) (index int, ok bool) {
+	i := sort.SearchStrings(ct.IDs, id)
+	if i < len(ct.IDs) && ct.IDs[i] == id {
+		return i + 1, true
+	}
+	return 0, false
+}
+
+// Copy copies the value of ctIn on the receiver, which must involve the same parties.
+func (ct *Ciphertext) Copy(ctIn *Ciphertext) {
+	if ct != ctIn {
+		if !equalIDs(ct.IDs, ctIn.IDs) {
+			panic("cannot Copy: ciphertexts do not involve the same parties")
+		}
+		for i := range ctIn.Value {
+			ct.Value[i].Copy(ctIn.Value[i])
+		}
+	}
+}
+
+// CopyNew returns a deep copy of the ciphertext.
+func (ct *Ciphertext) CopyNew() *Ciphertext {
+	ctOut := &Ciphertext{Value: make([]*ring.Poly, len(ct.Value)), IDs: append([]string{}, ct.IDs...)}
+	for i := range ct.Value {
+		ctOut.Value[i] = ct.Value[i].CopyNew()
+	}
+	return ctOut
+}
+
+// MergeIDs returns the sorted union of the party identifiers ids0 and ids1, without duplicates.
+func MergeIDs(ids0, ids1 []string) (ids []string) {
+
+	set := make(map[string]bool, len(ids0)+len(ids1))
+	for _, id := range append(append([]string{}, ids0...), ids1...) {
+		if !set[id] {
+			set[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return
+}
+
+// Components returns the components of ct in the order of ids, which must include ct.IDs, the first one being
+// the constant component. The components of the parties of ids that are not involved in ct are nil.
+func (ct *Ciphertext) Components(ids []string) (value []*ring.Poly) {
+	value = make([]*ring.Poly, len(ids)+1)
+	value[0] = ct.Value[0]
+	for i, id := range ids {
+		if j, ok := ct.Index(id); ok {
+			value[i+1] = ct.Value[j]
+		}
+	}
+	return
+}
+
+func equalIDs(ids0, ids1 []string) bool {
+	if len(ids0) != len(ids1) {
+		return false
+	}
+	for i := range ids0 {
+		if ids0[i] != ids1[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// minLevel returns the smallest level of the non-nil polynomials.
+func minLevel(polys ...*ring.Poly) (level int) {
+	level = -1
+	for _, p := range polys {
+		if p != nil && (level == -1 || p.Level() < level) {
+			level = p.Level()
+		}
+	}
+	return
+}
+
+// TensoredCiphertext is a multi-key ciphertext of degree two, as returned by the tensoring of two Ciphertexts,
+// which decrypts as Value[0] + sum_i Value[i+1] * s_{IDs[i]} + sum_{i <= j} Tensor[i][j] * s_{IDs[i]} * s_{IDs[j]}.
+// The entries Tensor[i][j] with j < i are not used, and nil entries stand for zero components.
+type TensoredCiphertext struct {
+	Value  []*ring.Poly
+	Tensor [][]*ring.Poly
+	IDs    []string
+}
+
+// NewTensoredCiphertext returns a new TensoredCiphertext at the given level for the parties ids, whose quadratic
+// components are all nil.
+func NewTensoredCiphertext(params rlwe.Parameters, ids []string, level int) *TensoredCiphertext {
+	ct := NewCiphertext(params, ids, level)
+	tensor := make([][]*ring.Poly, len(ct.IDs))
+	for i := range tensor {
+		tensor[i] = make([]*ring.Poly, len(ct.IDs))
+	}
+	return &TensoredCiphertext{Value: ct.Value, Tensor: tensor, IDs: ct.IDs}
+}
+
+// Level returns the level of the ciphertext.
+func (ct *TensoredCiphertext) Level() int {
+	return ct.Value[0].Level()
+}
@@ -0,0 +1,108 @@
+package mkrlwe
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// PartialDecryptionProtocol is the structure storing the parameters and the precomputations of the distributed
+// decryption of multi-key ciphertexts. Each party involved in a ciphertext computes the partial decryption
+// c_i * s_i + e_i of its component c_i with its secret key s_i and a smudging noise e_i. The plaintext is then
+// recovered by adding the aggregated shares of all the parties to the first component of the ciphertext.
+type PartialDecryptionProtocol struct {
+	params          rlwe.Parameters
+	gaussianSampler *ring.GaussianSampler
+	tmp             *ring.Poly
+}
+
+// PartialDecryptionShare is a type for the shares of the PartialDecryptionProtocol.
+type PartialDecryptionShare struct {
+	Value *ring.Poly
+}
+
+// MarshalBinary encodes a PartialDecryptionShare on a slice of bytes.
+func (share *PartialDecryptionShare) MarshalBinary() (data []byte, err error) {
+	return share.Value.MarshalBinary()
+}
+
+// UnmarshalBinary decodes a marshaled PartialDecryptionShare on the target share.
+func (share *PartialDecryptionShare) UnmarshalBinary(data []byte) (err error) {
+	share.Value = new(ring.Poly)
+	return share.Value.UnmarshalBinary(data)
+}
+
+// NewPartialDecryptionProtocol creates a new PartialDecryptionProtocol whose shares are smudged with a Gaussian
+// noise of standard deviation sigmaSmudging.
+func NewPartialDecryptionProtocol(params rlwe.Parameters, sigmaSmudging float64) *PartialDecryptionProtocol {
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+	return &PartialDecryptionProtocol{
+		params:          params,
+		gaussianSampler: ring.NewGaussianSampler(prng, params.RingQ(), sigmaSmudging, int(6*sigmaSmudging)),
+		tmp:             params.RingQ().NewPoly(),
+	}
+}
+
+// AllocateShare allocates a share of the PartialDecryptionProtocol at the given level.
+func (pdp *PartialDecryptionProtocol) AllocateShare(level int) *PartialDecryptionShare {
+	return &PartialDecryptionShare{pdp.params.RingQ().NewPolyLvl(level)}
+}
+
+// GenShare computes the share of the party id, of secret key sk, for the decryption of ct.
+// The share is in the same domain as ct. The method panics if the party is not involved in ct.
+func (pdp *PartialDecryptionProtocol) GenShare(id string, sk *rlwe.SecretKey, ct *Ciphertext, shareOut *PartialDecryptionShare) {
+
+	i, ok := ct.Index(id)
+	if !ok {
+		panic(fmt.Sprintf("cannot GenShare: party %s is not involved in the ciphertext", id))
+	}
+
+	ringQ := pdp.params.RingQ()
+
+	level := utils.MinInt(shareOut.Value.Level(), ct.Level())
+
+	ci := ct.Value[i]
+	if !ci.IsNTT {
+		ringQ.NTTLvl(level, ci, pdp.tmp)
+		ci = pdp.tmp
+	}
+
+	// c_i * s_i
+	ringQ.MulCoeffsMontgomeryLvl(level, ci, sk.Value.Q, shareOut.Value)
+
+	// e_i
+	pdp.gaussianSampler.ReadLvl(level, pdp.tmp)
+
+	if ct.Value[i].IsNTT {
+		ringQ.NTTLvl(level, pdp.tmp, pdp.tmp)
+	} else {
+		ringQ.InvNTTLvl(level, shareOut.Value, shareOut.Value)
+	}
+
+	ringQ.AddLvl(level, shareOut.Value, pdp.tmp, shareOut.Value)
+
+	shareOut.Value.Coeffs = shareOut.Value.Coeffs[:level+1]
+	shareOut.Value.IsNTT = ct.Value[i].IsNTT
+}
+
+// AggregateShares aggregates two shares of the PartialDecryptionProtocol.
+func (pdp *PartialDecryptionProtocol) AggregateShares(share1, share2, shareOut *PartialDecryptionShare) {
+	level := utils.MinInt(share1.Value.Level(), share2.Value.Level())
+	pdp.params.RingQ().AddLvl(level, share1.Value, share2.Value, shareOut.Value)
+	shareOut.Value.Coeffs = shareOut.Value.Coeffs[:level+1]
+	shareOut.Value.IsNTT = share1.Value.IsNTT
+}
+
+// Decrypt recovers the plaintext of ct from the aggregation combined of the shares of all the parties involved in ct,
+// and returns it in ptOut, in the same domain as ct.
+func (pdp *PartialDecryptionProtocol) Decrypt(combined *PartialDecryptionShare, ct *Ciphertext, ptOut *rlwe.Plaintext) {
+	level := utils.MinInt(utils.MinInt(combined.Value.Level(), ct.Level()), ptOut.Level())
+	pdp.params.RingQ().AddLvl(level, ct.Value[0], combined.Value, ptOut.Value)
+	ptOut.Value.Coeffs = ptOut.Value.Coeffs[:level+1]
+	ptOut.Value.IsNTT = ct.Value[0].IsNTT
+}
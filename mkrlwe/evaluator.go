@@ -0,0 +1,178 @@
+package mkrlwe
+
+import (
+	"fmt"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Evaluator is a struct for the scheme-agnostic operations on multi-key ciphertexts: the additions, which merge the
+// parties of their operands, and the relinearization of TensoredCiphertexts with the EvaluationKeys of the parties.
+type Evaluator struct {
+	params rlwe.Parameters
+	ks     *rlwe.KeySwitcher
+	evks   *EvaluationKeySet
+	swk    *rlwe.SwitchingKey
+	pool   [4]*ring.Poly
+}
+
+// NewEvaluator creates a new Evaluator relinearizing with the keys of evks.
+// Keys added to evks after the creation of the Evaluator, for example by parties joining the computation,
+// are used by the Evaluator.
+func NewEvaluator(params rlwe.Parameters, evks *EvaluationKeySet) *Evaluator {
+
+	if params.PCount() == 0 {
+		panic("cannot NewEvaluator: modulus P is empty")
+	}
+
+	eval := &Evaluator{params: params, ks: rlwe.NewKeySwitcher(params), evks: evks}
+	eval.allocate()
+	return eval
+}
+
+// ShallowCopy creates a shallow copy of the Evaluator in which all the read-only data-structures are
+// shared with the receiver and the temporary buffers are reallocated. The receiver and the returned
+// Evaluator can be used concurrently.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	evalOut := &Evaluator{params: eval.params, ks: eval.ks.ShallowCopy(), evks: eval.evks}
+	evalOut.allocate()
+	return evalOut
+}
+
+func (eval *Evaluator) allocate() {
+	levelQ, levelP := eval.params.QCount()-1, eval.params.PCount()-1
+	eval.swk = &rlwe.SwitchingKey{Value: make([][2]rlwe.PolyQP, eval.params.DecompCount(levelQ, levelP))}
+	for i := range eval.pool {
+		eval.pool[i] = eval.params.RingQ().NewPoly()
+	}
+}
+
+// Add adds ct0 to ct1 and returns the result in ctOut, which must involve the union of the parties of ct0 and ct1.
+// The components of the parties involved in only one of the operands are copied from this operand.
+func (eval *Evaluator) Add(ct0, ct1, ctOut *Ciphertext) {
+	eval.evaluateBinary(ct0, ct1, ctOut, eval.params.RingQ().AddLvl, ring.CopyValuesLvl)
+}
+
+// AddNew adds ct0 to ct1 and returns the result in a new Ciphertext.
+func (eval *Evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextForBinary(ct0, ct1)
+	eval.Add(ct0, ct1, ctOut)
+	return
+}
+
+// Sub subtracts ct1 from ct0 and returns the result in ctOut, which must involve the union of the parties of ct0 and ct1.
+func (eval *Evaluator) Sub(ct0, ct1, ctOut *Ciphertext) {
+	eval.evaluateBinary(ct0, ct1, ctOut, eval.params.RingQ().SubLvl, eval.params.RingQ().NegLvl)
+}
+
+// SubNew subtracts ct1 from ct0 and returns the result in a new Ciphertext.
+func (eval *Evaluator) SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = eval.newCiphertextForBinary(ct0, ct1)
+	eval.Sub(ct0, ct1, ctOut)
+	return
+}
+
+// Neg negates ct and returns the result in ctOut, which must involve the same parties as ct.
+func (eval *Evaluator) Neg(ct, ctOut *Ciphertext) {
+
+	if !equalIDs(ct.IDs, ctOut.IDs) {
+		panic("cannot Neg: ctOut must involve the same parties as the input")
+	}
+
+	level := minLevel(ct.Value[0], ctOut.Value[0])
+	for i := range ct.Value {
+		eval.params.RingQ().NegLvl(level, ct.Value[i], ctOut.Value[i])
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+		ctOut.Value[i].IsNTT = ct.Value[i].IsNTT
+	}
+}
+
+// Relinearize relinearizes the TensoredCiphertext ct into the Ciphertext ctOut, which must involve the same parties.
+// The quadratic component of each pair of parties (i, j) is key-switched with the EvaluationKeys of i and j,
+// which must be in the EvaluationKeySet of the Evaluator.
+func (eval *Evaluator) Relinearize(ct *TensoredCiphertext, ctOut *Ciphertext) {
+
+	if !equalIDs(ct.IDs, ctOut.IDs) {
+		panic("cannot Relinearize: ctOut must involve the same parties as the input")
+	}
+
+	ringQ := eval.params.RingQ()
+
+	level := minLevel(ct.Value[0], ctOut.Value[0])
+
+	evks := make([]*EvaluationKey, len(ct.IDs))
+	for i, id := range ct.IDs {
+		var ok bool
+		if evks[i], ok = eval.evks.GetEvaluationKey(id); !ok {
+			panic(fmt.Sprintf("cannot Relinearize: missing evaluation key for party %s", id))
+		}
+	}
+
+	for i := range ct.Value {
+		ring.CopyValuesLvl(level, ct.Value[i], ctOut.Value[i])
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+		ctOut.Value[i].IsNTT = ct.Value[i].IsNTT
+	}
+
+	c, c0, c1 := eval.pool[0], eval.pool[1], eval.pool[2]
+
+	for i := range ct.IDs {
+		for j := i; j < len(ct.IDs); j++ {
+
+			if ct.Tensor[i][j] == nil {
+				continue
+			}
+
+			// (<g^-1(c_ij), b_j>, <g^-1(c_ij), v_i>) ~ (-c_ij*a*s_j, c_ij*(a*r_i + s_i))
+			for k := range eval.swk.Value {
+				eval.swk.Value[k] = [2]rlwe.PolyQP{evks[j].B[k], evks[i].V[k]}
+			}
+
+			eval.ks.SwitchKeysInPlace(level, ct.Tensor[i][j], eval.swk, c, c1)
+			ringQ.AddLvl(level, ctOut.Value[j+1], c1, ctOut.Value[j+1])
+
+			// <g^-1(c), d_i> ~ (c*r_i - c'*s_i, c')
+			c.IsNTT = ct.Tensor[i][j].IsNTT
+			eval.ks.SwitchKeysInPlace(level, c, evks[i].D, c0, c1)
+			ringQ.AddLvl(level, ctOut.Value[0], c0, ctOut.Value[0])
+			ringQ.AddLvl(level, ctOut.Value[i+1], c1, ctOut.Value[i+1])
+		}
+	}
+}
+
+// evaluateBinary applies evaluate on the components of ct0 and ct1 of the parties involved in both operands.
+// The components involved only in ct0 are copied, and single is applied on the components involved only in ct1.
+func (eval *Evaluator) evaluateBinary(ct0, ct1, ctOut *Ciphertext, evaluate func(int, *ring.Poly, *ring.Poly, *ring.Poly), single func(int, *ring.Poly, *ring.Poly)) {
+
+	ids := MergeIDs(ct0.IDs, ct1.IDs)
+
+	if !equalIDs(ids, ctOut.IDs) {
+		panic("cannot evaluate: ctOut must involve the union of the parties of the operands")
+	}
+
+	level := minLevel(ct0.Value[0], ct1.Value[0], ctOut.Value[0])
+
+	value0, value1 := ct0.Components(ids), ct1.Components(ids)
+
+	for i := range ctOut.Value {
+		switch {
+		case value0[i] != nil && value1[i] != nil:
+			evaluate(level, value0[i], value1[i], ctOut.Value[i])
+		case value0[i] != nil:
+			ring.CopyValuesLvl(level, value0[i], ctOut.Value[i])
+		default:
+			single(level, value1[i], ctOut.Value[i])
+		}
+		ctOut.Value[i].Coeffs = ctOut.Value[i].Coeffs[:level+1]
+		ctOut.Value[i].IsNTT = ct0.Value[0].IsNTT
+	}
+}
+
+func (eval *Evaluator) newCiphertextForBinary(ct0, ct1 *Ciphertext) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(eval.params, MergeIDs(ct0.IDs, ct1.IDs), minLevel(ct0.Value[0], ct1.Value[0]))
+	for i := range ctOut.Value {
+		ctOut.Value[i].IsNTT = ct0.Value[0].IsNTT
+	}
+	return
+}
@@ -0,0 +1,196 @@
+package mkrlwe
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/drlwe"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// CRP is a type for the common reference polynomials a shared by the evaluation keys of all the parties.
+type CRP []rlwe.PolyQP
+
+// EvaluationKey is the public evaluation key of a party, used for the relinearization of the multi-key
+// ciphertexts involving the party (Chen, Dai, Kim and Song, "Efficient Multi-Key Homomorphic Encryption with
+// Packed Ciphertexts with Application to Oblivious Neural Network Inference", CCS 2019).
+//
+// Given the common reference polynomials a, the secret key s of the party, an ephemeral secret r and the gadget
+// vector g of the key-switching decomposition, it stores:
+//
+// B = -s*a + e, which is a public key of the party sampled with the common a,
+//
+// D = (-s*d + e + r*g, d), the encryption of r*g under s with a fresh uniform d,
+//
+// V = r*a + e + s*g.
+type EvaluationKey struct {
+	ID string
+	B  []rlwe.PolyQP
+	D  *rlwe.SwitchingKey
+	V  []rlwe.PolyQP
+}
+
+// EvaluationKeySet is a set of EvaluationKeys indexed by the identifier of their party.
+type EvaluationKeySet struct {
+	Keys map[string]*EvaluationKey
+}
+
+// NewEvaluationKeySet returns a new EvaluationKeySet storing the given keys.
+func NewEvaluationKeySet(keys ...*EvaluationKey) (evks *EvaluationKeySet) {
+	evks = &EvaluationKeySet{Keys: make(map[string]*EvaluationKey, len(keys))}
+	for _, evk := range keys {
+		evks.Add(evk)
+	}
+	return
+}
+
+// Add adds the key evk to the set, for example when a new party joins the computation.
+func (evks *EvaluationKeySet) Add(evk *EvaluationKey) {
+	evks.Keys[evk.ID] = evk
+}
+
+// GetEvaluationKey returns the EvaluationKey of the party id, and false if it is not in the set.
+func (evks *EvaluationKeySet) GetEvaluationKey(id string) (evk *EvaluationKey, ok bool) {
+	evk, ok = evks.Keys[id]
+	return
+}
+
+// KeyGenerator is a structure that stores the elements required to create the EvaluationKey of a party.
+type KeyGenerator struct {
+	params           rlwe.Parameters
+	kgen             rlwe.KeyGenerator
+	gaussianSamplerQ *ring.GaussianSampler
+	pBigInt          *big.Int
+	tmpQ             *ring.Poly
+}
+
+// NewKeyGenerator creates a new KeyGenerator for the EvaluationKeys of the parties.
+func NewKeyGenerator(params rlwe.Parameters) *KeyGenerator {
+
+	if params.PCount() == 0 {
+		panic("cannot NewKeyGenerator: modulus P is empty")
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	return &KeyGenerator{
+		params:           params,
+		kgen:             rlwe.NewKeyGenerator(params),
+		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		pBigInt:          params.RingP().ModulusBigint,
+		tmpQ:             params.RingQ().NewPoly(),
+	}
+}
+
+// SampleCRP samples the common reference polynomials of the EvaluationKeys from the provided common reference string.
+// All the parties must generate their EvaluationKey with the same CRP.
+func (kg *KeyGenerator) SampleCRP(crs drlwe.CRS) CRP {
+	levelQ, levelP := kg.params.QCount()-1, kg.params.PCount()-1
+	crp := make([]rlwe.PolyQP, kg.params.DecompCount(levelQ, levelP))
+	us := rlwe.NewUniformSamplerQP(kg.params, crs, kg.params.RingQP())
+	for i := range crp {
+		crp[i] = kg.params.RingQP().NewPoly()
+		us.Read(&crp[i])
+	}
+	return CRP(crp)
+}
+
+// GenEvaluationKey generates the EvaluationKey of the party id from its secret key and the common reference polynomials.
+func (kg *KeyGenerator) GenEvaluationKey(id string, sk *rlwe.SecretKey, crp CRP) (evk *EvaluationKey) {
+
+	ringQP := kg.params.RingQP()
+	levelQ, levelP := kg.params.QCount()-1, kg.params.PCount()-1
+
+	r := kg.kgen.GenSecretKey()
+
+	evk = &EvaluationKey{ID: id}
+
+	// D = (-s*d + e + r*g, d)
+	evk.D = kg.kgen.GenSwitchingKey(r, sk)
+
+	// B = -s*a + e
+	evk.B = kg.genGadgetCiphertext(nil, crp)
+	for i := range crp {
+		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, sk.Value, crp[i], evk.B[i])
+	}
+
+	// V = r*a + e + s*g
+	evk.V = kg.genGadgetCiphertext(sk.Value.Q, crp)
+	for i := range crp {
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, r.Value, crp[i], evk.V[i])
+	}
+
+	for i := range crp {
+		ringQP.MFormLvl(levelQ, levelP, evk.B[i], evk.B[i])
+		ringQP.MFormLvl(levelQ, levelP, evk.V[i], evk.V[i])
+	}
+
+	return
+}
+
+// genGadgetCiphertext returns the vector e + sk*g in the NTT domain, with a fresh error e for each element of the
+// gadget vector g = P * w_i * 2^(j*Pow2Base), where w_i is the i-th element of the CRT decomposition of Q.
+// If sk is nil, the vector of errors is returned. sk is expected in the NTT and Montgomery domain.
+func (kg *KeyGenerator) genGadgetCiphertext(sk *ring.Poly, crp CRP) (ct []rlwe.PolyQP) {
+
+	ringQ := kg.params.RingQ()
+	ringQP := kg.params.RingQP()
+	levelQ, levelP := kg.params.QCount()-1, kg.params.PCount()-1
+
+	if sk != nil {
+		ringQ.MulScalarBigint(sk, kg.pBigInt, kg.tmpQ)
+		ringQ.InvMForm(kg.tmpQ, kg.tmpQ)
+	}
+
+	alpha := kg.params.DecompAlpha(levelP)
+	decompRNS := kg.params.DecompRNS(levelQ, levelP)
+	decompPw2 := kg.params.DecompPw2(levelQ, levelP)
+
+	ct = make([]rlwe.PolyQP, len(crp))
+
+	for j := 0; j < decompPw2; j++ {
+		for i := 0; i < decompRNS; i++ {
+
+			idx := i*decompPw2 + j
+
+			// e
+			ct[idx] = ringQP.NewPoly()
+			kg.gaussianSamplerQ.Read(ct[idx].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(ct[idx].Q, levelP, nil, ct[idx].P)
+			ringQP.NTTLvl(levelQ, levelP, ct[idx], ct[idx])
+
+			if sk == nil {
+				continue
+			}
+
+			// e + sk * P * w_i * 2^(j*Pow2Base)
+			for k := 0; k < alpha; k++ {
+
+				index := i*alpha + k
+
+				// Handles the case where nb pj does not divides nb qi
+				if index >= kg.params.QCount() {
+					break
+				}
+
+				qi := ringQ.Modulus[index]
+				skP := kg.tmpQ.Coeffs[index]
+				h := ct[idx].Q.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					h[w] = ring.CRed(h[w]+skP[w], qi)
+				}
+			}
+		}
+
+		if pow2Base := kg.params.Pow2Base(); pow2Base != 0 && sk != nil {
+			ringQ.MulScalar(kg.tmpQ, 1<<pow2Base, kg.tmpQ)
+		}
+	}
+
+	return
+}
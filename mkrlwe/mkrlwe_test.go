@@ -0,0 +1,38 @@
+package mkrlwe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+func TestCiphertext(t *testing.T) {
+
+	params, err := rlwe.NewParametersFromLiteral(rlwe.TestPN12QP109)
+	require.NoError(t, err)
+
+	t.Run("MergeIDs", func(t *testing.T) {
+		require.Equal(t, []string{"alice", "bob", "carol"}, MergeIDs([]string{"carol", "alice"}, []string{"bob", "alice"}))
+		require.Nil(t, MergeIDs(nil, nil))
+	})
+
+	t.Run("Components", func(t *testing.T) {
+
+		ct := NewCiphertext(params, []string{"carol", "alice"}, params.MaxLevel())
+		require.Equal(t, []string{"alice", "carol"}, ct.IDs)
+		require.Len(t, ct.Value, 3)
+
+		index, ok := ct.Index("carol")
+		require.True(t, ok)
+		require.Equal(t, 2, index)
+
+		_, ok = ct.Index("bob")
+		require.False(t, ok)
+
+		value := ct.Components([]string{"alice", "bob", "carol"})
+		require.Equal(t, []*ring.Poly{ct.Value[0], ct.Value[1], nil, ct.Value[2]}, value)
+	})
+}
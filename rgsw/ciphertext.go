@@ -0,0 +1,124 @@
+// Package rgsw implements RGSW ciphertexts and the external product between RLWE and RGSW ciphertexts,
+// built on the RNS (and optional base-2^Pow2Base) gadget decomposition of the rlwe key-switching.
+package rgsw
+
+import (
+	"io"
+
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+const ciphertextTag = "RGSW"
+
+// Ciphertext is a generic type for RGSW ciphertexts. An RGSW encryption of m under the secret key s is the pair of
+// gadget encryptions of m and of m*s, each stored in the format of an rlwe.SwitchingKey:
+// Value[k].Value[i] = (-a_i*s + e_i + m*s^k*P*g_i, a_i) mod QP, where g_i is the i-th element of the gadget vector.
+type Ciphertext struct {
+	Value [2]*rlwe.SwitchingKey
+}
+
+// NewCiphertext allocates a new RGSW Ciphertext with zero values at the given levels.
+func NewCiphertext(params rlwe.Parameters, levelQ, levelP int) (ct *Ciphertext) {
+	return &Ciphertext{Value: [2]*rlwe.SwitchingKey{
+		rlwe.NewSwitchingKey(params, levelQ, levelP),
+		rlwe.NewSwitchingKey(params, levelQ, levelP),
+	}}
+}
+
+// LevelQ returns the level of the modulus Q of the ciphertext.
+func (ct *Ciphertext) LevelQ() int {
+	return ct.Value[0].Value[0][0].Q.Level()
+}
+
+// LevelP returns the level of the modulus P of the ciphertext.
+func (ct *Ciphertext) LevelP() int {
+	return ct.Value[0].Value[0][0].P.Level()
+}
+
+// Equals checks two Ciphertexts for equality.
+func (ct *Ciphertext) Equals(other *Ciphertext) bool {
+	if ct == other {
+		return true
+	}
+	return ct.Value[0].Equals(other.Value[0]) && ct.Value[1].Equals(other.Value[1])
+}
+
+// CopyNew creates a deep copy of the receiver Ciphertext and returns it.
+func (ct *Ciphertext) CopyNew() *Ciphertext {
+	return &Ciphertext{Value: [2]*rlwe.SwitchingKey{ct.Value[0].CopyNew(), ct.Value[1].CopyNew()}}
+}
+
+// GetDataLen returns the length in bytes of the target Ciphertext.
+func (ct *Ciphertext) GetDataLen(WithMetadata bool) (dataLen int) {
+	return ct.Value[0].GetDataLen(WithMetadata) + ct.Value[1].GetDataLen(WithMetadata)
+}
+
+// MarshalBinary encodes a Ciphertext in a byte slice.
+func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
+
+	var data0, data1 []byte
+
+	if data0, err = ct.Value[0].MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	if data1, err = ct.Value[1].MarshalBinary(); err != nil {
+		return nil, err
+	}
+
+	return append(data0, data1...), nil
+}
+
+// UnmarshalBinary decodes a previously marshaled Ciphertext in the target Ciphertext.
+func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+
+	ct.Value[0], ct.Value[1] = new(rlwe.SwitchingKey), new(rlwe.SwitchingKey)
+
+	if err = ct.Value[0].UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return ct.Value[1].UnmarshalBinary(data[ct.Value[0].GetDataLen(true):])
+}
+
+// WriteTo writes the target Ciphertext on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (ct *Ciphertext) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, ciphertextTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for _, swk := range ct.Value {
+		inc, err = swk.WriteTo(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a Ciphertext written by WriteTo from r on the target Ciphertext.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (ct *Ciphertext) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, ciphertextTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for i := range ct.Value {
+		if ct.Value[i] == nil {
+			ct.Value[i] = new(rlwe.SwitchingKey)
+		}
+		inc, err = ct.Value[i].ReadFrom(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,130 @@
+package rgsw
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Encryptor is a type for the secret-key encryption of RGSW ciphertexts.
+type Encryptor struct {
+	params rlwe.Parameters
+	sk     *rlwe.SecretKey
+
+	gaussianSamplerQ *ring.GaussianSampler
+	uniformSamplerQ  *ring.UniformSampler
+	uniformSamplerP  *ring.UniformSampler
+
+	poolQ *ring.Poly
+}
+
+// NewEncryptor creates a new Encryptor of RGSW ciphertexts under the secret key sk.
+func NewEncryptor(params rlwe.Parameters, sk *rlwe.SecretKey) *Encryptor {
+
+	if params.PCount() == 0 {
+		panic("cannot NewEncryptor: modulus P is empty")
+	}
+
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		panic(err)
+	}
+
+	return &Encryptor{
+		params:           params,
+		sk:               sk,
+		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
+		uniformSamplerQ:  ring.NewUniformSampler(prng, params.RingQ()),
+		uniformSamplerP:  ring.NewUniformSampler(prng, params.RingP()),
+		poolQ:            params.RingQ().NewPoly(),
+	}
+}
+
+// Encrypt encrypts the plaintext pt on the RGSW ciphertext ct, at the levels of ct.
+// The IsNTT flag of pt must reflect the domain of its value. If pt is nil, ct is an encryption of zero.
+func (enc *Encryptor) Encrypt(pt *rlwe.Plaintext, ct *Ciphertext) {
+
+	ringQ := enc.params.RingQ()
+
+	levelQ, levelP := ct.LevelQ(), ct.LevelP()
+
+	for _, swk := range ct.Value {
+		for i := range swk.Value {
+			enc.encryptZero(levelQ, levelP, swk.Value[i])
+		}
+	}
+
+	if pt == nil {
+		return
+	}
+
+	// m * P in the NTT and Montgomery domain
+	if pt.Value.IsNTT {
+		ring.CopyValuesLvl(levelQ, pt.Value, enc.poolQ)
+	} else {
+		ringQ.NTTLvl(levelQ, pt.Value, enc.poolQ)
+	}
+	ringQ.MFormLvl(levelQ, enc.poolQ, enc.poolQ)
+
+	pBigInt := new(big.Int).SetUint64(enc.params.P()[0])
+	for _, pi := range enc.params.P()[1 : levelP+1] {
+		pBigInt.Mul(pBigInt, new(big.Int).SetUint64(pi))
+	}
+	ringQ.MulScalarBigintLvl(levelQ, enc.poolQ, pBigInt, enc.poolQ)
+
+	alpha := enc.params.DecompAlpha(levelP)
+	decompRNS := enc.params.DecompRNS(levelQ, levelP)
+	decompPw2 := enc.params.DecompPw2(levelQ, levelP)
+
+	for j := 0; j < decompPw2; j++ {
+		for i := 0; i < decompRNS; i++ {
+
+			// The gadget element g_{i, j} is P * 2^(j*Pow2Base) mod the moduli of the i-th RNS group and 0 mod
+			// the others. m*P*g_{i, j} is added to the first element of the encryption of m and to the second
+			// element of the encryption of m*s, since (b, a + m*P*g) has the phase of (b, a) plus m*s*P*g.
+			for k := 0; k < alpha; k++ {
+
+				index := i*alpha + k
+
+				if index >= levelQ+1 {
+					break
+				}
+
+				qi := ringQ.Modulus[index]
+				m := enc.poolQ.Coeffs[index]
+				c0 := ct.Value[0].Value[i*decompPw2+j][0].Q.Coeffs[index]
+				c1 := ct.Value[1].Value[i*decompPw2+j][1].Q.Coeffs[index]
+
+				for w := 0; w < ringQ.N; w++ {
+					c0[w] = ring.CRed(c0[w]+m[w], qi)
+					c1[w] = ring.CRed(c1[w]+m[w], qi)
+				}
+			}
+		}
+
+		if pow2Base := enc.params.Pow2Base(); pow2Base != 0 {
+			ringQ.MulScalarLvl(levelQ, enc.poolQ, 1<<pow2Base, enc.poolQ)
+		}
+	}
+}
+
+// encryptZero writes on ct an encryption of zero (-a*s + e, a) mod QP in the NTT and Montgomery domain.
+func (enc *Encryptor) encryptZero(levelQ, levelP int, ct [2]rlwe.PolyQP) {
+
+	ringQP := enc.params.RingQP()
+
+	// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
+	enc.uniformSamplerQ.ReadLvl(levelQ, ct[1].Q)
+	enc.uniformSamplerP.ReadLvl(levelP, ct[1].P)
+
+	// e
+	enc.gaussianSamplerQ.ReadLvl(levelQ, ct[0].Q)
+	ringQP.ExtendBasisSmallNormAndCenter(ct[0].Q, levelP, nil, ct[0].P)
+	ringQP.NTTLazyLvl(levelQ, levelP, ct[0], ct[0])
+	ringQP.MFormLvl(levelQ, levelP, ct[0], ct[0])
+
+	// -a*s + e
+	ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, ct[1], enc.sk.Value, ct[0])
+}
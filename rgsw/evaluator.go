@@ -0,0 +1,79 @@
+package rgsw
+
+import (
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Evaluator is a type for the evaluation of the external product between RLWE and RGSW ciphertexts.
+// It reuses the gadget decomposition and the memory pools of an rlwe.KeySwitcher.
+type Evaluator struct {
+	*rlwe.KeySwitcher
+	params rlwe.Parameters
+}
+
+// NewEvaluator creates a new Evaluator.
+func NewEvaluator(params rlwe.Parameters) *Evaluator {
+	return &Evaluator{KeySwitcher: rlwe.NewKeySwitcher(params), params: params}
+}
+
+// ShallowCopy creates a shallow copy of the Evaluator, only reallocating its memory pools.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	return &Evaluator{KeySwitcher: eval.KeySwitcher.ShallowCopy(), params: eval.params}
+}
+
+// ExternalProduct computes the external product ctOut = ctIn x ctRGSW = <g^-1(ctIn[0]), ctRGSW[0]> + <g^-1(ctIn[1]), ctRGSW[1]>,
+// whose phase is the phase of ctIn multiplied by the plaintext of ctRGSW.
+// ctIn must be of degree 1 and ctOut can be ctIn. The output is at the smallest level of ctIn and ctOut, and in the
+// same domain as ctIn, as given by its IsNTT flag.
+func (eval *Evaluator) ExternalProduct(ctIn *rlwe.Ciphertext, ctRGSW *Ciphertext, ctOut *rlwe.Ciphertext) {
+
+	if ctIn.Degree() != 1 || ctOut.Degree() != 1 {
+		panic("cannot ExternalProduct: input and output ciphertexts must be of degree 1")
+	}
+
+	ringQP := eval.params.RingQP()
+
+	levelQ := utils.MinInt(ctIn.Level(), ctOut.Level())
+	levelP := ctRGSW.LevelP()
+
+	if levelQ > ctRGSW.LevelQ() {
+		panic("cannot ExternalProduct: RGSW ciphertext level is smaller than the RLWE ciphertext level")
+	}
+
+	c0QP, c1QP := eval.Pool[1], eval.Pool[2]
+	tmp0QP, tmp1QP := eval.Pool[3], eval.Pool[4]
+
+	// <g^-1(ctIn[0]), ctRGSW[0]> + <g^-1(ctIn[1]), ctRGSW[1]> mod QP
+	eval.SwitchKeysInPlaceNoModDown(levelQ, ctIn.Value[0], ctRGSW.Value[0], c0QP.Q, c0QP.P, c1QP.Q, c1QP.P)
+	eval.SwitchKeysInPlaceNoModDown(levelQ, ctIn.Value[1], ctRGSW.Value[1], tmp0QP.Q, tmp0QP.P, tmp1QP.Q, tmp1QP.P)
+	ringQP.AddLvl(levelQ, levelP, c0QP, tmp0QP, c0QP)
+	ringQP.AddLvl(levelQ, levelP, c1QP, tmp1QP, c1QP)
+
+	isNTT := ctIn.Value[0].IsNTT
+
+	// Division by P
+	if isNTT {
+		eval.Baseconverter.ModDownQPtoQNTT(levelQ, levelP, c0QP.Q, c0QP.P, ctOut.Value[0])
+		eval.Baseconverter.ModDownQPtoQNTT(levelQ, levelP, c1QP.Q, c1QP.P, ctOut.Value[1])
+	} else {
+		ringQ, ringP := eval.params.RingQ(), eval.params.RingP()
+		ringQ.InvNTTLazyLvl(levelQ, c0QP.Q, c0QP.Q)
+		ringQ.InvNTTLazyLvl(levelQ, c1QP.Q, c1QP.Q)
+		ringP.InvNTTLazyLvl(levelP, c0QP.P, c0QP.P)
+		ringP.InvNTTLazyLvl(levelP, c1QP.P, c1QP.P)
+		eval.Baseconverter.ModDownQPtoQ(levelQ, levelP, c0QP.Q, c0QP.P, ctOut.Value[0])
+		eval.Baseconverter.ModDownQPtoQ(levelQ, levelP, c1QP.Q, c1QP.P, ctOut.Value[1])
+	}
+
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:levelQ+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:levelQ+1]
+	ctOut.Value[0].IsNTT, ctOut.Value[1].IsNTT = isNTT, isNTT
+}
+
+// ExternalProductNew computes the external product ctIn x ctRGSW and returns the result on a new ciphertext.
+func (eval *Evaluator) ExternalProductNew(ctIn *rlwe.Ciphertext, ctRGSW *Ciphertext) (ctOut *rlwe.Ciphertext) {
+	ctOut = rlwe.NewCiphertext(eval.params, 1, ctIn.Level())
+	eval.ExternalProduct(ctIn, ctRGSW, ctOut)
+	return
+}
@@ -0,0 +1,223 @@
+package lut
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/rgsw"
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/rlwe/lwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Evaluator is a type for the programmable bootstrapping of LWE ciphertexts of paramsLWE (dimension n = paramsLWE.N()
+// and moduli paramsLWE.Q()) into RLWE ciphertexts of paramsLUT, by blind rotation of the lookup table polynomials.
+type Evaluator struct {
+	*rgsw.Evaluator
+	paramsLUT rlwe.Parameters
+	paramsLWE rlwe.Parameters
+	brk       *BlindRotationKey
+
+	// modulus[l] is Q_l = q_0 * ... * q_l and crt[l][i] is the CRT reconstruction constant (Q_l/q_i) * ((Q_l/q_i)^-1 mod q_i)
+	// of the moduli of paramsLWE
+	modulus []*big.Int
+	crt     [][]*big.Int
+
+	acc *rlwe.Ciphertext
+	tmp *rlwe.Ciphertext
+}
+
+// NewEvaluator creates a new Evaluator for the LWE ciphertexts of paramsLWE, with the BlindRotationKey brk generated by
+// GenBlindRotationKey(paramsLUT, skLUT, paramsLWE, skLWE).
+func NewEvaluator(paramsLUT, paramsLWE rlwe.Parameters, brk *BlindRotationKey) (eval *Evaluator) {
+
+	if paramsLUT.RingType() != ring.Standard {
+		panic("cannot NewEvaluator: only the standard ring is supported")
+	}
+
+	if brk.N() != paramsLWE.N() {
+		panic("cannot NewEvaluator: BlindRotationKey dimension does not match paramsLWE")
+	}
+
+	eval = &Evaluator{
+		Evaluator: rgsw.NewEvaluator(paramsLUT),
+		paramsLUT: paramsLUT,
+		paramsLWE: paramsLWE,
+		brk:       brk,
+		modulus:   make([]*big.Int, paramsLWE.QCount()),
+		crt:       make([][]*big.Int, paramsLWE.QCount()),
+		acc:       rlwe.NewCiphertext(paramsLUT, 1, paramsLUT.MaxLevel()),
+		tmp:       rlwe.NewCiphertext(paramsLUT, 1, paramsLUT.MaxLevel()),
+	}
+
+	Q := paramsLWE.Q()
+	for l := range eval.modulus {
+
+		eval.modulus[l] = new(big.Int).SetUint64(Q[0])
+		for _, qi := range Q[1 : l+1] {
+			eval.modulus[l].Mul(eval.modulus[l], new(big.Int).SetUint64(qi))
+		}
+
+		eval.crt[l] = make([]*big.Int, l+1)
+		for i := range eval.crt[l] {
+			qi := new(big.Int).SetUint64(Q[i])
+			QOverQi := new(big.Int).Quo(eval.modulus[l], qi)
+			eval.crt[l][i] = new(big.Int).ModInverse(QOverQi, qi)
+			eval.crt[l][i].Mul(eval.crt[l][i], QOverQi)
+		}
+	}
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of the Evaluator, only reallocating its memory pools.
+func (eval *Evaluator) ShallowCopy() *Evaluator {
+	return &Evaluator{
+		Evaluator: eval.Evaluator.ShallowCopy(),
+		paramsLUT: eval.paramsLUT,
+		paramsLWE: eval.paramsLWE,
+		brk:       eval.brk,
+		modulus:   eval.modulus,
+		crt:       eval.crt,
+		acc:       rlwe.NewCiphertext(eval.paramsLUT, 1, eval.paramsLUT.MaxLevel()),
+		tmp:       rlwe.NewCiphertext(eval.paramsLUT, 1, eval.paramsLUT.MaxLevel()),
+	}
+}
+
+// Evaluate bootstraps the LWE ciphertext ct through the lookup table polynomial lut generated by InitLUT and returns
+// an RLWE ciphertext of paramsLUT, in the coefficient domain and at the level of lut, whose constant coefficient
+// has the phase of the table entry selected by the phase of ct.
+func (eval *Evaluator) Evaluate(ct *lwe.Ciphertext, lut *ring.Poly) (ctOut *rlwe.Ciphertext) {
+
+	if ct.N() != eval.paramsLWE.N() {
+		panic("cannot Evaluate: ciphertext dimension does not match paramsLWE")
+	}
+
+	ringQ := eval.paramsLUT.RingQ()
+	level := utils.MinInt(lut.Level(), eval.paramsLUT.MaxLevel())
+	twoN := 2 * eval.paramsLUT.N()
+
+	acc := eval.acc
+	acc.Value[0].Coeffs = acc.Value[0].Coeffs[:level+1]
+	acc.Value[1].Coeffs = acc.Value[1].Coeffs[:level+1]
+
+	// acc = (LUT * X^b', 0)
+	b, a := eval.modSwitch(ct)
+	mulByMonomial(ringQ, level, lut, b, acc.Value[0])
+	acc.Value[1].Zero()
+
+	// acc = acc * X^(a'_j * s_j), for s_j in {-1, 0, 1}
+	for j, aj := range a {
+		if aj != 0 {
+			eval.cmux(level, aj, eval.brk.Value[j][0])
+			eval.cmux(level, twoN-aj, eval.brk.Value[j][1])
+		}
+	}
+
+	return acc.CopyNew()
+}
+
+// EvaluateAndExtract bootstraps the LWE ciphertext ct through the lookup table polynomial lut and returns the result
+// as an LWE ciphertext of dimension paramsLUT.N() under the coefficient vector of the RLWE secret key of paramsLUT.
+func (eval *Evaluator) EvaluateAndExtract(ct *lwe.Ciphertext, lut *ring.Poly) (ctOut *lwe.Ciphertext) {
+	return lwe.SampleExtract(eval.paramsLUT, eval.Evaluate(ct, lut), []int{0})[0]
+}
+
+// EvaluateAndRepack bootstraps each LWE ciphertext cts[i] through the lookup table polynomial luts[i] (or luts[0] if
+// luts has a single element) and packs the results with the Repacker rp of paramsLUT: the coefficient of index
+// i*N/len(cts) of the output RLWE ciphertext is the bootstrapping of cts[i]. As for lwe.Repacker.Repack, len(cts)
+// must be a power of two and nil entries stand for encryptions of zero.
+func (eval *Evaluator) EvaluateAndRepack(cts []*lwe.Ciphertext, luts []*ring.Poly, rp *lwe.Repacker) (ctOut *rlwe.Ciphertext) {
+
+	if len(luts) != 1 && len(luts) != len(cts) {
+		panic("cannot EvaluateAndRepack: there must be a single lookup table or one per ciphertext")
+	}
+
+	ctsLUT := make([]*lwe.Ciphertext, len(cts))
+	for i, ct := range cts {
+		if ct != nil {
+			ctsLUT[i] = eval.EvaluateAndExtract(ct, luts[utils.MinInt(i, len(luts)-1)])
+		}
+	}
+
+	return rp.Repack(ctsLUT)
+}
+
+// cmux computes acc = acc + (acc * X^k - acc) x ctRGSW, that is acc * X^k if ctRGSW encrypts 1 and acc if it encrypts 0.
+func (eval *Evaluator) cmux(level, k int, ctRGSW *rgsw.Ciphertext) {
+
+	ringQ := eval.paramsLUT.RingQ()
+
+	acc, tmp := eval.acc, eval.tmp
+	tmp.Value[0].Coeffs = tmp.Value[0].Coeffs[:level+1]
+	tmp.Value[1].Coeffs = tmp.Value[1].Coeffs[:level+1]
+
+	for i := range acc.Value {
+		mulByMonomial(ringQ, level, acc.Value[i], k, tmp.Value[i])
+		ringQ.SubLvl(level, tmp.Value[i], acc.Value[i], tmp.Value[i])
+	}
+
+	eval.ExternalProduct(tmp, ctRGSW, tmp)
+
+	for i := range acc.Value {
+		ringQ.AddLvl(level, acc.Value[i], tmp.Value[i], acc.Value[i])
+	}
+}
+
+// modSwitch returns the coefficients of the LWE ciphertext ct switched from the modulus Q_l to 2N: round(c * 2N / Q_l) mod 2N.
+func (eval *Evaluator) modSwitch(ct *lwe.Ciphertext) (b int, a []int) {
+
+	level := ct.Level()
+	Q := eval.modulus[level]
+	crt := eval.crt[level]
+
+	twoN := big.NewInt(int64(2 * eval.paramsLUT.N()))
+	QHalf := new(big.Int).Rsh(Q, 1)
+
+	acc, tmp := new(big.Int), new(big.Int)
+
+	switchCoeff := func(get func(i int) uint64) int {
+		acc.SetUint64(0)
+		for i := range crt {
+			acc.Add(acc, tmp.Mul(crt[i], tmp.SetUint64(get(i))))
+		}
+		acc.Mod(acc, Q)
+		acc.Mul(acc, twoN)
+		acc.Add(acc, QHalf)
+		acc.Quo(acc, Q)
+		return int(acc.Mod(acc, twoN).Int64())
+	}
+
+	b = switchCoeff(func(i int) uint64 { return ct.B[i] })
+
+	a = make([]int, ct.N())
+	for j := range a {
+		a[j] = switchCoeff(func(i int) uint64 { return ct.A[i][j] })
+	}
+
+	return
+}
+
+// mulByMonomial writes on p2 the product of p1 with the monomial X^k in Z_Q[X]/(X^N + 1) at the given level,
+// for 0 <= k < 2N. p1 and p2 must be distinct.
+func mulByMonomial(ringQ *ring.Ring, level int, p1 *ring.Poly, k int, p2 *ring.Poly) {
+
+	N := ringQ.N
+
+	for i := 0; i < level+1; i++ {
+
+		qi := ringQ.Modulus[i]
+		c1, c2 := p1.Coeffs[i], p2.Coeffs[i]
+
+		for j := 0; j < N; j++ {
+
+			// X^(j+k) = (-1)^((j+k)/N) * X^((j+k) mod N)
+			idx := j + k
+			if (idx/N)&1 == 0 || c1[j] == 0 {
+				c2[idx&(N-1)] = c1[j]
+			} else {
+				c2[idx&(N-1)] = qi - c1[j]
+			}
+		}
+	}
+}
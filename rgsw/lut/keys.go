@@ -0,0 +1,197 @@
+package lut
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/ldsec/lattigo/v2/rgsw"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+const blindRotationKeyTag = "BRKY"
+
+// BlindRotationKey is the evaluation key of the blind rotation of LWE ciphertexts under a ternary LWE secret key s of
+// dimension n. For each coefficient s_j, it stores the RGSW encryptions under the RLWE secret key of the LUT
+// parameters of the indicator bits [s_j = 1] (Value[j][0]) and [s_j = -1] (Value[j][1]).
+type BlindRotationKey struct {
+	Value [][2]*rgsw.Ciphertext
+}
+
+// GenBlindRotationKey generates the BlindRotationKey of the LWE secret key skLWE, that is the coefficient vector of
+// the ternary RLWE secret key of paramsLWE, encrypted under the RLWE secret key skLUT of paramsLUT.
+func GenBlindRotationKey(paramsLUT rlwe.Parameters, skLUT *rlwe.SecretKey, paramsLWE rlwe.Parameters, skLWE *rlwe.SecretKey) (brk *BlindRotationKey) {
+
+	ringQLWE := paramsLWE.RingQ()
+	skLWEInvNTT := ringQLWE.NewPolyLvl(0)
+	ringQLWE.InvNTTLvl(0, skLWE.Value.Q, skLWEInvNTT)
+	ringQLWE.InvMFormLvl(0, skLWEInvNTT, skLWEInvNTT)
+
+	q0 := ringQLWE.Modulus[0]
+
+	// Plaintexts 0 (nil) and 1
+	one := rlwe.NewPlaintext(paramsLUT, paramsLUT.MaxLevel())
+	for i := range one.Value.Coeffs {
+		one.Value.Coeffs[i][0] = 1
+	}
+
+	encryptor := rgsw.NewEncryptor(paramsLUT, skLUT)
+
+	brk = &BlindRotationKey{Value: make([][2]*rgsw.Ciphertext, paramsLWE.N())}
+
+	for j, s := range skLWEInvNTT.Coeffs[0] {
+
+		var ptPos, ptNeg *rlwe.Plaintext
+
+		switch s {
+		case 0:
+		case 1:
+			ptPos = one
+		case q0 - 1:
+			ptNeg = one
+		default:
+			panic("cannot GenBlindRotationKey: LWE secret key must be ternary")
+		}
+
+		for k, pt := range []*rlwe.Plaintext{ptPos, ptNeg} {
+			brk.Value[j][k] = rgsw.NewCiphertext(paramsLUT, paramsLUT.MaxLevel(), paramsLUT.PCount()-1)
+			encryptor.Encrypt(pt, brk.Value[j][k])
+		}
+	}
+
+	return
+}
+
+// N returns the dimension of the LWE secret key of the BlindRotationKey.
+func (brk *BlindRotationKey) N() int {
+	return len(brk.Value)
+}
+
+// Equals checks two BlindRotationKeys for equality.
+func (brk *BlindRotationKey) Equals(other *BlindRotationKey) bool {
+	if brk == other {
+		return true
+	}
+	if (brk == nil) != (other == nil) || len(brk.Value) != len(other.Value) {
+		return false
+	}
+	for j := range brk.Value {
+		if !brk.Value[j][0].Equals(other.Value[j][0]) || !brk.Value[j][1].Equals(other.Value[j][1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDataLen returns the length in bytes of the target BlindRotationKey.
+func (brk *BlindRotationKey) GetDataLen(WithMetadata bool) (dataLen int) {
+
+	if WithMetadata {
+		dataLen += 4
+	}
+
+	for j := range brk.Value {
+		dataLen += brk.Value[j][0].GetDataLen(WithMetadata) + brk.Value[j][1].GetDataLen(WithMetadata)
+	}
+
+	return
+}
+
+// MarshalBinary encodes a BlindRotationKey in a byte slice.
+func (brk *BlindRotationKey) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, 4, brk.GetDataLen(true))
+	binary.BigEndian.PutUint32(data, uint32(len(brk.Value)))
+
+	var dataCt []byte
+	for j := range brk.Value {
+		for _, ct := range brk.Value[j] {
+			if dataCt, err = ct.MarshalBinary(); err != nil {
+				return nil, err
+			}
+			data = append(data, dataCt...)
+		}
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a previously marshaled BlindRotationKey in the target BlindRotationKey.
+func (brk *BlindRotationKey) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 4 {
+		return errors.New("BlindRotationKey: too small bytearray")
+	}
+
+	brk.Value = make([][2]*rgsw.Ciphertext, binary.BigEndian.Uint32(data))
+
+	pointer := 4
+	for j := range brk.Value {
+		for k := range brk.Value[j] {
+			brk.Value[j][k] = new(rgsw.Ciphertext)
+			if err = brk.Value[j][k].UnmarshalBinary(data[pointer:]); err != nil {
+				return
+			}
+			pointer += brk.Value[j][k].GetDataLen(true)
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target BlindRotationKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (brk *BlindRotationKey) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, blindRotationKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint32(w, uint32(len(brk.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for j := range brk.Value {
+		for _, ct := range brk.Value[j] {
+			inc, err = ct.WriteTo(w)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a BlindRotationKey written by WriteTo from r on the target BlindRotationKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (brk *BlindRotationKey) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, blindRotationKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint32
+	size, inc, err = utils.ReadUint32(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	brk.Value = make([][2]*rgsw.Ciphertext, size)
+
+	for j := range brk.Value {
+		for k := range brk.Value[j] {
+			brk.Value[j][k] = new(rgsw.Ciphertext)
+			inc, err = brk.Value[j][k].ReadFrom(r)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
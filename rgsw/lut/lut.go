@@ -0,0 +1,82 @@
+// Package lut implements the programmable bootstrapping of LWE ciphertexts through arbitrary lookup tables, by
+// blind rotation of an RLWE accumulator with RGSW encryptions of the LWE secret key (FHEW/TFHE style).
+//
+// A message m in Z_t is encoded in the LWE phase as m * ScalingFactor(t) = m * round(Q/(2t)), so that the encoded
+// messages stay in [0, Q/2) and the negacyclic wrap-around of the blind rotation can be absorbed by the lookup table.
+// The output of the bootstrapping uses the same encoding, so that it can be key-switched back to the LWE secret key
+// with lwe.KeySwitcher and bootstrapped again.
+package lut
+
+import (
+	"math/big"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// ScalingFactor returns round(Q_level/(2t)), the scaling factor of the messages of Z_t in the phase of the LWE and
+// RLWE ciphertexts of params at the given level.
+func ScalingFactor(params rlwe.Parameters, level int, t uint64) (delta *big.Int) {
+
+	Q := new(big.Int).SetUint64(params.Q()[0])
+	for _, qi := range params.Q()[1 : level+1] {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+
+	twoT := new(big.Int).SetUint64(t << 1)
+
+	delta = new(big.Int).Add(Q, new(big.Int).Rsh(twoT, 1))
+	return delta.Quo(delta, twoT)
+}
+
+// InitLUT returns the lookup table polynomial of f: Z_t -> Z_t for the blind rotation of LWE ciphertexts of messages
+// of Z_t to RLWE ciphertexts of params at the given level. The output of the bootstrapping of an encryption of m through
+// the returned polynomial encrypts f(m) mod t with the scaling factor ScalingFactor(params, level, t).
+// The LWE phase is mapped to an exponent k of the accumulator LUT * X^k, whose constant coefficient is LUT[0] for
+// k = 0, -LUT[N-k] for 0 < k < N and LUT[2N-k] for N < k < 2N: the window of each message m is centered on m*N/t, and
+// the window of m = 0 extends to the small negative exponents.
+func InitLUT(f func(m uint64) uint64, t uint64, params rlwe.Parameters, level int) (lut *ring.Poly) {
+
+	ringQ := params.RingQ()
+	N := uint64(params.N())
+
+	if t == 0 || t > N {
+		panic("cannot InitLUT: t must be in [1, N]")
+	}
+
+	delta := ScalingFactor(params, level, t)
+
+	// f(m) * delta mod q_i for each m
+	values := make([][]uint64, t)
+	tmp := new(big.Int)
+	for m := range values {
+		values[m] = make([]uint64, level+1)
+		tmp.Mul(new(big.Int).SetUint64(f(uint64(m))%t), delta)
+		for i := range values[m] {
+			values[m][i] = new(big.Int).Mod(tmp, new(big.Int).SetUint64(ringQ.Modulus[i])).Uint64()
+		}
+	}
+
+	lut = ringQ.NewPolyLvl(level)
+
+	for j := uint64(0); j < N; j++ {
+
+		// Coefficient j is the constant coefficient of LUT * X^(2N-j), which is in the window of m = 0, for
+		// j <= N/(2t), and otherwise the opposite of the constant coefficient of LUT * X^(N-j), which is in
+		// the window of m = round((N-j)*t/N).
+		if j == 0 || 2*t*j <= N {
+			for i := range lut.Coeffs {
+				lut.Coeffs[i][j] = values[0][i]
+			}
+		} else {
+			m := (2*(N-j)*t + N) / (2 * N)
+			for i := range lut.Coeffs {
+				if v := values[m][i]; v != 0 {
+					lut.Coeffs[i][j] = ringQ.Modulus[i] - v
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,299 @@
+package lut
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/rlwe/lwe"
+)
+
+var (
+	// testParamsLUT is the set of parameters of the accumulator of the blind rotation
+	testParamsLUT = rlwe.ParametersLiteral{
+		LogN:  10,
+		Q:     []uint64{0x3fffffffef8001}, // 54 bits
+		P:     []uint64{0x7ffffffffb4001}, // 55 bits
+		Sigma: rlwe.DefaultSigma,
+	}
+
+	// testParamsLWE is the set of parameters of the LWE ciphertexts, which shares the moduli of testParamsLUT
+	testParamsLWE = rlwe.ParametersLiteral{
+		LogN:  8,
+		Q:     testParamsLUT.Q,
+		P:     testParamsLUT.P,
+		Sigma: rlwe.DefaultSigma,
+	}
+)
+
+func testString(params rlwe.Parameters, opname string) string {
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d/Pw2=%d",
+		opname,
+		params.LogN(),
+		params.LogQ(),
+		params.LogP(),
+		params.QCount(),
+		params.PCount(),
+		params.Pow2Base())
+}
+
+type testContext struct {
+	paramsLUT, paramsLWE rlwe.Parameters
+	kgenLUT              rlwe.KeyGenerator
+	skLUT, skLWE         *rlwe.SecretKey
+	brk                  *BlindRotationKey
+	eval                 *Evaluator
+	t                    uint64
+}
+
+func TestLUT(t *testing.T) {
+
+	paramsLUT, err := rlwe.NewParametersFromLiteral(testParamsLUT)
+	require.NoError(t, err)
+
+	paramsLWE, err := rlwe.NewParametersFromLiteral(testParamsLWE)
+	require.NoError(t, err)
+
+	tc := &testContext{paramsLUT: paramsLUT, paramsLWE: paramsLWE, t: 8}
+	tc.kgenLUT = rlwe.NewKeyGenerator(paramsLUT)
+	tc.skLUT = tc.kgenLUT.GenSecretKey()
+	tc.skLWE = rlwe.NewKeyGenerator(paramsLWE).GenSecretKey()
+	tc.brk = GenBlindRotationKey(paramsLUT, tc.skLUT, paramsLWE, tc.skLWE)
+	tc.eval = NewEvaluator(paramsLUT, paramsLWE, tc.brk)
+
+	for _, testSet := range []func(tc *testContext, t *testing.T){
+		testInitLUT,
+		testEvaluate,
+		testEvaluateAndRepack,
+		testMarshaller,
+	} {
+		testSet(tc, t)
+	}
+}
+
+// encryptLWE returns LWE encryptions under skLWE of the messages m of Z_t.
+func encryptLWE(tc *testContext, m []uint64) (cts []*lwe.Ciphertext) {
+
+	params := tc.paramsLWE
+	ringQ := params.RingQ()
+
+	delta := ScalingFactor(params, params.MaxLevel(), tc.t)
+
+	pt := rlwe.NewPlaintext(params, params.MaxLevel())
+	indices := make([]int, len(m))
+	for j := range m {
+		indices[j] = j
+		tmp := new(big.Int).Mul(delta, new(big.Int).SetUint64(m[j]))
+		for i := range pt.Value.Coeffs {
+			pt.Value.Coeffs[i][j] = new(big.Int).Mod(tmp, new(big.Int).SetUint64(ringQ.Modulus[i])).Uint64()
+		}
+	}
+
+	ct := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+	rlwe.NewEncryptor(params, tc.skLWE).Encrypt(pt, ct)
+
+	return lwe.SampleExtract(params, ct, indices)
+}
+
+// decode returns the message of Z_t of the phase, given mod the moduli of params.
+func decode(params rlwe.Parameters, t uint64, phase []uint64) uint64 {
+
+	level := len(phase) - 1
+	ringQ := params.RingQ()
+
+	Q := big.NewInt(1)
+	for _, qi := range ringQ.Modulus[:level+1] {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+
+	value := new(big.Int)
+	for i, c := range phase {
+		qi := new(big.Int).SetUint64(ringQ.Modulus[i])
+		QOverQi := new(big.Int).Quo(Q, qi)
+		crt := new(big.Int).ModInverse(QOverQi, qi)
+		crt.Mul(crt, QOverQi)
+		value.Add(value, crt.Mul(crt, new(big.Int).SetUint64(c)))
+	}
+	value.Mod(value, Q)
+
+	// round(value * 2t / Q) mod 2t
+	twoT := new(big.Int).SetUint64(2 * t)
+	value.Mul(value, twoT)
+	value.Add(value, new(big.Int).Rsh(Q, 1))
+	value.Quo(value, Q)
+
+	return value.Mod(value, twoT).Uint64()
+}
+
+func testInitLUT(tc *testContext, t *testing.T) {
+
+	t.Run(testString(tc.paramsLUT, "InitLUT"), func(t *testing.T) {
+
+		params := tc.paramsLUT
+		ringQ := params.RingQ()
+		N := params.N()
+
+		f := func(m uint64) uint64 { return m + 1 }
+		lut := InitLUT(f, tc.t, params, params.MaxLevel())
+
+		// The constant coefficient of LUT * X^k is f(m) * delta in the window of m
+		acc := ringQ.NewPoly()
+		for m := uint64(0); m < tc.t; m++ {
+			for _, k := range []int{int(m) * N / int(tc.t), int(m)*N/int(tc.t) + N/int(4*tc.t), (int(m)*N/int(tc.t) - N/int(4*tc.t) + 2*N) % (2 * N)} {
+				mulByMonomial(ringQ, params.MaxLevel(), lut, k, acc)
+				phase := make([]uint64, params.QCount())
+				for i := range phase {
+					phase[i] = acc.Coeffs[i][0]
+				}
+				require.Equal(t, f(m)%tc.t, decode(params, tc.t, phase))
+			}
+		}
+	})
+}
+
+func testEvaluate(tc *testContext, t *testing.T) {
+
+	paramsLUT, paramsLWE := tc.paramsLUT, tc.paramsLWE
+
+	m := []uint64{0, 1, 2, 3, 4, 5, 6, 7}
+	f := func(x uint64) uint64 { return (3*x*x + 1) % tc.t }
+	lut := InitLUT(f, tc.t, paramsLUT, paramsLUT.MaxLevel())
+
+	cts := encryptLWE(tc, m)
+	decLWE := lwe.NewDecryptor(paramsLWE, tc.skLWE)
+	decLUT := lwe.NewDecryptor(paramsLUT, tc.skLUT)
+
+	for i := range cts {
+		require.Equal(t, m[i], decode(paramsLWE, tc.t, decLWE.DecryptNew(cts[i])))
+	}
+
+	t.Run(testString(paramsLUT, "Evaluate"), func(t *testing.T) {
+
+		eval := tc.eval.ShallowCopy()
+
+		for i, ct := range cts[:2] {
+
+			ctOut := eval.Evaluate(ct, lut)
+			require.False(t, ctOut.Value[0].IsNTT)
+
+			pt := rlwe.NewPlaintext(paramsLUT, ctOut.Level())
+			rlwe.NewDecryptor(paramsLUT, tc.skLUT).Decrypt(ctOut, pt)
+
+			phase := make([]uint64, pt.Level()+1)
+			for j := range phase {
+				phase[j] = pt.Value.Coeffs[j][0]
+			}
+			require.Equal(t, f(m[i]), decode(paramsLUT, tc.t, phase))
+		}
+	})
+
+	t.Run(testString(paramsLUT, "EvaluateAndExtract"), func(t *testing.T) {
+
+		for i, ct := range cts {
+			ctOut := tc.eval.EvaluateAndExtract(ct, lut)
+			require.Equal(t, paramsLUT.N(), ctOut.N())
+			require.Equal(t, f(m[i]), decode(paramsLUT, tc.t, decLUT.DecryptNew(ctOut)))
+		}
+	})
+
+	t.Run(testString(paramsLUT, "EvaluateAndExtract/KeySwitch/Chained"), func(t *testing.T) {
+
+		swk := tc.kgenLUT.GenSwitchingKey(tc.skLUT, tc.skLWE)
+		ks := lwe.NewKeySwitcher(paramsLUT)
+
+		g := func(x uint64) uint64 { return (x + 5) % tc.t }
+		lutG := InitLUT(g, tc.t, paramsLUT, paramsLUT.MaxLevel())
+
+		for i, ct := range cts[:4] {
+
+			// f(m) under skLUT, switched back to skLWE
+			ctF := tc.eval.EvaluateAndExtract(ct, lut)
+			ctFLWE := lwe.NewCiphertext(paramsLWE.N(), paramsLWE.MaxLevel())
+			ks.SwitchKeys(ctF, swk, ctFLWE)
+			require.Equal(t, f(m[i]), decode(paramsLWE, tc.t, decLWE.DecryptNew(ctFLWE)))
+
+			// g(f(m)) under skLUT
+			ctGF := tc.eval.EvaluateAndExtract(ctFLWE, lutG)
+			require.Equal(t, g(f(m[i])), decode(paramsLUT, tc.t, decLUT.DecryptNew(ctGF)))
+		}
+	})
+}
+
+func testEvaluateAndRepack(tc *testContext, t *testing.T) {
+
+	paramsLUT := tc.paramsLUT
+
+	t.Run(testString(paramsLUT, "EvaluateAndRepack"), func(t *testing.T) {
+
+		m := []uint64{6, 3, 0, 7}
+		cts := encryptLWE(tc, m)
+		cts[2] = nil
+
+		f := func(x uint64) uint64 { return x / 2 }
+		g := func(x uint64) uint64 { return 7 - x }
+		luts := []*ring.Poly{
+			InitLUT(f, tc.t, paramsLUT, paramsLUT.MaxLevel()),
+			InitLUT(g, tc.t, paramsLUT, paramsLUT.MaxLevel()),
+			nil,
+			InitLUT(f, tc.t, paramsLUT, paramsLUT.MaxLevel()),
+		}
+
+		rtks := tc.kgenLUT.GenRotationKeys(lwe.GaloisElementsForRepack(paramsLUT), tc.skLUT)
+		rp := lwe.NewRepacker(paramsLUT, rtks)
+
+		ctOut := tc.eval.EvaluateAndRepack(cts, luts, rp)
+
+		pt := rlwe.NewPlaintext(paramsLUT, ctOut.Level())
+		rlwe.NewDecryptor(paramsLUT, tc.skLUT).Decrypt(ctOut, pt)
+
+		want := []uint64{f(m[0]), g(m[1]), 0, f(m[3])}
+		gap := paramsLUT.N() / len(cts)
+		phase := make([]uint64, pt.Level()+1)
+		for j := 0; j < paramsLUT.N(); j++ {
+
+			for i := range phase {
+				phase[i] = pt.Value.Coeffs[i][j]
+			}
+
+			var w uint64
+			if j%gap == 0 {
+				w = want[j/gap]
+			}
+
+			require.Equal(t, w, decode(paramsLUT, tc.t, phase))
+		}
+	})
+}
+
+func testMarshaller(tc *testContext, t *testing.T) {
+
+	t.Run(testString(tc.paramsLUT, "Marshaller/BlindRotationKey"), func(t *testing.T) {
+
+		data, err := tc.brk.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, data, tc.brk.GetDataLen(true))
+
+		brkNew := new(BlindRotationKey)
+		require.NoError(t, brkNew.UnmarshalBinary(data))
+		require.True(t, tc.brk.Equals(brkNew))
+	})
+
+	t.Run(testString(tc.paramsLUT, "Streaming/BlindRotationKey"), func(t *testing.T) {
+
+		buf := new(bytes.Buffer)
+		n, err := tc.brk.WriteTo(buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+
+		brkNew := new(BlindRotationKey)
+		m, err := brkNew.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, n, m)
+		require.True(t, tc.brk.Equals(brkNew))
+	})
+}
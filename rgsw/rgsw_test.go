@@ -0,0 +1,201 @@
+package rgsw
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+func testString(params rlwe.Parameters, opname string) string {
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d/Pw2=%d",
+		opname,
+		params.LogN(),
+		params.LogQ(),
+		params.LogP(),
+		params.QCount(),
+		params.PCount(),
+		params.Pow2Base())
+}
+
+type testContext struct {
+	params     rlwe.Parameters
+	sk         *rlwe.SecretKey
+	encryptor  rlwe.Encryptor
+	decryptor  rlwe.Decryptor
+	encRGSW    *Encryptor
+	eval       *Evaluator
+	delta      uint64 // scaling factor of the messages, such that the noise must be smaller than delta/2
+	ptRLWE     *rlwe.Plaintext
+	ptRGSW     *rlwe.Plaintext
+	monomialEx int // exponent of the monomial encrypted in ptRGSW
+}
+
+func TestRGSW(t *testing.T) {
+
+	defaultParams := []rlwe.ParametersLiteral{rlwe.TestPN12QP109, rlwe.TestPN12QP109Pw2, rlwe.TestPN13QP218, rlwe.TestPN14QP438}
+	if testing.Short() {
+		defaultParams = defaultParams[:3]
+	}
+
+	for _, defaultParam := range defaultParams {
+
+		params, err := rlwe.NewParametersFromLiteral(defaultParam)
+		if err != nil {
+			panic(err)
+		}
+
+		tc := &testContext{params: params}
+		tc.sk = rlwe.NewKeyGenerator(params).GenSecretKey()
+		tc.encryptor = rlwe.NewEncryptor(params, tc.sk)
+		tc.decryptor = rlwe.NewDecryptor(params, tc.sk)
+		tc.encRGSW = NewEncryptor(params, tc.sk)
+		tc.eval = NewEvaluator(params)
+		tc.delta = params.Q()[0] >> 5
+
+		// RLWE plaintext (i mod 16) * delta and RGSW plaintext X^monomialEx
+		ringQ := params.RingQ()
+		tc.ptRLWE = rlwe.NewPlaintext(params, params.MaxLevel())
+		tc.ptRGSW = rlwe.NewPlaintext(params, params.MaxLevel())
+		tc.monomialEx = 7
+		for i := range ringQ.Modulus {
+			for j := range tc.ptRLWE.Value.Coeffs[i] {
+				tc.ptRLWE.Value.Coeffs[i][j] = (uint64(j&15) * tc.delta) % ringQ.Modulus[i]
+			}
+			tc.ptRGSW.Value.Coeffs[i][tc.monomialEx] = 1
+		}
+
+		for _, testSet := range []func(tc *testContext, t *testing.T){
+			testExternalProduct,
+			testMarshaller,
+		} {
+			testSet(tc, t)
+			runtime.GC()
+		}
+	}
+}
+
+// verifyExternalProduct checks that ct decrypts to ptRLWE * X^monomialEx up to a noise smaller than delta/2.
+func verifyExternalProduct(tc *testContext, ct *rlwe.Ciphertext, t *testing.T) {
+
+	ringQ := tc.params.RingQ()
+	level := ct.Level()
+
+	pt := rlwe.NewPlaintext(tc.params, level)
+	tc.decryptor.Decrypt(ct, pt)
+
+	want := ringQ.NewPoly()
+	ringQ.MultByMonomial(tc.ptRLWE.Value, tc.monomialEx, want)
+
+	for i := 0; i < level+1; i++ {
+		qi := ringQ.Modulus[i]
+		for j := range pt.Value.Coeffs[i] {
+			e := ring.CRed(pt.Value.Coeffs[i][j]+qi-want.Coeffs[i][j], qi)
+			if e > qi>>1 {
+				e = qi - e
+			}
+			require.Less(t, e, tc.delta>>1)
+		}
+	}
+}
+
+func testExternalProduct(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	ctRGSW := NewCiphertext(params, params.MaxLevel(), params.PCount()-1)
+	tc.encRGSW.Encrypt(tc.ptRGSW, ctRGSW)
+
+	for _, isNTT := range []bool{true, false} {
+
+		t.Run(testString(params, fmt.Sprintf("ExternalProduct/IsNTT=%t", isNTT)), func(t *testing.T) {
+
+			ct := rlwe.NewCiphertext(params, 1, params.MaxLevel())
+			if isNTT {
+				ct = rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+			}
+			tc.encryptor.Encrypt(tc.ptRLWE, ct)
+
+			ctOut := tc.eval.ExternalProductNew(ct, ctRGSW)
+			require.Equal(t, isNTT, ctOut.Value[0].IsNTT)
+			verifyExternalProduct(tc, ctOut, t)
+
+			// In place
+			tc.eval.ShallowCopy().ExternalProduct(ct, ctRGSW, ct)
+			verifyExternalProduct(tc, ct, t)
+		})
+	}
+
+	t.Run(testString(params, "ExternalProduct/MinLevel"), func(t *testing.T) {
+
+		ct := rlwe.NewCiphertextNTT(params, 1, 0)
+		tc.encryptor.Encrypt(tc.ptRLWE, ct)
+
+		ctOut := tc.eval.ExternalProductNew(ct, ctRGSW)
+		require.Equal(t, 0, ctOut.Level())
+		verifyExternalProduct(tc, ctOut, t)
+	})
+
+	t.Run(testString(params, "ExternalProduct/Zero"), func(t *testing.T) {
+
+		ctZero := NewCiphertext(params, params.MaxLevel(), params.PCount()-1)
+		tc.encRGSW.Encrypt(nil, ctZero)
+
+		ct := rlwe.NewCiphertextNTT(params, 1, params.MaxLevel())
+		tc.encryptor.Encrypt(tc.ptRLWE, ct)
+		tc.eval.ExternalProduct(ct, ctZero, ct)
+
+		pt := rlwe.NewPlaintext(params, params.MaxLevel())
+		tc.decryptor.Decrypt(ct, pt)
+
+		ringQ := params.RingQ()
+		for i := range pt.Value.Coeffs {
+			qi := ringQ.Modulus[i]
+			for _, c := range pt.Value.Coeffs[i] {
+				if c > qi>>1 {
+					c = qi - c
+				}
+				require.Less(t, c, tc.delta>>1)
+			}
+		}
+	})
+}
+
+func testMarshaller(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	ct := NewCiphertext(params, params.MaxLevel(), params.PCount()-1)
+	tc.encRGSW.Encrypt(tc.ptRGSW, ct)
+
+	t.Run(testString(params, "Marshaller/Ciphertext"), func(t *testing.T) {
+
+		data, err := ct.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, data, ct.GetDataLen(true))
+
+		ctNew := new(Ciphertext)
+		require.NoError(t, ctNew.UnmarshalBinary(data))
+		require.True(t, ct.Equals(ctNew))
+	})
+
+	t.Run(testString(params, "Streaming/Ciphertext"), func(t *testing.T) {
+
+		buf := new(bytes.Buffer)
+		n, err := ct.WriteTo(buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+
+		ctNew := new(Ciphertext)
+		m, err := ctNew.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, n, m)
+		require.True(t, ct.Equals(ctNew))
+		require.True(t, ct.CopyNew().Equals(ctNew))
+	})
+}
@@ -2,6 +2,7 @@ package ring
 
 import (
 	"fmt"
+	"math"
 	"math/bits"
 )
 
@@ -10,6 +11,49 @@ func IsPrime(x uint64) bool {
 	return NewUint(x).ProbablyPrime(0)
 }
 
+// IsPrimePower returns (p, e, true) if x = p^e for a prime p and an integer e >= 1, and (0, 0, false) otherwise.
+func IsPrimePower(x uint64) (p uint64, e int, ok bool) {
+
+	if x < 2 {
+		return 0, 0, false
+	}
+
+	if IsPrime(x) {
+		return x, 1, true
+	}
+
+	for e = bits.Len64(x) - 1; e > 1; e-- {
+
+		// Integer e-th root of x, up to a rounding error of one
+		p = uint64(math.Round(math.Pow(float64(x), 1/float64(e))))
+
+		for _, r := range []uint64{p - 1, p, p + 1} {
+			if r < 2 {
+				continue
+			}
+
+			if pow, overflow := powUint64(r, e); !overflow && pow == x && IsPrime(r) {
+				return r, e, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// powUint64 returns x^e and whether the computation overflowed.
+func powUint64(x uint64, e int) (pow uint64, overflow bool) {
+	pow = 1
+	for i := 0; i < e; i++ {
+		hi, lo := bits.Mul64(pow, x)
+		if hi != 0 {
+			return 0, true
+		}
+		pow = lo
+	}
+	return pow, false
+}
+
 // GenerateNTTPrimes generates n NthRoot NTT friendly primes given logQ = size of the primes.
 // It will return all the appropriate primes, up to the number of n, with the
 // best available deviation from the base power of 2 for the given n.
@@ -91,10 +91,14 @@ type Ring struct {
 	NttPsi    [][]uint64 //powers of the inverse of the 2N-th primitive root in Montgomery form (in bit-reversed order)
 	NttPsiInv [][]uint64 //powers of the inverse of the 2N-th primitive root in Montgomery form (in bit-reversed order)
 	NttNInv   []uint64   //[N^-1] mod Qi in Montgomery form
+
+	// Number of goroutines among which the work is distributed (see WithConcurrency)
+	concurrency int
 }
 
 // NewRing creates a new RNS Ring with degree N and coefficient moduli Moduli with Standard NTT. N must be a power of two larger than 8. Moduli should be
 // a non-empty []uint64 with distinct prime elements. All moduli must also be equal to 1 modulo 2*N.
+// A modulus can also be the power p^e of a prime p equal to 1 modulo 2*N, in which case the NTT is done modulo p^e.
 // An error is returned with a nil *Ring in the case of non NTT-enabling parameters.
 func NewRing(N int, Moduli []uint64) (r *Ring, err error) {
 	return NewRingWithCustomNTT(N, Moduli, NumberTheoreticTransformerStandard{}, 2*N)
@@ -238,7 +242,7 @@ func (r *Ring) setParameters(N int, Modulus []uint64) error {
 	return nil
 }
 
-// genNTTParams checks that N has been correctly initialized, and checks that each modulus is a prime congruent to 1 mod 2N (i.e. NTT-friendly).
+// genNTTParams checks that N has been correctly initialized, and checks that each modulus is a prime, or the power of a prime, congruent to 1 mod 2N (i.e. NTT-friendly).
 // Then, it computes the variables required for the NTT. The purpose of ValidateParameters is to validate that the moduli allow the NTT, and to compute the
 // NTT parameters.
 func (r *Ring) genNTTParams(NthRoot uint64) error {
@@ -251,16 +255,23 @@ func (r *Ring) genNTTParams(NthRoot uint64) error {
 		panic("error : invalid r parameters (missing)")
 	}
 
-	// Check if each qi is prime and equal to 1 mod NthRoot
+	// Check if each qi is a prime (or the power of a prime) p equal to 1 mod NthRoot
+	primes := make([]uint64, len(r.Modulus))
+	totients := make([]uint64, len(r.Modulus))
 	for i, qi := range r.Modulus {
-		if !IsPrime(qi) {
-			return fmt.Errorf("invalid modulus (Modulus[%d] is not prime)", i)
+
+		p, _, ok := IsPrimePower(qi)
+		if !ok {
+			return fmt.Errorf("invalid modulus (Modulus[%d] is not prime or a prime power)", i)
 		}
 
-		if qi&(NthRoot-1) != 1 {
+		if p&(NthRoot-1) != 1 {
 			r.AllowsNTT = false
 			return fmt.Errorf("invalid modulus (Modulus[%d] != 1 mod NthRoot)", i)
 		}
+
+		primes[i] = p
+		totients[i] = qi / p * (p - 1)
 	}
 
 	r.NthRoot = NthRoot
@@ -273,7 +284,7 @@ func (r *Ring) genNTTParams(NthRoot uint64) error {
 
 		for i := 0; i < j; i++ {
 
-			r.RescaleParams[j-1][i] = MForm(r.Modulus[i]-ModExp(r.Modulus[j], r.Modulus[i]-2, r.Modulus[i]), r.Modulus[i], r.BredParams[i])
+			r.RescaleParams[j-1][i] = MForm(r.Modulus[i]-ModExp(r.Modulus[j], totients[i]-1, r.Modulus[i]), r.Modulus[i], r.BredParams[i])
 		}
 	}
 
@@ -288,21 +299,23 @@ func (r *Ring) genNTTParams(NthRoot uint64) error {
 	for i, qi := range r.Modulus {
 
 		// 1.1 Compute N^(-1) mod Q in Montgomery form
-		r.NttNInv[i] = MForm(ModExp(NthRoot>>1, qi-2, qi), qi, r.BredParams[i])
+		r.NttNInv[i] = MForm(ModExp(NthRoot>>1, totients[i]-1, qi), qi, r.BredParams[i])
 
 		// 1.2 Compute Psi and PsiInv in Montgomery form
 		r.NttPsi[i] = make([]uint64, NthRoot>>1)
 		r.NttPsiInv[i] = make([]uint64, NthRoot>>1)
 
-		// Finds a 2N-th primitive Root
-		g := primitiveRoot(qi)
+		// Finds a 2N-th primitive Root modulo p
+		p := primes[i]
+		g := primitiveRoot(p)
+		psi := ModExp(g, (p-1)/NthRoot, p)
 
-		power := (qi - 1) / NthRoot
-		powerInv := (qi - 1) - power
+		// Lifts it to a 2N-th primitive root modulo qi = p^e (Teichmüller lift)
+		psi = ModExp(psi, qi/p, qi)
 
 		// Computes Psi and PsiInv in Montgomery form
-		PsiMont := MForm(ModExp(g, power, qi), qi, r.BredParams[i])
-		PsiInvMont := MForm(ModExp(g, powerInv, qi), qi, r.BredParams[i])
+		PsiMont := MForm(psi, qi, r.BredParams[i])
+		PsiInvMont := MForm(ModExp(psi, NthRoot-1, qi), qi, r.BredParams[i])
 
 		r.PsiMont[i] = PsiMont
 		r.PsiInvMont[i] = PsiInvMont
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/bits"
 	"unsafe"
+
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // FastBasisExtender stores the necessary parameters for RNS basis extension.
@@ -199,8 +201,14 @@ func (be *FastBasisExtender) ModDownQPtoQ(levelQ, levelP int, p1Q, p1P, p2Q *Pol
 	be.ModUpPtoQ(levelP, levelQ, p1P, polypool)
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
-	for i := 0; i < levelQ+1; i++ {
-		SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+	if !ringQ.concurrent(levelQ + 1) {
+		for i := 0; i < levelQ+1; i++ {
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+		}
+	} else {
+		ringQ.forEachModulus(levelQ, func(i int) {
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+		})
 	}
 
 	// In total we do len(P) + len(Q) NTT, which is optimal (linear in the number of moduli of P and Q)
@@ -230,9 +238,16 @@ func (be *FastBasisExtender) ModDownQPtoQNTT(levelQ, levelP int, p1Q, p1P, p2Q *
 	ringQ.NTTLazyLvl(levelQ, polypoolQ, polypoolQ)
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
-	for i := 0; i < levelQ+1; i++ {
-		// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
-		SubVecAndMulScalarMontgomeryTwoQiVec(polypoolQ.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+	if !ringQ.concurrent(levelQ + 1) {
+		for i := 0; i < levelQ+1; i++ {
+			// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypoolQ.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+		}
+	} else {
+		ringQ.forEachModulus(levelQ, func(i int) {
+			// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypoolQ.Coeffs[i], p1Q.Coeffs[i], p2Q.Coeffs[i], ringQ.Modulus[i]-modDownParams[levelP][i], ringQ.Modulus[i], ringQ.MredParams[i])
+		})
 	}
 
 	// In total we do len(P) + len(Q) NTT, which is optimal (linear in the number of moduli of P and Q)
@@ -253,9 +268,16 @@ func (be *FastBasisExtender) ModDownQPtoP(levelQ, levelP int, p1Q, p1P, p2P *Pol
 	be.ModUpQtoP(levelQ, levelP, p1Q, polypool)
 
 	// Finally, for each level of p1 (and polypool since they now share the same basis) we compute p2 = (P^-1) * (p1 - polypool) mod Q
-	for i := 0; i < levelP+1; i++ {
-		// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
-		SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1P.Coeffs[i], p2P.Coeffs[i], ringP.Modulus[i]-modDownParams[levelP][i], ringP.Modulus[i], ringP.MredParams[i])
+	if !ringP.concurrent(levelP + 1) {
+		for i := 0; i < levelP+1; i++ {
+			// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1P.Coeffs[i], p2P.Coeffs[i], ringP.Modulus[i]-modDownParams[levelP][i], ringP.Modulus[i], ringP.MredParams[i])
+		}
+	} else {
+		ringP.forEachModulus(levelP, func(i int) {
+			// Then for each coefficient we compute (P^-1) * (p1[i][j] - polypool[i][j]) mod qi
+			SubVecAndMulScalarMontgomeryTwoQiVec(polypool.Coeffs[i], p1P.Coeffs[i], p2P.Coeffs[i], ringP.Modulus[i]-modDownParams[levelP][i], ringP.Modulus[i], ringP.MredParams[i])
+		})
 	}
 
 	// In total we do len(P) + len(Q) NTT, which is optimal (linear in the number of moduli of P and Q)
@@ -264,6 +286,19 @@ func (be *FastBasisExtender) ModDownQPtoP(levelQ, levelP int, p1Q, p1P, p2P *Pol
 // Caution, returns the values in [0, 2q-1]
 func modUpExact(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams) {
 
+	if !ringQ.concurrent(len(p1[0]) >> 3) {
+		modUpExactRange(p1, p2, ringQ, ringP, params, 0, len(p1[0]))
+		return
+	}
+
+	ringQ.forEachChunk(len(p1[0]), func(start, end int) {
+		modUpExactRange(p1, p2, ringQ, ringP, params, start, end)
+	})
+}
+
+// modUpExactRange applies modUpExact on the coefficients of index in [start, end).
+func modUpExactRange(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams, start, end int) {
+
 	var v [8]uint64
 	var y0, y1, y2, y3, y4, y5, y6, y7 [32]uint64
 
@@ -276,7 +311,7 @@ func modUpExact(p1, p2 [][]uint64, ringQ, ringP *Ring, params modupParams) {
 	qoverqimodp := params.qoverqimodp
 
 	// We loop over each coefficient and apply the basis extension
-	for x := 0; x < len(p1[0]); x = x + 8 {
+	for x := start; x < end; x = x + 8 {
 		reconstructRNS(len(p1), x, p1, &v, &y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7, Q, mredParamsQ, qoverqiinvqi)
 		for j := 0; j < len(p2); j++ {
 			multSum((*[8]uint64)(unsafe.Pointer(&p2[j][x])), &v, &y0, &y1, &y2, &y3, &y4, &y5, &y6, &y7, len(p1), P[j], mredParamsP[j], vtimesqmodp[j], qoverqimodp[j])
@@ -292,22 +327,30 @@ type Decomposer struct {
 	modUpParams  [][][]modupParams
 }
 
-// NewDecomposer creates a new Decomposer.
+// NewDecomposer creates a new Decomposer for the decompositions whose elements have at most len(ringP.Modulus)
+// moduli Qi.
 func NewDecomposer(ringQ, ringP *Ring) (decomposer *Decomposer) {
+	return NewDecomposerWithMaxAlpha(ringQ, ringP, len(ringP.Modulus))
+}
+
+// NewDecomposerWithMaxAlpha creates a new Decomposer for the decompositions whose elements have at most maxAlpha
+// moduli Qi, which can be larger than the number of moduli of ringP.
+func NewDecomposerWithMaxAlpha(ringQ, ringP *Ring, maxAlpha int) (decomposer *Decomposer) {
 	decomposer = new(Decomposer)
 
 	decomposer.ringQ = ringQ
 	decomposer.ringP = ringP
 
 	Q := ringQ.Modulus
+	P := ringP.Modulus
 
-	decomposer.modUpParams = make([][][]modupParams, len(ringP.Modulus)-1)
+	decomposer.modUpParams = make([][][]modupParams, utils.MaxInt(maxAlpha-1, 0))
 
-	for lvlP := range ringP.Modulus[1:] {
+	// The parameters of the decompositions with alpha moduli Qi per element are stored at index alpha-2
+	for alpha := 2; alpha < maxAlpha+1; alpha++ {
 
-		P := ringP.Modulus[:lvlP+2]
+		lvlP := alpha - 2
 
-		alpha := len(P)
 		beta := int(math.Ceil(float64(len(Q)) / float64(alpha)))
 
 		xalpha := make([]int, beta)
@@ -0,0 +1,68 @@
+package ring
+
+import (
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// WithConcurrency returns a copy of the Ring that distributes the work of its NTTs (across the RNS moduli) and of the
+// basis extensions from its moduli (across the coefficients) among the given number of goroutines. The value 1
+// disables the concurrency. The results do not depend on the concurrency.
+//
+// The copy shares the precomputed constants of r, which are never modified, so that r is left unchanged and that r
+// and the copy can be used at the same time.
+func (r *Ring) WithConcurrency(concurrency int) *Ring {
+	if concurrency < 1 {
+		panic("cannot WithConcurrency: concurrency must be at least 1")
+	}
+	rc := *r
+	rc.concurrency = concurrency
+	return &rc
+}
+
+// Concurrency returns the number of goroutines among which the Ring distributes its work.
+func (r *Ring) Concurrency() int {
+	return utils.MaxInt(r.concurrency, 1)
+}
+
+// concurrent returns true if the Ring distributes the given number of tasks among several goroutines. The callers
+// on the hot paths check it before calling forEachModulus or forEachChunk, and otherwise run a plain loop, since
+// the closures given to these methods are allocated on the heap.
+func (r *Ring) concurrent(tasks int) bool {
+	return r.concurrency > 1 && tasks > 1
+}
+
+// forEachModulus calls f(i) for each i in [0, level], concurrently if the concurrency of the Ring is larger than one.
+func (r *Ring) forEachModulus(level int, f func(i int)) {
+
+	if !r.concurrent(level + 1) {
+		for i := 0; i < level+1; i++ {
+			f(i)
+		}
+		return
+	}
+
+	if err := utils.RunParallel(r.concurrency, level+1, func(_, i int) {
+		f(i)
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// forEachChunk splits [0, n) into contiguous chunks whose size is a multiple of 8 and calls f(start, end) for each
+// chunk [start, end), concurrently if the concurrency of the Ring is larger than one. n must be a multiple of 8.
+func (r *Ring) forEachChunk(n int, f func(start, end int)) {
+
+	if !r.concurrent(n >> 3) {
+		f(0, n)
+		return
+	}
+
+	chunks := utils.MinInt(r.concurrency, n>>3)
+	size := ((n>>3 + chunks - 1) / chunks) << 3
+
+	if err := utils.RunParallel(chunks, (n+size-1)/size, func(_, i int) {
+		f(i*size, utils.MinInt((i+1)*size, n))
+	}); err != nil {
+		panic(err)
+	}
+}
@@ -23,64 +23,84 @@ type NumberTheoreticTransformerStandard struct {
 
 // Forward writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
 func (rntt NumberTheoreticTransformerStandard) Forward(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
-	}
+	rntt.ForwardLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // ForwardLvl writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
 // Only computes the NTT for the first level+1 moduli.
 func (rntt NumberTheoreticTransformerStandard) ForwardLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		NTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	})
 }
 
 // ForwardLazy writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
 // Returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerStandard) ForwardLazy(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
-	}
+	rntt.ForwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // ForwardLazyLvl writes the forward NTT in Z[X]/(X^N+1) of p1 on p2.
 // Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerStandard) ForwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		NTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	})
 }
 
 // Backward writes the backward NTT in Z[X]/(X^N+1) on p2.
 func (rntt NumberTheoreticTransformerStandard) Backward(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
-	}
+	rntt.BackwardLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // BackwardLvl writes the backward NTT in Z[X]/(X^N+1) on p2.
 // Only computes the NTT for the first level+1 moduli.
 func (rntt NumberTheoreticTransformerStandard) BackwardLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		InvNTT(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	})
 }
 
 // BackwardLazy writes the backward NTT in Z[X]/(X^N+1) on p2.
 // Returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerStandard) BackwardLazy(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
-	}
+	rntt.BackwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // BackwardLazyLvl writes the backward NTT in Z[X]/(X^N+1) on p2.
 // Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerStandard) BackwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		InvNTTLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	})
 }
 
 // ForwardVec writes the forward NTT in Z[X]/(X^N+1) of the i-th level of p1 on the i-th level of p2.
@@ -118,64 +138,84 @@ type NumberTheoreticTransformerConjugateInvariant struct {
 
 // Forward writes the forward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 func (rntt NumberTheoreticTransformerConjugateInvariant) Forward(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		NTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
-	}
+	rntt.ForwardLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // ForwardLvl writes the forward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Only computes the NTT for the first level+1 moduli.
 func (rntt NumberTheoreticTransformerConjugateInvariant) ForwardLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		NTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			NTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		NTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	})
 }
 
 // ForwardLazy writes the forward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerConjugateInvariant) ForwardLazy(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		NTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
-	}
+	rntt.ForwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // ForwardLazyLvl writes the forward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerConjugateInvariant) ForwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		NTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			NTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		NTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsi[x], r.Modulus[x], r.MredParams[x], r.BredParams[x])
+	})
 }
 
 // Backward writes the backward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 func (rntt NumberTheoreticTransformerConjugateInvariant) Backward(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		InvNTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
-	}
+	rntt.BackwardLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // BackwardLvl writes the backward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Only computes the NTT for the first level+1 moduli.
 func (rntt NumberTheoreticTransformerConjugateInvariant) BackwardLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		InvNTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			InvNTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		InvNTTConjugateInvariant(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	})
 }
 
 // BackwardLazy writes the backward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerConjugateInvariant) BackwardLazy(r *Ring, p1, p2 *Poly) {
-	for x := range r.Modulus {
-		InvNTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
-	}
+	rntt.BackwardLazyLvl(r, len(r.Modulus)-1, p1, p2)
 }
 
 // BackwardLazyLvl writes the backward NTT in Z[X+X^-1]/(X^2N+1) on p2.
 // Only computes the NTT for the first level+1 moduli and returns values in the range [0, 2q-1].
 func (rntt NumberTheoreticTransformerConjugateInvariant) BackwardLazyLvl(r *Ring, level int, p1, p2 *Poly) {
-	for x := 0; x < level+1; x++ {
-		InvNTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	if !r.concurrent(level + 1) {
+		for x := 0; x < level+1; x++ {
+			InvNTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+		}
+		return
 	}
+
+	r.forEachModulus(level, func(x int) {
+		InvNTTConjugateInvariantLazy(p1.Coeffs[x], p2.Coeffs[x], r.N, r.NttPsiInv[x], r.NttNInv[x], r.Modulus[x], r.MredParams[x])
+	})
 }
 
 // ForwardVec writes the forward NTT in Z[X+X^-1]/(X^2N+1) of the i-th level of p1 on the i-th level of p2.
@@ -3,7 +3,9 @@ package ring
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/ldsec/lattigo/v2/utils"
+	"io"
 	"math/bits"
 )
 
@@ -306,6 +308,107 @@ func (pol *Poly) MarshalBinary() (data []byte, err error) {
 	return
 }
 
+// MaxLogN is the log2 of the largest polynomial degree accepted by Poly.ReadFromStream.
+const MaxLogN = 17
+
+// MaxModuliCount is the largest number of moduli accepted by Poly.ReadFromStream.
+const MaxModuliCount = 64
+
+// polyTag is the tag of the header written by Poly.WriteToStream.
+const polyTag = "POLY"
+
+// WriteToStream writes the target polynomial on w, preceded by a versioned header, with the same metadata and
+// coefficient encoding as MarshalBinary. The coefficients are written one modulus at a time, so that the
+// memory overhead is independent of the number of moduli.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (pol *Poly) WriteToStream(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, polyTag); err != nil {
+		return
+	}
+
+	N := pol.Degree()
+
+	metadata := [4]byte{uint8(bits.Len64(uint64(N)) - 1), uint8(pol.LenModuli())}
+	if pol.IsNTT {
+		metadata[2] = 1
+	}
+	if pol.IsMForm {
+		metadata[3] = 1
+	}
+
+	var inc int
+	inc, err = w.Write(metadata[:])
+	if n += int64(inc); err != nil {
+		return
+	}
+
+	buff := make([]byte, N<<3)
+	for _, coeffs := range pol.Coeffs {
+		for j, c := range coeffs {
+			binary.BigEndian.PutUint64(buff[j<<3:], c)
+		}
+		inc, err = w.Write(buff)
+		if n += int64(inc); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFromStream reads a polynomial written by WriteToStream from r on the target polynomial. The coefficients
+// of the target polynomial are re-allocated only if their dimensions do not match the ones of the read polynomial.
+// The header is checked against MaxLogN and MaxModuliCount before any allocation.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (pol *Poly) ReadFromStream(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, polyTag); err != nil {
+		return
+	}
+
+	var metadata [4]byte
+	var inc int
+	inc, err = io.ReadFull(r, metadata[:])
+	if n += int64(inc); err != nil {
+		return
+	}
+
+	if metadata[0] > MaxLogN {
+		return n, fmt.Errorf("invalid polynomial encoding: logN=%d is larger than %d", metadata[0], MaxLogN)
+	}
+
+	if metadata[1] > MaxModuliCount {
+		return n, fmt.Errorf("invalid polynomial encoding: %d moduli is larger than %d", metadata[1], MaxModuliCount)
+	}
+
+	N := 1 << metadata[0]
+	numberModuli := int(metadata[1])
+
+	pol.IsNTT = metadata[2] == 1
+	pol.IsMForm = metadata[3] == 1
+
+	if len(pol.Coeffs) != numberModuli || (numberModuli > 0 && len(pol.Coeffs[0]) != N) {
+		pol.Coeffs = make([][]uint64, numberModuli)
+		for i := range pol.Coeffs {
+			pol.Coeffs[i] = make([]uint64, N)
+		}
+	}
+
+	buff := make([]byte, N<<3)
+	for _, coeffs := range pol.Coeffs {
+		inc, err = io.ReadFull(r, buff)
+		if n += int64(inc); err != nil {
+			return
+		}
+		for j := range coeffs {
+			coeffs[j] = binary.BigEndian.Uint64(buff[j<<3:])
+		}
+	}
+
+	return
+}
+
 // UnmarshalBinary decodes a slice of byte on the target polynomial.
 func (pol *Poly) UnmarshalBinary(data []byte) (err error) {
 
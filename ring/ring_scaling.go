@@ -40,8 +40,7 @@ func NewRNSScaler(ringQ, ringT *Ring) (rnss *RNSScaler) {
 	t := ringT.Modulus[0]
 
 	rnss.qHalf = new(big.Int)
-	rnss.qInv = rnss.qHalf.Mod(ringQ.ModulusBigint, NewUint(t)).Uint64()
-	rnss.qInv = ModExp(rnss.qInv, t-2, t)
+	rnss.qInv = rnss.qHalf.ModInverse(ringQ.ModulusBigint, NewUint(t)).Uint64()
 	rnss.qInv = MForm(rnss.qInv, t, BRedParams(t))
 
 	rnss.qHalf.Set(ringQ.ModulusBigint)
@@ -1,6 +1,7 @@
 package ring
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"math/big"
@@ -60,6 +61,7 @@ func TestRing(t *testing.T) {
 	}
 
 	testNewRing(t)
+	testNTTPrimePower(t)
 	for _, defaultParam := range defaultParams[:] {
 
 		var testContext *testParams
@@ -83,6 +85,7 @@ func TestRing(t *testing.T) {
 		testExtendBasis(testContext, t)
 		testScaling(testContext, t)
 		testMultByMonomial(testContext, t)
+		testConcurrency(testContext, t)
 	}
 }
 
@@ -166,6 +169,78 @@ func testNewRing(t *testing.T) {
 		require.NotNil(t, r)
 		require.NoError(t, err)
 
+		r, err = NewRing(16, []uint64{97 * 97 * 97}) // Passing NTT-enabling prime power coeff modulus
+		require.NotNil(t, r)
+		require.NoError(t, err)
+
+		r, err = NewRing(16, []uint64{97 * 89}) // Passing a composite coeff modulus that is not a prime power
+		require.NotNil(t, r)
+		require.Error(t, err)
+
+	})
+}
+
+func testNTTPrimePower(t *testing.T) {
+
+	t.Run("NTT/PrimePower/", func(t *testing.T) {
+
+		N := 64
+
+		p, e, ok := IsPrimePower(65537 * 65537)
+		require.True(t, ok)
+		require.Equal(t, uint64(65537), p)
+		require.Equal(t, 2, e)
+
+		_, _, ok = IsPrimePower(65537 * 97)
+		require.False(t, ok)
+
+		for _, qi := range GenerateNTTPrimesP(61, 2*N, 4) {
+			p, e, ok = IsPrimePower(qi)
+			require.True(t, ok)
+			require.Equal(t, qi, p)
+			require.Equal(t, 1, e)
+		}
+
+		q := uint64(65537 * 65537)
+
+		ringQ, err := NewRing(N, []uint64{q})
+		require.NoError(t, err)
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+
+		sampler := NewUniformSampler(prng, ringQ)
+
+		p0 := sampler.ReadNew()
+		p1 := sampler.ReadNew()
+
+		// Schoolbook negacyclic product modulo q
+		want := make([]*big.Int, N)
+		for i := range want {
+			want[i] = new(big.Int)
+		}
+		tmp := new(big.Int)
+		for i := 0; i < N; i++ {
+			for j := 0; j < N; j++ {
+				tmp.Mul(NewUint(p0.Coeffs[0][i]), NewUint(p1.Coeffs[0][j]))
+				if i+j < N {
+					want[i+j].Add(want[i+j], tmp)
+				} else {
+					want[i+j-N].Sub(want[i+j-N], tmp)
+				}
+			}
+		}
+
+		ringQ.NTT(p0, p0)
+		ringQ.NTT(p1, p1)
+		ringQ.MForm(p0, p0)
+		ringQ.MulCoeffsMontgomery(p0, p1, p0)
+		ringQ.InvNTT(p0, p0)
+
+		Q := NewUint(q)
+		for i := 0; i < N; i++ {
+			require.Equal(t, want[i].Mod(want[i], Q).Uint64(), p0.Coeffs[0][i])
+		}
 	})
 }
 
@@ -322,6 +397,50 @@ func testMarshalBinary(testContext *testParams, t *testing.T) {
 			require.Equal(t, p.Coeffs[i][:testContext.ringQ.N], pTest.Coeffs[i][:testContext.ringQ.N])
 		}
 	})
+
+	t.Run(testString("WriteTo/Poly/", testContext.ringQ), func(t *testing.T) {
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		p.IsNTT = true
+
+		buff := new(bytes.Buffer)
+		n, err := p.WriteToStream(buff)
+		require.NoError(t, err)
+		require.Equal(t, int64(buff.Len()), n)
+		require.Equal(t, int64(p.GetDataLen(true)+5), n)
+
+		pTest := new(Poly)
+		n, err = pTest.ReadFromStream(buff)
+		require.NoError(t, err)
+		require.Equal(t, int64(p.GetDataLen(true)+5), n)
+		require.True(t, p.Equals(pTest))
+		require.True(t, pTest.IsNTT)
+
+		// Truncated stream
+		buff.Reset()
+		_, err = p.WriteToStream(buff)
+		require.NoError(t, err)
+		_, err = pTest.ReadFromStream(bytes.NewReader(buff.Bytes()[:buff.Len()-1]))
+		require.Error(t, err)
+
+		// Unsupported version
+		data := buff.Bytes()
+		data[4]++
+		_, err = pTest.ReadFromStream(bytes.NewReader(data))
+		require.Error(t, err)
+		data[4]--
+
+		// Oversized header: rejected before allocating the coefficients
+		logN := data[5]
+		data[5] = MaxLogN + 1
+		_, err = pTest.ReadFromStream(bytes.NewReader(data))
+		require.Error(t, err)
+
+		data[5] = logN
+		data[6] = MaxModuliCount + 1
+		_, err = pTest.ReadFromStream(bytes.NewReader(data))
+		require.Error(t, err)
+	})
 }
 
 func testUniformSampler(testContext *testParams, t *testing.T) {
@@ -764,3 +883,69 @@ func testMultByMonomial(testContext *testParams, t *testing.T) {
 		require.Equal(t, p3Want.Coeffs[0][:testContext.ringQ.N], p3Test.Coeffs[0][:testContext.ringQ.N])
 	})
 }
+
+func testConcurrency(testContext *testParams, t *testing.T) {
+
+	t.Run(testString("Concurrency/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ, ringP := testContext.ringQ, testContext.ringP
+
+		ringQConc := ringQ.WithConcurrency(3)
+		ringPConc := ringP.WithConcurrency(3)
+
+		require.Equal(t, 1, ringQ.Concurrency())
+		require.Equal(t, 3, ringQConc.Concurrency())
+		require.Panics(t, func() { ringQ.WithConcurrency(0) })
+
+		levelQ, levelP := len(ringQ.Modulus)-1, len(ringP.Modulus)-1
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		want, have := ringQ.NewPoly(), ringQ.NewPoly()
+
+		ringQ.NTT(p, want)
+		ringQConc.NTT(p, have)
+		require.True(t, ringQ.Equal(want, have))
+
+		ringQ.InvNTTLazyLvl(levelQ-1, p, want)
+		ringQConc.InvNTTLazyLvl(levelQ-1, p, have)
+		require.True(t, ringQ.EqualLvl(levelQ-1, want, have))
+
+		ringQCI, err := NewRingConjugateInvariant(ringQ.N, ringQ.Modulus)
+		require.NoError(t, err)
+		ringQCIConc := ringQCI.WithConcurrency(3)
+
+		ringQCI.NTTLazy(p, want)
+		ringQCIConc.NTTLazy(p, have)
+		require.True(t, ringQ.Equal(want, have))
+
+		pP := testContext.uniformSamplerP.ReadNew()
+		wantP, haveP := ringP.NewPoly(), ringP.NewPoly()
+
+		NewFastBasisExtender(ringQ, ringP).ModUpQtoP(levelQ, levelP, p, wantP)
+		NewFastBasisExtender(ringQConc, ringPConc).ModUpQtoP(levelQ, levelP, p, haveP)
+		require.True(t, ringP.Equal(wantP, haveP))
+
+		NewFastBasisExtender(ringQ, ringP).ModDownQPtoQNTT(levelQ, levelP, p, pP, want)
+		NewFastBasisExtender(ringQConc, ringPConc).ModDownQPtoQNTT(levelQ, levelP, p, pP, have)
+		require.True(t, ringQ.Equal(want, have))
+	})
+
+	t.Run(testString("Concurrency/NoAllocation/", testContext.ringQ), func(t *testing.T) {
+
+		ringQ, ringP := testContext.ringQ, testContext.ringP
+		levelQ, levelP := len(ringQ.Modulus)-1, len(ringP.Modulus)-1
+
+		p := testContext.uniformSamplerQ.ReadNew()
+		pP := testContext.uniformSamplerP.ReadNew()
+		buff, buffP := ringQ.NewPoly(), ringP.NewPoly()
+		be := NewFastBasisExtender(ringQ, ringP)
+
+		// Without concurrency, the NTT and the basis extensions do not allocate
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			ringQ.NTT(p, buff)
+			ringQ.InvNTTLvl(levelQ, buff, buff)
+			be.ModUpQtoP(levelQ, levelP, p, buffP)
+			be.ModDownQPtoQNTT(levelQ, levelP, p, pP, buff)
+		}))
+	})
+}
@@ -14,6 +14,11 @@ type Encryptor interface {
 	// EncryptFromCRP encrypts the input plaintext and writes the result in ctOut.
 	// The encryption algorithm depends on the implementor.
 	EncryptFromCRP(pt *Plaintext, crp *ring.Poly, ctOut *Ciphertext)
+
+	// EncryptSeeded encrypts the input plaintext with the uniformly random element sampled from a KeyedPRNG
+	// keyed with seed, and writes the result in the compressed form ctOut.
+	// The encryption algorithm depends on the implementor.
+	EncryptSeeded(pt *Plaintext, seed []byte, ctOut *SeededCiphertext)
 }
 
 // encryptorBase is a struct used to encrypt Plaintexts. It stores the public-key and/or secret-key.
@@ -23,7 +28,7 @@ type encryptorBase struct {
 	ringQ *ring.Ring
 	ringP *ring.Ring
 
-	poolQ [1]*ring.Poly
+	poolQ [2]*ring.Poly
 	poolP [3]*ring.Poly
 
 	gaussianSampler *ring.GaussianSampler
@@ -240,6 +245,23 @@ func (encryptor *skEncryptor) EncryptFromCRP(plaintext *Plaintext, crp *ring.Pol
 	encryptor.encrypt(plaintext, ctOut)
 }
 
+// EncryptSeeded encrypts the input Plaintext with the uniformly random element c1 sampled from a KeyedPRNG keyed
+// with seed, and writes the result in the compressed form ctOut. The Ciphertext is recovered with ctOut.Expand.
+func (encryptor *skEncryptor) EncryptSeeded(plaintext *Plaintext, seed []byte, ctOut *SeededCiphertext) {
+
+	levelQ := utils.MinInt(plaintext.Level(), ctOut.Level())
+
+	// Shallow copy of the pool, so that its coefficients are not truncated to levelQ
+	c1 := *encryptor.poolQ[1]
+	c1.Coeffs = c1.Coeffs[:levelQ+1]
+
+	sampleCRP(encryptor.params, seed, &c1)
+
+	encryptor.encrypt(plaintext, &Ciphertext{Value: []*ring.Poly{ctOut.Value, &c1}})
+
+	ctOut.Seed = append(ctOut.Seed[:0], seed...)
+}
+
 func (encryptor *skEncryptor) encrypt(plaintext *Plaintext, ciphertext *Ciphertext) {
 
 	ringQ := encryptor.ringQ
@@ -313,7 +335,7 @@ func newEncryptorBase(params Parameters) encryptorBase {
 		params:          params,
 		ringQ:           ringQ,
 		ringP:           ringP,
-		poolQ:           [1]*ring.Poly{ringQ.NewPoly()},
+		poolQ:           [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()},
 		poolP:           poolP,
 		gaussianSampler: ring.NewGaussianSampler(prng, ringQ, params.Sigma(), int(6*params.Sigma())),
 		ternarySampler:  ring.NewTernarySampler(prng, ringQ, 0.5, false),
@@ -324,3 +346,7 @@ func newEncryptorBase(params Parameters) encryptorBase {
 func (encryptor *encryptorBase) EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ctOut *Ciphertext) {
 	panic("Cannot encrypt with CRP using an encryptor created with the public-key")
 }
+
+func (encryptor *encryptorBase) EncryptSeeded(plaintext *Plaintext, seed []byte, ctOut *SeededCiphertext) {
+	panic("Cannot encrypt with seed using an encryptor created with the public-key")
+}
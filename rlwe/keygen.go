@@ -1,7 +1,6 @@
 package rlwe
 
 import (
-	"math"
 	"math/big"
 
 	"github.com/ldsec/lattigo/v2/ring"
@@ -15,17 +14,23 @@ type KeyGenerator interface {
 	GenSecretKeyWithDistrib(p float64) (sk *SecretKey)
 	GenSecretKeySparse(hw int) (sk *SecretKey)
 	GenPublicKey(sk *SecretKey) (pk *PublicKey)
+	GenPublicKeySeeded(sk *SecretKey) (pk *SeededPublicKey)
 	GenKeyPair() (sk *SecretKey, pk *PublicKey)
 	GenKeyPairSparse(hw int) (sk *SecretKey, pk *PublicKey)
 	GenRelinearizationKey(sk *SecretKey, maxDegree int) (evk *RelinearizationKey)
+	GenRelinearizationKeyLvl(levelQ int, sk *SecretKey, maxDegree int) (evk *RelinearizationKey)
 	GenSwitchingKey(skInput, skOutput *SecretKey) (newevakey *SwitchingKey)
 	GenSwitchingKeyForGalois(galEl uint64, sk *SecretKey) (swk *SwitchingKey)
 	GenRotationKeys(galEls []uint64, sk *SecretKey) (rks *RotationKeySet)
+	GenRotationKeysLvl(levelQ int, galEls []uint64, sk *SecretKey) (rks *RotationKeySet)
 	GenSwitchingKeyForRotationBy(k int, sk *SecretKey) (swk *SwitchingKey)
 	GenRotationKeysForRotations(ks []int, inclueSwapRows bool, sk *SecretKey) (rks *RotationKeySet)
 	GenSwitchingKeyForRowRotation(sk *SecretKey) (swk *SwitchingKey)
 	GenRotationKeysForInnerSum(sk *SecretKey) (rks *RotationKeySet)
 	GenSwitchingKeysForRingSwap(skCKKS, skCI *SecretKey) (swkStdToConjugateInvariant, swkConjugateInvariantToStd *SwitchingKey)
+	GenSwitchingKeySeeded(skInput, skOutput *SecretKey) (swk *SeededSwitchingKey)
+	GenSwitchingKeyForGaloisSeeded(galEl uint64, sk *SecretKey) (swk *SeededSwitchingKey)
+	GenRotationKeysSeeded(galEls []uint64, sk *SecretKey) (rks *SeededRotationKeySet)
 }
 
 // KeyGenerator is a structure that stores the elements required to create new keys,
@@ -35,8 +40,7 @@ type keyGenerator struct {
 	poolQ            *ring.Poly
 	poolQP           PolyQP
 	gaussianSamplerQ *ring.GaussianSampler
-	uniformSamplerQ  *ring.UniformSampler
-	uniformSamplerP  *ring.UniformSampler
+	uniformSampler   UniformSamplerQP
 }
 
 // NewKeyGenerator creates a new KeyGenerator, from which the secret and public keys, as well as the evaluation,
@@ -53,8 +57,7 @@ func NewKeyGenerator(params Parameters) KeyGenerator {
 		poolQ:            params.RingQ().NewPoly(),
 		poolQP:           params.RingQP().NewPoly(),
 		gaussianSamplerQ: ring.NewGaussianSampler(prng, params.RingQ(), params.Sigma(), int(6*params.Sigma())),
-		uniformSamplerQ:  ring.NewUniformSampler(prng, params.RingQ()),
-		uniformSamplerP:  ring.NewUniformSampler(prng, params.RingP()),
+		uniformSampler:   NewUniformSamplerQP(params, prng, params.RingQP()),
 	}
 }
 
@@ -90,23 +93,40 @@ func (keygen *keyGenerator) GenSecretKeySparse(hw int) (sk *SecretKey) {
 
 // GenPublicKey generates a new public key from the provided SecretKey.
 func (keygen *keyGenerator) GenPublicKey(sk *SecretKey) (pk *PublicKey) {
+	pk = NewPublicKey(keygen.params)
+	keygen.genPublicKey(sk, keygen.uniformSampler, pk)
+	return
+}
+
+// GenPublicKeySeeded generates a new public key as GenPublicKey and returns it in the compressed form
+// of a SeededPublicKey, with a new random seed.
+func (keygen *keyGenerator) GenPublicKeySeeded(sk *SecretKey) (spk *SeededPublicKey) {
+
+	spk = &SeededPublicKey{Seed: newSeed()}
+
+	pk := NewPublicKey(keygen.params)
+	keygen.genPublicKey(sk, keygen.newSeededSampler(spk.Seed), pk)
+
+	spk.Value = pk.Value[0]
+
+	return
+}
+
+// genPublicKey writes on pk a new public key of sk, whose uniform element is sampled from the provided sampler.
+func (keygen *keyGenerator) genPublicKey(sk *SecretKey, sampler UniformSamplerQP, pk *PublicKey) {
 
-	pk = new(PublicKey)
 	ringQP := keygen.params.RingQP()
 	levelQ, levelP := keygen.params.QCount()-1, keygen.params.PCount()-1
 
 	//pk[0] = [-as + e]
 	//pk[1] = [a]
-	pk = NewPublicKey(keygen.params)
 	keygen.gaussianSamplerQ.Read(pk.Value[0].Q)
 	ringQP.ExtendBasisSmallNormAndCenter(pk.Value[0].Q, levelP, nil, pk.Value[0].P)
 	ringQP.NTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
 
-	keygen.uniformSamplerQ.Read(pk.Value[1].Q)
-	keygen.uniformSamplerP.Read(pk.Value[1].P)
+	sampler.Read(&pk.Value[1])
 
 	ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, sk.Value, pk.Value[1], pk.Value[0])
-	return pk
 }
 
 // GenKeyPair generates a new SecretKey with distribution [1/3, 1/3, 1/3] and a corresponding public key.
@@ -123,12 +143,21 @@ func (keygen *keyGenerator) GenKeyPairSparse(hw int) (sk *SecretKey, pk *PublicK
 
 // GenRelinKey generates a new EvaluationKey that will be used to relinearize Ciphertexts during multiplication.
 func (keygen *keyGenerator) GenRelinearizationKey(sk *SecretKey, maxDegree int) (evk *RelinearizationKey) {
+	return keygen.GenRelinearizationKeyLvl(keygen.params.MaxLevel(), sk, maxDegree)
+}
+
+// GenRelinearizationKeyLvl generates a new RelinearizationKey as GenRelinearizationKey, but modulo the moduli Qi
+// up to levelQ only, which reduces its size. It can only relinearize ciphertexts of level at most levelQ.
+func (keygen *keyGenerator) GenRelinearizationKeyLvl(levelQ int, sk *SecretKey, maxDegree int) (evk *RelinearizationKey) {
 
 	if keygen.params.PCount() == 0 {
 		panic("modulus P is empty")
 	}
 
-	levelQ := keygen.params.QCount() - 1
+	if levelQ < 0 || levelQ > keygen.params.MaxLevel() {
+		panic("cannot GenRelinearizationKeyLvl: levelQ must be in [0, MaxLevel]")
+	}
+
 	levelP := keygen.params.PCount() - 1
 
 	evk = new(RelinearizationKey)
@@ -141,7 +170,7 @@ func (keygen *keyGenerator) GenRelinearizationKey(sk *SecretKey, maxDegree int)
 	ringQ := keygen.params.RingQ()
 	for i := 0; i < maxDegree; i++ {
 		ringQ.MulCoeffsMontgomery(keygen.poolQP.Q, sk.Value.Q, keygen.poolQP.Q)
-		keygen.genSwitchingKey(keygen.poolQP.Q, sk.Value, evk.Keys[i])
+		keygen.genSwitchingKey(keygen.poolQP.Q, sk.Value, keygen.uniformSampler, evk.Keys[i])
 	}
 
 	return
@@ -150,9 +179,21 @@ func (keygen *keyGenerator) GenRelinearizationKey(sk *SecretKey, maxDegree int)
 // GenRotationKeys generates a RotationKeySet from a list of galois element corresponding to the desired rotations
 // See also GenRotationKeysForRotations.
 func (keygen *keyGenerator) GenRotationKeys(galEls []uint64, sk *SecretKey) (rks *RotationKeySet) {
-	rks = NewRotationKeySet(keygen.params, galEls)
+	return keygen.GenRotationKeysLvl(keygen.params.MaxLevel(), galEls, sk)
+}
+
+// GenRotationKeysLvl generates a RotationKeySet as GenRotationKeys, but modulo the moduli Qi up to levelQ only,
+// which reduces its size. Its keys can only rotate ciphertexts of level at most levelQ.
+func (keygen *keyGenerator) GenRotationKeysLvl(levelQ int, galEls []uint64, sk *SecretKey) (rks *RotationKeySet) {
+
+	if levelQ < 0 || levelQ > keygen.params.MaxLevel() {
+		panic("cannot GenRotationKeysLvl: levelQ must be in [0, MaxLevel]")
+	}
+
+	rks = &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(galEls))}
 	for _, galEl := range galEls {
-		keygen.genrotKey(sk.Value, keygen.params.InverseGaloisElement(galEl), rks.Keys[galEl])
+		rks.Keys[galEl] = NewSwitchingKey(keygen.params, levelQ, keygen.params.PCount()-1)
+		keygen.genrotKey(sk.Value, keygen.params.InverseGaloisElement(galEl), keygen.uniformSampler, rks.Keys[galEl])
 	}
 	return rks
 }
@@ -160,7 +201,7 @@ func (keygen *keyGenerator) GenRotationKeys(galEls []uint64, sk *SecretKey) (rks
 func (keygen *keyGenerator) GenSwitchingKeyForRotationBy(k int, sk *SecretKey) (swk *SwitchingKey) {
 	swk = NewSwitchingKey(keygen.params, keygen.params.QCount()-1, keygen.params.PCount()-1)
 	galElInv := keygen.params.GaloisElementForColumnRotationBy(-int(k))
-	keygen.genrotKey(sk.Value, galElInv, swk)
+	keygen.genrotKey(sk.Value, galElInv, keygen.uniformSampler, swk)
 	return
 }
 
@@ -180,13 +221,17 @@ func (keygen *keyGenerator) GenRotationKeysForRotations(ks []int, includeConjuga
 
 func (keygen *keyGenerator) GenSwitchingKeyForRowRotation(sk *SecretKey) (swk *SwitchingKey) {
 	swk = NewSwitchingKey(keygen.params, keygen.params.QCount()-1, keygen.params.PCount()-1)
-	keygen.genrotKey(sk.Value, keygen.params.GaloisElementForRowRotation(), swk)
+	keygen.genrotKey(sk.Value, keygen.params.GaloisElementForRowRotation(), keygen.uniformSampler, swk)
 	return
 }
 
 func (keygen *keyGenerator) GenSwitchingKeyForGalois(galoisEl uint64, sk *SecretKey) (swk *SwitchingKey) {
+	return keygen.genSwitchingKeyForGalois(galoisEl, sk, keygen.uniformSampler)
+}
+
+func (keygen *keyGenerator) genSwitchingKeyForGalois(galoisEl uint64, sk *SecretKey, sampler UniformSamplerQP) (swk *SwitchingKey) {
 	swk = NewSwitchingKey(keygen.params, keygen.params.QCount()-1, keygen.params.PCount()-1)
-	keygen.genrotKey(sk.Value, keygen.params.InverseGaloisElement(galoisEl), swk)
+	keygen.genrotKey(sk.Value, keygen.params.InverseGaloisElement(galoisEl), sampler, swk)
 	return
 }
 
@@ -195,7 +240,7 @@ func (keygen *keyGenerator) GenRotationKeysForInnerSum(sk *SecretKey) (rks *Rota
 	return keygen.GenRotationKeys(keygen.params.GaloisElementsForRowInnerSum(), sk)
 }
 
-func (keygen *keyGenerator) genrotKey(sk PolyQP, galEl uint64, swk *SwitchingKey) {
+func (keygen *keyGenerator) genrotKey(sk PolyQP, galEl uint64, sampler UniformSamplerQP, swk *SwitchingKey) {
 
 	skIn := sk
 	skOut := keygen.poolQP
@@ -205,7 +250,7 @@ func (keygen *keyGenerator) genrotKey(sk PolyQP, galEl uint64, swk *SwitchingKey
 	ringQ.PermuteNTTWithIndexLvl(keygen.params.QCount()-1, skIn.Q, index, skOut.Q)
 	ringQ.PermuteNTTWithIndexLvl(keygen.params.PCount()-1, skIn.P, index, skOut.P)
 
-	keygen.genSwitchingKey(skIn.Q, skOut, swk)
+	keygen.genSwitchingKey(skIn.Q, skOut, sampler, swk)
 }
 
 // GenSwitchingKeysForRingSwap generates the necessary switching keys to switch from a standard ring to to a conjugate invariant ring and vice-versa.
@@ -230,6 +275,10 @@ func (keygen *keyGenerator) GenSwitchingKeysForRingSwap(skStd, skConjugateInvari
 // When key-switching a ciphertext from X^{N} to Y^{N/n}, the output of the key-switch is in still X^{N} and
 // must be mapped Y^{N/n} using SwitchCiphertextRingDegreeNTT(ctLargeDim, ringQLargeDim, ctSmallDim).
 func (keygen *keyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (swk *SwitchingKey) {
+	return keygen.genSwitchingKeyFromSecretKeys(skInput, skOutput, keygen.uniformSampler)
+}
+
+func (keygen *keyGenerator) genSwitchingKeyFromSecretKeys(skInput, skOutput *SecretKey, sampler UniformSamplerQP) (swk *SwitchingKey) {
 
 	if keygen.params.PCount() == 0 {
 		panic("Cannot GenSwitchingKey: modulus P is empty")
@@ -240,7 +289,7 @@ func (keygen *keyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (swk *
 	if len(skInput.Value.Q.Coeffs[0]) > len(skOutput.Value.Q.Coeffs[0]) { // N -> n
 		ring.MapSmallDimensionToLargerDimensionNTT(skOutput.Value.Q, keygen.poolQP.Q)
 		ring.MapSmallDimensionToLargerDimensionNTT(skOutput.Value.P, keygen.poolQP.P)
-		keygen.genSwitchingKey(skInput.Value.Q, keygen.poolQP, swk)
+		keygen.genSwitchingKey(skInput.Value.Q, keygen.poolQP, sampler, swk)
 	} else { // N -> N or n -> N
 		ring.MapSmallDimensionToLargerDimensionNTT(skInput.Value.Q, keygen.poolQ)
 
@@ -278,12 +327,63 @@ func (keygen *keyGenerator) GenSwitchingKey(skInput, skOutput *SecretKey) (swk *
 			}
 		}
 
-		keygen.genSwitchingKey(keygen.poolQ, skOutput.Value, swk)
+		keygen.genSwitchingKey(keygen.poolQ, skOutput.Value, sampler, swk)
+	}
+
+	return
+}
+
+// GenSwitchingKeySeeded generates a new key-switching key as GenSwitchingKey and returns it in the compressed
+// form of a SeededSwitchingKey, with a new random seed.
+func (keygen *keyGenerator) GenSwitchingKeySeeded(skInput, skOutput *SecretKey) (swk *SeededSwitchingKey) {
+	return keygen.genSeeded(func(sampler UniformSamplerQP) *SwitchingKey {
+		return keygen.genSwitchingKeyFromSecretKeys(skInput, skOutput, sampler)
+	})
+}
+
+// GenSwitchingKeyForGaloisSeeded generates a new key-switching key as GenSwitchingKeyForGalois and returns it in
+// the compressed form of a SeededSwitchingKey, with a new random seed.
+func (keygen *keyGenerator) GenSwitchingKeyForGaloisSeeded(galoisEl uint64, sk *SecretKey) (swk *SeededSwitchingKey) {
+	return keygen.genSeeded(func(sampler UniformSamplerQP) *SwitchingKey {
+		return keygen.genSwitchingKeyForGalois(galoisEl, sk, sampler)
+	})
+}
+
+// GenRotationKeysSeeded generates a RotationKeySet as GenRotationKeys and returns it in the compressed
+// form of a SeededRotationKeySet, with a new random seed per key.
+func (keygen *keyGenerator) GenRotationKeysSeeded(galEls []uint64, sk *SecretKey) (rks *SeededRotationKeySet) {
+	rks = &SeededRotationKeySet{Keys: make(map[uint64]*SeededSwitchingKey, len(galEls))}
+	for _, galEl := range galEls {
+		rks.Keys[galEl] = keygen.GenSwitchingKeyForGaloisSeeded(galEl, sk)
+	}
+	return
+}
+
+// genSeeded calls gen with a uniform sampler reading from a KeyedPRNG keyed with a new random seed, and returns
+// the generated switching key in the compressed form of a SeededSwitchingKey.
+func (keygen *keyGenerator) genSeeded(gen func(sampler UniformSamplerQP) *SwitchingKey) (sswk *SeededSwitchingKey) {
+
+	seed := newSeed()
+
+	swk := gen(keygen.newSeededSampler(seed))
+
+	sswk = &SeededSwitchingKey{Seed: seed, Value: make([]PolyQP, len(swk.Value))}
+	for i := range swk.Value {
+		sswk.Value[i] = swk.Value[i][0]
 	}
 
 	return
 }
 
+// newSeededSampler returns a new UniformSamplerQP reading from a KeyedPRNG keyed with the provided seed.
+func (keygen *keyGenerator) newSeededSampler(seed []byte) UniformSamplerQP {
+	prng, err := utils.NewKeyedPRNG(seed)
+	if err != nil {
+		panic(err)
+	}
+	return NewUniformSamplerQP(keygen.params, prng, keygen.params.RingQP())
+}
+
 // genSecretKeyFromSampler generates a new SecretKey sampled from the provided Sampler.
 func (keygen *keyGenerator) genSecretKeyFromSampler(sampler ring.Sampler) *SecretKey {
 	ringQP := keygen.params.RingQP()
@@ -297,7 +397,7 @@ func (keygen *keyGenerator) genSecretKeyFromSampler(sampler ring.Sampler) *Secre
 	return sk
 }
 
-func (keygen *keyGenerator) genSwitchingKey(skIn *ring.Poly, skOut PolyQP, swk *SwitchingKey) {
+func (keygen *keyGenerator) genSwitchingKey(skIn *ring.Poly, skOut PolyQP, sampler UniformSamplerQP, swk *SwitchingKey) {
 
 	ringQ := keygen.params.RingQ()
 	ringQP := keygen.params.RingQP()
@@ -319,48 +419,60 @@ func (keygen *keyGenerator) genSwitchingKey(skIn *ring.Poly, skOut PolyQP, swk *
 	// Computes P * skIn
 	ringQ.MulScalarBigintLvl(levelQ, skIn, pBigInt, keygen.poolQ)
 
-	alpha := levelP + 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	alpha := keygen.params.DecompAlpha(levelP)
+	decompRNS := keygen.params.DecompRNS(levelQ, levelP)
+	decompPw2 := keygen.params.DecompPw2(levelQ, levelP)
+
+	// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
+	// The elements are sampled in increasing order of index, which is the order expected by SeededSwitchingKey.Expand.
+	for i := range swk.Value {
+		sampler.Read(&swk.Value[i][1])
+	}
 
 	var index int
-	for i := 0; i < beta; i++ {
-
-		// e
-		keygen.gaussianSamplerQ.ReadLvl(levelQ, swk.Value[i][0].Q)
-		ringQP.ExtendBasisSmallNormAndCenter(swk.Value[i][0].Q, levelP, nil, swk.Value[i][0].P)
-		ringQP.NTTLazyLvl(levelQ, levelP, swk.Value[i][0], swk.Value[i][0])
-		ringQP.MFormLvl(levelQ, levelP, swk.Value[i][0], swk.Value[i][0])
-
-		// a (since a is uniform, we consider we already sample it in the NTT and Montgomery domain)
-		keygen.uniformSamplerQ.ReadLvl(levelQ, swk.Value[i][1].Q)
-		keygen.uniformSamplerP.ReadLvl(levelP, swk.Value[i][1].P)
-
-		// e + (skIn * P) * (q_star * q_tild) mod QP
-		//
-		// q_prod = prod(q[i*alpha+j])
-		// q_star = Q/qprod
-		// q_tild = q_star^-1 mod q_prod
-		//
-		// Therefore : (skIn * P) * (q_star * q_tild) = sk*P mod q[i*alpha+j], else 0
-		for j := 0; j < alpha; j++ {
-
-			index = i*alpha + j
-
-			// It handles the case where nb pj does not divide nb qi
-			if index >= levelQ+1 {
-				break
-			}
+	for j := 0; j < decompPw2; j++ {
+		for i := 0; i < decompRNS; i++ {
+
+			swkVal := swk.Value[i*decompPw2+j]
+
+			// e
+			keygen.gaussianSamplerQ.ReadLvl(levelQ, swkVal[0].Q)
+			ringQP.ExtendBasisSmallNormAndCenter(swkVal[0].Q, levelP, nil, swkVal[0].P)
+			ringQP.NTTLazyLvl(levelQ, levelP, swkVal[0], swkVal[0])
+			ringQP.MFormLvl(levelQ, levelP, swkVal[0], swkVal[0])
+
+			// e + (skIn * P * 2^(j*Pow2Base)) * (q_star * q_tild) mod QP
+			//
+			// q_prod = prod(q[i*alpha+k])
+			// q_star = Q/qprod
+			// q_tild = q_star^-1 mod q_prod
+			//
+			// Therefore : (skIn * P * 2^(j*Pow2Base)) * (q_star * q_tild) = sk*P*2^(j*Pow2Base) mod q[i*alpha+k], else 0
+			for k := 0; k < alpha; k++ {
+
+				index = i*alpha + k
+
+				// It handles the case where nb pj does not divide nb qi
+				if index >= levelQ+1 {
+					break
+				}
 
-			qi := ringQ.Modulus[index]
-			p0tmp := keygen.poolQ.Coeffs[index]
-			p1tmp := swk.Value[i][0].Q.Coeffs[index]
+				qi := ringQ.Modulus[index]
+				p0tmp := keygen.poolQ.Coeffs[index]
+				p1tmp := swkVal[0].Q.Coeffs[index]
 
-			for w := 0; w < ringQ.N; w++ {
-				p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+				for w := 0; w < ringQ.N; w++ {
+					p1tmp[w] = ring.CRed(p1tmp[w]+p0tmp[w], qi)
+				}
 			}
+
+			// (skIn * P * 2^(j*Pow2Base)) * (q_star * q_tild) - a * skOut + e mod QP
+			ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, swkVal[1], skOut, swkVal[0])
 		}
 
-		// (skIn * P) * (q_star * q_tild) - a * skOut + e mod QP
-		ringQP.MulCoeffsMontgomeryAndSubLvl(levelQ, levelP, swk.Value[i][1], skOut, swk.Value[i][0])
+		// skIn * P * 2^((j+1)*Pow2Base)
+		if pow2Base := keygen.params.Pow2Base(); pow2Base != 0 {
+			ringQ.MulScalarLvl(levelQ, keygen.poolQ, 1<<pow2Base, keygen.poolQ)
+		}
 	}
 }
@@ -1,9 +1,5 @@
 package rlwe
 
-import (
-	"math"
-)
-
 // SecretKey is a type for generic RLWE secret keys.
 type SecretKey struct {
 	Value PolyQP
@@ -15,6 +11,9 @@ type PublicKey struct {
 }
 
 // SwitchingKey is a type for generic RLWE public switching keys.
+// Value stores one element per digit of the key-switching decomposition: the element at index
+// i * decompPw2 + j is associated with the j-th base-2^Pow2Base digit of the i-th element of the
+// RNS decomposition basis (see Parameters.DecompRNS and Parameters.DecompPw2).
 type SwitchingKey struct {
 	Value [][2]PolyQP
 }
@@ -79,7 +78,7 @@ func (rtks *RotationKeySet) GetRotationKey(galoisEl uint64) (*SwitchingKey, bool
 
 // NewSwitchingKey returns a new public switching key with pre-allocated zero-value
 func NewSwitchingKey(params Parameters, levelQ, levelP int) *SwitchingKey {
-	decompSize := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	decompSize := params.DecompCount(levelQ, levelP)
 	swk := new(SwitchingKey)
 	swk.Value = make([][2]PolyQP, int(decompSize))
 	for i := 0; i < decompSize; i++ {
@@ -170,6 +169,17 @@ func (swk *SwitchingKey) Equals(other *SwitchingKey) bool {
 	return true
 }
 
+// LevelQ returns the level of the modulus Q of the SwitchingKey, which can be smaller than the maximum level of
+// the parameters (see KeyGenerator.GenRelinearizationKeyLvl and KeyGenerator.GenRotationKeysLvl).
+func (swk *SwitchingKey) LevelQ() int {
+	return swk.Value[0][0].Q.Level()
+}
+
+// LevelP returns the level of the modulus P of the SwitchingKey.
+func (swk *SwitchingKey) LevelP() int {
+	return swk.Value[0][0].P.Level()
+}
+
 // CopyNew creates a deep copy of the receiver SwitchingKey and returns it.
 func (swk *SwitchingKey) CopyNew() *SwitchingKey {
 	if swk == nil || len(swk.Value) == 0 {
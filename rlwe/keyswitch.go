@@ -1,9 +1,10 @@
 package rlwe
 
 import (
-	"math"
+	"fmt"
 
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
 )
 
 // KeySwitcher is a struct for RLWE key-switching.
@@ -20,20 +21,31 @@ type keySwitcherBuffer struct {
 	Pool         [6]PolyQP
 	PoolInvNTT   *ring.Poly
 	PoolDecompQP []PolyQP // Memory pool for the basis extension in hoisting
+
+	// Buffers of the goroutines of the concurrent key switching, allocated on demand
+	workers []*keySwitcherWorker
+}
+
+// keySwitcherWorker stores the buffers of a goroutine of the concurrent key switching: the decomposed digit c2 and the
+// accumulators c0 and c1 of the digits processed by the goroutine.
+type keySwitcherWorker struct {
+	c2, c0, c1 PolyQP
+	used       bool
+	reduce     int
 }
 
 func newKeySwitcherBuffer(params Parameters) *keySwitcherBuffer {
 
 	buff := new(keySwitcherBuffer)
-	beta := params.Beta()
+	decompCount := params.DecompCount(params.QCount()-1, params.PCount()-1)
 	ringQP := params.RingQP()
 
 	buff.Pool = [6]PolyQP{ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly(), ringQP.NewPoly()}
 
 	buff.PoolInvNTT = params.RingQ().NewPoly()
 
-	buff.PoolDecompQP = make([]PolyQP, beta)
-	for i := 0; i < beta; i++ {
+	buff.PoolDecompQP = make([]PolyQP, decompCount)
+	for i := 0; i < decompCount; i++ {
 		buff.PoolDecompQP[i] = ringQP.NewPoly()
 	}
 
@@ -45,7 +57,7 @@ func NewKeySwitcher(params Parameters) *KeySwitcher {
 	ks := new(KeySwitcher)
 	ks.Parameters = &params
 	ks.Baseconverter = ring.NewFastBasisExtender(params.RingQ(), params.RingP())
-	ks.Decomposer = ring.NewDecomposer(params.RingQ(), params.RingP())
+	ks.Decomposer = ring.NewDecomposerWithMaxAlpha(params.RingQ(), params.RingP(), utils.MaxInt(params.Alpha(), params.PCount()))
 	ks.keySwitcherBuffer = newKeySwitcherBuffer(params)
 	return ks
 }
@@ -60,6 +72,33 @@ func (ks *KeySwitcher) ShallowCopy() *KeySwitcher {
 	}
 }
 
+// SetConcurrency sets the number of goroutines among which the KeySwitcher distributes the decomposition digits of a
+// key switching, as well as its NTTs and basis extensions (see ring.Ring.WithConcurrency). The default value 1
+// disables the concurrency. The results do not depend on the concurrency.
+//
+// The setting only applies to this KeySwitcher and to its subsequent shallow copies: the rings of the parameters it
+// was created with are left unchanged.
+func (ks *KeySwitcher) SetConcurrency(concurrency int) {
+
+	if concurrency < 1 {
+		panic("cannot SetConcurrency: concurrency must be at least 1")
+	}
+
+	params := *ks.Parameters
+	params.ringQ = params.ringQ.WithConcurrency(concurrency)
+	if params.ringP != nil {
+		params.ringP = params.ringP.WithConcurrency(concurrency)
+	}
+
+	ks.Parameters = &params
+	ks.Baseconverter = ring.NewFastBasisExtender(params.ringQ, params.ringP)
+}
+
+// Concurrency returns the number of goroutines among which the KeySwitcher distributes its work.
+func (ks *KeySwitcher) Concurrency() int {
+	return ks.RingQ().Concurrency()
+}
+
 // SwitchKeysInPlace applies the general key-switching procedure of the form [c0 + cx*evakey[0], c1 + cx*evakey[1]]
 // Will return the result in the same NTT domain as the input cx.
 func (ks *KeySwitcher) SwitchKeysInPlace(levelQ int, cx *ring.Poly, evakey *SwitchingKey, p0, p1 *ring.Poly) {
@@ -82,10 +121,11 @@ func (ks *KeySwitcher) SwitchKeysInPlace(levelQ int, cx *ring.Poly, evakey *Swit
 	}
 }
 
-// DecomposeNTT applies the full RNS basis decomposition for all q_alpha_i on c2.
+// DecomposeNTT applies the full RNS basis decomposition for all q_alpha_i on c2, followed by
+// the base-2^Pow2Base digit decomposition if Pow2Base is not zero.
 // Expects the IsNTT flag of c2 to correctly reflect the domain of c2.
 // PoolDecompQ and PoolDecompQ are vectors of polynomials (mod Q and mod P) that store the
-// special RNS decomposition of c2 (in the NTT domain)
+// special RNS decomposition of c2 (in the NTT domain), indexed as the elements of a SwitchingKey.
 func (ks *KeySwitcher) DecomposeNTT(levelQ, levelP, alpha int, c2 *ring.Poly, PoolDecomp []PolyQP) {
 
 	ringQ := ks.RingQ()
@@ -102,10 +142,18 @@ func (ks *KeySwitcher) DecomposeNTT(levelQ, levelP, alpha int, c2 *ring.Poly, Po
 		ringQ.NTTLvl(levelQ, polyInvNTT, polyNTT)
 	}
 
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	decompRNS := ks.DecompRNS(levelQ, levelP)
+	decompPw2 := ks.DecompPw2(levelQ, levelP)
 
-	for i := 0; i < beta; i++ {
-		ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, polyNTT, polyInvNTT, PoolDecomp[i].Q, PoolDecomp[i].P)
+	// The digits are independent and are distributed among the goroutines set by the concurrency of the KeySwitcher
+	if err := utils.RunParallel(ks.Concurrency(), decompRNS*decompPw2, func(_, i int) {
+		if ks.Pow2Base() == 0 {
+			ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, polyNTT, polyInvNTT, PoolDecomp[i].Q, PoolDecomp[i].P)
+		} else {
+			ks.DecomposeSingleNTTPw2(levelQ, levelP, i/decompPw2, i%decompPw2, polyInvNTT, PoolDecomp[i].Q, PoolDecomp[i].P)
+		}
+	}); err != nil {
+		panic(err)
 	}
 }
 
@@ -119,7 +167,7 @@ func (ks *KeySwitcher) DecomposeSingleNTT(levelQ, levelP, alpha, beta int, c2NTT
 
 	ks.Decomposer.DecomposeAndSplit(levelQ, levelP, alpha, beta, c2InvNTT, c2QiQ, c2QiP)
 
-	p0idxst := beta * (levelP + 1)
+	p0idxst := beta * alpha
 	p0idxed := p0idxst + 1
 
 	// c2_qi = cx mod qi mod qi
@@ -134,14 +182,47 @@ func (ks *KeySwitcher) DecomposeSingleNTT(levelQ, levelP, alpha, beta int, c2NTT
 	ringP.NTTLazyLvl(levelP, c2QiP, c2QiP)
 }
 
+// DecomposeSingleNTTPw2 takes the input polynomial c2InvNTT (out of the NTT domain) and returns on c2QiQ and c2QiP
+// the receiver polynomials respectively mod Q and mod P (in the NTT domain) the decompPw2-th base-2^Pow2Base digit
+// of c2InvNTT mod q_decompRNS. It requires Pow2Base to be non-zero, in which case the RNS decomposition has a single
+// modulus per element.
+func (ks *KeySwitcher) DecomposeSingleNTTPw2(levelQ, levelP, decompRNS, decompPw2 int, c2InvNTT, c2QiQ, c2QiP *ring.Poly) {
+
+	pow2Base := ks.Pow2Base()
+	mask := uint64(1)<<pow2Base - 1
+	shift := uint64(decompPw2 * pow2Base)
+
+	src := c2InvNTT.Coeffs[decompRNS]
+	digit := c2QiQ.Coeffs[0]
+	for x := range digit {
+		digit[x] = (src[x] >> shift) & mask
+	}
+
+	// The digit is smaller than all the moduli
+	for x := 1; x < levelQ+1; x++ {
+		copy(c2QiQ.Coeffs[x], digit)
+	}
+
+	for x := 0; x < levelP+1; x++ {
+		copy(c2QiP.Coeffs[x], digit)
+	}
+
+	ks.RingQ().NTTLazyLvl(levelQ, c2QiQ, c2QiQ)
+	ks.RingP().NTTLazyLvl(levelP, c2QiP, c2QiP)
+}
+
 // SwitchKeysInPlaceNoModDown applies the key-switch to the polynomial cx :
 //
 // pool2 = dot(decomp(cx) * evakey[0]) mod QP (encrypted input is multiplied by P factor)
 // pool3 = dot(decomp(cx) * evakey[1]) mod QP (encrypted input is multiplied by P factor)
 //
 // Expects the flag IsNTT of cx to correctly reflect the domain of cx.
+// The level of evakey can be larger than levelQ, in which case only the first elements of its decomposition and
+// its first levelQ+1 moduli Qi are used, but it cannot be smaller.
 func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, evakey *SwitchingKey, c0Q, c0P, c1Q, c1P *ring.Poly) {
 
+	checkKeyLevel("SwitchKeysInPlaceNoModDown", levelQ, evakey)
+
 	var reduce int
 
 	ringQ := ks.RingQ()
@@ -166,24 +247,39 @@ func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, eva
 
 	reduce = 0
 
-	alpha := len(evakey.Value[0][0].P.Coeffs)
-	levelP := alpha - 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(levelP+1)))
+	levelP := len(evakey.Value[0][0].P.Coeffs) - 1
+	alpha := ks.DecompAlpha(levelP)
+	decompRNS := ks.DecompRNS(levelQ, levelP)
+	decompPw2 := ks.DecompPw2(levelQ, levelP)
+	stride := ks.decompStride(evakey)
 
 	QiOverF := ks.Parameters.QiOverflowMargin(levelQ) >> 1
 	PiOverF := ks.Parameters.PiOverflowMargin(levelP) >> 1
 
+	if concurrency := utils.MinInt(ks.Concurrency(), decompRNS*decompPw2); concurrency > 1 {
+		ks.switchKeysDigitsConcurrent(concurrency, levelQ, levelP, alpha, cxNTT, cxInvNTT, evakey, c0QP, c1QP)
+		return
+	}
+
 	// Key switching with CRT decomposition for the Qi
-	for i := 0; i < beta; i++ {
+	for i := 0; i < decompRNS*decompPw2; i++ {
 
-		ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, cxNTT, cxInvNTT, c2QP.Q, c2QP.P)
+		idxRNS, idxPw2 := i/decompPw2, i%decompPw2
+
+		if ks.Pow2Base() == 0 {
+			ks.DecomposeSingleNTT(levelQ, levelP, alpha, idxRNS, cxNTT, cxInvNTT, c2QP.Q, c2QP.P)
+		} else {
+			ks.DecomposeSingleNTTPw2(levelQ, levelP, idxRNS, idxPw2, cxInvNTT, c2QP.Q, c2QP.P)
+		}
+
+		evakeyi := evakey.Value[idxRNS*stride+idxPw2]
 
 		if i == 0 {
-			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][0], c2QP, c0QP)
-			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][1], c2QP, c1QP)
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[0], c2QP, c0QP)
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[1], c2QP, c1QP)
 		} else {
-			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakey.Value[i][0], c2QP, c0QP)
-			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakey.Value[i][1], c2QP, c1QP)
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[0], c2QP, c0QP)
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[1], c2QP, c1QP)
 		}
 
 		if reduce%QiOverF == QiOverF-1 {
@@ -210,6 +306,91 @@ func (ks *KeySwitcher) SwitchKeysInPlaceNoModDown(levelQ int, cx *ring.Poly, eva
 	}
 }
 
+// switchKeysDigitsConcurrent is the concurrent counterpart of the loop over the decomposition digits of
+// SwitchKeysInPlaceNoModDown: the digits are distributed among the given number of goroutines, which accumulate
+// their products with the key in their own buffers, and the accumulators are then reduced and summed. Since the
+// outputs are fully reduced, they are identical to the ones of the sequential loop.
+func (ks *KeySwitcher) switchKeysDigitsConcurrent(concurrency, levelQ, levelP, alpha int, cxNTT, cxInvNTT *ring.Poly, evakey *SwitchingKey, c0QP, c1QP PolyQP) {
+
+	ringQ := ks.RingQ()
+	ringP := ks.RingP()
+	ringQP := ks.RingQP()
+
+	decompPw2 := ks.DecompPw2(levelQ, levelP)
+	stride := ks.decompStride(evakey)
+
+	QiOverF := ks.Parameters.QiOverflowMargin(levelQ) >> 1
+	PiOverF := ks.Parameters.PiOverflowMargin(levelP) >> 1
+
+	for len(ks.workers) < concurrency {
+		ks.workers = append(ks.workers, &keySwitcherWorker{c2: ringQP.NewPoly(), c0: ringQP.NewPoly(), c1: ringQP.NewPoly()})
+	}
+
+	for _, w := range ks.workers {
+		w.used, w.reduce = false, 0
+	}
+
+	if err := utils.RunParallel(concurrency, ks.DecompRNS(levelQ, levelP)*decompPw2, func(worker, i int) {
+
+		w := ks.workers[worker]
+
+		idxRNS, idxPw2 := i/decompPw2, i%decompPw2
+
+		if ks.Pow2Base() == 0 {
+			ks.DecomposeSingleNTT(levelQ, levelP, alpha, idxRNS, cxNTT, cxInvNTT, w.c2.Q, w.c2.P)
+		} else {
+			ks.DecomposeSingleNTTPw2(levelQ, levelP, idxRNS, idxPw2, cxInvNTT, w.c2.Q, w.c2.P)
+		}
+
+		evakeyi := evakey.Value[idxRNS*stride+idxPw2]
+
+		if !w.used {
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[0], w.c2, w.c0)
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[1], w.c2, w.c1)
+			w.used = true
+		} else {
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[0], w.c2, w.c0)
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[1], w.c2, w.c1)
+		}
+
+		if w.reduce%QiOverF == QiOverF-1 {
+			ringQ.ReduceLvl(levelQ, w.c0.Q, w.c0.Q)
+			ringQ.ReduceLvl(levelQ, w.c1.Q, w.c1.Q)
+		}
+
+		if w.reduce%PiOverF == PiOverF-1 {
+			ringP.ReduceLvl(levelP, w.c0.P, w.c0.P)
+			ringP.ReduceLvl(levelP, w.c1.P, w.c1.P)
+		}
+
+		w.reduce++
+	}); err != nil {
+		panic(err)
+	}
+
+	first := true
+	for _, w := range ks.workers[:concurrency] {
+
+		if !w.used {
+			continue
+		}
+
+		for _, acc := range []PolyQP{w.c0, w.c1} {
+			ringQ.ReduceLvl(levelQ, acc.Q, acc.Q)
+			ringP.ReduceLvl(levelP, acc.P, acc.P)
+		}
+
+		if first {
+			ringQP.CopyValuesLvl(levelQ, levelP, w.c0, c0QP)
+			ringQP.CopyValuesLvl(levelQ, levelP, w.c1, c1QP)
+			first = false
+		} else {
+			ringQP.AddLvl(levelQ, levelP, c0QP, w.c0, c0QP)
+			ringQP.AddLvl(levelQ, levelP, c1QP, w.c1, c1QP)
+		}
+	}
+}
+
 // KeyswitchHoisted applies the key-switch to the decomposed polynomial c2 mod QP (PoolDecompQ and PoolDecompP)
 // and divides the result by P, reducing the basis from QP to Q.
 //
@@ -230,8 +411,12 @@ func (ks *KeySwitcher) KeyswitchHoisted(levelQ int, PoolDecompQP []PolyQP, evake
 //
 // pool2 = dot(PoolDecompQ||PoolDecompP * evakey[0]) mod QP
 // pool3 = dot(PoolDecompQ||PoolDecompP * evakey[1]) mod QP
+//
+// As for SwitchKeysInPlaceNoModDown, the level of evakey cannot be smaller than levelQ.
 func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []PolyQP, evakey *SwitchingKey, c0Q, c1Q, c0P, c1P *ring.Poly) {
 
+	checkKeyLevel("KeyswitchHoistedNoModDown", levelQ, evakey)
+
 	ringQ := ks.RingQ()
 	ringP := ks.RingP()
 	ringQP := ks.RingQP()
@@ -239,23 +424,29 @@ func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []Poly
 	c0QP := PolyQP{c0Q, c0P}
 	c1QP := PolyQP{c1Q, c1P}
 
-	alpha := len(evakey.Value[0][0].P.Coeffs)
-	levelP := alpha - 1
-	beta := int(math.Ceil(float64(levelQ+1) / float64(alpha)))
+	levelP := len(evakey.Value[0][0].P.Coeffs) - 1
+	decompRNS := ks.DecompRNS(levelQ, levelP)
+	decompPw2 := ks.DecompPw2(levelQ, levelP)
+	stride := ks.decompStride(evakey)
 
 	QiOverF := ks.Parameters.QiOverflowMargin(levelQ) >> 1
 	PiOverF := ks.Parameters.PiOverflowMargin(levelP) >> 1
 
 	// Key switching with CRT decomposition for the Qi
 	var reduce int
-	for i := 0; i < beta; i++ {
+	for i := 0; i < decompRNS*decompPw2; i++ {
+
+		idxRNS, idxPw2 := i/decompPw2, i%decompPw2
+
+		evakeyi := evakey.Value[idxRNS*stride+idxPw2]
+		decompi := PoolDecompQP[idxRNS*decompPw2+idxPw2]
 
 		if i == 0 {
-			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][0], PoolDecompQP[i], c0QP)
-			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakey.Value[i][1], PoolDecompQP[i], c1QP)
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[0], decompi, c0QP)
+			ringQP.MulCoeffsMontgomeryConstantLvl(levelQ, levelP, evakeyi[1], decompi, c1QP)
 		} else {
-			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakey.Value[i][0], PoolDecompQP[i], c0QP)
-			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakey.Value[i][1], PoolDecompQP[i], c1QP)
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[0], decompi, c0QP)
+			ringQP.MulCoeffsMontgomeryConstantAndAddNoModLvl(levelQ, levelP, evakeyi[1], decompi, c1QP)
 		}
 
 		if reduce%QiOverF == QiOverF-1 {
@@ -281,3 +472,16 @@ func (ks *KeySwitcher) KeyswitchHoistedNoModDown(levelQ int, PoolDecompQP []Poly
 		ringP.ReduceLvl(levelP, c1QP.P, c1QP.P)
 	}
 }
+
+// checkKeyLevel panics if the level of evakey is smaller than the level levelQ of the key-switching.
+func checkKeyLevel(op string, levelQ int, evakey *SwitchingKey) {
+	if levelQ > evakey.LevelQ() {
+		panic(fmt.Sprintf("cannot %s: the level of the key-switching (%d) is larger than the level of the key (%d)", op, levelQ, evakey.LevelQ()))
+	}
+}
+
+// decompStride returns the number of base-2^Pow2Base digits per element of the RNS decomposition basis
+// in the given switching key, which can be generated for a larger level than the one of the key-switching.
+func (ks *KeySwitcher) decompStride(evakey *SwitchingKey) int {
+	return ks.DecompPw2(evakey.Value[0][0].Q.Level(), evakey.Value[0][0].P.Level())
+}
@@ -0,0 +1,157 @@
+// Package lwe implements LWE ciphertexts and their conversion from and to RLWE ciphertexts, following
+// Chen, Dai, Kim and Song, "Efficient Homomorphic Conversion Between (Ring) LWE Ciphertexts" (https://eprint.iacr.org/2020/015):
+// the extraction of the coefficients of an RLWE ciphertext as LWE ciphertexts, the LWE key-switching
+// and the repacking of LWE ciphertexts into a single RLWE ciphertext.
+package lwe
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+const ciphertextTag = "LWEC"
+
+// Ciphertext is an RNS LWE ciphertext (b, a) of dimension N, whose phase is b + <a, s> mod Q
+// for the secret key s of dimension N.
+type Ciphertext struct {
+	B []uint64   // B[i] is b mod q_i
+	A [][]uint64 // A[i] is the vector a mod q_i
+}
+
+// NewCiphertext allocates a new LWE Ciphertext of dimension N at the given level.
+func NewCiphertext(N, level int) (ct *Ciphertext) {
+	ct = &Ciphertext{B: make([]uint64, level+1), A: make([][]uint64, level+1)}
+	for i := range ct.A {
+		ct.A[i] = make([]uint64, N)
+	}
+	return
+}
+
+// N returns the dimension of the ciphertext.
+func (ct *Ciphertext) N() int {
+	return len(ct.A[0])
+}
+
+// Level returns the level of the ciphertext.
+func (ct *Ciphertext) Level() int {
+	return len(ct.B) - 1
+}
+
+// Copy copies the value of ctIn on the receiver, at the smallest level of the two.
+func (ct *Ciphertext) Copy(ctIn *Ciphertext) {
+	for i := 0; i < utils.MinInt(ct.Level(), ctIn.Level())+1; i++ {
+		ct.B[i] = ctIn.B[i]
+		copy(ct.A[i], ctIn.A[i])
+	}
+}
+
+// CopyNew returns a deep copy of the ciphertext.
+func (ct *Ciphertext) CopyNew() (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(ct.N(), ct.Level())
+	ctOut.Copy(ct)
+	return
+}
+
+// GetDataLen returns the length in bytes of the binary encoding of the ciphertext.
+func (ct *Ciphertext) GetDataLen() int {
+	return 5 + 8*(ct.Level()+1)*(ct.N()+1)
+}
+
+// MarshalBinary encodes the ciphertext on a slice of bytes.
+func (ct *Ciphertext) MarshalBinary() (data []byte, err error) {
+
+	if ct.Level() > 0xFE {
+		return nil, errors.New("lwe.Ciphertext: uint8 overflow on level")
+	}
+
+	data = make([]byte, ct.GetDataLen())
+	data[0] = uint8(ct.Level() + 1)
+	binary.BigEndian.PutUint32(data[1:], uint32(ct.N()))
+
+	ptr := 5
+	for i := range ct.B {
+		binary.BigEndian.PutUint64(data[ptr:], ct.B[i])
+		ptr += 8
+		for _, c := range ct.A[i] {
+			binary.BigEndian.PutUint64(data[ptr:], c)
+			ptr += 8
+		}
+	}
+
+	return
+}
+
+// UnmarshalBinary decodes a slice of bytes generated by MarshalBinary on the target ciphertext.
+func (ct *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+
+	if len(data) < 5 {
+		return errors.New("lwe.Ciphertext: too small bytearray")
+	}
+
+	moduli, N := int(data[0]), int(binary.BigEndian.Uint32(data[1:]))
+
+	if len(data) != 5+8*moduli*(N+1) {
+		return errors.New("lwe.Ciphertext: invalid bytearray length")
+	}
+
+	*ct = *NewCiphertext(N, moduli-1)
+
+	ptr := 5
+	for i := range ct.B {
+		ct.B[i] = binary.BigEndian.Uint64(data[ptr:])
+		ptr += 8
+		for j := range ct.A[i] {
+			ct.A[i][j] = binary.BigEndian.Uint64(data[ptr:])
+			ptr += 8
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target Ciphertext on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (ct *Ciphertext) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, ciphertextTag); err != nil {
+		return
+	}
+
+	var data []byte
+	if data, err = ct.MarshalBinary(); err != nil {
+		return
+	}
+
+	inc, err := w.Write(data)
+	n += int64(inc)
+
+	return
+}
+
+// ReadFrom reads a Ciphertext written by WriteTo from r on the target Ciphertext.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (ct *Ciphertext) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, ciphertextTag); err != nil {
+		return
+	}
+
+	header := make([]byte, 5)
+	inc, err := io.ReadFull(r, header)
+	if n += int64(inc); err != nil {
+		return
+	}
+
+	data := make([]byte, 5+8*int(header[0])*(int(binary.BigEndian.Uint32(header[1:]))+1))
+	copy(data, header)
+
+	inc, err = io.ReadFull(r, data[5:])
+	if n += int64(inc); err != nil {
+		return
+	}
+
+	return n, ct.UnmarshalBinary(data)
+}
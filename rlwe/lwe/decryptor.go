@@ -0,0 +1,47 @@
+package lwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Decryptor is a structure for the decryption of LWE ciphertexts under the coefficient vector of an RLWE secret key.
+type Decryptor struct {
+	ringQ *ring.Ring
+	sk    *ring.Poly
+}
+
+// NewDecryptor creates a new Decryptor for the LWE ciphertexts of dimension params.N() under the secret key sk.
+func NewDecryptor(params rlwe.Parameters, sk *rlwe.SecretKey) *Decryptor {
+	ringQ := params.RingQ()
+	skInvNTT := ringQ.NewPoly()
+	ringQ.InvNTT(sk.Value.Q, skInvNTT)
+	ringQ.InvMForm(skInvNTT, skInvNTT)
+	return &Decryptor{ringQ: ringQ, sk: skInvNTT}
+}
+
+// DecryptNew returns the phase b + <a, s> mod q_i of the LWE ciphertext ct, for each modulus q_i up to its level.
+func (dec *Decryptor) DecryptNew(ct *Ciphertext) (phase []uint64) {
+
+	if ct.N() != dec.ringQ.N {
+		panic("cannot DecryptNew: ciphertext and secret key dimensions do not match")
+	}
+
+	phase = make([]uint64, ct.Level()+1)
+
+	for i := range phase {
+
+		qi := dec.ringQ.Modulus[i]
+		bredParams := dec.ringQ.BredParams[i]
+		s := dec.sk.Coeffs[i]
+
+		acc := ct.B[i]
+		for j, aj := range ct.A[i] {
+			acc = ring.CRed(acc+ring.BRed(aj, s[j], qi, bredParams), qi)
+		}
+
+		phase[i] = acc
+	}
+
+	return
+}
@@ -0,0 +1,89 @@
+package lwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// SampleExtract returns the LWE ciphertexts of the coefficients of the given indices of the plaintext of ct, such that
+// the phase of the i-th LWE ciphertext is the coefficient indices[i] of the phase of ct.
+// The LWE ciphertexts are at the level of ct and of dimension N, under the coefficient vector of the secret key of ct.
+func SampleExtract(params rlwe.Parameters, ct *rlwe.Ciphertext, indices []int) (ctOut []*Ciphertext) {
+
+	if params.RingType() != ring.Standard {
+		panic("cannot SampleExtract: only the standard ring is supported")
+	}
+
+	if ct.Degree() != 1 {
+		panic("cannot SampleExtract: input ciphertext must be of degree 1")
+	}
+
+	ringQ := params.RingQ()
+	level := ct.Level()
+
+	c0, c1 := ct.Value[0], ct.Value[1]
+	if ct.Value[0].IsNTT {
+		c0, c1 = ringQ.NewPolyLvl(level), ringQ.NewPolyLvl(level)
+		ringQ.InvNTTLvl(level, ct.Value[0], c0)
+		ringQ.InvNTTLvl(level, ct.Value[1], c1)
+	}
+
+	ctOut = make([]*Ciphertext, len(indices))
+	for k, idx := range indices {
+
+		if idx < 0 || idx >= params.N() {
+			panic("cannot SampleExtract: index out of range")
+		}
+
+		ctOut[k] = NewCiphertext(params.N(), level)
+		for i := 0; i < level+1; i++ {
+			extract(idx, 1, c0.Coeffs[i], c1.Coeffs[i], ringQ.Modulus[i], &ctOut[k].B[i], ctOut[k].A[i])
+		}
+	}
+
+	return
+}
+
+// extract writes on (b, a) the LWE encryption mod q of the coefficient idx of the phase c0 + c1*s of the negacyclic
+// RLWE ciphertext (c0, c1) of dimension len(a), whose coefficients are read with the stride gap.
+//
+// The coefficient idx of c1*s is sum_{j <= idx} c1[idx-j]*s_j - sum_{j > idx} c1[N+idx-j]*s_j.
+func extract(idx, gap int, c0, c1 []uint64, q uint64, b *uint64, a []uint64) {
+
+	N := len(a)
+
+	*b = c0[idx*gap]
+
+	for j := 0; j <= idx; j++ {
+		a[j] = c1[(idx-j)*gap]
+	}
+
+	for j := idx + 1; j < N; j++ {
+		if c := c1[(N+idx-j)*gap]; c != 0 {
+			a[j] = q - c
+		} else {
+			a[j] = 0
+		}
+	}
+}
+
+// embed writes on (c0, c1) an RLWE ciphertext mod q, in the coefficient domain, whose phase has the phase of the LWE
+// ciphertext (b, a) as constant coefficient. It is the inverse of extract for idx = 0.
+func embed(b uint64, a []uint64, q uint64, c0, c1 []uint64) {
+
+	N := len(a)
+
+	for j := range c0 {
+		c0[j] = 0
+	}
+	c0[0] = b
+
+	c1[0] = a[0]
+	for j := 1; j < N; j++ {
+		if a[j] != 0 {
+			c1[N-j] = q - a[j]
+		} else {
+			c1[N-j] = 0
+		}
+	}
+}
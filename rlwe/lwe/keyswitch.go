@@ -0,0 +1,84 @@
+package lwe
+
+import (
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// KeySwitcher is a structure for the key-switching of LWE ciphertexts of dimension N. The LWE ciphertexts are embedded
+// as the constant coefficient of RLWE ciphertexts of dimension N, key-switched with an rlwe.KeySwitcher and extracted
+// back, which reduces the cost of the key-switching from O(N^2) to O(N log N).
+type KeySwitcher struct {
+	params rlwe.Parameters
+	ks     *rlwe.KeySwitcher
+	ctRLWE *rlwe.Ciphertext
+}
+
+// NewKeySwitcher creates a new KeySwitcher for the LWE ciphertexts of dimension params.N().
+func NewKeySwitcher(params rlwe.Parameters) *KeySwitcher {
+
+	if params.RingType() != ring.Standard {
+		panic("cannot NewKeySwitcher: only the standard ring is supported")
+	}
+
+	return &KeySwitcher{
+		params: params,
+		ks:     rlwe.NewKeySwitcher(params),
+		ctRLWE: rlwe.NewCiphertextNTT(params, 1, params.MaxLevel()),
+	}
+}
+
+// ShallowCopy creates a shallow copy of the KeySwitcher, only reallocating its buffers.
+func (ks *KeySwitcher) ShallowCopy() *KeySwitcher {
+	return &KeySwitcher{
+		params: ks.params,
+		ks:     ks.ks.ShallowCopy(),
+		ctRLWE: rlwe.NewCiphertextNTT(ks.params, 1, ks.params.MaxLevel()),
+	}
+}
+
+// SwitchKeys switches the LWE ciphertext ctIn of dimension N under the secret key skIn to the LWE ciphertext ctOut
+// under the secret key skOut, with the switching key swk = rlwe.KeyGenerator.GenSwitchingKey(skIn, skOut).
+// skOut can be of dimension n < N, in which case ctOut must be of dimension n: since the switching key maps skIn
+// to skOut(X^{N/n}), the output is read on the coefficients of index multiple of N/n.
+// The level of ctOut is the smallest level of ctIn and ctOut.
+func (ks *KeySwitcher) SwitchKeys(ctIn *Ciphertext, swk *rlwe.SwitchingKey, ctOut *Ciphertext) {
+
+	ringQ := ks.params.RingQ()
+
+	N, n := ctIn.N(), ctOut.N()
+
+	if N != ks.params.N() {
+		panic("cannot SwitchKeys: ctIn dimension does not match the parameters")
+	}
+
+	if n > N || N%n != 0 {
+		panic("cannot SwitchKeys: ctOut dimension must divide ctIn dimension")
+	}
+
+	level := ctIn.Level()
+	if ctOut.Level() < level {
+		level = ctOut.Level()
+	}
+
+	c0, c1 := ks.ctRLWE.Value[0], ks.ctRLWE.Value[1]
+
+	for i := 0; i < level+1; i++ {
+		embed(ctIn.B[i], ctIn.A[i], ringQ.Modulus[i], c0.Coeffs[i], c1.Coeffs[i])
+	}
+
+	ringQ.NTTLvl(level, c1, c1)
+	c1.IsNTT = true
+
+	// (c0, 0) + <g^-1(c1), swk>
+	ks.ks.SwitchKeysInPlace(level, c1, swk, ks.ks.Pool[1].Q, ks.ks.Pool[2].Q)
+	ringQ.InvNTTLvl(level, ks.ks.Pool[1].Q, ks.ks.Pool[1].Q)
+	ringQ.InvNTTLvl(level, ks.ks.Pool[2].Q, c1)
+	ringQ.AddLvl(level, c0, ks.ks.Pool[1].Q, c0)
+
+	for i := 0; i < level+1; i++ {
+		extract(0, N/n, c0.Coeffs[i], c1.Coeffs[i], ringQ.Modulus[i], &ctOut.B[i], ctOut.A[i])
+	}
+
+	ctOut.B, ctOut.A = ctOut.B[:level+1], ctOut.A[:level+1]
+}
@@ -0,0 +1,278 @@
+package lwe
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+func testString(params rlwe.Parameters, opname string) string {
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d/Pw2=%d",
+		opname,
+		params.LogN(),
+		params.LogQ(),
+		params.LogP(),
+		params.QCount(),
+		params.PCount(),
+		params.Pow2Base())
+}
+
+type testContext struct {
+	params    rlwe.Parameters
+	kgen      rlwe.KeyGenerator
+	sk        *rlwe.SecretKey
+	encryptor rlwe.Encryptor
+	decryptor rlwe.Decryptor
+	delta     uint64 // scaling factor of the messages, such that the noise must be smaller than delta/2
+}
+
+func TestLWE(t *testing.T) {
+
+	defaultParams := []rlwe.ParametersLiteral{rlwe.TestPN12QP109Pw2, rlwe.TestPN13QP218, rlwe.TestPN14QP438}
+	if testing.Short() {
+		defaultParams = defaultParams[:2]
+	}
+
+	for _, defaultParam := range defaultParams {
+
+		params, err := rlwe.NewParametersFromLiteral(defaultParam)
+		if err != nil {
+			panic(err)
+		}
+
+		tc := &testContext{params: params, kgen: rlwe.NewKeyGenerator(params)}
+		tc.sk = tc.kgen.GenSecretKey()
+		tc.encryptor = rlwe.NewEncryptor(params, tc.sk)
+		tc.decryptor = rlwe.NewDecryptor(params, tc.sk)
+		tc.delta = params.Q()[0] >> 5
+
+		for _, testSet := range []func(tc *testContext, t *testing.T){
+			testSampleExtract,
+			testKeySwitcher,
+			testRepacker,
+			testMarshaller,
+		} {
+			testSet(tc, t)
+			runtime.GC()
+		}
+	}
+}
+
+// encryptCoefficients returns an RLWE encryption, in the NTT domain, of the polynomial whose coefficients are
+// (i mod 16) * delta.
+func encryptCoefficients(tc *testContext, level int) (ct *rlwe.Ciphertext) {
+	ringQ := tc.params.RingQ()
+	pt := rlwe.NewPlaintext(tc.params, level)
+	for i := 0; i < level+1; i++ {
+		for j := range pt.Value.Coeffs[i] {
+			pt.Value.Coeffs[i][j] = (uint64(j&15) * tc.delta) % ringQ.Modulus[i]
+		}
+	}
+	ct = rlwe.NewCiphertextNTT(tc.params, 1, level)
+	tc.encryptor.Encrypt(pt, ct)
+	return
+}
+
+// verifyPhase checks that each residue of phase is the message m*delta up to a noise smaller than delta/2.
+func verifyPhase(tc *testContext, phase []uint64, m uint64, t *testing.T) {
+	for i, c := range phase {
+		qi := tc.params.RingQ().Modulus[i]
+		e := ring.CRed(c+qi-(m*tc.delta)%qi, qi)
+		if e > qi>>1 {
+			e = qi - e
+		}
+		require.Less(t, e, tc.delta>>1)
+	}
+}
+
+func testSampleExtract(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	t.Run(testString(params, "SampleExtract"), func(t *testing.T) {
+
+		level := params.MaxLevel()
+		ct := encryptCoefficients(tc, level)
+
+		indices := []int{0, 1, 17, params.N() >> 1, params.N() - 1}
+
+		cts := SampleExtract(params, ct, indices)
+		require.Len(t, cts, len(indices))
+
+		dec := NewDecryptor(params, tc.sk)
+		for k, idx := range indices {
+			require.Equal(t, params.N(), cts[k].N())
+			require.Equal(t, level, cts[k].Level())
+			verifyPhase(tc, dec.DecryptNew(cts[k]), uint64(idx&15), t)
+		}
+	})
+}
+
+func testKeySwitcher(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	paramsSmallDim, err := rlwe.NewParametersFromLiteral(rlwe.ParametersLiteral{
+		LogN:  params.LogN() - 1,
+		Q:     params.Q(),
+		P:     params.P(),
+		Sigma: rlwe.DefaultSigma,
+	})
+	require.NoError(t, err)
+
+	ks := NewKeySwitcher(params)
+
+	t.Run(testString(params, "KeySwitcher/SameDimension"), func(t *testing.T) {
+
+		skOut := tc.kgen.GenSecretKey()
+		swk := tc.kgen.GenSwitchingKey(tc.sk, skOut)
+
+		level := params.MaxLevel()
+		ctIn := SampleExtract(params, encryptCoefficients(tc, level), []int{5})[0]
+		ctOut := NewCiphertext(params.N(), level)
+
+		ks.SwitchKeys(ctIn, swk, ctOut)
+
+		require.Equal(t, level, ctOut.Level())
+		verifyPhase(tc, NewDecryptor(params, skOut).DecryptNew(ctOut), 5, t)
+	})
+
+	t.Run(testString(params, "KeySwitcher/LargeToSmall"), func(t *testing.T) {
+
+		skOut := rlwe.NewKeyGenerator(paramsSmallDim).GenSecretKey()
+		swk := tc.kgen.GenSwitchingKey(tc.sk, skOut)
+
+		ctIn := SampleExtract(params, encryptCoefficients(tc, params.MaxLevel()), []int{params.N() - 3})[0]
+		ctOut := NewCiphertext(paramsSmallDim.N(), 0)
+
+		ks.ShallowCopy().SwitchKeys(ctIn, swk, ctOut)
+
+		require.Equal(t, paramsSmallDim.N(), ctOut.N())
+		require.Equal(t, 0, ctOut.Level())
+		verifyPhase(tc, NewDecryptor(paramsSmallDim, skOut).DecryptNew(ctOut), uint64((params.N()-3)&15), t)
+	})
+}
+
+func testRepacker(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	rtks := tc.kgen.GenRotationKeys(GaloisElementsForRepack(params), tc.sk)
+	rp := NewRepacker(params, rtks)
+
+	for _, n := range []int{1, 16} {
+
+		t.Run(testString(params, fmt.Sprintf("Repack/n=%d", n)), func(t *testing.T) {
+
+			level := params.MaxLevel()
+
+			// Takes the LWE ciphertexts of the coefficients n-1, ..., 0 so that the
+			// messages are reordered by the repacking.
+			indices := make([]int, n)
+			for i := range indices {
+				indices[i] = n - 1 - i
+			}
+
+			cts := SampleExtract(params, encryptCoefficients(tc, level), indices)
+
+			ctOut := rp.ShallowCopy().Repack(cts)
+			require.Equal(t, level, ctOut.Level())
+
+			pt := rlwe.NewPlaintext(params, level)
+			tc.decryptor.Decrypt(ctOut, pt)
+
+			gap := params.N() / n
+			phase := make([]uint64, level+1)
+			for j := 0; j < params.N(); j++ {
+
+				for i := range phase {
+					phase[i] = pt.Value.Coeffs[i][j]
+				}
+
+				var m uint64
+				if j%gap == 0 {
+					m = uint64(indices[j/gap] & 15)
+				}
+
+				verifyPhase(tc, phase, m, t)
+			}
+		})
+	}
+
+	t.Run(testString(params, "Repack/Nil"), func(t *testing.T) {
+
+		level := params.MaxLevel()
+
+		cts := SampleExtract(params, encryptCoefficients(tc, level), []int{0, 0, 3, 0})
+		cts[0], cts[1], cts[3] = nil, nil, nil
+
+		ctOut := rp.Repack(cts)
+
+		pt := rlwe.NewPlaintext(params, level)
+		tc.decryptor.Decrypt(ctOut, pt)
+
+		gap := params.N() / len(cts)
+		phase := make([]uint64, level+1)
+		for j := 0; j < params.N(); j++ {
+
+			for i := range phase {
+				phase[i] = pt.Value.Coeffs[i][j]
+			}
+
+			var m uint64
+			if j == 2*gap {
+				m = 3
+			}
+
+			verifyPhase(tc, phase, m, t)
+		}
+	})
+}
+
+func testMarshaller(tc *testContext, t *testing.T) {
+
+	params := tc.params
+
+	ct := NewCiphertext(params.N(), params.MaxLevel())
+	prng, _ := utils.NewPRNG()
+	sampler := ring.NewUniformSampler(prng, params.RingQ())
+	poly := sampler.ReadNew()
+	for i := range ct.A {
+		copy(ct.A[i], poly.Coeffs[i])
+		ct.B[i] = poly.Coeffs[i][0]
+	}
+
+	t.Run(testString(params, "Marshaller/Ciphertext"), func(t *testing.T) {
+
+		data, err := ct.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, data, ct.GetDataLen())
+
+		ctNew := new(Ciphertext)
+		require.NoError(t, ctNew.UnmarshalBinary(data))
+		require.Equal(t, ct, ctNew)
+
+		require.Error(t, ctNew.UnmarshalBinary(data[:len(data)-1]))
+	})
+
+	t.Run(testString(params, "Streaming/Ciphertext"), func(t *testing.T) {
+
+		buf := new(bytes.Buffer)
+		n, err := ct.WriteTo(buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), n)
+
+		ctNew := new(Ciphertext)
+		m, err := ctNew.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, n, m)
+		require.Equal(t, ct, ctNew)
+	})
+}
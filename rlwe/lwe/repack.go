@@ -0,0 +1,211 @@
+package lwe
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/rlwe"
+)
+
+// Repacker is a structure for the repacking of LWE ciphertexts of dimension N into a single RLWE ciphertext of
+// dimension N, with the recursive packing algorithm of Chen, Dai, Kim and Song followed by a field trace.
+// It uses the rotation keys of the Galois elements returned by GaloisElementsForRepack.
+type Repacker struct {
+	params          rlwe.Parameters
+	ks              *rlwe.KeySwitcher
+	rtks            *rlwe.RotationKeySet
+	permuteNTTIndex map[uint64][]uint64
+	xPow            []*ring.Poly // xPow[L] is X^{N/2^L} in the NTT and Montgomery domain
+	nInv            *big.Int     // N^-1 mod Q
+	tmp             *rlwe.Ciphertext
+}
+
+// GaloisElementsForRepack returns the Galois elements of the rotation keys required by the Repacker.
+// The L-th element, for 1 <= L <= log(N), is the Galois element 5^{2^{L-2}} mod 2N (2N-1 for L = 1)
+// of the automorphism X -> X^{1 + 2^L mod 2^{L+1}} used at the L-th step of the packing and of the trace.
+func GaloisElementsForRepack(params rlwe.Parameters) (galEls []uint64) {
+	galEls = make([]uint64, params.LogN())
+	for L := 1; L <= params.LogN(); L++ {
+		galEls[L-1] = galoisElementForRepack(params, L)
+	}
+	return
+}
+
+func galoisElementForRepack(params rlwe.Parameters, L int) uint64 {
+	if L == 1 {
+		return params.GaloisElementForRowRotation()
+	}
+	return params.GaloisElementForColumnRotationBy(1 << (L - 2))
+}
+
+// NewRepacker creates a new Repacker from the rotation keys rtks, which must include the keys for GaloisElementsForRepack(params).
+func NewRepacker(params rlwe.Parameters, rtks *rlwe.RotationKeySet) (rp *Repacker) {
+
+	if params.RingType() != ring.Standard {
+		panic("cannot NewRepacker: only the standard ring is supported")
+	}
+
+	ringQ := params.RingQ()
+
+	rp = &Repacker{
+		params:          params,
+		ks:              rlwe.NewKeySwitcher(params),
+		rtks:            rtks,
+		permuteNTTIndex: make(map[uint64][]uint64),
+		xPow:            make([]*ring.Poly, params.LogN()+1),
+		tmp:             rlwe.NewCiphertextNTT(params, 1, params.MaxLevel()),
+	}
+
+	for _, galEl := range GaloisElementsForRepack(params) {
+		if _, ok := rtks.GetRotationKey(galEl); !ok {
+			panic("cannot NewRepacker: missing rotation key")
+		}
+		rp.permuteNTTIndex[galEl] = ringQ.PermuteNTTIndex(galEl)
+	}
+
+	for L := 1; L <= params.LogN(); L++ {
+		rp.xPow[L] = ringQ.NewPoly()
+		for i := range ringQ.Modulus {
+			rp.xPow[L].Coeffs[i][params.N()>>L] = ring.MForm(1, ringQ.Modulus[i], ringQ.BredParams[i])
+		}
+		ringQ.NTT(rp.xPow[L], rp.xPow[L])
+	}
+
+	rp.nInv = new(big.Int).ModInverse(big.NewInt(int64(params.N())), ringQ.ModulusBigint)
+
+	return
+}
+
+// ShallowCopy creates a shallow copy of the Repacker, only reallocating its buffers.
+func (rp *Repacker) ShallowCopy() *Repacker {
+	return &Repacker{
+		params:          rp.params,
+		ks:              rp.ks.ShallowCopy(),
+		rtks:            rp.rtks,
+		permuteNTTIndex: rp.permuteNTTIndex,
+		xPow:            rp.xPow,
+		nInv:            rp.nInv,
+		tmp:             rlwe.NewCiphertextNTT(rp.params, 1, rp.params.MaxLevel()),
+	}
+}
+
+// Repack packs the LWE ciphertexts cts of dimension N into an RLWE ciphertext of dimension N, in the NTT domain,
+// whose coefficient of index i*N/len(cts) has the phase of cts[i] and whose other coefficients are zero.
+// len(cts) must be a power of two smaller or equal to N, and nil entries stand for encryptions of zero.
+// The output ciphertext is at the smallest level of the input ciphertexts.
+func (rp *Repacker) Repack(cts []*Ciphertext) (ctOut *rlwe.Ciphertext) {
+
+	N := rp.params.N()
+
+	if len(cts) == 0 || len(cts) > N || len(cts)&(len(cts)-1) != 0 {
+		panic("cannot Repack: the number of ciphertexts must be a power of two smaller or equal to N")
+	}
+
+	level := -1
+	for _, ct := range cts {
+		if ct != nil {
+			if ct.N() != N {
+				panic("cannot Repack: ciphertexts dimension does not match the parameters")
+			}
+			if level == -1 || ct.Level() < level {
+				level = ct.Level()
+			}
+		}
+	}
+
+	if level == -1 {
+		panic("cannot Repack: all the ciphertexts are nil")
+	}
+
+	ringQ := rp.params.RingQ()
+
+	// Embeds each LWE ciphertext scaled by N^-1 as the constant coefficient of an RLWE ciphertext,
+	// since the packing and the trace multiply the coefficients by len(cts) and N/len(cts)
+	ctsRLWE := make([]*rlwe.Ciphertext, len(cts))
+	for k, ct := range cts {
+		if ct != nil {
+			ctsRLWE[k] = rlwe.NewCiphertextNTT(rp.params, 1, level)
+			for i := 0; i < level+1; i++ {
+				embed(ct.B[i], ct.A[i], ringQ.Modulus[i], ctsRLWE[k].Value[0].Coeffs[i], ctsRLWE[k].Value[1].Coeffs[i])
+			}
+			for _, c := range ctsRLWE[k].Value {
+				ringQ.MulScalarBigintLvl(level, c, rp.nInv, c)
+				ringQ.NTTLvl(level, c, c)
+			}
+		}
+	}
+
+	ctOut = rp.pack(ctsRLWE, level)
+
+	// Trace from X^{N/len(cts)} to X, which zeroes the coefficients of index not multiple of N/len(cts)
+	for L := bits.Len64(uint64(len(cts))); L <= rp.params.LogN(); L++ {
+		rp.automorphism(ctOut, galoisElementForRepack(rp.params, L), rp.tmp)
+		ringQ.AddLvl(level, ctOut.Value[0], rp.tmp.Value[0], ctOut.Value[0])
+		ringQ.AddLvl(level, ctOut.Value[1], rp.tmp.Value[1], ctOut.Value[1])
+	}
+
+	return
+}
+
+// pack recursively merges the RLWE ciphertexts cts, whose messages are in their constant coefficient,
+// into ct(X) = ctEven(X) + X^{N/2^L}*ctOdd(X) + phi_L(ctEven(X) - X^{N/2^L}*ctOdd(X)), where L = log(len(cts)).
+func (rp *Repacker) pack(cts []*rlwe.Ciphertext, level int) (ctOut *rlwe.Ciphertext) {
+
+	if len(cts) == 1 {
+		return cts[0]
+	}
+
+	L := bits.Len64(uint64(len(cts))) - 1
+
+	even := make([]*rlwe.Ciphertext, len(cts)>>1)
+	odd := make([]*rlwe.Ciphertext, len(cts)>>1)
+	for i := range even {
+		even[i], odd[i] = cts[2*i], cts[2*i+1]
+	}
+
+	ctEven, ctOdd := rp.pack(even, level), rp.pack(odd, level)
+
+	if ctEven == nil && ctOdd == nil {
+		return nil
+	}
+
+	if ctEven == nil {
+		ctEven = rlwe.NewCiphertextNTT(rp.params, 1, level)
+	}
+
+	ringQ := rp.params.RingQ()
+
+	tmp := ctEven.CopyNew()
+
+	if ctOdd != nil {
+		for i := range ctOdd.Value {
+			// ctOdd * X^{N/2^L}
+			ringQ.MulCoeffsMontgomeryLvl(level, ctOdd.Value[i], rp.xPow[L], ctOdd.Value[i])
+			// ctEven - ctOdd * X^{N/2^L}
+			ringQ.SubLvl(level, tmp.Value[i], ctOdd.Value[i], tmp.Value[i])
+			// ctEven + ctOdd * X^{N/2^L}
+			ringQ.AddLvl(level, ctEven.Value[i], ctOdd.Value[i], ctEven.Value[i])
+		}
+	}
+
+	rp.automorphism(tmp, galoisElementForRepack(rp.params, L), tmp)
+
+	for i := range ctEven.Value {
+		ringQ.AddLvl(level, ctEven.Value[i], tmp.Value[i], ctEven.Value[i])
+	}
+
+	return ctEven
+}
+
+// automorphism applies the automorphism of Galois element galEl on ctIn and returns the result on ctOut.
+func (rp *Repacker) automorphism(ctIn *rlwe.Ciphertext, galEl uint64, ctOut *rlwe.Ciphertext) {
+	ringQ := rp.params.RingQ()
+	rtk, _ := rp.rtks.GetRotationKey(galEl)
+	level := ctIn.Level()
+	index := rp.permuteNTTIndex[galEl]
+	rp.ks.SwitchKeysInPlace(level, ctIn.Value[1], rtk, rp.ks.Pool[1].Q, rp.ks.Pool[2].Q)
+	ringQ.AddLvl(level, rp.ks.Pool[1].Q, ctIn.Value[0], rp.ks.Pool[1].Q)
+	ringQ.PermuteNTTWithIndexLvl(level, rp.ks.Pool[1].Q, index, ctOut.Value[0])
+	ringQ.PermuteNTTWithIndexLvl(level, rp.ks.Pool[2].Q, index, ctOut.Value[1])
+}
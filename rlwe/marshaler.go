@@ -3,7 +3,25 @@ package rlwe
 import (
 	"encoding/binary"
 	"errors"
+	"io"
+	"sort"
+
 	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// Tags of the headers written by the WriteTo methods of the rlwe objects.
+const (
+	polyQPTag               = "PLQP"
+	ciphertextTag           = "CTXT"
+	secretKeyTag            = "SKEY"
+	publicKeyTag            = "PKEY"
+	switchingKeyTag         = "SWKY"
+	relinearizationKeyTag   = "RLKY"
+	rotationKeySetTag       = "RTKS"
+	seededCiphertextTag     = "SCTX"
+	seededSwitchingKeyTag   = "SSWK"
+	seededRotationKeySetTag = "SRKS"
 )
 
 // GetDataLen returns the length in bytes of the target Ciphertext.
@@ -192,7 +210,7 @@ func (rlk *RelinearizationKey) UnmarshalBinary(data []byte) (err error) {
 func (swk *SwitchingKey) GetDataLen(WithMetadata bool) (dataLen int) {
 
 	if WithMetadata {
-		dataLen++
+		dataLen += 2
 	}
 
 	for j := uint64(0); j < uint64(len(swk.Value)); j++ {
@@ -230,9 +248,13 @@ func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
 	var err error
 	var inc int
 
-	data[pointer] = uint8(len(swk.Value))
+	if len(swk.Value) > 0xFFFF {
+		return pointer, errors.New("SwitchingKey: uint16 overflow on decomposition size")
+	}
 
-	pointer++
+	binary.BigEndian.PutUint16(data[pointer:], uint16(len(swk.Value)))
+
+	pointer += 2
 
 	for j := 0; j < len(swk.Value); j++ {
 
@@ -254,9 +276,9 @@ func (swk *SwitchingKey) encode(pointer int, data []byte) (int, error) {
 
 func (swk *SwitchingKey) decode(data []byte) (pointer int, err error) {
 
-	decomposition := int(data[0])
+	decomposition := int(binary.BigEndian.Uint16(data))
 
-	pointer = 1
+	pointer = 2
 
 	swk.Value = make([][2]PolyQP, decomposition)
 
@@ -333,3 +355,796 @@ func (rtks *RotationKeySet) UnmarshalBinary(data []byte) (err error) {
 
 	return nil
 }
+
+// GetDataLen returns the length in bytes of the target SeededCiphertext.
+func (sct *SeededCiphertext) GetDataLen(WithMetaData bool) (dataLen int) {
+	// MetaData is :
+	// 1 byte : Seed length
+	if WithMetaData {
+		dataLen++
+	}
+
+	return dataLen + len(sct.Seed) + sct.Value.GetDataLen(WithMetaData)
+}
+
+// MarshalBinary encodes a SeededCiphertext on a byte slice. The total size
+// in byte is 1 + len(Seed) + 8 + 8 * N * numberModuliQ.
+func (sct *SeededCiphertext) MarshalBinary() (data []byte, err error) {
+
+	if len(sct.Seed) > 0xFF {
+		return nil, errors.New("SeededCiphertext: uint8 overflow on seed length")
+	}
+
+	data = make([]byte, sct.GetDataLen(true))
+
+	data[0] = uint8(len(sct.Seed))
+	pointer := 1 + copy(data[1:], sct.Seed)
+
+	if _, err = sct.Value.WriteTo(data[pointer:]); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled SeededCiphertext on the target SeededCiphertext.
+func (sct *SeededCiphertext) UnmarshalBinary(data []byte) (err error) {
+
+	var pointer int
+	if sct.Seed, pointer, err = decodeSeed(data); err != nil {
+		return err
+	}
+
+	sct.Value = new(ring.Poly)
+
+	var inc int
+	if inc, err = sct.Value.DecodePolyNew(data[pointer:]); err != nil {
+		return err
+	}
+
+	if pointer+inc != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	return nil
+}
+
+// GetDataLen returns the length in bytes of the target SeededPublicKey.
+func (spk *SeededPublicKey) GetDataLen(WithMetadata bool) (dataLen int) {
+
+	// MetaData is :
+	// 1 byte : Seed length
+	if WithMetadata {
+		dataLen++
+	}
+
+	return dataLen + len(spk.Seed) + spk.Value.GetDataLen(WithMetadata)
+}
+
+// MarshalBinary encodes a SeededPublicKey in a byte slice.
+func (spk *SeededPublicKey) MarshalBinary() (data []byte, err error) {
+
+	if len(spk.Seed) > 0xFF {
+		return nil, errors.New("SeededPublicKey: uint8 overflow on seed length")
+	}
+
+	data = make([]byte, spk.GetDataLen(true))
+
+	data[0] = uint8(len(spk.Seed))
+	pointer := 1 + copy(data[1:], spk.Seed)
+
+	if _, err = spk.Value.WriteTo(data[pointer:]); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled SeededPublicKey in the target SeededPublicKey.
+func (spk *SeededPublicKey) UnmarshalBinary(data []byte) (err error) {
+
+	var pointer, inc int
+	if spk.Seed, pointer, err = decodeSeed(data); err != nil {
+		return err
+	}
+
+	if inc, err = spk.Value.DecodePolyNew(data[pointer:]); err != nil {
+		return err
+	}
+
+	if pointer+inc != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	return nil
+}
+
+// GetDataLen returns the length in bytes of the target SeededSwitchingKey.
+func (sswk *SeededSwitchingKey) GetDataLen(WithMetadata bool) (dataLen int) {
+
+	// MetaData is :
+	// 1 byte : Seed length
+	// 2 byte : decomposition size
+	if WithMetadata {
+		dataLen += 3
+	}
+
+	dataLen += len(sswk.Seed)
+
+	for j := range sswk.Value {
+		dataLen += sswk.Value[j].GetDataLen(WithMetadata)
+	}
+
+	return
+}
+
+// MarshalBinary encodes a SeededSwitchingKey in a byte slice.
+func (sswk *SeededSwitchingKey) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, sswk.GetDataLen(true))
+
+	if _, err = sswk.encode(0, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled SeededSwitchingKey in the target SeededSwitchingKey.
+func (sswk *SeededSwitchingKey) UnmarshalBinary(data []byte) (err error) {
+
+	if _, err = sswk.decode(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sswk *SeededSwitchingKey) encode(pointer int, data []byte) (int, error) {
+
+	var err error
+	var inc int
+
+	if len(sswk.Seed) > 0xFF {
+		return pointer, errors.New("SeededSwitchingKey: uint8 overflow on seed length")
+	}
+
+	if len(sswk.Value) > 0xFFFF {
+		return pointer, errors.New("SeededSwitchingKey: uint16 overflow on decomposition size")
+	}
+
+	data[pointer] = uint8(len(sswk.Seed))
+	pointer++
+
+	pointer += copy(data[pointer:], sswk.Seed)
+
+	binary.BigEndian.PutUint16(data[pointer:], uint16(len(sswk.Value)))
+	pointer += 2
+
+	for j := range sswk.Value {
+		if inc, err = sswk.Value[j].WriteTo(data[pointer:]); err != nil {
+			return pointer, err
+		}
+		pointer += inc
+	}
+
+	return pointer, nil
+}
+
+func (sswk *SeededSwitchingKey) decode(data []byte) (pointer int, err error) {
+
+	if sswk.Seed, pointer, err = decodeSeed(data); err != nil {
+		return
+	}
+
+	if len(data) < pointer+2 {
+		return pointer, errors.New("too small bytearray")
+	}
+
+	decomposition := int(binary.BigEndian.Uint16(data[pointer:]))
+	pointer += 2
+
+	sswk.Value = make([]PolyQP, decomposition)
+
+	var inc int
+	for j := range sswk.Value {
+		if inc, err = sswk.Value[j].DecodePolyNew(data[pointer:]); err != nil {
+			return
+		}
+		pointer += inc
+	}
+
+	return
+}
+
+// GetDataLen returns the length in bytes of the target SeededRotationKeySet.
+func (srtks *SeededRotationKeySet) GetDataLen(WithMetaData bool) (dataLen int) {
+	for _, k := range srtks.Keys {
+		if WithMetaData {
+			dataLen += 4
+		}
+		dataLen += k.GetDataLen(WithMetaData)
+	}
+	return
+}
+
+// MarshalBinary encodes a SeededRotationKeySet in a byte slice.
+func (srtks *SeededRotationKeySet) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, srtks.GetDataLen(true))
+
+	pointer := int(0)
+
+	for galEL, key := range srtks.Keys {
+
+		binary.BigEndian.PutUint32(data[pointer:pointer+4], uint32(galEL))
+		pointer += 4
+
+		if pointer, err = key.encode(pointer, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled SeededRotationKeySet in the target SeededRotationKeySet.
+func (srtks *SeededRotationKeySet) UnmarshalBinary(data []byte) (err error) {
+
+	srtks.Keys = make(map[uint64]*SeededSwitchingKey)
+
+	for len(data) > 0 {
+
+		if len(data) < 4 {
+			return errors.New("too small bytearray")
+		}
+
+		galEl := uint64(binary.BigEndian.Uint32(data))
+		data = data[4:]
+
+		sswk := new(SeededSwitchingKey)
+		var inc int
+		if inc, err = sswk.decode(data); err != nil {
+			return err
+		}
+		data = data[inc:]
+		srtks.Keys[galEl] = sswk
+	}
+
+	return nil
+}
+
+// decodeSeed decodes a seed prefixed by its length on one byte and returns the number of bytes read.
+func decodeSeed(data []byte) (seed []byte, pointer int, err error) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, 0, errors.New("too small bytearray")
+	}
+	pointer = 1 + int(data[0])
+	seed = make([]byte, data[0])
+	copy(seed, data[1:pointer])
+	return
+}
+
+// WriteTo writes the target Ciphertext on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (ciphertext *Ciphertext) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, ciphertextTag); err != nil {
+		return
+	}
+
+	if len(ciphertext.Value) > 0xFF {
+		return n, errors.New("Ciphertext: uint8 overflow on degree")
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint8(w, uint8(len(ciphertext.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for _, el := range ciphertext.Value {
+		inc, err = el.WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a Ciphertext written by WriteTo from r on the target Ciphertext.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (ciphertext *Ciphertext) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, ciphertextTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint8
+	size, inc, err = utils.ReadUint8(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(ciphertext.Value) != int(size) {
+		ciphertext.Value = make([]*ring.Poly, size)
+	}
+
+	for i := range ciphertext.Value {
+		if ciphertext.Value[i] == nil {
+			ciphertext.Value[i] = new(ring.Poly)
+		}
+		inc, err = ciphertext.Value[i].ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target SecretKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (sk *SecretKey) WriteTo(w io.Writer) (n int64, err error) {
+	if n, err = utils.WriteHeader(w, secretKeyTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = sk.Value.WriteToStream(w)
+	return n + inc, err
+}
+
+// ReadFrom reads a SecretKey written by WriteTo from r on the target SecretKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (sk *SecretKey) ReadFrom(r io.Reader) (n int64, err error) {
+	if n, err = utils.ReadHeader(r, secretKeyTag); err != nil {
+		return
+	}
+	var inc int64
+	inc, err = sk.Value.ReadFromStream(r)
+	return n + inc, err
+}
+
+// WriteTo writes the target PublicKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (pk *PublicKey) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, publicKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for i := range pk.Value {
+		inc, err = pk.Value[i].WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a PublicKey written by WriteTo from r on the target PublicKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (pk *PublicKey) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, publicKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for i := range pk.Value {
+		inc, err = pk.Value[i].ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target SwitchingKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (swk *SwitchingKey) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, switchingKeyTag); err != nil {
+		return
+	}
+
+	if len(swk.Value) > 0xFFFF {
+		return n, errors.New("SwitchingKey: uint16 overflow on decomposition size")
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint16(w, uint16(len(swk.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for j := range swk.Value {
+		for k := range swk.Value[j] {
+			inc, err = swk.Value[j][k].WriteToStream(w)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a SwitchingKey written by WriteTo from r on the target SwitchingKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (swk *SwitchingKey) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, switchingKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var decomposition uint16
+	decomposition, inc, err = utils.ReadUint16(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(swk.Value) != int(decomposition) {
+		swk.Value = make([][2]PolyQP, decomposition)
+	}
+
+	for j := range swk.Value {
+		for k := range swk.Value[j] {
+			inc, err = swk.Value[j][k].ReadFromStream(r)
+			if n += inc; err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target RelinearizationKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (rlk *RelinearizationKey) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, relinearizationKeyTag); err != nil {
+		return
+	}
+
+	if len(rlk.Keys) > 0xFF {
+		return n, errors.New("RelinearizationKey: uint8 overflow on degree")
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint8(w, uint8(len(rlk.Keys)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for _, swk := range rlk.Keys {
+		inc, err = swk.WriteTo(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a RelinearizationKey written by WriteTo from r on the target RelinearizationKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (rlk *RelinearizationKey) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, relinearizationKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var deg uint8
+	deg, inc, err = utils.ReadUint8(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(rlk.Keys) != int(deg) {
+		rlk.Keys = make([]*SwitchingKey, deg)
+	}
+
+	for i := range rlk.Keys {
+		if rlk.Keys[i] == nil {
+			rlk.Keys[i] = new(SwitchingKey)
+		}
+		inc, err = rlk.Keys[i].ReadFrom(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target RotationKeySet on w, preceded by a versioned header. The keys are written one
+// after the other in increasing order of galois element, so that the memory overhead is independent of the
+// number of keys. It returns the number of bytes written, and the corresponding error, if it occurred.
+func (rtks *RotationKeySet) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, rotationKeySetTag); err != nil {
+		return
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint32(w, uint32(len(rtks.Keys)))
+	if n += inc; err != nil {
+		return
+	}
+
+	galEls := make([]uint64, 0, len(rtks.Keys))
+	for galEl := range rtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	for _, galEl := range galEls {
+
+		inc, err = utils.WriteUint32(w, uint32(galEl))
+		if n += inc; err != nil {
+			return
+		}
+
+		inc, err = rtks.Keys[galEl].WriteTo(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a RotationKeySet written by WriteTo from r on the target RotationKeySet.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (rtks *RotationKeySet) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, rotationKeySetTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint32
+	size, inc, err = utils.ReadUint32(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	rtks.Keys = make(map[uint64]*SwitchingKey)
+
+	for i := uint32(0); i < size; i++ {
+
+		var galEl uint32
+		galEl, inc, err = utils.ReadUint32(r)
+		if n += inc; err != nil {
+			return
+		}
+
+		swk := new(SwitchingKey)
+		inc, err = swk.ReadFrom(r)
+		if n += inc; err != nil {
+			return
+		}
+
+		rtks.Keys[uint64(galEl)] = swk
+	}
+
+	return
+}
+
+// WriteTo writes the target SeededCiphertext on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (sct *SeededCiphertext) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, seededCiphertextTag); err != nil {
+		return
+	}
+
+	var inc int64
+	inc, err = writeSeed(w, sct.Seed)
+	if n += inc; err != nil {
+		return
+	}
+
+	inc, err = sct.Value.WriteToStream(w)
+	return n + inc, err
+}
+
+// ReadFrom reads a SeededCiphertext written by WriteTo from r on the target SeededCiphertext.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (sct *SeededCiphertext) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, seededCiphertextTag); err != nil {
+		return
+	}
+
+	var inc int64
+	sct.Seed, inc, err = readSeed(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if sct.Value == nil {
+		sct.Value = new(ring.Poly)
+	}
+
+	inc, err = sct.Value.ReadFromStream(r)
+	return n + inc, err
+}
+
+// WriteTo writes the target SeededSwitchingKey on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (sswk *SeededSwitchingKey) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, seededSwitchingKeyTag); err != nil {
+		return
+	}
+
+	if len(sswk.Value) > 0xFFFF {
+		return n, errors.New("SeededSwitchingKey: uint16 overflow on decomposition size")
+	}
+
+	var inc int64
+	inc, err = writeSeed(w, sswk.Seed)
+	if n += inc; err != nil {
+		return
+	}
+
+	inc, err = utils.WriteUint16(w, uint16(len(sswk.Value)))
+	if n += inc; err != nil {
+		return
+	}
+
+	for j := range sswk.Value {
+		inc, err = sswk.Value[j].WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a SeededSwitchingKey written by WriteTo from r on the target SeededSwitchingKey.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (sswk *SeededSwitchingKey) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, seededSwitchingKeyTag); err != nil {
+		return
+	}
+
+	var inc int64
+	sswk.Seed, inc, err = readSeed(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	var decomposition uint16
+	decomposition, inc, err = utils.ReadUint16(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	if len(sswk.Value) != int(decomposition) {
+		sswk.Value = make([]PolyQP, decomposition)
+	}
+
+	for j := range sswk.Value {
+		inc, err = sswk.Value[j].ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// WriteTo writes the target SeededRotationKeySet on w, preceded by a versioned header, with the keys
+// in increasing order of galois element.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (srtks *SeededRotationKeySet) WriteTo(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, seededRotationKeySetTag); err != nil {
+		return
+	}
+
+	var inc int64
+	inc, err = utils.WriteUint32(w, uint32(len(srtks.Keys)))
+	if n += inc; err != nil {
+		return
+	}
+
+	galEls := make([]uint64, 0, len(srtks.Keys))
+	for galEl := range srtks.Keys {
+		galEls = append(galEls, galEl)
+	}
+	sort.Slice(galEls, func(i, j int) bool { return galEls[i] < galEls[j] })
+
+	for _, galEl := range galEls {
+
+		inc, err = utils.WriteUint32(w, uint32(galEl))
+		if n += inc; err != nil {
+			return
+		}
+
+		inc, err = srtks.Keys[galEl].WriteTo(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFrom reads a SeededRotationKeySet written by WriteTo from r on the target SeededRotationKeySet.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (srtks *SeededRotationKeySet) ReadFrom(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, seededRotationKeySetTag); err != nil {
+		return
+	}
+
+	var inc int64
+	var size uint32
+	size, inc, err = utils.ReadUint32(r)
+	if n += inc; err != nil {
+		return
+	}
+
+	srtks.Keys = make(map[uint64]*SeededSwitchingKey)
+
+	for i := uint32(0); i < size; i++ {
+
+		var galEl uint32
+		galEl, inc, err = utils.ReadUint32(r)
+		if n += inc; err != nil {
+			return
+		}
+
+		sswk := new(SeededSwitchingKey)
+		inc, err = sswk.ReadFrom(r)
+		if n += inc; err != nil {
+			return
+		}
+
+		srtks.Keys[uint64(galEl)] = sswk
+	}
+
+	return
+}
+
+// writeSeed writes a seed on w, prefixed by its length on one byte.
+func writeSeed(w io.Writer, seed []byte) (n int64, err error) {
+
+	if len(seed) > 0xFF {
+		return 0, errors.New("uint8 overflow on seed length")
+	}
+
+	if n, err = utils.WriteUint8(w, uint8(len(seed))); err != nil {
+		return
+	}
+
+	inc, err := w.Write(seed)
+	return n + int64(inc), err
+}
+
+// readSeed reads a seed written by writeSeed from r.
+func readSeed(r io.Reader) (seed []byte, n int64, err error) {
+
+	var size uint8
+	if size, n, err = utils.ReadUint8(r); err != nil {
+		return
+	}
+
+	seed = make([]byte, size)
+	inc, err := io.ReadFull(r, seed)
+	return seed, n + int64(inc), err
+}
@@ -40,8 +40,12 @@ type ParametersLiteral struct {
 	P        []uint64
 	LogQ     []int `json:",omitempty"`
 	LogP     []int `json:",omitempty"`
+	Pow2Base int   `json:",omitempty"`
+	DNum     int   `json:",omitempty"`
 	Sigma    float64
 	RingType ring.Type
+	Lambda   int `json:",omitempty"` // Required classical security in bits, checked with Parameters.CheckSecurity (0 to disable)
+	H        int `json:",omitempty"` // Hamming weight of the secret for the security check (0 for a uniform ternary secret)
 }
 
 // Parameters represents a set of generic RLWE parameters. Its fields are private and
@@ -50,6 +54,8 @@ type Parameters struct {
 	logN     int
 	qi       []uint64
 	pi       []uint64
+	pow2Base int
+	dnum     int
 	sigma    float64
 	ringQ    *ring.Ring
 	ringP    *ring.Ring
@@ -58,8 +64,17 @@ type Parameters struct {
 
 // NewParameters returns a new set of generic RLWE parameters from the given ring degree logn, moduli q and p, and
 // error distribution parameter sigma. It returns the empty parameters Parameters{} and a non-nil error if the
-// specified parameters are invalid.
+// specified parameters are invalid. The base-2 digit decomposition of the key-switching can be enabled with the
+// Pow2Base field of ParametersLiteral, and its number of RNS elements can be set with the DNum field of
+// ParametersLiteral (see NewParametersFromLiteral).
 func NewParameters(logn int, q, p []uint64, sigma float64, ringType ring.Type) (Parameters, error) {
+	return newParameters(logn, q, p, 0, 0, sigma, ringType)
+}
+
+// newParameters returns a new set of generic RLWE parameters as NewParameters, with the base-2 logarithm pow2Base
+// of the digit decomposition of the key-switching (0 for no decomposition) and the number dnum of elements of its
+// RNS decomposition basis (0 for one element per #P moduli Qi).
+func newParameters(logn int, q, p []uint64, pow2Base, dnum int, sigma float64, ringType ring.Type) (Parameters, error) {
 	var err error
 	if err = checkSizeParams(logn, len(q), len(p)); err != nil {
 		return Parameters{}, err
@@ -69,6 +84,8 @@ func NewParameters(logn int, q, p []uint64, sigma float64, ringType ring.Type) (
 		logN:     logn,
 		pi:       make([]uint64, len(p)),
 		qi:       make([]uint64, len(q)),
+		pow2Base: pow2Base,
+		dnum:     dnum,
 		sigma:    sigma,
 		ringType: ringType,
 	}
@@ -79,6 +96,14 @@ func NewParameters(logn int, q, p []uint64, sigma float64, ringType ring.Type) (
 		return Parameters{}, err
 	}
 
+	if err = checkPow2Base(pow2Base, q, p); err != nil {
+		return Parameters{}, err
+	}
+
+	if err = checkDNum(dnum, pow2Base, q, p); err != nil {
+		return Parameters{}, err
+	}
+
 	copy(params.qi, q)
 	copy(params.pi, p)
 
@@ -86,8 +111,9 @@ func NewParameters(logn int, q, p []uint64, sigma float64, ringType ring.Type) (
 }
 
 // NewParametersFromLiteral instantiate a set of generic RLWE parameters from a ParametersLiteral specification.
-// It returns the empty parameters Parameters{} and a non-nil error if the specified parameters are invalid.
-func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
+// It returns the empty parameters Parameters{} and a non-nil error if the specified parameters are invalid, or if
+// Lambda is set and the parameters do not reach a security of Lambda bits for a secret of Hamming weight H.
+func NewParametersFromLiteral(paramDef ParametersLiteral) (params Parameters, err error) {
 	if paramDef.Sigma == 0 {
 		// prevents the zero value of ParameterLiteral to result in a noise-less parameter instance.
 		// Users should use the NewParameters method to explicitely create noiseless instances.
@@ -95,10 +121,9 @@ func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
 	}
 	switch {
 	case paramDef.Q != nil && paramDef.LogQ == nil && paramDef.P != nil && paramDef.LogP == nil:
-		return NewParameters(paramDef.LogN, paramDef.Q, paramDef.P, paramDef.Sigma, paramDef.RingType)
+		params, err = newParameters(paramDef.LogN, paramDef.Q, paramDef.P, paramDef.Pow2Base, paramDef.DNum, paramDef.Sigma, paramDef.RingType)
 	case paramDef.LogQ != nil && paramDef.Q == nil && paramDef.LogP != nil && paramDef.P == nil:
 		var q, p []uint64
-		var err error
 		switch paramDef.RingType {
 		case ring.Standard:
 			q, p, err = GenModuli(paramDef.LogN, paramDef.LogQ, paramDef.LogP)
@@ -110,10 +135,22 @@ func NewParametersFromLiteral(paramDef ParametersLiteral) (Parameters, error) {
 		if err != nil {
 			return Parameters{}, err
 		}
-		return NewParameters(paramDef.LogN, q, p, paramDef.Sigma, paramDef.RingType)
+		params, err = newParameters(paramDef.LogN, q, p, paramDef.Pow2Base, paramDef.DNum, paramDef.Sigma, paramDef.RingType)
 	default:
 		return Parameters{}, fmt.Errorf("invalid parameter literal")
 	}
+
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	if paramDef.Lambda != 0 {
+		if err = params.CheckSecurity(paramDef.Lambda, paramDef.H); err != nil {
+			return Parameters{}, err
+		}
+	}
+
+	return params, nil
 }
 
 // StandardParameters returns a RLWE parameter set that corresponds to the
@@ -273,12 +310,13 @@ func (p Parameters) LogQP() int {
 	return tmp.BitLen()
 }
 
-// Alpha returns the number of moduli in in P
+// Alpha returns the number of moduli Qi per element of the RNS decomposition basis of the key-switching:
+// Ceil(#Qi / DNum) if DNum is set, else the number of moduli in P, or one if Pow2Base is not zero.
 func (p Parameters) Alpha() int {
-	return p.PCount()
+	return p.DecompAlpha(p.PCount() - 1)
 }
 
-// Beta returns the number of element in the RNS decomposition basis: Ceil(lenQi / lenPi)
+// Beta returns the number of element in the RNS decomposition basis: Ceil(lenQi / Alpha)
 func (p Parameters) Beta() int {
 	if p.Alpha() != 0 {
 		return int(math.Ceil(float64(p.QCount()) / float64(p.Alpha())))
@@ -287,6 +325,58 @@ func (p Parameters) Beta() int {
 	return 1
 }
 
+// DNum returns the number of elements of the RNS decomposition basis of the key-switching set with the DNum
+// field of ParametersLiteral, or zero if it was not set, in which case it is given by the number of moduli in P.
+// The actual number of elements is given by Beta, which can be smaller than DNum if DNum does not divide #Qi.
+func (p Parameters) DNum() int {
+	return p.dnum
+}
+
+// Pow2Base returns the base-2 logarithm of the digit decomposition of the key-switching
+// within each element of the RNS decomposition basis (0 if there is no such decomposition).
+func (p Parameters) Pow2Base() int {
+	return p.pow2Base
+}
+
+// DecompAlpha returns the number of moduli Qi per element of the RNS decomposition basis of the key-switching
+// with the moduli P up to levelP: levelP+1, or one if Pow2Base is not zero, in which case each element is
+// further decomposed in base 2^Pow2Base. If DNum is set, the key-switching with all the moduli P has
+// Ceil(#Qi / DNum) moduli Qi per element instead.
+func (p Parameters) DecompAlpha(levelP int) int {
+	if p.pow2Base != 0 {
+		return 1
+	}
+	if p.dnum != 0 && levelP == len(p.pi)-1 {
+		return (len(p.qi) + p.dnum - 1) / p.dnum
+	}
+	return levelP + 1
+}
+
+// DecompRNS returns the number of elements in the RNS decomposition basis of the key-switching
+// for the given levels: Ceil((levelQ+1) / DecompAlpha(levelP)).
+func (p Parameters) DecompRNS(levelQ, levelP int) int {
+	alpha := p.DecompAlpha(levelP)
+	return (levelQ + alpha) / alpha
+}
+
+// DecompPw2 returns the number of base-2^Pow2Base digits in which each element of the RNS decomposition basis
+// is decomposed during the key-switching at the given levels (1 if Pow2Base is 0).
+func (p Parameters) DecompPw2(levelQ, levelP int) int {
+	if p.pow2Base == 0 {
+		return 1
+	}
+	var maxBits int
+	for _, qi := range p.qi[:levelQ+1] {
+		maxBits = utils.MaxInt(maxBits, bits.Len64(qi-1))
+	}
+	return (maxBits + p.pow2Base - 1) / p.pow2Base
+}
+
+// DecompCount returns the number of elements of a switching key for the given levels, that is DecompRNS * DecompPw2.
+func (p Parameters) DecompCount(levelQ, levelP int) int {
+	return p.DecompRNS(levelQ, levelP) * p.DecompPw2(levelQ, levelP)
+}
+
 // QiOverflowMargin returns floor(2^64 / max(Qi)), i.e. the number of times elements of Z_max{Qi} can
 // be added together before overflowing 2^64.
 func (p *Parameters) QiOverflowMargin(level int) int {
@@ -347,6 +437,8 @@ func (p Parameters) Equals(other Parameters) bool {
 	res := p.logN == other.logN
 	res = res && utils.EqualSliceUint64(p.qi, other.qi)
 	res = res && utils.EqualSliceUint64(p.pi, other.pi)
+	res = res && (p.pow2Base == other.pow2Base)
+	res = res && (p.dnum == other.dnum)
 	res = res && (p.sigma == other.sigma)
 	res = res && (p.ringType == other.ringType)
 	return res
@@ -374,6 +466,8 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 	// 1 byte : ringType
 	// 8 * (#Q) : Q
 	// 8 * (#P) : P
+	// 1 byte : pow2Base
+	// 1 byte : dnum
 	b := utils.NewBuffer(make([]byte, 0, p.MarshalBinarySize()))
 	b.WriteUint8(uint8(p.logN))
 	b.WriteUint8(uint8(len(p.qi)))
@@ -382,12 +476,15 @@ func (p Parameters) MarshalBinary() ([]byte, error) {
 	b.WriteUint8(uint8(p.ringType))
 	b.WriteUint64Slice(p.qi)
 	b.WriteUint64Slice(p.pi)
+	b.WriteUint8(uint8(p.pow2Base))
+	b.WriteUint8(uint8(p.dnum))
 	return b.Bytes(), nil
 }
 
 // UnmarshalBinary decodes a []byte into a parameter set struct.
+// It also decodes the layout without the pow2Base and dnum bytes, with the default decomposition.
 func (p *Parameters) UnmarshalBinary(data []byte) error {
-	if len(data) < 11 {
+	if len(data) < 12 {
 		return fmt.Errorf("invalid rlwe.Parameter serialization")
 	}
 	b := utils.NewBuffer(data)
@@ -401,24 +498,34 @@ func (p *Parameters) UnmarshalBinary(data []byte) error {
 		return err
 	}
 
+	var pow2Base, dnum int
+	switch len(data) - 12 - (lenQ+lenP)<<3 {
+	case 0:
+	case 2:
+		pow2Base = int(data[len(data)-2])
+		dnum = int(data[len(data)-1])
+	default:
+		return fmt.Errorf("invalid rlwe.Parameter serialization")
+	}
+
 	qi := make([]uint64, lenQ)
 	pi := make([]uint64, lenP)
 	b.ReadUint64Slice(qi)
 	b.ReadUint64Slice(pi)
 
 	var err error
-	*p, err = NewParameters(logN, qi, pi, sigma, ringType)
+	*p, err = newParameters(logN, qi, pi, pow2Base, dnum, sigma, ringType)
 	return err
 }
 
 // MarshalBinarySize returns the length of the []byte encoding of the reciever.
 func (p Parameters) MarshalBinarySize() int {
-	return 12 + (len(p.qi)+len(p.pi))<<3
+	return 14 + (len(p.qi)+len(p.pi))<<3
 }
 
 // MarshalJSON returns a JSON representation of this parameter set. See `Marshal` from the `encoding/json` package.
 func (p Parameters) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&ParametersLiteral{LogN: p.logN, Q: p.qi, P: p.pi, Sigma: p.sigma})
+	return json.Marshal(&ParametersLiteral{LogN: p.logN, Q: p.qi, P: p.pi, Pow2Base: p.pow2Base, DNum: p.dnum, Sigma: p.sigma})
 }
 
 // UnmarshalJSON reads a JSON representation of a parameter set into the receiver Parameter. See `Unmarshal` from the `encoding/json` package.
@@ -469,6 +576,78 @@ func CheckModuli(q, p []uint64) error {
 	return nil
 }
 
+// checkPow2Base checks that the digits of the base-2^pow2Base decomposition are smaller than all the moduli
+// and that there is at least one modulus P: the key-switching keys are still defined modulo QP and the
+// result of the key-switching is divided by P, which then only has to be larger than the digits. Since
+// each element of the RNS decomposition basis is a single modulus Qi, all the moduli P are used regardless
+// of their number.
+func checkPow2Base(pow2Base int, q, p []uint64) error {
+
+	if pow2Base == 0 {
+		return nil
+	}
+
+	if pow2Base < 0 {
+		return fmt.Errorf("pow2Base=%d cannot be negative", pow2Base)
+	}
+
+	if len(p) == 0 {
+		return fmt.Errorf("pow2Base=%d requires at least one modulus P, by which the key-switching divides", pow2Base)
+	}
+
+	for _, qi := range append(append([]uint64{}, q...), p...) {
+		if bits.Len64(qi)-1 < pow2Base {
+			return fmt.Errorf("pow2Base=%d is larger than the bit-size of the modulus %d", pow2Base, qi)
+		}
+	}
+
+	return nil
+}
+
+// checkDNum checks that the number dnum of elements of the RNS decomposition basis of the key-switching is in
+// [1, #Qi] if it is set, that it is not set together with pow2Base, and that P is large enough with respect to
+// the elements of the decomposition: the key-switching multiplies each of them by a key and divides the result by
+// P, so that the product of the moduli Qi of an element must not be larger than P. As for the default
+// decomposition into elements of #P moduli Qi, which can be slightly larger than the moduli P, a tolerance of one
+// bit is allowed.
+func checkDNum(dnum, pow2Base int, q, p []uint64) error {
+
+	if dnum == 0 {
+		return nil
+	}
+
+	if dnum < 0 || dnum > len(q) {
+		return fmt.Errorf("dnum=%d is not in [1, #Qi=%d]", dnum, len(q))
+	}
+
+	if pow2Base != 0 {
+		return fmt.Errorf("dnum=%d cannot be set together with pow2Base=%d", dnum, pow2Base)
+	}
+
+	if len(p) == 0 {
+		return fmt.Errorf("dnum=%d requires at least one modulus P, by which the key-switching divides", dnum)
+	}
+
+	var logP float64
+	for _, pi := range p {
+		logP += math.Log2(float64(pi))
+	}
+
+	alpha := (len(q) + dnum - 1) / dnum
+
+	for i := 0; i < len(q); i += alpha {
+		var logDigit float64
+		for _, qi := range q[i:utils.MinInt(i+alpha, len(q))] {
+			logDigit += math.Log2(float64(qi))
+		}
+		if logDigit > logP+1 {
+			return fmt.Errorf("dnum=%d gives an element of the decomposition of %.2f bits, larger than P (%.2f bits)", dnum, logDigit, logP)
+		}
+	}
+
+	return nil
+}
+
 func checkSizeParams(logN int, lenQ, lenP int) error {
 	if logN > MaxLogN {
 		return fmt.Errorf("logN=%d is larger than MaxLogN=%d", logN, MaxLogN)
@@ -1,6 +1,8 @@
 package rlwe
 
 import (
+	"io"
+
 	"github.com/ldsec/lattigo/v2/ring"
 	"github.com/ldsec/lattigo/v2/utils"
 )
@@ -254,6 +256,52 @@ func (p *PolyQP) DecodePolyNew(data []byte) (pt int, err error) {
 	return
 }
 
+// WriteToStream writes the target PolyQP on w, preceded by a versioned header.
+// It returns the number of bytes written, and the corresponding error, if it occurred.
+func (p *PolyQP) WriteToStream(w io.Writer) (n int64, err error) {
+
+	if n, err = utils.WriteHeader(w, polyQPTag); err != nil {
+		return
+	}
+
+	var inc int64
+	for _, pol := range []*ring.Poly{p.Q, p.P} {
+		inc, err = pol.WriteToStream(w)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadFromStream reads a PolyQP written by WriteToStream from r on the target PolyQP.
+// It returns the number of bytes read, and the corresponding error, if it occurred.
+func (p *PolyQP) ReadFromStream(r io.Reader) (n int64, err error) {
+
+	if n, err = utils.ReadHeader(r, polyQPTag); err != nil {
+		return
+	}
+
+	if p.Q == nil {
+		p.Q = new(ring.Poly)
+	}
+
+	if p.P == nil {
+		p.P = new(ring.Poly)
+	}
+
+	var inc int64
+	for _, pol := range []*ring.Poly{p.Q, p.P} {
+		inc, err = pol.ReadFromStream(r)
+		if n += inc; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 // UniformSamplerQP is a type for sampling polynomials in RingQP.
 type UniformSamplerQP struct {
 	samplerQ, samplerP ring.UniformSampler
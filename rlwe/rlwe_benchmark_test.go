@@ -51,7 +51,7 @@ func benchHoistedKeySwitch(kgen KeyGenerator, keySwitcher *KeySwitcher, b *testi
 	b.Run(testString(params, "DecomposeNTT/"), func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			keySwitcher.DecomposeNTT(ciphertext.Level(), params.PCount()-1, params.PCount(), ciphertext.Value[1], keySwitcher.PoolDecompQP)
+			keySwitcher.DecomposeNTT(ciphertext.Level(), params.PCount()-1, params.Alpha(), ciphertext.Value[1], keySwitcher.PoolDecompQP)
 		}
 	})
 
@@ -1,9 +1,11 @@
 package rlwe
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"math/bits"
@@ -20,22 +22,24 @@ import (
 var flagParamString = flag.String("params", "", "specify the test cryptographic parameters as a JSON string. Overrides -short and -long.")
 
 // TestParams is a set of test parameters for the correctness of the rlwe pacakge.
-var TestParams = []ParametersLiteral{TestPN12QP109, TestPN13QP218, TestPN14QP438, TestPN15QP880, TestPN16QP240, TestPN17QP360}
+var TestParams = []ParametersLiteral{TestPN12QP109, TestPN12QP109Pw2, TestPN13QP218, TestPN14QP438, TestPN15QP880, TestPN16QP240, TestPN17QP360}
 
 func testString(params Parameters, opname string) string {
-	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d",
+	return fmt.Sprintf("%s/logN=%d/logQ=%d/logP=%d/#Qi=%d/#Pi=%d/Pw2=%d/DNum=%d",
 		opname,
 		params.LogN(),
 		params.LogQ(),
 		params.LogP(),
 		params.QCount(),
-		params.PCount())
+		params.PCount(),
+		params.Pow2Base(),
+		params.DNum())
 }
 
 func TestRLWE(t *testing.T) {
 	defaultParams := TestParams // the default test runs for ring degree N=2^12, 2^13, 2^14, 2^15
 	if testing.Short() {
-		defaultParams = TestParams[:2] // the short test suite runs for ring degree N=2^12, 2^13
+		defaultParams = TestParams[:3] // the short test suite runs for ring degree N=2^12 (with and without the base-2^16 digit decomposition) and N=2^13
 	}
 
 	if *flagParamString != "" {
@@ -58,8 +62,11 @@ func TestRLWE(t *testing.T) {
 			testEncryptor,
 			testDecryptor,
 			testKeySwitcher,
+			testKeySwitcherConcurrency,
 			testKeySwitchDimension,
 			testMarshaller,
+			testSeeded,
+			testStreaming,
 		} {
 			testSet(kgen, t)
 			runtime.GC()
@@ -67,6 +74,209 @@ func TestRLWE(t *testing.T) {
 	}
 }
 
+func TestPow2Base(t *testing.T) {
+
+	q, p := TestPN12QP109.Q, TestPN12QP109.P
+
+	_, err := NewParametersFromLiteral(ParametersLiteral{LogN: 12, Q: q, P: p, Pow2Base: 16})
+	assert.NoError(t, err)
+
+	// Negative base
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: 12, Q: q, P: p, Pow2Base: -1})
+	assert.Error(t, err)
+
+	// Digits larger than the moduli
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: 12, Q: q, P: p, Pow2Base: 40})
+	assert.Error(t, err)
+
+	// No modulus P
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: 12, Q: q, P: []uint64{}, Pow2Base: 16})
+	assert.Error(t, err)
+
+	// More than one modulus P: each element of the RNS decomposition basis is still a single modulus Qi
+	params, err := NewParametersFromLiteral(ParametersLiteral{LogN: 12, Q: q[:1], P: append([]uint64{q[1]}, p...), Pow2Base: 16})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, params.Alpha())
+	assert.Equal(t, params.QCount(), params.Beta())
+
+	t.Run(testString(params, "KeySwitch/Pw2/MultipleP"), func(t *testing.T) {
+
+		kgen := NewKeyGenerator(params)
+		sk, skOut := kgen.GenSecretKey(), kgen.GenSecretKey()
+		swk := kgen.GenSwitchingKey(sk, skOut)
+		require.Len(t, swk.Value, params.DecompCount(params.MaxLevel(), params.PCount()-1))
+
+		ringQ := params.RingQ()
+		plaintext := NewPlaintext(params, params.MaxLevel())
+		plaintext.Value.IsNTT = true
+		ciphertext := NewCiphertextNTT(params, 1, plaintext.Level())
+		NewEncryptor(params, sk).Encrypt(plaintext, ciphertext)
+
+		ks := NewKeySwitcher(params)
+		ks.SwitchKeysInPlace(ciphertext.Level(), ciphertext.Value[1], swk, ks.Pool[1].Q, ks.Pool[2].Q)
+		ringQ.Add(ciphertext.Value[0], ks.Pool[1].Q, ciphertext.Value[0])
+		ring.CopyValues(ks.Pool[2].Q, ciphertext.Value[1])
+		ringQ.MulCoeffsMontgomeryAndAddLvl(ciphertext.Level(), ciphertext.Value[1], skOut.Value.Q, ciphertext.Value[0])
+		ringQ.InvNTTLvl(ciphertext.Level(), ciphertext.Value[0], ciphertext.Value[0])
+		require.GreaterOrEqual(t, 10+params.LogN(), log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
+	})
+}
+
+func TestDNum(t *testing.T) {
+
+	logN := 13
+	logQ := []int{30, 25, 25, 25, 25, 25}
+
+	// Elements of two moduli Q of at most 55 bits, for a single modulus P
+	logP, err := LogPForDNum(logN, logQ, 3, 128)
+	require.NoError(t, err)
+	require.Equal(t, []int{55}, logP)
+
+	// Not secure enough
+	_, err = LogPForDNum(logN, logQ, 3, 192)
+	require.Error(t, err)
+
+	// Invalid dnum
+	_, err = LogPForDNum(logN, logQ, 7, 128)
+	require.Error(t, err)
+
+	params, err := NewParametersFromLiteral(ParametersLiteral{LogN: logN, LogQ: logQ, LogP: logP, DNum: 3})
+	require.NoError(t, err)
+	require.Equal(t, 3, params.DNum())
+	require.Equal(t, 2, params.Alpha())
+	require.Equal(t, 3, params.Beta())
+
+	q, p := params.Q(), params.P()
+
+	// dnum not in [1, #Qi]
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: logN, Q: q, P: p, DNum: -1})
+	require.Error(t, err)
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: logN, Q: q, P: p, DNum: len(q) + 1})
+	require.Error(t, err)
+
+	// dnum together with pow2Base
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: logN, Q: q, P: p, DNum: 3, Pow2Base: 8})
+	require.Error(t, err)
+
+	// P smaller than the elements of the decomposition
+	_, err = NewParametersFromLiteral(ParametersLiteral{LogN: logN, Q: q, P: p, DNum: 2})
+	require.Error(t, err)
+
+	kgen := NewKeyGenerator(params)
+
+	for _, testSet := range []func(kgen KeyGenerator, t *testing.T){
+		testSwitchKeyGen,
+		testKeySwitcher,
+		testMarshaller,
+	} {
+		testSet(kgen, t)
+	}
+
+	t.Run(testString(params, "KeySwitch/KeyBelowMaxLevel"), func(t *testing.T) {
+
+		ringQ := params.RingQ()
+		sk := kgen.GenSecretKey()
+
+		levelKey := params.MaxLevel() - 2
+
+		rlk := kgen.GenRelinearizationKeyLvl(levelKey, sk, 1)
+		require.Equal(t, levelKey, rlk.Keys[0].LevelQ())
+		require.Equal(t, params.PCount()-1, rlk.Keys[0].LevelP())
+		require.Len(t, rlk.Keys[0].Value, params.DecompCount(levelKey, params.PCount()-1))
+
+		galEl := params.GaloisElementForColumnRotationBy(1)
+		rtks := kgen.GenRotationKeysLvl(levelKey, []uint64{galEl}, sk)
+		require.Equal(t, levelKey, rtks.Keys[galEl].LevelQ())
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+		sampler := ring.NewUniformSampler(prng, ringQ)
+
+		ks := NewKeySwitcher(params)
+
+		s2 := ringQ.NewPoly()
+		ringQ.MulCoeffsMontgomery(sk.Value.Q, sk.Value.Q, s2)
+
+		// Relinearizes [-c1*s - c2*s^2, c1, c2] at the level of the key and below
+		for _, level := range []int{levelKey, levelKey - 2} {
+
+			c0, c1, c2 := ringQ.NewPolyLvl(level), ringQ.NewPolyLvl(level), ringQ.NewPolyLvl(level)
+			sampler.ReadLvl(level, c1)
+			sampler.ReadLvl(level, c2)
+			c2.IsNTT = true
+
+			ringQ.MulCoeffsMontgomeryLvl(level, c1, sk.Value.Q, c0)
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, c2, s2, c0)
+			ringQ.NegLvl(level, c0, c0)
+
+			ks.SwitchKeysInPlace(level, c2, rlk.Keys[0], ks.Pool[1].Q, ks.Pool[2].Q)
+			ringQ.AddLvl(level, c0, ks.Pool[1].Q, c0)
+			ringQ.AddLvl(level, c1, ks.Pool[2].Q, c1)
+			ringQ.MulCoeffsMontgomeryAndAddLvl(level, c1, sk.Value.Q, c0)
+			ringQ.InvNTTLvl(level, c0, c0)
+
+			require.GreaterOrEqual(t, 10+params.LogN(), log2OfInnerSum(level, ringQ, c0))
+		}
+
+		// The level of the key-switching cannot be larger than the level of the key
+		c2 := sampler.ReadNew()
+		c2.IsNTT = true
+		require.Panics(t, func() { ks.SwitchKeysInPlace(params.MaxLevel(), c2, rlk.Keys[0], ks.Pool[1].Q, ks.Pool[2].Q) })
+	})
+}
+
+func TestSecurity(t *testing.T) {
+
+	t.Run("HEStandard", func(t *testing.T) {
+		// The primal uSVP estimate agrees with the tables of the HE Standard for a ternary secret
+		for logN := 10; logN <= 15; logN++ {
+			for _, lambda := range []int{128, 192, 256} {
+				maxLogQP, err := MaxLogQP(logN, lambda)
+				require.NoError(t, err)
+				estimate := EstimateSecurity(logN, float64(maxLogQP), DefaultSigma, 0)
+				require.GreaterOrEqual(t, estimate, float64(lambda-1))
+				require.LessOrEqual(t, estimate, float64(lambda+10))
+			}
+		}
+	})
+
+	t.Run("SparseSecret", func(t *testing.T) {
+		require.Less(t, EstimateSecurity(16, 1550, DefaultSigma, 192), EstimateSecurity(16, 1550, DefaultSigma, 0))
+		require.Less(t, EstimateSecurity(16, 1550, DefaultSigma, 64), EstimateSecurity(16, 1550, DefaultSigma, 192))
+	})
+
+	t.Run("ParametersLiteral", func(t *testing.T) {
+
+		paramsLit := TestPN13QP218
+
+		paramsLit.Lambda = 128
+		params, err := NewParametersFromLiteral(paramsLit)
+		require.NoError(t, err)
+		require.NoError(t, params.CheckSecurity(128, 0))
+		require.GreaterOrEqual(t, params.SecurityEstimate(0), 128.0)
+
+		// Above the HE Standard bound
+		paramsLit.Lambda = 192
+		_, err = NewParametersFromLiteral(paramsLit)
+		require.Error(t, err)
+
+		// A sparse secret falls below the requested security
+		paramsLit.Lambda = 128
+		paramsLit.H = 64
+		_, err = NewParametersFromLiteral(paramsLit)
+		require.Error(t, err)
+
+		paramsLit.Lambda = 112
+		_, err = NewParametersFromLiteral(paramsLit)
+		require.NoError(t, err)
+
+		// Invalid Hamming weight
+		paramsLit.H = params.N() + 1
+		_, err = NewParametersFromLiteral(paramsLit)
+		require.Error(t, err)
+	})
+}
+
 // Returns the ceil(log2) of the sum of the absolute value of all the coefficients
 func log2OfInnerSum(level int, ringQ *ring.Ring, poly *ring.Poly) (logSum int) {
 	sumRNS := make([]uint64, level+1)
@@ -171,7 +381,7 @@ func testSwitchKeyGen(kgen KeyGenerator, t *testing.T) {
 
 		// Generates Decomp([-asIn + w*P*sOut + e, a])
 		swk := NewSwitchingKey(params, params.QCount()-1, params.PCount()-1)
-		kgen.(*keyGenerator).genSwitchingKey(skIn.Value.Q, skOut.Value, swk)
+		kgen.(*keyGenerator).genSwitchingKey(skIn.Value.Q, skOut.Value, kgen.(*keyGenerator).uniformSampler, swk)
 
 		// Decrypts
 		// [-asIn + w*P*sOut + e, a] + [asIn]
@@ -181,10 +391,10 @@ func testSwitchKeyGen(kgen KeyGenerator, t *testing.T) {
 
 		// Sums all basis together (equivalent to multiplying with CRT decomposition of 1)
 		// sum([1]_w * [w*P*sOut + e]) = P*sOut + sum(e)
-		for j := range swk.Value {
-			if j > 0 {
-				ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
-			}
+		// Only the lowest base-2^Pow2Base digit of each element of the RNS basis is summed.
+		decompPw2 := params.DecompPw2(levelQ, levelP)
+		for j := decompPw2; j < len(swk.Value); j += decompPw2 {
+			ringQP.AddLvl(levelQ, levelP, swk.Value[0][0], swk.Value[j][0], swk.Value[0][0])
 		}
 
 		// sOut * P
@@ -334,8 +544,8 @@ func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 	ringP := params.RingP()
 
 	levelQ := params.MaxLevel()
-	alpha := params.PCount()
-	levelP := alpha - 1
+	levelP := params.PCount() - 1
+	alpha := params.DecompAlpha(levelP)
 
 	QBig := ring.NewUint(1)
 	for i := range ringQ.Modulus[:levelQ+1] {
@@ -377,7 +587,7 @@ func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 		tmpQ := ringQ.NewPolyLvl(ciphertext.Level())
 		tmpP := ringP.NewPolyLvl(levelP)
 
-		for i := 0; i < len(ks.PoolDecompQP); i++ {
+		for i := 0; i < params.DecompRNS(levelQ, levelP); i++ {
 
 			ks.DecomposeSingleNTT(levelQ, levelP, alpha, i, ciphertext.Value[1], c2InvNTT, ks.PoolDecompQP[i].Q, ks.PoolDecompQP[i].P)
 
@@ -418,6 +628,51 @@ func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 		}
 	})
 
+	// Tests that the base-2^Pow2Base digits of a polynomial recombine to
+	// the polynomial mod each element of the RNS decomposition basis
+	if params.Pow2Base() != 0 {
+		t.Run(testString(params, "DecomposeNTT/Pw2"), func(t *testing.T) {
+
+			c2InvNTT := ringQ.NewPolyLvl(ciphertext.Level())
+			ringQ.InvNTT(ciphertext.Value[1], c2InvNTT)
+
+			decompRNS := params.DecompRNS(levelQ, levelP)
+			decompPw2 := params.DecompPw2(levelQ, levelP)
+
+			ks.DecomposeNTT(levelQ, levelP, alpha, ciphertext.Value[1], ks.PoolDecompQP)
+
+			tmpQ := ringQ.NewPolyLvl(levelQ)
+			tmpP := ringP.NewPolyLvl(levelP)
+
+			for i := 0; i < decompRNS; i++ {
+
+				want := c2InvNTT.Coeffs[i]
+				have := make([]uint64, ringQ.N)
+
+				for j := decompPw2 - 1; j >= 0; j-- {
+
+					ringQ.ReduceLvl(levelQ, ks.PoolDecompQP[i*decompPw2+j].Q, tmpQ)
+					ringQ.InvNTTLvl(levelQ, tmpQ, tmpQ)
+					ringP.ReduceLvl(levelP, ks.PoolDecompQP[i*decompPw2+j].P, tmpP)
+					ringP.InvNTTLvl(levelP, tmpP, tmpP)
+
+					for x := range have {
+						// The digit is identical mod all the moduli
+						for k := 0; k < levelQ+1; k++ {
+							require.Equal(t, tmpQ.Coeffs[0][x], tmpQ.Coeffs[k][x])
+						}
+						require.Equal(t, tmpQ.Coeffs[0][x], tmpP.Coeffs[0][x])
+						require.Less(t, tmpQ.Coeffs[0][x], uint64(1)<<params.Pow2Base())
+
+						have[x] = have[x]<<params.Pow2Base() + tmpQ.Coeffs[0][x]
+					}
+				}
+
+				require.Equal(t, want, have)
+			}
+		})
+	}
+
 	// Test that Dec(KS(Enc(ct, sk), skOut), skOut) has a small norm
 	t.Run(testString(params, "KeySwitch/Standard"), func(t *testing.T) {
 		swk := kgen.GenSwitchingKey(sk, skOut)
@@ -430,6 +685,73 @@ func testKeySwitcher(kgen KeyGenerator, t *testing.T) {
 	})
 }
 
+func testKeySwitcherConcurrency(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	if params.PCount() == 0 {
+		t.Skip("#Pi is empty")
+	}
+
+	t.Run(testString(params, "KeySwitch/Concurrency"), func(t *testing.T) {
+
+		ks := NewKeySwitcher(params)
+		ksConc := NewKeySwitcher(params)
+		ksConc.SetConcurrency(4)
+
+		require.Equal(t, 4, ksConc.Concurrency())
+		require.Equal(t, 1, ks.Concurrency())
+		require.Equal(t, 1, params.RingQ().Concurrency())
+		require.Equal(t, 4, ksConc.ShallowCopy().Concurrency())
+		require.Panics(t, func() { ksConc.SetConcurrency(0) })
+
+		swk := kgen.GenSwitchingKey(kgen.GenSecretKey(), kgen.GenSecretKey())
+
+		ringQ := params.RingQ()
+		levelQ := params.MaxLevel()
+
+		prng, err := utils.NewPRNG()
+		require.NoError(t, err)
+		sampler := ring.NewUniformSampler(prng, ringQ)
+
+		for _, isNTT := range []bool{true, false} {
+
+			cx := sampler.ReadNew()
+			cx.IsNTT = isNTT
+
+			want := [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}
+			have := [2]*ring.Poly{ringQ.NewPoly(), ringQ.NewPoly()}
+
+			ks.SwitchKeysInPlace(levelQ, cx, swk, want[0], want[1])
+			ksConc.SwitchKeysInPlace(levelQ, cx, swk, have[0], have[1])
+
+			for i := range want {
+				require.True(t, ringQ.Equal(want[i], have[i]))
+			}
+		}
+
+		levelP := params.PCount() - 1
+		decompCount := params.DecompCount(levelQ, levelP)
+		poolWant := make([]PolyQP, decompCount)
+		poolHave := make([]PolyQP, decompCount)
+		for i := range poolWant {
+			poolWant[i] = params.RingQP().NewPoly()
+			poolHave[i] = params.RingQP().NewPoly()
+		}
+
+		cx := sampler.ReadNew()
+		cx.IsNTT = true
+
+		ks.DecomposeNTT(levelQ, levelP, params.DecompAlpha(levelP), cx, poolWant)
+		ksConc.DecomposeNTT(levelQ, levelP, params.DecompAlpha(levelP), cx, poolHave)
+
+		for i := range poolWant {
+			require.True(t, ringQ.Equal(poolWant[i].Q, poolHave[i].Q))
+			require.True(t, params.RingP().Equal(poolWant[i].P, poolHave[i].P))
+		}
+	})
+}
+
 func testKeySwitchDimension(kgen KeyGenerator, t *testing.T) {
 
 	paramsLargeDim := kgen.(*keyGenerator).params
@@ -441,6 +763,34 @@ func testKeySwitchDimension(kgen KeyGenerator, t *testing.T) {
 		RingType: paramsLargeDim.RingType(),
 	})
 
+	t.Run(testString(paramsLargeDim, "SwitchCiphertextRingDegreeNTT/LargeToSmall"), func(t *testing.T) {
+
+		ringQLargeDim := paramsLargeDim.RingQ()
+		ringQSmallDim := paramsSmallDim.RingQ()
+
+		prng, _ := utils.NewPRNG()
+		ctLargeDim := NewCiphertextRandom(prng, paramsLargeDim, 1, paramsSmallDim.MaxLevel())
+		ctLargeDimCopy := ctLargeDim.CopyNew()
+		ctSmallDim := NewCiphertextNTT(paramsSmallDim, 1, paramsSmallDim.MaxLevel())
+
+		SwitchCiphertextRingDegreeNTT(ctLargeDim, ringQSmallDim, ringQLargeDim, ctSmallDim)
+
+		// The input is left unchanged and the output stores the coefficients of degree multiple of N/n
+		gap := paramsLargeDim.N() / paramsSmallDim.N()
+		polLargeDim := ringQLargeDim.NewPolyLvl(paramsSmallDim.MaxLevel())
+		polSmallDim := ringQSmallDim.NewPolyLvl(paramsSmallDim.MaxLevel())
+		for i := range ctSmallDim.Value {
+			require.True(t, ctLargeDim.Value[i].Equals(ctLargeDimCopy.Value[i]))
+			ringQLargeDim.InvNTTLvl(paramsSmallDim.MaxLevel(), ctLargeDim.Value[i], polLargeDim)
+			ringQSmallDim.InvNTTLvl(paramsSmallDim.MaxLevel(), ctSmallDim.Value[i], polSmallDim)
+			for j := range polSmallDim.Coeffs {
+				for w := range polSmallDim.Coeffs[j] {
+					require.Equal(t, polLargeDim.Coeffs[j][w*gap], polSmallDim.Coeffs[j][w])
+				}
+			}
+		}
+	})
+
 	t.Run(testString(paramsLargeDim, "KeySwitchDimension/LargeToSmall"), func(t *testing.T) {
 
 		ringQLargeDim := paramsLargeDim.RingQ()
@@ -525,6 +875,16 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, params, p)
 		assert.Equal(t, params.RingQ(), p.RingQ())
+
+		// The layout without the trailing pow2Base and dnum bytes is decoded with the default decomposition
+		paramsDefault, err := newParameters(params.LogN(), params.Q(), params.P(), 0, 0, params.Sigma(), params.RingType())
+		assert.Nil(t, err)
+		err = p.UnmarshalBinary(bytes[:len(bytes)-2])
+		assert.Nil(t, err)
+		assert.True(t, paramsDefault.Equals(p))
+
+		assert.NotNil(t, p.UnmarshalBinary(bytes[:len(bytes)-1]))
+		assert.NotNil(t, p.UnmarshalBinary(append(bytes, 0)))
 	})
 
 	t.Run("Marshaller/Parameters/JSON", func(t *testing.T) {
@@ -652,3 +1012,222 @@ func testMarshaller(kgen KeyGenerator, t *testing.T) {
 		rotationKey.Equals(resRotationKey)
 	})
 }
+
+func testSeeded(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+	ringQ := params.RingQ()
+
+	sk := kgen.GenSecretKey()
+	skOut := kgen.GenSecretKey()
+
+	for _, isNTT := range []bool{false, true} {
+		t.Run(testString(params, fmt.Sprintf("Seeded/Ciphertext/IsNTT=%t", isNTT)), func(t *testing.T) {
+
+			plaintext := NewPlaintext(params, params.MaxLevel())
+			plaintext.Value.IsNTT = isNTT
+
+			sct := NewSeededCiphertext(params, plaintext.Level())
+			sct.Value.IsNTT = isNTT
+
+			seed := []byte{'l', 'a', 't', 't', 'i', 'g', 'o'}
+			NewEncryptor(params, sk).EncryptSeeded(plaintext, seed, sct)
+
+			data, err := sct.MarshalBinary()
+			require.NoError(t, err)
+
+			ct := NewCiphertext(params, 1, params.MaxLevel())
+			require.Less(t, len(data), ct.GetDataLen(true)/2+len(seed)+16)
+
+			sctTest := new(SeededCiphertext)
+			require.NoError(t, sctTest.UnmarshalBinary(data))
+			require.Equal(t, seed, sctTest.Seed)
+			require.True(t, sct.Value.Equals(sctTest.Value))
+
+			ct = sctTest.ExpandNew(params)
+			require.Equal(t, isNTT, ct.Value[1].IsNTT)
+
+			pt := NewPlaintext(params, ct.Level())
+			NewDecryptor(params, sk).Decrypt(ct, pt)
+			if pt.Value.IsNTT {
+				ringQ.InvNTTLvl(pt.Level(), pt.Value, pt.Value)
+			}
+			require.GreaterOrEqual(t, 5+params.LogN(), log2OfInnerSum(pt.Level(), ringQ, pt.Value))
+		})
+	}
+
+	t.Run(testString(params, "Seeded/SwitchingKey"), func(t *testing.T) {
+
+		sswk := kgen.GenSwitchingKeySeeded(sk, skOut)
+
+		data, err := sswk.MarshalBinary()
+		require.NoError(t, err)
+
+		sswkTest := new(SeededSwitchingKey)
+		require.NoError(t, sswkTest.UnmarshalBinary(data))
+		require.True(t, sswk.Equals(sswkTest))
+
+		swk := sswkTest.ExpandNew(params)
+		require.Len(t, swk.Value, params.DecompCount(params.MaxLevel(), params.PCount()-1))
+
+		// Test that Dec(KS(Enc(ct, sk), skOut), skOut) has a small norm
+		ks := NewKeySwitcher(params)
+		plaintext := NewPlaintext(params, params.MaxLevel())
+		plaintext.Value.IsNTT = true
+		ciphertext := NewCiphertextNTT(params, 1, plaintext.Level())
+		NewEncryptor(params, sk).Encrypt(plaintext, ciphertext)
+
+		ks.SwitchKeysInPlace(ciphertext.Level(), ciphertext.Value[1], swk, ks.Pool[1].Q, ks.Pool[2].Q)
+		ringQ.Add(ciphertext.Value[0], ks.Pool[1].Q, ciphertext.Value[0])
+		ring.CopyValues(ks.Pool[2].Q, ciphertext.Value[1])
+		ringQ.MulCoeffsMontgomeryAndAddLvl(ciphertext.Level(), ciphertext.Value[1], skOut.Value.Q, ciphertext.Value[0])
+		ringQ.InvNTTLvl(ciphertext.Level(), ciphertext.Value[0], ciphertext.Value[0])
+		require.GreaterOrEqual(t, 11+params.LogN(), log2OfInnerSum(ciphertext.Level(), ringQ, ciphertext.Value[0]))
+	})
+
+	t.Run(testString(params, "Seeded/PublicKey"), func(t *testing.T) {
+
+		spk := kgen.GenPublicKeySeeded(sk)
+
+		data, err := spk.MarshalBinary()
+		require.NoError(t, err)
+
+		spkTest := new(SeededPublicKey)
+		require.NoError(t, spkTest.UnmarshalBinary(data))
+		require.True(t, spk.Equals(spkTest))
+
+		pk := spkTest.ExpandNew(params)
+
+		// [-as + e] + [as]
+		ringQP := params.RingQP()
+		levelQ, levelP := params.QCount()-1, params.PCount()-1
+		ringQP.MulCoeffsMontgomeryAndAddLvl(levelQ, levelP, sk.Value, pk.Value[1], pk.Value[0])
+		ringQP.InvNTTLvl(levelQ, levelP, pk.Value[0], pk.Value[0])
+		log2Bound := bits.Len64(uint64(math.Floor(DefaultSigma*6)) * uint64(params.N()))
+		require.GreaterOrEqual(t, log2Bound, log2OfInnerSum(levelQ, ringQ, pk.Value[0].Q))
+	})
+
+	t.Run(testString(params, "Seeded/RotationKeySet"), func(t *testing.T) {
+
+		galEls := []uint64{params.GaloisElementForColumnRotationBy(1), params.GaloisElementForRowRotation()}
+
+		srtks := kgen.GenRotationKeysSeeded(galEls, sk)
+
+		data, err := srtks.MarshalBinary()
+		require.NoError(t, err)
+
+		srtksTest := new(SeededRotationKeySet)
+		require.NoError(t, srtksTest.UnmarshalBinary(data))
+		require.True(t, srtks.Equals(srtksTest))
+
+		rtks := srtksTest.ExpandNew(params)
+		for _, galEl := range galEls {
+			swk, inSet := rtks.GetRotationKey(galEl)
+			require.True(t, inSet)
+			require.True(t, srtks.Keys[galEl].Value[0].Equals(swk.Value[0][0]))
+		}
+	})
+}
+
+func testStreaming(kgen KeyGenerator, t *testing.T) {
+
+	params := kgen.(*keyGenerator).params
+
+	sk, pk := kgen.GenKeyPair()
+
+	// roundTrip streams objIn through a buffer into objOut and checks that the same
+	// number of bytes is reported on both ends.
+	roundTrip := func(t *testing.T, objIn io.WriterTo, objOut io.ReaderFrom) {
+		buff := new(bytes.Buffer)
+		n, err := objIn.WriteTo(buff)
+		require.NoError(t, err)
+		require.Equal(t, int64(buff.Len()), n)
+
+		m, err := objOut.ReadFrom(buff)
+		require.NoError(t, err)
+		require.Equal(t, n, m)
+		require.Zero(t, buff.Len())
+	}
+
+	t.Run(testString(params, "Streaming/Ciphertext"), func(t *testing.T) {
+
+		prng, _ := utils.NewPRNG()
+
+		ciphertextWant := NewCiphertextRandom(prng, params, 2, params.MaxLevel())
+		ciphertextTest := new(Ciphertext)
+		roundTrip(t, ciphertextWant, ciphertextTest)
+
+		require.Equal(t, ciphertextWant.Degree(), ciphertextTest.Degree())
+		for i := range ciphertextWant.Value {
+			require.True(t, ciphertextWant.Value[i].Equals(ciphertextTest.Value[i]))
+		}
+
+		// A stream holding another object must be rejected
+		buff := new(bytes.Buffer)
+		_, err := sk.WriteTo(buff)
+		require.NoError(t, err)
+		_, err = ciphertextTest.ReadFrom(buff)
+		require.Error(t, err)
+	})
+
+	t.Run(testString(params, "Streaming/Sk"), func(t *testing.T) {
+		skTest := new(SecretKey)
+		roundTrip(t, sk, skTest)
+		require.True(t, sk.Value.Equals(skTest.Value))
+	})
+
+	t.Run(testString(params, "Streaming/Pk"), func(t *testing.T) {
+		pkTest := new(PublicKey)
+		roundTrip(t, pk, pkTest)
+		require.True(t, pk.Equals(pkTest))
+	})
+
+	t.Run(testString(params, "Streaming/SwitchingKeys"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		swk := kgen.GenSwitchingKey(sk, kgen.GenSecretKey())
+		swkTest := new(SwitchingKey)
+		roundTrip(t, swk, swkTest)
+		require.True(t, swk.Equals(swkTest))
+
+		rlk := kgen.GenRelinearizationKey(sk, 2)
+		rlkTest := new(RelinearizationKey)
+		roundTrip(t, rlk, rlkTest)
+		require.True(t, rlk.Equals(rlkTest))
+
+		galEls := []uint64{params.GaloisElementForColumnRotationBy(1), params.GaloisElementForColumnRotationBy(-1)}
+		rtks := kgen.GenRotationKeys(galEls, sk)
+		rtksTest := new(RotationKeySet)
+		roundTrip(t, rtks, rtksTest)
+		require.True(t, rtks.Equals(rtksTest))
+	})
+
+	t.Run(testString(params, "Streaming/Seeded"), func(t *testing.T) {
+
+		if params.PCount() == 0 {
+			t.Skip("method is unsuported when params.PCount() == 0")
+		}
+
+		sct := NewSeededCiphertextNTT(params, params.MaxLevel())
+		plaintext := NewPlaintext(params, params.MaxLevel())
+		plaintext.Value.IsNTT = true
+		NewEncryptor(params, sk).EncryptSeeded(plaintext, []byte{'l', 'a', 't', 't', 'i', 'g', 'o'}, sct)
+		sctTest := new(SeededCiphertext)
+		roundTrip(t, sct, sctTest)
+		require.Equal(t, sct.Seed, sctTest.Seed)
+		require.True(t, sct.Value.Equals(sctTest.Value))
+
+		sswk := kgen.GenSwitchingKeySeeded(sk, kgen.GenSecretKey())
+		sswkTest := new(SeededSwitchingKey)
+		roundTrip(t, sswk, sswkTest)
+		require.True(t, sswk.Equals(sswkTest))
+
+		srtks := kgen.GenRotationKeysSeeded([]uint64{params.GaloisElementForColumnRotationBy(1)}, sk)
+		srtksTest := new(SeededRotationKeySet)
+		roundTrip(t, srtks, srtksTest)
+		require.True(t, srtks.Equals(srtksTest))
+	})
+}
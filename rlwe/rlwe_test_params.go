@@ -8,6 +8,14 @@ var (
 		P:     []uint64{0x8000016001},             // 30 bits
 		Sigma: DefaultSigma,
 	}
+	// TestPN12QP109Pw2 is the TestPN12QP109 parameter set with a base-2^16 digit decomposition of the key-switching
+	TestPN12QP109Pw2 = ParametersLiteral{
+		LogN:     12,
+		Q:        []uint64{0x7ffffec001, 0x40002001}, // 39 + 39 bits
+		P:        []uint64{0x8000016001},             // 30 bits
+		Pow2Base: 16,
+		Sigma:    DefaultSigma,
+	}
 	// TestPN13QP218 is a set of default parameters with logN=13 and logQP=218
 	TestPN13QP218 = ParametersLiteral{
 		LogN:  13,
@@ -0,0 +1,232 @@
+package rlwe
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// heStandardMaxLogQP stores, for logN in [10, 15] and a security of 128, 192 and 256 bits, the largest bit-size
+// of the modulus QP given by the tables of the Homomorphic Encryption Standard (https://homomorphicencryption.org)
+// for a ternary secret and the classical setting.
+var heStandardMaxLogQP = map[int][3]int{
+	10: {27, 19, 14},
+	11: {54, 37, 29},
+	12: {109, 75, 58},
+	13: {218, 152, 118},
+	14: {438, 305, 237},
+	15: {881, 611, 476},
+}
+
+// MaxLogQP returns the largest bit-size of the modulus QP for which the RLWE problem of ring degree 2^logN with a
+// ternary secret and an error of standard deviation DefaultSigma has a classical security of at least lambda bits
+// according to the Homomorphic Encryption Standard. lambda must be 128, 192 or 256. Since the standard stops at
+// logN=15, the values for logN=16 and logN=17 are extrapolated linearly in the ring degree, as done for the default
+// parameter sets of the library. It returns an error if lambda is not supported or if logN is not in [10, MaxLogN].
+func MaxLogQP(logN, lambda int) (int, error) {
+
+	var idx int
+	switch lambda {
+	case 128:
+		idx = 0
+	case 192:
+		idx = 1
+	case 256:
+		idx = 2
+	default:
+		return 0, fmt.Errorf("lambda=%d is not supported (must be 128, 192 or 256)", lambda)
+	}
+
+	if logN < 10 || logN > MaxLogN {
+		return 0, fmt.Errorf("logN=%d is not in [10, %d]", logN, MaxLogN)
+	}
+
+	if logN > 15 {
+		return heStandardMaxLogQP[15][idx] << (logN - 15), nil
+	}
+
+	return heStandardMaxLogQP[logN][idx], nil
+}
+
+// LogPForDNum returns the bit-sizes of the moduli P for the moduli Q of bit-sizes logQ and a key-switching whose RNS
+// decomposition has dnum elements (see ParametersLiteral.DNum). P is the smallest modulus that is at least as large
+// as the elements of the decomposition, split into as few moduli of at most MaxModuliSize bits as possible.
+// It returns an error if dnum is not in [1, len(logQ)] or if the resulting modulus QP is larger than MaxLogQP(logN,
+// lambda), that is, if the parameters do not reach a security of lambda bits.
+func LogPForDNum(logN int, logQ []int, dnum, lambda int) (logP []int, err error) {
+
+	if dnum < 1 || dnum > len(logQ) {
+		return nil, fmt.Errorf("dnum=%d is not in [1, #Qi=%d]", dnum, len(logQ))
+	}
+
+	if err = checkModuliLogSize(logQ, nil); err != nil {
+		return nil, err
+	}
+
+	alpha := (len(logQ) + dnum - 1) / dnum
+
+	var logDigit, logQSum int
+	for i := 0; i < len(logQ); i += alpha {
+		var sum int
+		for j := i; j < i+alpha && j < len(logQ); j++ {
+			sum += logQ[j]
+		}
+		if sum > logDigit {
+			logDigit = sum
+		}
+		logQSum += sum
+	}
+
+	count := (logDigit + MaxModuliSize - 1) / MaxModuliSize
+	size := (logDigit + count - 1) / count
+
+	logP = make([]int, count)
+	for i := range logP {
+		logP[i] = size
+	}
+
+	maxLogQP, err := MaxLogQP(logN, lambda)
+	if err != nil {
+		return nil, err
+	}
+
+	if logQP := logQSum + count*size; logQP > maxLogQP {
+		return nil, fmt.Errorf("logQP=%d for dnum=%d is larger than the largest logQP=%d for a security of %d bits with logN=%d", logQP, dnum, maxLogQP, lambda, logN)
+	}
+
+	return logP, nil
+}
+
+// EstimateSecurity returns an estimate, in bits, of the classical security of the RLWE problem of ring degree 2^logN,
+// with a modulus of logQ bits, an error of standard deviation sigma and a ternary secret with h non-zero coefficients
+// (h = 0 for a uniform ternary secret). The estimate is the cost of the primal uSVP attack: it finds the smallest
+// BKZ block size beta for which the embedding lattice of m samples, with the secret rescaled to the size of the error,
+// satisfies the success condition of Alkim et al. (NewHope, USENIX Security 2016) for some m up to 2 * 2^logN, and
+// returns the cost 0.292 * beta + 16.4 + log2(8 * d) of BKZ-beta with sieving in dimension d, which is the cost model
+// used to compute the tables of the Homomorphic Encryption Standard. For a sparse secret, the attack is also run
+// after guessing that k coefficients of the secret are zero, with a cost divided by the probability of the guess,
+// and the smallest cost over k is returned. The estimate does not take into account the hybrid attacks, which can
+// perform better against very sparse secrets.
+func EstimateSecurity(logN int, logQ, sigma float64, h int) (lambda float64) {
+
+	n := 1 << logN
+
+	if h <= 0 {
+		return primalUSVPCost(n, n, logQ, sigma, math.Sqrt(2.0/3.0))
+	}
+
+	h = utils.MinInt(h, n)
+
+	lambda = math.Inf(1)
+
+	// logProb is the base-2 logarithm of the probability that the k guessed coefficients of the secret are zero.
+	var logProb float64
+	for k, step := 0, utils.MaxInt(n>>8, 1); k <= n-h && -logProb < lambda; k += step {
+
+		lambda = math.Min(lambda, primalUSVPCost(n-k, n, logQ, sigma, math.Sqrt(float64(h)/float64(n-k)))-logProb)
+
+		for j := k; j < k+step && j < n-h; j++ {
+			logProb += math.Log2(float64(n-j-h) / float64(n-j))
+		}
+	}
+
+	return
+}
+
+// primalUSVPCost returns the cost of the primal uSVP attack on the LWE problem with a secret of dimension n and of
+// standard deviation sigmaS, at most mMax samples of modulus logQ bits and an error of standard deviation sigma
+// (see EstimateSecurity).
+func primalUSVPCost(n, mMax int, logQ, sigma, sigmaS float64) float64 {
+
+	logNu := math.Max(math.Log2(sigma/sigmaS), 0)
+
+	step := utils.MaxInt(mMax>>8, 1)
+
+	// successDim returns the dimension of the embedding lattice for which the attack succeeds with BKZ-beta, or zero
+	// if there is none.
+	successDim := func(beta int) (dim int) {
+
+		b := float64(beta)
+		logDelta := math.Log2(math.Pow(math.Pi*b, 1/b)*b/(2*math.Pi*math.E)) / (2 * (b - 1))
+		target := math.Log2(sigma) + 0.5*math.Log2(b)
+
+		best := math.Inf(-1)
+		for m := step; m <= mMax; m += step {
+
+			d := n + m + 1
+
+			if beta > d {
+				continue
+			}
+
+			if logVol := (2*b-float64(d)-1)*logDelta + (float64(m)*logQ+float64(n)*logNu)/float64(d); logVol > best && logVol >= target {
+				best, dim = logVol, d
+			}
+		}
+
+		return
+	}
+
+	// The success condition is monotone in the block size, which is found by binary search.
+	lo, hi := 40, n+mMax+1
+	for lo < hi {
+		if mid := (lo + hi) >> 1; successDim(mid) != 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	dim := successDim(lo)
+	if dim == 0 {
+		dim = n + mMax + 1
+	}
+
+	return 0.292*float64(lo) + 16.4 + math.Log2(8*float64(dim))
+}
+
+// SecurityEstimate returns an estimate, in bits, of the classical security of the receiver for a ternary secret with
+// h non-zero coefficients (h = 0 for a uniform ternary secret) given by EstimateSecurity. The moduli Q and P are both
+// taken into account since the key-switching keys are encrypted under QP. A conjugate invariant ring of degree N is
+// estimated as the standard ring of degree N, since both have the same dimension as lattices.
+func (p Parameters) SecurityEstimate(h int) float64 {
+	return EstimateSecurity(p.logN, p.logQPFloat(), p.sigma, h)
+}
+
+// CheckSecurity returns an error if the receiver does not reach a classical security of lambda bits for a ternary
+// secret with h non-zero coefficients (h = 0 for a uniform ternary secret). For a uniform ternary secret and lambda
+// equal to 128, 192 or 256, the bit-size of QP is compared with MaxLogQP, that is, with the tables of the Homomorphic
+// Encryption Standard. Otherwise, the estimate given by SecurityEstimate is compared with lambda.
+func (p Parameters) CheckSecurity(lambda, h int) error {
+
+	if h < 0 || h > p.N() {
+		return fmt.Errorf("h=%d is not in [0, N=%d]", h, p.N())
+	}
+
+	if h == 0 {
+		if maxLogQP, err := MaxLogQP(p.logN, lambda); err == nil {
+			if logQP := p.LogQP(); logQP > maxLogQP {
+				return fmt.Errorf("logQP=%d is larger than the largest logQP=%d for a security of %d bits with logN=%d", logQP, maxLogQP, lambda, p.logN)
+			}
+			return nil
+		}
+	}
+
+	if estimate := p.SecurityEstimate(h); estimate < float64(lambda) {
+		return fmt.Errorf("estimated security of %.1f bits for h=%d is below the requested %d bits", estimate, h, lambda)
+	}
+
+	return nil
+}
+
+// logQPFloat returns the base-2 logarithm of the modulus QP.
+func (p Parameters) logQPFloat() (logQP float64) {
+	for _, qi := range p.qi {
+		logQP += math.Log2(float64(qi))
+	}
+	for _, pi := range p.pi {
+		logQP += math.Log2(float64(pi))
+	}
+	return
+}
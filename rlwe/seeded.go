@@ -0,0 +1,238 @@
+package rlwe
+
+import (
+	"crypto/rand"
+
+	"github.com/ldsec/lattigo/v2/ring"
+	"github.com/ldsec/lattigo/v2/utils"
+)
+
+// seedSize is the size in bytes of the seeds generated by the KeyGenerator for the seeded keys.
+const seedSize = 32
+
+// SeededCiphertext is a compressed representation of a degree-one Ciphertext encrypted with the secret key.
+// Instead of the uniformly random element c1, it stores the seed of the KeyedPRNG from which c1 is sampled,
+// which approximately halves the size of the Ciphertext. It is expanded back into a Ciphertext with Expand.
+type SeededCiphertext struct {
+	Seed  []byte
+	Value *ring.Poly
+}
+
+// SeededPublicKey is a compressed representation of a PublicKey. Instead of the uniformly random element
+// Value[1] of the PublicKey, it stores the seed of the KeyedPRNG from which this element is sampled.
+// It is expanded back into a PublicKey with Expand.
+type SeededPublicKey struct {
+	Seed  []byte
+	Value PolyQP
+}
+
+// SeededSwitchingKey is a compressed representation of a SwitchingKey. Instead of the uniformly random elements
+// Value[i][1] of the SwitchingKey, it stores the seed of the KeyedPRNG from which these elements are sampled,
+// in increasing order of i. It is expanded back into a SwitchingKey with Expand.
+type SeededSwitchingKey struct {
+	Seed  []byte
+	Value []PolyQP
+}
+
+// SeededRotationKeySet is a compressed representation of a RotationKeySet. It stores a map of SeededSwitchingKey
+// indexed by the galois element defining the automorphism.
+type SeededRotationKeySet struct {
+	Keys map[uint64]*SeededSwitchingKey
+}
+
+// NewSeededCiphertext returns a new SeededCiphertext at level `level` with zero values and an empty seed.
+func NewSeededCiphertext(params Parameters, level int) *SeededCiphertext {
+	return &SeededCiphertext{Value: ring.NewPoly(params.N(), level+1)}
+}
+
+// NewSeededCiphertextNTT returns a new SeededCiphertext at level `level` with zero values and an empty seed,
+// which will be encrypted in the NTT domain.
+func NewSeededCiphertextNTT(params Parameters, level int) *SeededCiphertext {
+	sct := NewSeededCiphertext(params, level)
+	sct.Value.IsNTT = true
+	return sct
+}
+
+// Level returns the level of the target SeededCiphertext.
+func (sct *SeededCiphertext) Level() int {
+	return len(sct.Value.Coeffs) - 1
+}
+
+// Expand decompresses the receiver SeededCiphertext on ctOut, which must be of degree one and at least
+// at the level of the receiver.
+func (sct *SeededCiphertext) Expand(params Parameters, ctOut *Ciphertext) {
+
+	level := sct.Level()
+
+	if ctOut.Degree() != 1 || ctOut.Level() < level {
+		panic("cannot Expand: ctOut must be of degree one and at least at the level of the SeededCiphertext")
+	}
+
+	ring.CopyValuesLvl(level, sct.Value, ctOut.Value[0])
+	ctOut.Value[0].Coeffs = ctOut.Value[0].Coeffs[:level+1]
+	ctOut.Value[1].Coeffs = ctOut.Value[1].Coeffs[:level+1]
+
+	sampleCRP(params, sct.Seed, ctOut.Value[1])
+
+	if !sct.Value.IsNTT {
+		params.RingQ().InvNTTLvl(level, ctOut.Value[1], ctOut.Value[1])
+	}
+
+	ctOut.Value[0].IsNTT = sct.Value.IsNTT
+	ctOut.Value[1].IsNTT = sct.Value.IsNTT
+}
+
+// ExpandNew decompresses the receiver SeededCiphertext and returns the result on a new Ciphertext.
+func (sct *SeededCiphertext) ExpandNew(params Parameters) (ctOut *Ciphertext) {
+	ctOut = NewCiphertext(params, 1, sct.Level())
+	sct.Expand(params, ctOut)
+	return
+}
+
+// NewSeededPublicKey returns a new SeededPublicKey with zero values and an empty seed.
+func NewSeededPublicKey(params Parameters) *SeededPublicKey {
+	return &SeededPublicKey{Value: params.RingQP().NewPoly()}
+}
+
+// Expand decompresses the receiver SeededPublicKey on pkOut.
+func (spk *SeededPublicKey) Expand(params Parameters, pkOut *PublicKey) {
+
+	prng, err := utils.NewKeyedPRNG(spk.Seed)
+	if err != nil {
+		panic(err)
+	}
+
+	pkOut.Value[0].CopyValues(spk.Value)
+	NewUniformSamplerQP(params, prng, params.RingQP()).Read(&pkOut.Value[1])
+}
+
+// ExpandNew decompresses the receiver SeededPublicKey and returns the result on a new PublicKey.
+func (spk *SeededPublicKey) ExpandNew(params Parameters) (pkOut *PublicKey) {
+	pkOut = NewPublicKey(params)
+	spk.Expand(params, pkOut)
+	return
+}
+
+// Equals checks two SeededPublicKeys for equality.
+func (spk *SeededPublicKey) Equals(other *SeededPublicKey) bool {
+	if spk == other {
+		return true
+	}
+	if (spk == nil) != (other == nil) {
+		return false
+	}
+	return string(spk.Seed) == string(other.Seed) && spk.Value.Equals(other.Value)
+}
+
+// NewSeededSwitchingKey returns a new SeededSwitchingKey with pre-allocated zero-value and an empty seed.
+func NewSeededSwitchingKey(params Parameters, levelQ, levelP int) *SeededSwitchingKey {
+	sswk := &SeededSwitchingKey{Value: make([]PolyQP, params.DecompCount(levelQ, levelP))}
+	for i := range sswk.Value {
+		sswk.Value[i] = params.RingQP().NewPolyLvl(levelQ, levelP)
+	}
+	return sswk
+}
+
+// Expand decompresses the receiver SeededSwitchingKey on swkOut, which must have the same
+// decomposition and levels as the receiver.
+func (sswk *SeededSwitchingKey) Expand(params Parameters, swkOut *SwitchingKey) {
+
+	if len(swkOut.Value) != len(sswk.Value) {
+		panic("cannot Expand: swkOut must have the same decomposition as the SeededSwitchingKey")
+	}
+
+	prng, err := utils.NewKeyedPRNG(sswk.Seed)
+	if err != nil {
+		panic(err)
+	}
+
+	us := NewUniformSamplerQP(params, prng, params.RingQP())
+
+	for i := range sswk.Value {
+		swkOut.Value[i][0].CopyValues(sswk.Value[i])
+		us.Read(&swkOut.Value[i][1])
+	}
+}
+
+// ExpandNew decompresses the receiver SeededSwitchingKey and returns the result on a new SwitchingKey.
+func (sswk *SeededSwitchingKey) ExpandNew(params Parameters) (swkOut *SwitchingKey) {
+	swkOut = NewSwitchingKey(params, sswk.Value[0].Q.Level(), sswk.Value[0].P.Level())
+	sswk.Expand(params, swkOut)
+	return
+}
+
+// Equals checks two SeededSwitchingKeys for equality.
+func (sswk *SeededSwitchingKey) Equals(other *SeededSwitchingKey) bool {
+	if sswk == other {
+		return true
+	}
+	if (sswk == nil) != (other == nil) {
+		return false
+	}
+	if string(sswk.Seed) != string(other.Seed) || len(sswk.Value) != len(other.Value) {
+		return false
+	}
+	for i := range sswk.Value {
+		if !sswk.Value[i].Equals(other.Value[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewSeededRotationKeySet returns a new SeededRotationKeySet with pre-allocated seeded switching keys
+// for each distinct galoisElement value.
+func NewSeededRotationKeySet(params Parameters, galoisElement []uint64) (rotKey *SeededRotationKeySet) {
+	rotKey = new(SeededRotationKeySet)
+	rotKey.Keys = make(map[uint64]*SeededSwitchingKey, len(galoisElement))
+	for _, galEl := range galoisElement {
+		rotKey.Keys[galEl] = NewSeededSwitchingKey(params, params.QCount()-1, params.PCount()-1)
+	}
+	return
+}
+
+// ExpandNew decompresses the receiver SeededRotationKeySet and returns the result on a new RotationKeySet.
+func (srtks *SeededRotationKeySet) ExpandNew(params Parameters) (rtks *RotationKeySet) {
+	rtks = &RotationKeySet{Keys: make(map[uint64]*SwitchingKey, len(srtks.Keys))}
+	for galEl, sswk := range srtks.Keys {
+		rtks.Keys[galEl] = sswk.ExpandNew(params)
+	}
+	return
+}
+
+// Equals checks two SeededRotationKeySets for equality.
+func (srtks *SeededRotationKeySet) Equals(other *SeededRotationKeySet) bool {
+	if srtks == other {
+		return true
+	}
+	if (srtks == nil) || (other == nil) {
+		return false
+	}
+	if len(srtks.Keys) != len(other.Keys) {
+		return false
+	}
+	for galEl, otherKey := range other.Keys {
+		if key, inSet := srtks.Keys[galEl]; !inSet || !otherKey.Equals(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// sampleCRP samples pol uniformly at its level from a KeyedPRNG keyed with seed.
+func sampleCRP(params Parameters, seed []byte, pol *ring.Poly) {
+	prng, err := utils.NewKeyedPRNG(seed)
+	if err != nil {
+		panic(err)
+	}
+	ring.NewUniformSampler(prng, params.RingQ()).Read(pol)
+}
+
+// newSeed returns a new random seed of seedSize bytes.
+func newSeed() (seed []byte) {
+	seed = make([]byte, seedSize)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// RunParallel calls task(worker, i) for each i in [0, n), distributing the indexes among min(workers, n) goroutines
+// identified by worker in [0, min(workers, n)). A worker processes its indexes sequentially, so that it can safely
+// use resources (e.g. an evaluator) indexed by worker. RunParallel returns once all the tasks are completed.
+//
+// A panic in a task is recovered in the goroutine running it and does not stop the remaining tasks. RunParallel
+// returns a *TaskPanic reporting the panic of the task with the smallest index, or nil if no task panicked.
+func RunParallel(workers, n int, task func(worker, i int)) (err error) {
+
+	if workers < 1 {
+		panic("cannot RunParallel: workers must be at least 1")
+	}
+
+	workers = MinInt(workers, n)
+
+	errs := make([]error, n)
+
+	if workers == 1 {
+		for i := 0; i < n; i++ {
+			errs[i] = runTask(task, 0, i)
+		}
+	} else {
+
+		indexes := make(chan int, n)
+		for i := 0; i < n; i++ {
+			indexes <- i
+		}
+		close(indexes)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func(worker int) {
+				defer wg.Done()
+				for i := range indexes {
+					errs[i] = runTask(task, worker, i)
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	for _, err = range errs {
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// TaskPanic is the error returned by RunParallel for a task that panicked. It stores the recovered value and the
+// stack of the goroutine running the task at the time of the panic, which would otherwise be lost when the panic is
+// reported to, or raised again in, the calling goroutine.
+type TaskPanic struct {
+	Worker int         // Worker is the index of the worker that ran the task
+	Index  int         // Index is the index of the task
+	Value  interface{} // Value is the value recovered from the panic
+	Stack  []byte      // Stack is the stack trace of the goroutine at the time of the panic
+}
+
+// Error returns the description of the panic followed by its stack trace.
+func (e *TaskPanic) Error() string {
+	return fmt.Sprintf("task %d panicked on worker %d: %v\n\n%s", e.Index, e.Worker, e.Value, e.Stack)
+}
+
+// Unwrap returns the value recovered from the panic if it is an error, and nil otherwise.
+func (e *TaskPanic) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// runTask calls task(worker, i) and returns the panic it raised, if any, as a *TaskPanic.
+func runTask(task func(worker, i int), worker, i int) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = &TaskPanic{Worker: worker, Index: i, Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	task(worker, i)
+
+	return
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunParallel(t *testing.T) {
+
+	for _, workers := range []int{1, 3, 16} {
+
+		n := 10
+		out := make([]int, n)
+		seen := make([]map[int]bool, workers)
+		mutex := &sync.Mutex{}
+
+		err := RunParallel(workers, n, func(worker, i int) {
+			out[i] = i * i
+			mutex.Lock()
+			if seen[worker] == nil {
+				seen[worker] = make(map[int]bool)
+			}
+			seen[worker][i] = true
+			mutex.Unlock()
+		})
+		require.NoError(t, err)
+
+		count := 0
+		for i := range out {
+			require.Equal(t, i*i, out[i])
+		}
+		for _, s := range seen {
+			count += len(s)
+		}
+		require.Equal(t, n, count)
+	}
+
+	require.Panics(t, func() { RunParallel(0, 1, func(worker, i int) {}) })
+
+	for _, workers := range []int{1, 3} {
+
+		n := 10
+		done := make([]bool, n)
+
+		err := RunParallel(workers, n, func(worker, i int) {
+			if i == 4 || i == 7 {
+				panic(fmt.Sprintf("failure %d", i))
+			}
+			done[i] = true
+		})
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "task 4 panicked")
+		require.Contains(t, err.Error(), "failure 4")
+
+		// The error holds the stack of the panicking task
+		taskPanic, ok := err.(*TaskPanic)
+		require.True(t, ok)
+		require.Equal(t, 4, taskPanic.Index)
+		require.Equal(t, "failure 4", taskPanic.Value)
+		require.Contains(t, string(taskPanic.Stack), "parallel_test.go")
+		require.Contains(t, err.Error(), string(taskPanic.Stack))
+
+		for i := range done {
+			require.Equal(t, i != 4 && i != 7, done[i])
+		}
+	}
+
+	// A panic with an error value can be unwrapped
+	errTask := errors.New("task error")
+	err := RunParallel(2, 4, func(worker, i int) {
+		if i == 2 {
+			panic(errTask)
+		}
+	})
+	require.True(t, errors.Is(err, errTask))
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// StreamVersion is the version of the serialization format used by the WriteTo and ReadFrom methods
+// of lattigo's objects. ReadFrom returns an error on a stream written with a different version.
+const StreamVersion uint8 = 1
+
+// WriteHeader writes on w the header of a streamed object, which is the four-byte tag identifying the
+// type of the object followed by StreamVersion. It returns the number of bytes written.
+func WriteHeader(w io.Writer, tag string) (n int64, err error) {
+
+	if len(tag) != 4 {
+		return 0, fmt.Errorf("invalid stream tag %q: must be four bytes long", tag)
+	}
+
+	var header [5]byte
+	copy(header[:4], tag)
+	header[4] = StreamVersion
+
+	inc, err := w.Write(header[:])
+	return int64(inc), err
+}
+
+// ReadHeader reads the header of a streamed object from r and checks that it matches the given
+// four-byte tag and StreamVersion. It returns the number of bytes read.
+func ReadHeader(r io.Reader, tag string) (n int64, err error) {
+
+	var header [5]byte
+
+	inc, err := io.ReadFull(r, header[:])
+	if n = int64(inc); err != nil {
+		return
+	}
+
+	if string(header[:4]) != tag {
+		return n, fmt.Errorf("invalid stream header: expected object %q but got %q", tag, header[:4])
+	}
+
+	if header[4] != StreamVersion {
+		return n, fmt.Errorf("invalid stream header: unsupported version %d (current version is %d)", header[4], StreamVersion)
+	}
+
+	return
+}
+
+// WriteUint8 writes v on w and returns the number of bytes written.
+func WriteUint8(w io.Writer, v uint8) (n int64, err error) {
+	inc, err := w.Write([]byte{v})
+	return int64(inc), err
+}
+
+// WriteUint16 writes v on w in big-endian order and returns the number of bytes written.
+func WriteUint16(w io.Writer, v uint16) (n int64, err error) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	inc, err := w.Write(b[:])
+	return int64(inc), err
+}
+
+// WriteUint32 writes v on w in big-endian order and returns the number of bytes written.
+func WriteUint32(w io.Writer, v uint32) (n int64, err error) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	inc, err := w.Write(b[:])
+	return int64(inc), err
+}
+
+// ReadUint8 reads an uint8 from r and returns it with the number of bytes read.
+func ReadUint8(r io.Reader) (v uint8, n int64, err error) {
+	var b [1]byte
+	inc, err := io.ReadFull(r, b[:])
+	return b[0], int64(inc), err
+}
+
+// ReadUint16 reads a big-endian uint16 from r and returns it with the number of bytes read.
+func ReadUint16(r io.Reader) (v uint16, n int64, err error) {
+	var b [2]byte
+	inc, err := io.ReadFull(r, b[:])
+	return binary.BigEndian.Uint16(b[:]), int64(inc), err
+}
+
+// ReadUint32 reads a big-endian uint32 from r and returns it with the number of bytes read.
+func ReadUint32(r io.Reader) (v uint32, n int64, err error) {
+	var b [4]byte
+	inc, err := io.ReadFull(r, b[:])
+	return binary.BigEndian.Uint32(b[:]), int64(inc), err
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHeader(t *testing.T) {
+
+	buff := new(bytes.Buffer)
+
+	n, err := WriteHeader(buff, "TEST")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+
+	n, err = ReadHeader(bytes.NewReader(buff.Bytes()), "TEST")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+
+	// Wrong tag
+	_, err = ReadHeader(bytes.NewReader(buff.Bytes()), "FAIL")
+	require.Error(t, err)
+
+	// Wrong version
+	data := buff.Bytes()
+	data[4] = StreamVersion + 1
+	_, err = ReadHeader(bytes.NewReader(data), "TEST")
+	require.Error(t, err)
+
+	// Truncated header
+	_, err = ReadHeader(bytes.NewReader(data[:3]), "TEST")
+	require.Error(t, err)
+
+	// Invalid tag length
+	_, err = WriteHeader(buff, "TOOLONG")
+	require.Error(t, err)
+}
+
+func TestStreamUint(t *testing.T) {
+
+	buff := new(bytes.Buffer)
+
+	_, err := WriteUint8(buff, 0xfe)
+	require.NoError(t, err)
+	_, err = WriteUint16(buff, 0xfedc)
+	require.NoError(t, err)
+	_, err = WriteUint32(buff, 0xfedcba98)
+	require.NoError(t, err)
+	require.Equal(t, 7, buff.Len())
+
+	v8, _, err := ReadUint8(buff)
+	require.NoError(t, err)
+	require.Equal(t, uint8(0xfe), v8)
+
+	v16, _, err := ReadUint16(buff)
+	require.NoError(t, err)
+	require.Equal(t, uint16(0xfedc), v16)
+
+	v32, _, err := ReadUint32(buff)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xfedcba98), v32)
+
+	_, _, err = ReadUint8(buff)
+	require.Error(t, err)
+}